@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// markerPrefixes are the text prefixes that replace the emoji markers of log
+// lines in accessible mode. Other emoji markers become [INFO].
+var markerPrefixes = map[rune]string{
+	'✅': "[OK]",
+	'❌': "[ERROR]",
+	'⚠': "[WARN]",
+	'⏳': "[WAIT]",
+	'⏸': "[WAIT]",
+	'💡': "[TIP]",
+	'👉': "[NEXT]",
+	'🌐': "[URL]",
+	'🔎': "[DEBUG]",
+	'⬆': "[UPLOAD]",
+}
+
+// isAccessible reports whether output must be readable by screen readers
+// and plain log collectors: text prefixes instead of emoji markers, no
+// boxes, QR codes or rewritten lines. It is enabled by --accessible,
+// ROBOTX_ACCESSIBLE=1, ACCESSIBLE=1 or TERM=dumb.
+func (a *app) isAccessible() bool {
+	return a.outputMode().accessible
+}
+
+// envAccessible reports whether the environment alone asks for accessible
+// output.
+func envAccessible() bool {
+	return envTruthy("ROBOTX_ACCESSIBLE") || envTruthy("ACCESSIBLE") || os.Getenv("TERM") == "dumb"
+}
+
+// textMarkers replaces the emoji marker that starts a line of s, and the
+// spacing after it, with a text prefix such as [OK].
+func textMarkers(s string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		r, size := utf8.DecodeRuneInString(line)
+		if !isEmojiMarker(r) {
+			continue
+		}
+		prefix, ok := markerPrefixes[r]
+		if !ok {
+			prefix = "[INFO]"
+		}
+		rest := strings.TrimPrefix(line[size:], "\ufe0f")
+		lines[i] = prefix + " " + strings.TrimLeft(rest, " ")
+	}
+	return strings.Join(lines, "")
+}
+
+// isEmojiMarker reports whether r is a pictograph used to mark log lines.
+// Box drawing and other text symbols are left alone.
+func isEmojiMarker(r rune) bool {
+	switch {
+	case r >= 0x1F000:
+		return true
+	case r >= 0x2300 && r <= 0x23FF, r >= 0x2600 && r <= 0x27BF, r >= 0x2B00 && r <= 0x2BFF:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
+
+	"github.com/spf13/cobra"
+)
+
+type analyticsOptions struct {
+	*app
+	projectID string
+	since     string
+	top       int
+	csv       bool
+}
+
+type analyticsResponse struct {
+	*client.Analytics
+	ProjectID string `json:"project_id"`
+	// ErrorRate is the share of responses with a 5xx status.
+	ErrorRate float64 `json:"error_rate"`
+}
+
+func newAnalyticsCmd(a *app) *cobra.Command {
+	o := &analyticsOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "analytics",
+		Short: "Show production traffic of a project",
+		Long: `Show the production traffic of a project over the window given by --since:
+request count, bandwidth, the most requested paths and a breakdown by HTTP
+status code.
+
+--csv prints the same data as CSV rows (kind,key,requests,bandwidth_bytes)
+for spreadsheets. Inside a deployed project directory --project-id defaults
+to the recorded project.`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	cmd.Flags().StringVar(&o.since, "since", "7d", "Size of the window (e.g. 24h, 7d, 2w)")
+	cmd.Flags().IntVar(&o.top, "top", 10, "Number of top paths to show")
+	cmd.Flags().BoolVar(&o.csv, "csv", false, "Print CSV instead of tables")
+	return cmd
+}
+
+func (o *analyticsOptions) run(cmd *cobra.Command, args []string) error {
+	window, err := parseAge(o.since)
+	if err != nil {
+		return newCLIError("invalid_argument", fmt.Sprintf("invalid --since: %v", err), ExitGeneral, nil)
+	}
+	if o.top < 0 {
+		return newCLIError("invalid_argument", "--top must not be negative", ExitGeneral, nil)
+	}
+	if o.csv && o.isJSONOutput() {
+		return newCLIError("invalid_argument", "--csv cannot be combined with JSON output", ExitGeneral, nil)
+	}
+
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	projectID, _ := stateProjectID(o.projectID)
+	if projectID == "" {
+		return newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	c := o.newCachedAPIClient(baseURL, apiKey)
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityAnalytics) {
+		return newCLIError("unsupported_server", "this RobotX server does not support analytics", ExitAPI, nil)
+	}
+	analytics, err := c.GetAnalytics(projectID, time.Now().Add(-window))
+	if err != nil {
+		if client.IsNotFound(err) {
+			return newCLIError("not_found", fmt.Sprintf("no analytics for project %s", projectID), ExitNotFound, err)
+		}
+		return newCLIError("api_error", "failed to get analytics", ExitAPI, err)
+	}
+	sort.SliceStable(analytics.TopPaths, func(i, j int) bool { return analytics.TopPaths[i].Requests > analytics.TopPaths[j].Requests })
+	if o.top > 0 && len(analytics.TopPaths) > o.top {
+		analytics.TopPaths = analytics.TopPaths[:o.top]
+	}
+	if analytics.TopPaths == nil {
+		analytics.TopPaths = []client.AnalyticsPath{}
+	}
+	if analytics.StatusCodes == nil {
+		analytics.StatusCodes = map[string]int64{}
+	}
+
+	resp := analyticsResponse{Analytics: analytics, ProjectID: projectID, ErrorRate: serverErrorRate(analytics.StatusCodes)}
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if o.isJSONOutput() {
+		return nil
+	}
+	if o.csv {
+		return o.writeAnalyticsCSV(analytics)
+	}
+
+	out := o.out()
+	fmt.Fprintf(out, "Requests:    %d\n", analytics.Requests)
+	fmt.Fprintf(out, "Bandwidth:   %s\n", pipeline.FormatBytes(analytics.BandwidthBytes))
+	fmt.Fprintf(out, "Error rate:  %.2f%% (5xx)\n", resp.ErrorRate*100)
+	if len(analytics.TopPaths) > 0 {
+		fmt.Fprintln(out)
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PATH\tREQUESTS\tBANDWIDTH")
+		for _, p := range analytics.TopPaths {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", p.Path, p.Requests, pipeline.FormatBytes(p.BandwidthBytes))
+		}
+		_ = w.Flush()
+	}
+	if len(analytics.StatusCodes) > 0 {
+		fmt.Fprintln(out)
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "STATUS\tREQUESTS\tSHARE")
+		for _, code := range sortedStatusCodes(analytics.StatusCodes) {
+			count := analytics.StatusCodes[code]
+			fmt.Fprintf(w, "%s\t%d\t%.1f%%\n", code, count, statusShare(count, analytics.StatusCodes))
+		}
+		_ = w.Flush()
+	}
+	return nil
+}
+
+// writeAnalyticsCSV prints one "total" row, one row per top path and one row
+// per status code.
+func (o *analyticsOptions) writeAnalyticsCSV(analytics *client.Analytics) error {
+	w := csv.NewWriter(o.out())
+	rows := [][]string{
+		{"kind", "key", "requests", "bandwidth_bytes"},
+		{"total", "", strconv.FormatInt(analytics.Requests, 10), strconv.FormatInt(analytics.BandwidthBytes, 10)},
+	}
+	for _, p := range analytics.TopPaths {
+		rows = append(rows, []string{"path", p.Path, strconv.FormatInt(p.Requests, 10), strconv.FormatInt(p.BandwidthBytes, 10)})
+	}
+	for _, code := range sortedStatusCodes(analytics.StatusCodes) {
+		rows = append(rows, []string{"status", code, strconv.FormatInt(analytics.StatusCodes[code], 10), ""})
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return newCLIError("output_error", "failed to write CSV output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// serverErrorRate is the share of 5xx responses, counting "5xx" style keys
+// as well as individual codes.
+func serverErrorRate(codes map[string]int64) float64 {
+	var total, failed int64
+	for code, count := range codes {
+		total += count
+		if len(code) == 3 && code[0] == '5' {
+			failed += count
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(failed) / float64(total)
+}
+
+func statusShare(count int64, codes map[string]int64) float64 {
+	var total int64
+	for _, n := range codes {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(count) * 100 / float64(total)
+}
+
+func sortedStatusCodes(codes map[string]int64) []string {
+	keys := make([]string, 0, len(codes))
+	for code := range codes {
+		keys = append(keys, code)
+	}
+	sort.Strings(keys)
+	return keys
+}
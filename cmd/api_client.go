@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+)
+
+// newAPIClient builds a client with the configured fallback endpoints and,
+// in verbose mode, reports which endpoint served each request.
+func (a *app) newAPIClient(baseURL, apiKey string) *client.Client {
+	c := client.NewClient(baseURL, apiKey)
+	c.SetFallbackBaseURLs(a.configuredFallbackBaseURLs())
+	a.dumpHTTP(c)
+	c.SetDeprecationHandler(a.warnAPIDeprecation)
+	if a.verbose || a.tracer != nil {
+		c.SetObserver(a.observeRequest)
+	}
+	if cache := a.responseCache(); cache != nil {
+		// Writes still clear the cache so later cached reads stay fresh.
+		c.SetResponseCache(cache, false)
+	}
+	return c
+}
+
+// newCachedAPIClient is newAPIClient for read-only commands: with the cache
+// enabled, GET responses are served from it unless --no-cache is set.
+func (a *app) newCachedAPIClient(baseURL, apiKey string) *client.Client {
+	c := a.newAPIClient(baseURL, apiKey)
+	if cache := a.responseCache(); cache != nil {
+		c.SetResponseCache(cache, !a.noCache)
+	}
+	return c
+}
+
+// responseCache returns the disk cache in the data directory, or nil unless
+// it is enabled with --cache or the cache config key.
+func (a *app) responseCache() *client.ResponseCache {
+	if !a.cacheEnabled {
+		return nil
+	}
+	dir, err := robotxDataDir()
+	if err != nil {
+		return nil
+	}
+	return client.NewResponseCache(filepath.Join(dir, "cache"), a.cacheTTL)
+}
+
+func (a *app) observeRequest(info client.RequestInfo) {
+	if a.verbose {
+		a.logRequestInfo(info)
+	}
+	if a.tracer != nil {
+		a.traceRequest(info)
+	}
+}
+
+func (a *app) configuredFallbackBaseURLs() []string {
+	var urls []string
+	for _, raw := range a.v.GetStringSlice("fallback_base_urls") {
+		for _, part := range strings.Split(raw, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				urls = append(urls, part)
+			}
+		}
+	}
+	return urls
+}
+
+func (a *app) logRequestInfo(info client.RequestInfo) {
+	failover := ""
+	if info.Failover {
+		failover = " [failover]"
+	}
+	elapsed := info.Duration.Round(time.Millisecond)
+	if info.Cached {
+		a.logf("🔎 %s %s -> %d from cache\n", info.Method, info.URL, info.Status)
+		return
+	}
+	if info.Err != nil {
+		a.logf("🔎 %s %s failed after %s via %s%s: %v\n", info.Method, info.URL, elapsed, info.Endpoint, failover, info.Err)
+		return
+	}
+	a.logf("🔎 %s %s -> %d in %s via %s%s\n", info.Method, info.URL, info.Status, elapsed, info.Endpoint, failover)
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+)
+
+// upgradeCommand installs the latest robotx release.
+const upgradeCommand = "curl -fsSL https://raw.githubusercontent.com/haibingtown/robotx_cli/main/scripts/install.sh | bash"
+
+type unsupportedVersionDetails struct {
+	CLIVersion    string `json:"cli_version"`
+	APIVersion    string `json:"api_version"`
+	MinAPIVersion string `json:"min_api_version,omitempty"`
+	ServerMessage string `json:"server_message,omitempty"`
+	Upgrade       string `json:"upgrade"`
+}
+
+// unsupportedVersionError replaces an error caused by the server refusing
+// the CLI's API version, whatever the command was doing, with one telling
+// the user to upgrade.
+func unsupportedVersionError(err error) *cliError {
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		return nil
+	}
+	message := fmt.Sprintf("this robotx (%s, API version %s) is no longer supported by the server", version, client.APIVersion)
+	if apiErr.MinAPIVersion != "" {
+		message = fmt.Sprintf("this robotx (%s, API version %s) is no longer supported by the server, which requires API version %s or later", version, client.APIVersion, apiErr.MinAPIVersion)
+	}
+	cliErr := newCLIError("unsupported_cli_version", message+"; upgrade robotx with: "+upgradeCommand, ExitAPI, nil)
+	cliErr.Details = unsupportedVersionDetails{
+		CLIVersion:    version,
+		APIVersion:    client.APIVersion,
+		MinAPIVersion: apiErr.MinAPIVersion,
+		ServerMessage: apiErr.Message,
+		Upgrade:       upgradeCommand,
+	}
+	return cliErr
+}
+
+// warnAPIDeprecation tells the user, once per invocation, that the server
+// has deprecated the CLI's API version.
+func (a *app) warnAPIDeprecation(d client.APIDeprecation) {
+	if d.Sunset.IsZero() {
+		a.logf("⚠️  The server has deprecated the API version of this robotx (%s); upgrade soon with: %s\n", version, upgradeCommand)
+	} else {
+		a.logf("⚠️  The server has deprecated the API version of this robotx (%s) and stops serving it on %s; upgrade with: %s\n", version, d.Sunset.Local().Format("2006-01-02"), upgradeCommand)
+	}
+	if d.Link != "" {
+		a.logf("   Details: %s\n", d.Link)
+	}
+}
@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
+
+	"github.com/spf13/cobra"
+)
+
+type approvalsOptions struct {
+	*app
+	projectID string
+	all       bool
+	comment   string
+	reason    string
+}
+
+type approvalsListResponse struct {
+	ProjectID string                    `json:"project_id"`
+	Approvals []*client.PublishApproval `json:"approvals"`
+}
+
+type approvalResponse struct {
+	Approval      *client.PublishApproval `json:"approval"`
+	ProductionURL string                  `json:"production_url,omitempty"`
+	// EnvChanges lists the production env changes synced from robotx.yaml
+	// before an approved publish.
+	EnvChanges []pipeline.EnvVarChange `json:"env_changes,omitempty"`
+}
+
+func newApprovalsCmd(a *app) *cobra.Command {
+	o := &approvalsOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "approvals",
+		Short: "Approve or reject publish requests",
+		Long: `publish --require-approval leaves production unchanged and creates a publish
+request instead, so that a second person or a separate pipeline stage must
+confirm the production push. approve publishes the requested build; reject
+discards the request. The server may refuse approvals by the requester.
+
+status lists the pending requests of a project.`,
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the pending publish requests of a project",
+		Args:  cobra.NoArgs,
+		RunE:  o.runList,
+	}
+	listCmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	listCmd.Flags().BoolVar(&o.all, "all", false, "Include approved and rejected requests")
+
+	approveCmd := &cobra.Command{
+		Use:     "approve <approval-id>",
+		Short:   "Approve a publish request, publishing its build",
+		Example: `  robotx approvals approve apr_123 --comment "QA passed" --yes`,
+		Args:    cobra.ExactArgs(1),
+		RunE:    o.runApprove,
+	}
+	approveCmd.Flags().StringVar(&o.comment, "comment", "", "Comment recorded with the approval")
+
+	rejectCmd := &cobra.Command{
+		Use:   "reject <approval-id>",
+		Short: "Reject a publish request",
+		Args:  cobra.ExactArgs(1),
+		RunE:  o.runReject,
+	}
+	rejectCmd.Flags().StringVar(&o.reason, "reason", "", "Reason recorded with the rejection")
+
+	cmd.AddCommand(listCmd, approveCmd, rejectCmd)
+	return cmd
+}
+
+// approvalsClient returns a client for a server with publish approvals.
+func (o *approvalsOptions) approvalsClient() (*client.Client, string, error) {
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return nil, "", newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return nil, "", newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	c := o.newAPIClient(baseURL, apiKey)
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityPublishApprovals) {
+		return nil, "", newCLIError("unsupported_server", "this RobotX server does not support publish approvals", ExitAPI, nil)
+	}
+	return c, baseURL, nil
+}
+
+func (o *approvalsOptions) runList(cmd *cobra.Command, args []string) error {
+	projectID, _ := stateProjectID(o.projectID)
+	if projectID == "" {
+		return newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+	}
+	c, _, err := o.approvalsClient()
+	if err != nil {
+		return err
+	}
+	status := client.ApprovalPending
+	if o.all {
+		status = ""
+	}
+	approvals, err := c.ListApprovals(projectID, status)
+	if err != nil {
+		return newCLIError("api_error", "failed to list publish requests", ExitAPI, err)
+	}
+	if approvals == nil {
+		approvals = []*client.PublishApproval{}
+	}
+
+	if err := o.emitSuccess("approvals "+cmd.Name(), approvalsListResponse{ProjectID: projectID, Approvals: approvals}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if o.isJSONOutput() {
+		return nil
+	}
+	if len(approvals) == 0 {
+		fmt.Fprintln(o.out(), "No publish requests.")
+		return nil
+	}
+	w := tabwriter.NewWriter(o.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "APPROVAL_ID\tBUILD_ID\tSTATUS\tREQUESTED_BY\tCREATED\tDECIDED_BY")
+	for _, approval := range approvals {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			approval.ApprovalID,
+			approval.BuildID,
+			approval.Status,
+			valueOrDash(approval.RequestedBy),
+			formatBuildTimePtr(approval.CreatedAt),
+			valueOrDash(approval.DecidedBy),
+		)
+	}
+	_ = w.Flush()
+	return nil
+}
+
+func (o *approvalsOptions) runApprove(cmd *cobra.Command, args []string) error {
+	c, baseURL, err := o.approvalsClient()
+	if err != nil {
+		return err
+	}
+	approval, err := o.pendingApproval(c, args[0])
+	if err != nil {
+		return err
+	}
+	if err := o.confirm(fmt.Sprintf("Publish build %s of %s to production", approval.BuildID, approval.ProjectID)); err != nil {
+		return err
+	}
+
+	// The production env is synced as publish would, from the robotx.yaml
+	// of the project being published.
+	_, st := stateProjectID("")
+	if st != nil && st.ProjectID != approval.ProjectID {
+		st = nil
+	}
+	envChanges, err := o.syncPublishEnv(c, baseURL, approval.ProjectID, st)
+	if err != nil {
+		return err
+	}
+	approved, err := c.ApprovePublish(approval.ApprovalID, o.comment)
+	if err != nil {
+		return newCLIError("publish_failed", "failed to approve the publish request", ExitPublish, err)
+	}
+	o.logf("✅ Approved publish request %s\n", approved.ApprovalID)
+	prodURL := o.finishPublish(c, baseURL, approved.ProjectID, approved.BuildID, approved.ProductionURL, st)
+	o.recordHistory(historyEntry{
+		Command:       "approvals " + cmd.Name(),
+		ProjectID:     approved.ProjectID,
+		BuildID:       approved.BuildID,
+		ProductionURL: prodURL,
+		Args:          []string{"approvals", cmd.Name(), approved.ApprovalID},
+	})
+
+	return o.emitApproval(cmd, approvalResponse{Approval: approved, ProductionURL: prodURL, EnvChanges: envChanges})
+}
+
+func (o *approvalsOptions) runReject(cmd *cobra.Command, args []string) error {
+	c, _, err := o.approvalsClient()
+	if err != nil {
+		return err
+	}
+	approval, err := o.pendingApproval(c, args[0])
+	if err != nil {
+		return err
+	}
+	rejected, err := c.RejectPublish(approval.ApprovalID, o.reason)
+	if err != nil {
+		return newCLIError("api_error", "failed to reject the publish request", ExitAPI, err)
+	}
+	o.logf("🚫 Rejected publish request %s; build %s was not published\n", rejected.ApprovalID, rejected.BuildID)
+	return o.emitApproval(cmd, approvalResponse{Approval: rejected})
+}
+
+// approvalPollInterval is how often a publish request is checked while
+// waiting for it to be decided.
+const approvalPollInterval = 10 * time.Second
+
+// waitForApproval polls the publish request until it is approved or
+// rejected, or timeout elapses, and returns it as last seen. Failed checks
+// are retried on the next poll.
+func waitForApproval(ctx context.Context, c *client.Client, approval *client.PublishApproval, timeout time.Duration) (*client.PublishApproval, error) {
+	deadline := time.Now().Add(timeout)
+	for approval.Status == client.ApprovalPending && time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return approval, ctx.Err()
+		case <-time.After(min(approvalPollInterval, time.Until(deadline))):
+		}
+		if next, err := c.GetApproval(approval.ApprovalID); err == nil {
+			approval = next
+		}
+	}
+	return approval, nil
+}
+
+// notifySlack posts the card of a publish request to a Slack webhook. The
+// request stands without it, so failures only warn.
+func (a *app) notifySlack(ctx context.Context, c *client.Client, baseURL, webhook string, approval *client.PublishApproval) {
+	var projectName, previewURL string
+	if project, err := c.GetProject(approval.ProjectID); err == nil {
+		projectName = project.Name
+		if build, err := c.GetBuild(approval.ProjectID, approval.BuildID); err == nil {
+			previewURL = resolvePreviewURL(baseURL, project, build)
+		}
+	}
+	if err := postSlackMessage(ctx, webhook, approvalSlackMessage(approval, projectName, previewURL)); err != nil {
+		a.logf("⚠️  Failed to post the publish request to Slack: %v\n", err)
+		return
+	}
+	a.logf("💬 Posted publish request %s to Slack\n", approval.ApprovalID)
+}
+
+// pendingApproval returns the publish request approvalID, refusing one that
+// was already decided.
+func (o *approvalsOptions) pendingApproval(c *client.Client, approvalID string) (*client.PublishApproval, error) {
+	approval, err := c.GetApproval(approvalID)
+	if err != nil {
+		if errors.Is(err, client.ErrUnsupported) {
+			return nil, newCLIError("unsupported_server", "this RobotX server does not support publish approvals", ExitAPI, err)
+		}
+		if client.IsNotFound(err) {
+			return nil, newCLIError("not_found", fmt.Sprintf("publish request %s not found", approvalID), ExitNotFound, err)
+		}
+		return nil, newCLIError("api_error", "failed to get the publish request", ExitAPI, err)
+	}
+	if approval.Status != client.ApprovalPending {
+		return nil, newCLIError("approval_decided", fmt.Sprintf("publish request %s is already %s", approvalID, approval.Status), ExitGeneral, nil)
+	}
+	return approval, nil
+}
+
+func (o *approvalsOptions) emitApproval(cmd *cobra.Command, resp approvalResponse) error {
+	if err := o.emitSuccess("approvals "+cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
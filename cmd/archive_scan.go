@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultLargeFileThresholdMB = 50
+
+// largeFileEntry describes a file above the large-file threshold found while packaging.
+type largeFileEntry struct {
+	Path       string `json:"path"`
+	SizeBytes  int64  `json:"size_bytes"`
+	Binary     bool   `json:"binary"`
+	HardLinkOf string `json:"hard_link_of,omitempty"`
+	Skipped    bool   `json:"skipped"`
+}
+
+type packageOptions struct {
+	SkipBinaries       bool
+	LargeFileThreshold int64
+}
+
+// hardLinkEntry describes a file packaged a second time because it is a hard
+// link of a file already in the archive.
+type hardLinkEntry struct {
+	Path   string `json:"path"`
+	LinkOf string `json:"link_of"`
+}
+
+type packageReport struct {
+	LargeFiles []largeFileEntry
+	HardLinks  []hardLinkEntry
+	// seen holds every regular file inspected so far, by size: hard links
+	// share their size, so only files of equal size need comparing.
+	seen map[int64][]scannedFile
+}
+
+type scannedFile struct {
+	relPath string
+	info    os.FileInfo
+}
+
+var binaryExtensions = map[string]bool{
+	".mp4": true, ".mov": true, ".avi": true, ".mkv": true, ".webm": true, ".m4v": true,
+	".mp3": true, ".wav": true, ".flac": true,
+	".bin": true, ".safetensors": true, ".pt": true, ".pth": true, ".ckpt": true,
+	".onnx": true, ".gguf": true, ".h5": true, ".pb": true, ".tflite": true, ".npy": true, ".npz": true,
+	".zip": true, ".tar": true, ".gz": true, ".tgz": true, ".7z": true, ".rar": true,
+	".iso": true, ".dmg": true, ".exe": true, ".dll": true, ".so": true, ".dylib": true,
+}
+
+// inspect records a large file or a hard link of a file already packaged,
+// and reports whether the file should be left out of the archive.
+func (r *packageReport) inspect(path, relPath string, info os.FileInfo, opts packageOptions) bool {
+	if r == nil {
+		return false
+	}
+	linkOf := r.hardLinkOf(relPath, info)
+	if opts.LargeFileThreshold <= 0 || info.Size() < opts.LargeFileThreshold {
+		return false
+	}
+
+	entry := largeFileEntry{
+		Path:       filepath.ToSlash(relPath),
+		SizeBytes:  info.Size(),
+		Binary:     isBinaryFile(path),
+		HardLinkOf: linkOf,
+	}
+	entry.Skipped = opts.SkipBinaries && entry.Binary
+	r.LargeFiles = append(r.LargeFiles, entry)
+	return entry.Skipped
+}
+
+// hardLinkOf returns the path of an earlier regular file that relPath is a
+// hard link of, recording the link, or "" when it has none.
+func (r *packageReport) hardLinkOf(relPath string, info os.FileInfo) string {
+	if !info.Mode().IsRegular() {
+		return ""
+	}
+	if r.seen == nil {
+		r.seen = map[int64][]scannedFile{}
+	}
+	for _, prev := range r.seen[info.Size()] {
+		if os.SameFile(prev.info, info) {
+			link := hardLinkEntry{Path: filepath.ToSlash(relPath), LinkOf: filepath.ToSlash(prev.relPath)}
+			r.HardLinks = append(r.HardLinks, link)
+			return link.LinkOf
+		}
+	}
+	r.seen[info.Size()] = append(r.seen[info.Size()], scannedFile{relPath: relPath, info: info})
+	return ""
+}
+
+func isBinaryFile(path string) bool {
+	if binaryExtensions[strings.ToLower(filepath.Ext(path))] {
+		return true
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	head := make([]byte, 8000)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return false
+	}
+	for _, b := range head[:n] {
+		if b == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *app) logLargeFiles(label string, report *packageReport) {
+	if report == nil {
+		return
+	}
+	a.logHardLinks(label, report)
+	if len(report.LargeFiles) == 0 {
+		return
+	}
+	a.logf("⚠️  %s contains %d large file(s):\n", label, len(report.LargeFiles))
+	for _, entry := range report.LargeFiles {
+		notes := []string{}
+		if entry.Binary {
+			notes = append(notes, "binary")
+		}
+		if entry.HardLinkOf != "" {
+			notes = append(notes, "hard link of "+entry.HardLinkOf)
+		}
+		if entry.Skipped {
+			notes = append(notes, "skipped")
+		}
+		suffix := ""
+		if len(notes) > 0 {
+			suffix = " (" + strings.Join(notes, ", ") + ")"
+		}
+		a.logf("   - %s %.2f MB%s\n", entry.Path, float64(entry.SizeBytes)/(1024.0*1024.0), suffix)
+	}
+	if !report.anySkipped() && report.anyBinary() {
+		a.logf("💡 Use --skip-binaries to leave large binary files out of the upload.\n")
+	}
+}
+
+// logHardLinks lists the hard links packaged as separate copies of a file.
+func (a *app) logHardLinks(label string, report *packageReport) {
+	if len(report.HardLinks) == 0 {
+		return
+	}
+	a.logf("⚠️  %s contains %d hard link(s), each uploaded as a separate copy:\n", label, len(report.HardLinks))
+	for _, link := range report.HardLinks {
+		a.logf("   - %s (hard link of %s)\n", link.Path, link.LinkOf)
+	}
+}
+
+func (r *packageReport) anyBinary() bool {
+	for _, entry := range r.LargeFiles {
+		if entry.Binary {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *packageReport) anySkipped() bool {
+	for _, entry := range r.LargeFiles {
+		if entry.Skipped {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
+)
+
+const auditToolAuto = "auto"
+
+// auditTools are the values of --audit-tool.
+var auditTools = []string{auditToolAuto, pipeline.AuditToolNpm, pipeline.AuditToolPnpm, pipeline.AuditToolOSV}
+
+// projectAuditor audits the project's dependencies with npm audit, pnpm
+// audit or osv-scanner, or reads the output of an earlier run from
+// --audit-report.
+type projectAuditor struct {
+	*deployOptions
+}
+
+func (o projectAuditor) Audit(ctx context.Context, d *pipeline.Deploy) (*pipeline.AuditReport, error) {
+	if path := strings.TrimSpace(o.auditReport); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		d.Logf(pipeline.LevelInfo, "Reading audit report %s", path)
+		return pipeline.ParseAuditReport(data)
+	}
+
+	tool := o.auditTool
+	if tool == auditToolAuto || tool == "" {
+		var err error
+		if tool, err = detectAuditTool(d.ProjectPath); err != nil {
+			return nil, err
+		}
+	}
+	var args []string
+	switch tool {
+	case pipeline.AuditToolNpm, pipeline.AuditToolPnpm:
+		args = []string{"audit", "--json"}
+	case pipeline.AuditToolOSV:
+		args = []string{"--format", "json", "--recursive", "."}
+	}
+	if _, err := exec.LookPath(tool); err != nil {
+		return nil, fmt.Errorf("%s is not installed; install it or pass the output of an earlier run with --audit-report", tool)
+	}
+
+	d.Logf(pipeline.LevelInfo, "Running %s %s", tool, strings.Join(args, " "))
+	cmd := exec.CommandContext(ctx, tool, args...)
+	setInterruptGroup(cmd)
+	cmd.WaitDelay = 10 * time.Second
+	cmd.Dir = d.ProjectPath
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// The tools exit non-zero when they find vulnerabilities, so the exit
+	// status only matters when there is no report to read.
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	report, err := pipeline.ParseAuditReport(stdout.Bytes())
+	if err != nil {
+		if runErr != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				lines := strings.Split(msg, "\n")
+				return nil, fmt.Errorf("%s failed: %w: %s", tool, runErr, strings.TrimSpace(lines[len(lines)-1]))
+			}
+			return nil, fmt.Errorf("%s failed: %w", tool, runErr)
+		}
+		return nil, err
+	}
+	report.Tool = tool
+	return report, nil
+}
+
+// detectAuditTool picks the audit tool from the lockfile of the project in
+// dir: pnpm or npm for their lockfiles, osv-scanner for anything else.
+func detectAuditTool(dir string) (string, error) {
+	switch {
+	case fileExists(filepath.Join(dir, "pnpm-lock.yaml")):
+		return pipeline.AuditToolPnpm, nil
+	case fileExists(filepath.Join(dir, "package-lock.json")), fileExists(filepath.Join(dir, "npm-shrinkwrap.json")):
+		return pipeline.AuditToolNpm, nil
+	}
+	if _, err := exec.LookPath(pipeline.AuditToolOSV); err == nil {
+		return pipeline.AuditToolOSV, nil
+	}
+	return "", fmt.Errorf("no package-lock.json or pnpm-lock.yaml in %s and osv-scanner is not installed; install osv-scanner or pass --audit-tool", dir)
+}
+
+func validAuditTool(tool string) bool {
+	for _, t := range auditTools {
+		if tool == t {
+			return true
+		}
+	}
+	return false
+}
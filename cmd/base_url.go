@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// normalizeBaseURL validates the configured base_url and rewrites it to the
+// form the client expects: https:// is assumed when the scheme is missing,
+// and trailing slashes and a trailing /api path are dropped. Offline
+// commands are not checked, so a bad value can still be fixed with config.
+func (a *app) normalizeBaseURL(cmd *cobra.Command) error {
+	raw := a.v.GetString("base_url")
+	if strings.TrimSpace(raw) == "" || isOfflineCommand(cmd) {
+		return nil
+	}
+	normalized, err := cleanBaseURL(raw)
+	if err != nil {
+		return newCLIError("invalid_base_url", fmt.Sprintf("invalid base URL %q: %v (use the server's root URL, e.g. https://robotx.example.com)", raw, err), ExitGeneral, nil)
+	}
+	if normalized != raw {
+		a.v.Set("base_url", normalized)
+		if a.verbose {
+			a.logf("🔧 Using base URL %s (normalized from %q)\n", normalized, raw)
+		}
+	}
+	return nil
+}
+
+// cleanBaseURL returns the normalized form of a base URL, or why it cannot
+// be one.
+func cleanBaseURL(raw string) (string, error) {
+	s := strings.TrimSpace(raw)
+	if strings.ContainsAny(s, " \t\r\n") {
+		return "", fmt.Errorf("contains whitespace")
+	}
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("not a URL")
+	}
+	switch {
+	case u.Scheme != "http" && u.Scheme != "https":
+		return "", fmt.Errorf("scheme must be http or https, got %s", u.Scheme)
+	case u.Hostname() == "":
+		return "", fmt.Errorf("missing host")
+	case u.User != nil:
+		return "", fmt.Errorf("credentials in the URL are not supported; use --api-key")
+	case u.RawQuery != "" || u.Fragment != "":
+		return "", fmt.Errorf("query strings and fragments are not allowed")
+	}
+	if port := u.Port(); port != "" && strings.Trim(port, "0123456789") != "" {
+		return "", fmt.Errorf("invalid port %s", port)
+	}
+
+	// API paths are appended to the base URL, so ".../api" would double them.
+	path := strings.TrimRight(u.EscapedPath(), "/")
+	path = strings.TrimRight(strings.TrimSuffix(path, "/api"), "/")
+	return u.Scheme + "://" + strings.ToLower(u.Host) + path, nil
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
+)
+
+// projectConfigFileName is the project file holding deploy settings such as
+// size budgets. It is read by the CLI only.
+const projectConfigFileName = "robotx.yaml"
+
+// budgetKeys are the budgets accepted under "budgets" in robotx.yaml.
+var budgetKeys = []string{"total", "max_js", "max_css", "max_file"}
+
+// projectConfig is the part of robotx.yaml the CLI reads; other top-level
+// keys are left alone.
+type projectConfig struct {
+	Budgets map[string]string            `yaml:"budgets"`
+	Targets map[string]*deployTarget     `yaml:"targets"`
+	Env     map[string]map[string]string `yaml:"env"`
+}
+
+// budgetsBreakdown is attached to budget_exceeded errors.
+type budgetsBreakdown struct {
+	File string `json:"file"`
+	*pipeline.BudgetExceededError
+}
+
+// loadProjectConfig reads the robotx.yaml of the project in projectPath,
+// with its template variables expanded. A project without one has an empty
+// config.
+func loadProjectConfig(projectPath string) (*projectConfig, error) {
+	var cfg projectConfig
+	raw, err := os.ReadFile(filepath.Join(projectPath, projectConfigFileName))
+	if os.IsNotExist(err) {
+		return &cfg, nil
+	}
+	if err != nil {
+		return nil, newCLIError("invalid_project_config", "failed to read "+projectConfigFileName, ExitGeneral, err)
+	}
+	if err := decodeProjectYAML(raw, projectPath, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// loadBudgets reads the size budgets of the project in projectPath. It
+// returns zero budgets when the project has no robotx.yaml or no budgets.
+func loadBudgets(projectPath string) (pipeline.Budgets, error) {
+	var budgets pipeline.Budgets
+	cfg, err := loadProjectConfig(projectPath)
+	if err != nil {
+		return budgets, err
+	}
+
+	fields := map[string]*int64{
+		"total":    &budgets.Total,
+		"max_js":   &budgets.MaxJS,
+		"max_css":  &budgets.MaxCSS,
+		"max_file": &budgets.MaxFile,
+	}
+	keys := make([]string, 0, len(cfg.Budgets))
+	for key := range cfg.Budgets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		field, ok := fields[key]
+		if !ok {
+			return budgets, newCLIError("invalid_project_config", fmt.Sprintf("%s: unknown budget %q (use %s)", projectConfigFileName, key, strings.Join(budgetKeys, ", ")), ExitGeneral, nil)
+		}
+		size, err := parseByteSize(cfg.Budgets[key])
+		if err != nil {
+			return budgets, newCLIError("invalid_project_config", fmt.Sprintf("%s: budgets.%s: %v", projectConfigFileName, key, err), ExitGeneral, nil)
+		}
+		*field = size
+	}
+	return budgets, nil
+}
+
+// byteUnits are the size suffixes parseByteSize accepts, longest first.
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// parseByteSize parses sizes such as "250KB", "1.5 MB" or "4096"; units are
+// binary (1 KB = 1024 bytes).
+func parseByteSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q: use a positive number of bytes or a KB/MB/GB size", value)
+	}
+	return int64(n * float64(multiplier)), nil
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// buildLogTailBytes bounds how much local build output is kept for failure
+// analysis; the signatures that matter are near the end.
+const buildLogTailBytes = 64 << 10
+
+// buildFailure is the analysis of a failed local build. It is printed as
+// suggestions and returned as the error details in JSON output.
+type buildFailure struct {
+	Category    string   `json:"failure_category"`
+	Summary     string   `json:"summary"`
+	MatchedLine string   `json:"matched_line,omitempty"`
+	Command     string   `json:"command,omitempty"`
+	Suggestions []string `json:"suggestions"`
+}
+
+type failureSignature struct {
+	category    string
+	summary     string
+	patterns    []*regexp.Regexp
+	suggestions []string
+}
+
+// buildFailureSignatures are checked in order; the first match wins, so more
+// specific signatures come first.
+var buildFailureSignatures = []failureSignature{
+	{
+		category: "out_of_memory",
+		summary:  "The build ran out of memory",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`JavaScript heap out of memory`),
+			regexp.MustCompile(`Reached heap limit`),
+			regexp.MustCompile(`(?i)\bENOMEM\b`),
+			regexp.MustCompile(`(?m)^Killed\s*$`),
+		},
+		suggestions: []string{
+			`Raise the Node.js heap limit, e.g. NODE_OPTIONS="--max-old-space-size=4096" robotx deploy ...`,
+			"Close other memory-hungry processes or build on a machine with more memory",
+		},
+	},
+	{
+		category: "node_version_mismatch",
+		summary:  "The installed Node.js version does not match what the project requires",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`The engine "node" is incompatible`),
+			regexp.MustCompile(`\bEBADENGINE\b`),
+			regexp.MustCompile(`(?i)unsupported engine`),
+			regexp.MustCompile(`(?i)requires (a )?node(\.js)? (version )?[v>=^~]*\d`),
+			regexp.MustCompile(`ERR_OSSL_EVP_UNSUPPORTED|digital envelope routines::unsupported`),
+			regexp.MustCompile(`SyntaxError: Unexpected token '(\?|\.)'`),
+		},
+		suggestions: []string{
+			`Check "engines.node" in package.json and .nvmrc, then switch Node.js versions (e.g. nvm use)`,
+			"Run node --version to confirm which version the build uses",
+		},
+	},
+	{
+		category: "typescript_errors",
+		summary:  "TypeScript reported type errors",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`error TS\d+:`),
+			regexp.MustCompile(`Found \d+ errors?( in \d+ files?)?\.`),
+		},
+		suggestions: []string{
+			"Fix the type errors reported above; run npx tsc --noEmit to list them all",
+			"If the build script runs tsc before bundling, pass a bundle-only command with --build-command to deploy anyway",
+		},
+	},
+	{
+		category: "missing_dependency",
+		summary:  "A module or command the build needs is not installed",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`Cannot find module '[^']+'`),
+			regexp.MustCompile(`Module not found: (Error: )?Can't resolve`),
+			regexp.MustCompile(`ERR_MODULE_NOT_FOUND`),
+			regexp.MustCompile(`Failed to resolve import`),
+			regexp.MustCompile(`(?m)(sh: \d+: |sh: )?\S+: (command )?not found\s*$`),
+			regexp.MustCompile(`npm ERR! (code )?E404`),
+			regexp.MustCompile(`\bERESOLVE\b`),
+		},
+		suggestions: []string{
+			"Add the missing package to package.json dependencies and commit the lockfile",
+			"Make sure the install step ran; pass --install-command (e.g. \"npm ci\") if the project needs a custom one",
+			"For peer dependency conflicts (ERESOLVE), try --install-command \"npm install --legacy-peer-deps\"",
+		},
+	},
+}
+
+// analyzeBuildFailure matches the build output against the known failure
+// signatures. Output that matches none is reported as "unknown".
+func analyzeBuildFailure(output, command string) *buildFailure {
+	for _, sig := range buildFailureSignatures {
+		for _, pattern := range sig.patterns {
+			loc := pattern.FindStringIndex(output)
+			if loc == nil {
+				continue
+			}
+			return &buildFailure{
+				Category:    sig.category,
+				Summary:     sig.summary,
+				MatchedLine: lineAt(output, loc[0]),
+				Command:     command,
+				Suggestions: sig.suggestions,
+			}
+		}
+	}
+	return &buildFailure{
+		Category: "unknown",
+		Summary:  "The build failed for a reason robotx does not recognize",
+		Command:  command,
+		Suggestions: []string{
+			"Run the command locally in the project directory to reproduce: " + command,
+		},
+	}
+}
+
+func lineAt(s string, offset int) string {
+	start := strings.LastIndexByte(s[:offset], '\n') + 1
+	end := strings.IndexByte(s[offset:], '\n')
+	if end < 0 {
+		end = len(s)
+	} else {
+		end += offset
+	}
+	return strings.TrimSpace(s[start:end])
+}
+
+// buildFailureError is a failed local build command together with the
+// analysis of its output.
+type buildFailureError struct {
+	err      error
+	analysis *buildFailure
+}
+
+func (e *buildFailureError) Error() string { return e.err.Error() }
+
+func (e *buildFailureError) Unwrap() error { return e.err }
+
+// tailBuffer keeps the last max bytes written to it. The build's stdout and
+// stderr are copied concurrently, so writes are serialized.
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func newTailBuffer(max int) *tailBuffer {
+	return &tailBuffer{max: max}
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - t.max; over > 0 {
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// buildLogsDir holds the output of local builds, one file per build, next to
+// the deploy state: <project>/.robotx/logs/<build-id>.log.
+const (
+	buildLogsDir  = "logs"
+	maxBuildLogs  = 20
+	buildLogsPerm = 0o644
+
+	// buildLogCommandPrefix starts the line recording each command run.
+	buildLogCommandPrefix = "$ "
+)
+
+// buildLogPath returns the log file of buildID for the project whose state
+// file is st.
+func (st *deployState) buildLogPath(buildID string) string {
+	return filepath.Join(filepath.Dir(st.path), buildLogsDir, buildID+".log")
+}
+
+// createBuildLog opens the log file for a local build of the project in
+// projectPath and removes the oldest logs beyond maxBuildLogs.
+func createBuildLog(projectPath, buildID string) (*os.File, error) {
+	dir := filepath.Join(projectPath, deployStateDir, buildLogsDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	pruneBuildLogs(dir, maxBuildLogs-1)
+	return os.OpenFile(filepath.Join(dir, buildID+".log"), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, buildLogsPerm)
+}
+
+// appendBuildLog opens the log file of a local build for more output, such
+// as the functions build that runs after the main one.
+func appendBuildLog(projectPath, buildID string) (*os.File, error) {
+	dir := filepath.Join(projectPath, deployStateDir, buildLogsDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filepath.Join(dir, buildID+".log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, buildLogsPerm)
+}
+
+func pruneBuildLogs(dir string, keep int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	type logFile struct {
+		path    string
+		modTime int64
+	}
+	var logs []logFile
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".log" {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			logs = append(logs, logFile{filepath.Join(dir, entry.Name()), info.ModTime().UnixNano()})
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i].modTime > logs[j].modTime })
+	for i := keep; i < len(logs); i++ {
+		os.Remove(logs[i].path)
+	}
+}
+
+// Log levels understood by logs --level, lowest first.
+var logLevels = []string{"info", "warn", "error"}
+
+var (
+	errorLinePattern = regexp.MustCompile(`(?i)\b(error|err!|fatal|panic|failed)\b`)
+	warnLinePattern  = regexp.MustCompile(`(?i)\b(warn|warning|deprecated)\b`)
+)
+
+// logLineLevel guesses the level of a build output line from its text; build
+// tools do not share a log format. Command lines are info.
+func logLineLevel(line string) int {
+	switch {
+	case strings.HasPrefix(line, buildLogCommandPrefix):
+		return 0
+	case errorLinePattern.MatchString(line):
+		return 2
+	case warnLinePattern.MatchString(line):
+		return 1
+	}
+	return 0
+}
+
+// logFilter selects log lines by regular expression and minimum level.
+type logFilter struct {
+	pattern  *regexp.Regexp
+	minLevel int
+}
+
+func newLogFilter(grep, level string) (*logFilter, error) {
+	f := &logFilter{}
+	if grep != "" {
+		re, err := regexp.Compile(grep)
+		if err != nil {
+			return nil, newCLIError("invalid_argument", fmt.Sprintf("invalid --grep pattern: %v", err), ExitGeneral, nil)
+		}
+		f.pattern = re
+	}
+	if level = strings.ToLower(strings.TrimSpace(level)); level != "" {
+		f.minLevel = -1
+		for i, name := range logLevels {
+			if name == level {
+				f.minLevel = i
+			}
+		}
+		if f.minLevel < 0 {
+			return nil, newCLIError("invalid_argument", fmt.Sprintf("invalid --level %q (expected %s)", level, strings.Join(logLevels, ", ")), ExitGeneral, nil)
+		}
+	}
+	return f, nil
+}
+
+func (f *logFilter) match(line string) bool {
+	if f.minLevel > 0 && logLineLevel(line) < f.minLevel {
+		return false
+	}
+	return f.pattern == nil || f.pattern.MatchString(line)
+}
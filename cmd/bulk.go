@@ -0,0 +1,383 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// envKeyPattern limits environment variable names to the portable set.
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Result states of a bulk item.
+const (
+	bulkPlanned = "planned"
+	bulkOK      = "ok"
+	bulkFailed  = "failed"
+)
+
+type bulkOptions struct {
+	*app
+	file   string
+	dryRun bool
+}
+
+// bulkFile is the operations file read by bulk.
+type bulkFile struct {
+	Operations []bulkOperation `yaml:"operations"`
+}
+
+// bulkOperation applies its actions to one or more projects, referenced by
+// ID or name.
+type bulkOperation struct {
+	Project    string            `yaml:"project"`
+	Projects   []string          `yaml:"projects"`
+	Rename     string            `yaml:"rename"`
+	Visibility string            `yaml:"visibility"`
+	Env        map[string]string `yaml:"env"`
+	UnsetEnv   []string          `yaml:"unset_env"`
+	Delete     bool              `yaml:"delete"`
+}
+
+type bulkResult struct {
+	Operation   int      `json:"operation"`
+	Project     string   `json:"project"`
+	ProjectID   string   `json:"project_id,omitempty"`
+	ProjectName string   `json:"project_name,omitempty"`
+	Actions     []string `json:"actions"`
+	Status      string   `json:"status"`
+	Error       string   `json:"error,omitempty"`
+}
+
+type bulkResponse struct {
+	File      string       `json:"file"`
+	DryRun    bool         `json:"dry_run"`
+	Results   []bulkResult `json:"results"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+}
+
+func newBulkCmd(a *app) *cobra.Command {
+	o := &bulkOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "bulk",
+		Short: "Apply batched operations to many projects",
+		Long: `Apply the operations of a YAML file to many projects: rename, visibility
+changes, runtime environment variable updates and deletion.
+
+  operations:
+    - project: my-app            # project ID or name
+      rename: my-app-legacy
+    - projects: [proj_a1, proj_b2]
+      visibility: private
+      env: {API_URL: https://api.example.com}
+      unset_env: [OLD_TOKEN]
+    - project: proj_c3
+      delete: true
+
+The whole file is validated before anything changes. Each operation and
+project is applied in order; a failure is reported for that item and the
+remaining items still run. --dry-run resolves the projects and prints the
+plan without changing anything. Files with deletions ask for confirmation
+unless --yes is set. Use -f - to read the file from stdin.`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVarP(&o.file, "file", "f", "", "Operations file (- for stdin)")
+	cmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "Print the planned changes without applying them")
+	markFlagsRequired(cmd, "file")
+	return cmd
+}
+
+func (o *bulkOptions) run(cmd *cobra.Command, args []string) error {
+	ops, err := o.readOperations(cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	usesEnv := false
+	for _, op := range ops {
+		usesEnv = usesEnv || len(op.Env) > 0 || len(op.UnsetEnv) > 0
+	}
+	if usesEnv && !serverCapabilities(c, baseURL).Supports(client.CapabilityProjectEnv) {
+		return newCLIError("unsupported_server", "this RobotX server does not support project environment variables", ExitAPI, nil)
+	}
+	projects, err := c.ListProjects(0)
+	if err != nil {
+		return newCLIError("api_error", "failed to list projects", ExitAPI, err)
+	}
+
+	resp := bulkResponse{File: o.file, DryRun: o.dryRun, Results: []bulkResult{}}
+	deletions := 0
+	for i, op := range ops {
+		for _, ref := range op.refs() {
+			result := bulkResult{Operation: i + 1, Project: ref, Actions: op.describe(), Status: bulkPlanned}
+			if project, err := resolveBulkProject(c, projects, ref); err != nil {
+				result.Status, result.Error = bulkFailed, err.Error()
+			} else {
+				result.ProjectID, result.ProjectName = project.ProjectID, project.Name
+			}
+			if op.Delete {
+				deletions++
+			}
+			resp.Results = append(resp.Results, result)
+		}
+	}
+
+	if !o.dryRun {
+		if deletions > 0 {
+			if err := o.confirm(fmt.Sprintf("Apply %s to %d project(s), deleting %d", o.file, len(resp.Results), deletions)); err != nil {
+				return err
+			}
+		}
+		n := 0
+		for i, op := range ops {
+			for range op.refs() {
+				result := &resp.Results[n]
+				n++
+				if result.Status == bulkFailed {
+					o.logf("❌ #%d %s: %s\n", i+1, result.Project, result.Error)
+					continue
+				}
+				if err := applyBulkOperation(c, op, result.ProjectID); err != nil {
+					result.Status, result.Error = bulkFailed, err.Error()
+					o.logf("❌ #%d %s: %s\n", i+1, result.Project, result.Error)
+					continue
+				}
+				result.Status = bulkOK
+				o.logf("✅ #%d %s: %s\n", i+1, result.Project, strings.Join(result.Actions, ", "))
+			}
+		}
+	}
+	for _, r := range resp.Results {
+		switch r.Status {
+		case bulkFailed:
+			resp.Failed++
+		case bulkOK:
+			resp.Succeeded++
+		}
+	}
+
+	o.printBulkResults(resp)
+	if resp.Failed > 0 {
+		cliErr := newCLIError("bulk_failed", fmt.Sprintf("%d of %d item(s) failed", resp.Failed, len(resp.Results)), ExitAPI, nil)
+		cliErr.Details = resp
+		return cliErr
+	}
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// readOperations parses and validates the whole operations file.
+func (o *bulkOptions) readOperations(stdin io.Reader) ([]bulkOperation, error) {
+	var raw []byte
+	var err error
+	name := o.file
+	if o.file == "-" {
+		name = "stdin"
+		raw, err = io.ReadAll(stdin)
+	} else {
+		raw, err = os.ReadFile(o.file)
+	}
+	if err != nil {
+		return nil, newCLIError("invalid_bulk_file", "failed to read "+name, ExitGeneral, err)
+	}
+
+	var file bulkFile
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, newCLIError("invalid_bulk_file", "failed to parse "+name, ExitGeneral, err)
+	}
+	if len(file.Operations) == 0 {
+		return nil, newCLIError("invalid_bulk_file", name+" has no operations", ExitGeneral, nil)
+	}
+	var problems []string
+	for i, op := range file.Operations {
+		for _, problem := range op.validate() {
+			problems = append(problems, fmt.Sprintf("operation %d: %s", i+1, problem))
+		}
+	}
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			o.logf("❌ %s\n", problem)
+		}
+		cliErr := newCLIError("invalid_bulk_file", fmt.Sprintf("%s has %d problem(s)", name, len(problems)), ExitGeneral, nil)
+		cliErr.Details = map[string]interface{}{"problems": problems}
+		return nil, cliErr
+	}
+	return file.Operations, nil
+}
+
+func (op bulkOperation) refs() []string {
+	var refs []string
+	if ref := strings.TrimSpace(op.Project); ref != "" {
+		refs = append(refs, ref)
+	}
+	for _, ref := range op.Projects {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+func (op bulkOperation) validate() []string {
+	var problems []string
+	refs := op.refs()
+	if len(refs) == 0 {
+		problems = append(problems, "project or projects is required")
+	}
+	hasChanges := op.Rename != "" || op.Visibility != "" || len(op.Env) > 0 || len(op.UnsetEnv) > 0
+	switch {
+	case op.Delete && hasChanges:
+		problems = append(problems, "delete cannot be combined with other actions")
+	case !op.Delete && !hasChanges:
+		problems = append(problems, "no action: set rename, visibility, env, unset_env or delete")
+	}
+	if op.Rename != "" {
+		if len(refs) > 1 {
+			problems = append(problems, "rename applies to a single project")
+		}
+		if err := validateProjectName(op.Rename); err != nil {
+			problems = append(problems, "rename: "+err.Error())
+		}
+	}
+	if op.Visibility != "" && op.Visibility != "public" && op.Visibility != "private" {
+		problems = append(problems, fmt.Sprintf("visibility must be public or private, got %q", op.Visibility))
+	}
+	for key := range op.Env {
+		if !envKeyPattern.MatchString(key) {
+			problems = append(problems, fmt.Sprintf("invalid env name %q", key))
+		}
+	}
+	for _, key := range op.UnsetEnv {
+		if !envKeyPattern.MatchString(key) {
+			problems = append(problems, fmt.Sprintf("invalid unset_env name %q", key))
+		}
+		if _, ok := op.Env[key]; ok {
+			problems = append(problems, fmt.Sprintf("%s is both set and unset", key))
+		}
+	}
+	return problems
+}
+
+// describe lists the actions of an operation; env values are not shown.
+func (op bulkOperation) describe() []string {
+	var actions []string
+	if op.Rename != "" {
+		actions = append(actions, "rename to "+op.Rename)
+	}
+	if op.Visibility != "" {
+		actions = append(actions, "visibility "+op.Visibility)
+	}
+	if len(op.Env) > 0 {
+		keys := make([]string, 0, len(op.Env))
+		for key := range op.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		actions = append(actions, "set env "+strings.Join(keys, " "))
+	}
+	if len(op.UnsetEnv) > 0 {
+		actions = append(actions, "unset env "+strings.Join(op.UnsetEnv, " "))
+	}
+	if op.Delete {
+		actions = append(actions, "delete")
+	}
+	return actions
+}
+
+// resolveBulkProject finds a project by ID or name in the listed projects,
+// falling back to a lookup by ID for projects beyond the list.
+func resolveBulkProject(c *client.Client, projects []*client.Project, ref string) (*client.Project, error) {
+	var byName []*client.Project
+	for _, p := range projects {
+		if p.ProjectID == ref {
+			return p, nil
+		}
+		if p.Name == ref {
+			byName = append(byName, p)
+		}
+	}
+	switch len(byName) {
+	case 1:
+		return byName[0], nil
+	case 0:
+	default:
+		return nil, fmt.Errorf("name %s matches %d projects; use the project ID", ref, len(byName))
+	}
+	project, err := c.GetProject(ref)
+	if err != nil {
+		if client.IsNotFound(err) {
+			return nil, fmt.Errorf("project not found")
+		}
+		return nil, err
+	}
+	return project, nil
+}
+
+func applyBulkOperation(c *client.Client, op bulkOperation, projectID string) error {
+	if op.Delete {
+		return c.DeleteProject(projectID)
+	}
+	if op.Rename != "" || op.Visibility != "" {
+		if _, err := c.UpdateProject(projectID, client.UpdateProjectRequest{Name: op.Rename, Visibility: op.Visibility}); err != nil {
+			return err
+		}
+	}
+	if len(op.Env) > 0 {
+		if err := c.SetProjectEnv(projectID, op.Env); err != nil {
+			return err
+		}
+	}
+	for _, key := range op.UnsetEnv {
+		if err := c.DeleteProjectEnv(projectID, key); err != nil {
+			return fmt.Errorf("unset %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (o *bulkOptions) printBulkResults(resp bulkResponse) {
+	if o.isJSONOutput() {
+		return
+	}
+	w := tabwriter.NewWriter(o.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tPROJECT\tPROJECT_ID\tACTIONS\tRESULT")
+	for _, r := range resp.Results {
+		result := r.Status
+		if r.Error != "" {
+			result += ": " + r.Error
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", r.Operation, r.Project, valueOrDash(r.ProjectID), strings.Join(r.Actions, ", "), result)
+	}
+	_ = w.Flush()
+	if resp.DryRun {
+		fmt.Fprintln(o.out(), "Dry run: no changes were made.")
+	}
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+type cacheOptions struct {
+	*app
+	projectID string
+	paths     []string
+}
+
+type cachePurgeResponse struct {
+	ProjectID string `json:"project_id"`
+	PurgeID   string `json:"purge_id,omitempty"`
+	// Paths are the purged paths; empty means the whole site.
+	Paths  []string `json:"paths,omitempty"`
+	Status string   `json:"status,omitempty"`
+}
+
+func newCacheCmd(a *app) *cobra.Command {
+	o := &cacheOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the CDN cache of a project",
+		Long: `Manage the CDN cache in front of a project's production URL.
+
+Inside a deployed project directory --project-id defaults to the recorded
+project.`,
+	}
+
+	purgeCmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Invalidate cached content after it changed",
+		Long: `Invalidate the CDN cache of a project's production URL so visitors get the
+current content, e.g. after files put. Without --path the whole site is
+purged; --path, which can be repeated, purges single paths, or everything
+under a directory when the path ends with / or *.
+
+publish --purge purges the whole site after a successful publish.`,
+		Example: `  robotx cache purge
+  robotx cache purge --path /index.html --path /assets/`,
+		Args: cobra.NoArgs,
+		RunE: o.runPurge,
+	}
+	cmd.AddCommand(purgeCmd)
+
+	cmd.PersistentFlags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	purgeCmd.Flags().StringSliceVar(&o.paths, "path", nil, "Path to purge, like /index.html (repeatable; default: the whole site)")
+	return cmd
+}
+
+func (o *cacheOptions) runPurge(cmd *cobra.Command, args []string) error {
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	paths := make([]string, 0, len(o.paths))
+	for _, p := range o.paths {
+		normalized, err := normalizePurgePath(p)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, normalized)
+	}
+	projectID, _ := stateProjectID(o.projectID)
+	if projectID == "" {
+		return newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityCachePurge) {
+		return newCLIError("unsupported_server", "this RobotX server does not support purging the CDN cache", ExitAPI, nil)
+	}
+	purge, err := o.purgeCache(c, projectID, paths)
+	if err != nil {
+		if client.IsNotFound(err) {
+			return newCLIError("unsupported_server", "this RobotX server does not support purging the CDN cache", ExitAPI, err)
+		}
+		return newCLIError("api_error", "failed to purge the CDN cache", ExitAPI, err)
+	}
+
+	if err := o.emitSuccess("cache "+cmd.Name(), cachePurgeResponse{
+		ProjectID: projectID,
+		PurgeID:   purge.PurgeID,
+		Paths:     purge.Paths,
+		Status:    purge.Status,
+	}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// purgeCache purges paths, or the whole site when empty, from the CDN cache
+// of the project, reporting progress.
+func (a *app) purgeCache(c *client.Client, projectID string, paths []string) (*client.CachePurge, error) {
+	if len(paths) == 0 {
+		a.logf("🧹 Purging the CDN cache of %s...\n", projectID)
+	} else {
+		a.logf("🧹 Purging %s from the CDN cache of %s...\n", strings.Join(paths, ", "), projectID)
+	}
+	purge, err := c.PurgeCache(projectID, paths)
+	if err != nil {
+		return nil, err
+	}
+	if purge.Status != "" && purge.Status != "completed" {
+		a.logf("✅ Cache purge %s (%s)\n", purge.Status, firstNonEmpty(purge.PurgeID, "no ID"))
+	} else {
+		a.logf("✅ Cache purged\n")
+	}
+	return purge, nil
+}
+
+// purgeAfterPublish purges the whole site for publish --purge. The publish
+// has already succeeded, so failures are only reported.
+func (a *app) purgeAfterPublish(c *client.Client, baseURL, projectID string) bool {
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityCachePurge) {
+		a.logf("⚠️  Skipping cache purge: this RobotX server does not support purging the CDN cache\n")
+		return false
+	}
+	if _, err := a.purgeCache(c, projectID, nil); err != nil {
+		a.logf("⚠️  Failed to purge the CDN cache: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// normalizePurgePath turns p into a clean absolute path of the site,
+// keeping the trailing / or * that purges everything below it.
+func normalizePurgePath(p string) (string, error) {
+	p = strings.TrimSpace(strings.ReplaceAll(p, "\\", "/"))
+	if p == "" {
+		return "", newCLIError("invalid_argument", "invalid --path: name a path of the site, like /index.html", ExitGeneral, nil)
+	}
+	for _, segment := range strings.Split(p, "/") {
+		if segment == ".." {
+			return "", newCLIError("invalid_argument", fmt.Sprintf("invalid path %q: '..' is not allowed", p), ExitGeneral, nil)
+		}
+	}
+	base := strings.TrimSuffix(p, "*")
+	cleaned := path.Clean("/" + base)
+	if strings.HasSuffix(base, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	if base != p {
+		cleaned += "*"
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+)
+
+const capabilitiesCacheTTL = time.Hour
+
+type cachedCapabilities struct {
+	FetchedAt    time.Time            `json:"fetched_at"`
+	Capabilities *client.Capabilities `json:"capabilities"`
+}
+
+// serverCapabilities returns capabilities for baseURL, using a short-lived
+// on-disk cache so each CLI invocation does not pay for an extra request.
+func serverCapabilities(c *client.Client, baseURL string) *client.Capabilities {
+	key := strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	path, pathErr := robotxDataPath("capabilities.json")
+
+	cache := map[string]cachedCapabilities{}
+	if pathErr == nil {
+		if raw, err := os.ReadFile(path); err == nil {
+			_ = json.Unmarshal(raw, &cache)
+		}
+		if entry, ok := cache[key]; ok && entry.Capabilities != nil && time.Since(entry.FetchedAt) < capabilitiesCacheTTL {
+			client.SetCapabilities(key, entry.Capabilities)
+			return entry.Capabilities
+		}
+	}
+
+	caps, err := c.GetCapabilities()
+	if err != nil {
+		// Capability discovery is best-effort; fall back to endpoint probing.
+		return &client.Capabilities{Known: false}
+	}
+	if pathErr == nil {
+		cache[key] = cachedCapabilities{FetchedAt: time.Now(), Capabilities: caps}
+		if raw, err := json.MarshalIndent(cache, "", "  "); err == nil {
+			_ = os.WriteFile(path, raw, 0o600)
+		}
+	}
+	return caps
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// ciTemplates holds the pipeline files written by robotx ci init. Like the
+// examples they use [[ ]] delimiters so CI expressions stay literal.
+//
+//go:embed ci/*.tmpl
+var ciTemplates embed.FS
+
+// ciProviders maps each --provider to its template and the path of the
+// pipeline file relative to the repository root.
+var ciProviders = map[string]struct {
+	template string
+	path     string
+}{
+	"github":   {template: "ci/github.tmpl", path: filepath.Join(".github", "workflows", "robotx.yml")},
+	"gitlab":   {template: "ci/gitlab.tmpl", path: ".gitlab-ci.yml"},
+	"circleci": {template: "ci/circleci.tmpl", path: filepath.Join(".circleci", "config.yml")},
+}
+
+const defaultCINodeVersion = "20"
+
+// ciData parameterizes the pipeline templates.
+type ciData struct {
+	ProjectName    string
+	ProjectPath    string
+	ProjectType    string
+	BaseURL        string
+	ReleaseVersion string
+	Branch         string
+	Node           bool
+	NodeVersion    string
+	Corepack       bool
+	Image          string
+	DeployFlags    string
+}
+
+type ciInitResponse struct {
+	Provider       string `json:"provider"`
+	Path           string `json:"path"`
+	ProjectType    string `json:"project_type"`
+	PackageManager string `json:"package_manager,omitempty"`
+	Written        bool   `json:"written"`
+	Content        string `json:"content,omitempty"`
+}
+
+type ciInitOptions struct {
+	*app
+	provider string
+	name     string
+	branch   string
+	force    bool
+	dryRun   bool
+}
+
+func newCICmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ci",
+		Short: "Set up continuous deployment",
+	}
+	cmd.AddCommand(newCIInitCmd(a))
+	return cmd
+}
+
+func newCIInitCmd(a *app) *cobra.Command {
+	o := &ciInitOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Write a CI pipeline that deploys the project",
+		Long: `Write a pipeline file for GitHub Actions, GitLab CI or CircleCI that installs
+robotx and deploys the current project: pull requests get a preview build
+and pushes to the main branch are published.
+
+The pipeline is tailored to the project: Node projects get the Node version
+from .nvmrc or .node-version and the install and build commands of the
+package manager whose lockfile is present. The file is written at the root
+of the git repository; a project in a subdirectory is deployed by path.
+
+The API key is never written into the file. The pipeline reads
+ROBOTX_API_KEY from a repository secret (GitHub), a masked CI/CD variable
+(GitLab) or the robotx context (CircleCI).`,
+		Example: `  robotx ci init --provider github
+  robotx ci init --provider gitlab --name my-app
+  robotx ci init --provider circleci --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+	cmd.Flags().StringVar(&o.provider, "provider", "", "CI provider (github|gitlab|circleci)")
+	cmd.Flags().StringVarP(&o.name, "name", "n", "", "Project name to deploy (default: the deployed project or the directory name)")
+	cmd.Flags().StringVar(&o.branch, "branch", "main", "Branch whose pushes are published to production")
+	cmd.Flags().BoolVar(&o.force, "force", false, "Overwrite an existing pipeline file")
+	cmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "Print the pipeline file instead of writing it")
+	_ = cmd.MarkFlagRequired("provider")
+	_ = cmd.RegisterFlagCompletionFunc("provider", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return ciProviderNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+	return cmd
+}
+
+func (o *ciInitOptions) run(cmd *cobra.Command, _ []string) error {
+	provider, ok := ciProviders[strings.ToLower(strings.TrimSpace(o.provider))]
+	if !ok {
+		return newCLIError("invalid_argument", fmt.Sprintf("unknown CI provider %q (supported: %s)", o.provider, strings.Join(ciProviderNames(), ", ")), ExitGeneral, nil)
+	}
+	name := strings.ToLower(strings.TrimSpace(o.provider))
+
+	// The project settings are shared with robotx examples.
+	project := (&examplesOptions{app: o.app, name: o.name}).exampleData()
+	root := gitRootDir(project.ProjectDir)
+	projectPath, err := filepath.Rel(root, project.ProjectDir)
+	if err != nil {
+		projectPath = "."
+	}
+	data := ciData{
+		ProjectName:    project.ProjectName,
+		ProjectPath:    filepath.ToSlash(projectPath),
+		BaseURL:        project.BaseURL,
+		ReleaseVersion: project.ReleaseVersion,
+		Branch:         firstNonEmpty(strings.TrimSpace(o.branch), "main"),
+	}
+	pm := detectCIProject(project.ProjectDir, name, &data)
+
+	content, err := renderTemplate(ciTemplates, provider.template, data)
+	if err != nil {
+		return newCLIError("render_failed", "failed to render pipeline file", ExitGeneral, err)
+	}
+	target := filepath.Join(root, provider.path)
+	resp := ciInitResponse{Provider: name, Path: target, ProjectType: data.ProjectType, PackageManager: pm}
+
+	if o.dryRun {
+		resp.Content = content
+		if err := o.emitSuccess("ci "+cmd.Name(), resp); err != nil {
+			return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+		}
+		if !o.isJSONOutput() {
+			fmt.Fprint(o.out(), content)
+		}
+		return nil
+	}
+
+	if fileExists(target) && !o.force {
+		return newCLIError("file_exists", fmt.Sprintf("%s already exists; use --force to overwrite it", target), ExitGeneral, nil)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return newCLIError("write_failed", "failed to create "+filepath.Dir(target), ExitGeneral, err)
+	}
+	if err := os.WriteFile(target, []byte(content), 0o644); err != nil {
+		return newCLIError("write_failed", "failed to write "+target, ExitGeneral, err)
+	}
+	resp.Written = true
+
+	if err := o.emitSuccess("ci "+cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if !o.isJSONOutput() {
+		o.logf("✅ Wrote %s (%s project)\n", target, data.ProjectType)
+		o.logf("👉 Add ROBOTX_API_KEY %s, then commit the file\n", o.tr(ciSecretHint[name]))
+	}
+	return nil
+}
+
+// detectCIProject fills in the project type, toolchain image and deploy
+// flags of data from the project directory, and returns the package manager
+// of a Node project.
+func detectCIProject(dir, provider string, data *ciData) string {
+	data.ProjectType = "static"
+	if !fileExists(filepath.Join(dir, "package.json")) {
+		if provider == "circleci" {
+			data.Image = "cimg/base:stable"
+		} else {
+			data.Image = "buildpack-deps:bookworm-curl"
+		}
+		return ""
+	}
+
+	data.ProjectType = "node"
+	data.Node = true
+	data.NodeVersion = defaultCINodeVersion
+	for _, file := range []string{".nvmrc", ".node-version"} {
+		if raw, err := os.ReadFile(filepath.Join(dir, file)); err == nil {
+			if v := strings.TrimPrefix(strings.TrimSpace(string(raw)), "v"); v != "" {
+				data.NodeVersion = v
+				break
+			}
+		}
+	}
+	switch provider {
+	case "circleci":
+		// cimg/node is tagged by full version; a major version uses lts.
+		tag := "lts"
+		if strings.Contains(data.NodeVersion, ".") {
+			tag = data.NodeVersion
+		}
+		data.Image = "cimg/node:" + tag
+	default:
+		data.Image = "node:" + data.NodeVersion
+	}
+
+	pm, install, build := "npm", "", ""
+	switch {
+	case fileExists(filepath.Join(dir, "pnpm-lock.yaml")):
+		pm, install, build = "pnpm", "pnpm install --frozen-lockfile", "pnpm run build"
+		data.Corepack = true
+	case fileExists(filepath.Join(dir, "yarn.lock")):
+		pm, install, build = "yarn", "yarn install --frozen-lockfile", "yarn build"
+	case fileExists(filepath.Join(dir, "package-lock.json")):
+		install = "npm ci"
+	}
+	// Without a build script the build command is left to the build plan.
+	if !hasBuildScript(filepath.Join(dir, "package.json")) {
+		build = ""
+	}
+	if install != "" {
+		data.DeployFlags += fmt.Sprintf(" --install-command '%s'", install)
+	}
+	if build != "" {
+		data.DeployFlags += fmt.Sprintf(" --build-command '%s'", build)
+	}
+	return pm
+}
+
+func hasBuildScript(packageJSON string) bool {
+	raw, err := os.ReadFile(packageJSON)
+	if err != nil {
+		return false
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(raw, &pkg); err != nil {
+		return false
+	}
+	return strings.TrimSpace(pkg.Scripts["build"]) != ""
+}
+
+// gitRootDir returns the nearest directory from dir upward that holds .git,
+// or dir itself outside a git repository.
+func gitRootDir(dir string) string {
+	for d := dir; ; {
+		if fileExists(filepath.Join(d, ".git")) {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// ciSecretHint tells where each provider expects ROBOTX_API_KEY.
+var ciSecretHint = map[string]string{
+	"github":   "as a repository secret",
+	"gitlab":   "as a masked CI/CD variable",
+	"circleci": "to a CircleCI context named robotx",
+}
+
+func ciProviderNames() []string {
+	names := make([]string, 0, len(ciProviders))
+	for name := range ciProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,413 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+type configOptions struct {
+	*app
+	showSecrets bool
+	force       bool
+}
+
+type configViewResponse struct {
+	ConfigFile string                 `json:"config_file"`
+	Config     map[string]interface{} `json:"config"`
+}
+
+type configValueResponse struct {
+	ConfigFile string      `json:"config_file"`
+	Key        string      `json:"key"`
+	Value      interface{} `json:"value,omitempty"`
+	Removed    bool        `json:"removed,omitempty"`
+}
+
+type configValidateResponse struct {
+	ConfigFile string        `json:"config_file"`
+	Valid      bool          `json:"valid"`
+	Issues     []configIssue `json:"issues"`
+}
+
+func newConfigCmd(a *app) *cobra.Command {
+	o := &configOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "View and edit the CLI config file",
+		Long: `View and edit the RobotX config file. Keys use dotted paths,
+for example base_url or profiles.staging.base_url.`,
+	}
+
+	viewCmd := &cobra.Command{
+		Use:   "view",
+		Short: "Print the config file",
+		Args:  cobra.NoArgs,
+		RunE:  o.runView,
+	}
+	getCmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print a single config value",
+		Args:  cobra.ExactArgs(1),
+		RunE:  o.runGet,
+	}
+	setCmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a config value",
+		Args:  cobra.ExactArgs(2),
+		RunE:  o.runSet,
+	}
+	unsetCmd := &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a config value",
+		Args:  cobra.ExactArgs(1),
+		RunE:  o.runUnset,
+	}
+	validateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the config file for unknown keys and invalid values",
+		Args:  cobra.NoArgs,
+		RunE:  o.runValidate,
+	}
+	cmd.AddCommand(viewCmd, getCmd, setCmd, unsetCmd, validateCmd, newConfigMigrateCmd(o), newConfigEncryptCmd(o), newConfigDecryptCmd(o))
+
+	viewCmd.Flags().BoolVar(&o.showSecrets, "show-secrets", false, "Print secret values such as api_key unmasked")
+	getCmd.Flags().BoolVar(&o.showSecrets, "show-secrets", false, "Print secret values such as api_key unmasked")
+	setCmd.Flags().BoolVar(&o.force, "force", false, "Allow keys that are not part of the config schema")
+	return cmd
+}
+
+func (o *configOptions) runView(cmd *cobra.Command, args []string) error {
+	path, doc, err := o.loadConfigForEdit()
+	if err != nil {
+		return err
+	}
+	cfg, err := configDocumentMap(doc)
+	if err != nil {
+		return newCLIError("invalid_config", "failed to parse config file", ExitGeneral, err)
+	}
+	if !o.showSecrets {
+		maskConfigSecrets(cfg)
+	}
+
+	if err := o.emitSuccess("config "+cmd.Name(), configViewResponse{ConfigFile: path, Config: cfg}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if o.isJSONOutput() {
+		return nil
+	}
+
+	o.logf("📄 Config file: %s\n", path)
+	if len(cfg) == 0 {
+		fmt.Fprintln(o.out(), "(empty)")
+		return nil
+	}
+	out, err := yaml.Marshal(cfg)
+	if err != nil {
+		return newCLIError("output_error", "failed to render config", ExitGeneral, err)
+	}
+	fmt.Fprint(o.out(), string(out))
+	return nil
+}
+
+func (o *configOptions) runGet(cmd *cobra.Command, args []string) error {
+	path, doc, err := o.loadConfigForEdit()
+	if err != nil {
+		return err
+	}
+	keyPath, err := parseConfigKeyPath(args[0])
+	if err != nil {
+		return err
+	}
+	node := lookupConfigNode(doc.Content[0], keyPath)
+	if node == nil {
+		return newCLIError("config_key_not_found", fmt.Sprintf("config key not set: %s", args[0]), ExitGeneral, nil)
+	}
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return newCLIError("invalid_config", "failed to decode config value", ExitGeneral, err)
+	}
+	if !o.showSecrets {
+		last := keyPath[len(keyPath)-1]
+		wrapped := map[string]interface{}{last: value}
+		maskConfigSecrets(wrapped)
+		value = wrapped[last]
+	}
+
+	if err := o.emitSuccess("config "+cmd.Name(), configValueResponse{ConfigFile: path, Key: args[0], Value: value}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if o.isJSONOutput() {
+		return nil
+	}
+	if node.Kind == yaml.ScalarNode {
+		fmt.Fprintln(o.out(), fmt.Sprint(value))
+		return nil
+	}
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return newCLIError("output_error", "failed to render config value", ExitGeneral, err)
+	}
+	fmt.Fprint(o.out(), string(out))
+	return nil
+}
+
+func (o *configOptions) runSet(cmd *cobra.Command, args []string) error {
+	path, doc, err := o.loadConfigForEdit()
+	if err != nil {
+		return err
+	}
+	keyPath, err := parseConfigKeyPath(args[0])
+	if err != nil {
+		return err
+	}
+	if known, suggestion := isKnownConfigPath(cmd.Root(), keyPath); !known && !o.force {
+		msg := fmt.Sprintf("unknown config key: %s", args[0])
+		if suggestion != "" {
+			msg = fmt.Sprintf("%s (did you mean %s?)", msg, suggestion)
+		}
+		return newCLIError("unknown_config_key", msg+"; use --force to set it anyway", ExitGeneral, nil)
+	}
+
+	var valueDoc yaml.Node
+	if err := yaml.Unmarshal([]byte(args[1]), &valueDoc); err != nil || len(valueDoc.Content) == 0 {
+		valueDoc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str", Value: args[1]}}}
+	}
+	if err := setConfigNode(doc.Content[0], keyPath, valueDoc.Content[0]); err != nil {
+		return newCLIError("invalid_config", err.Error(), ExitGeneral, nil)
+	}
+	if err := saveConfigDocument(path, doc); err != nil {
+		return newCLIError("config_write_failed", "failed to write config file", ExitGeneral, err)
+	}
+
+	var value interface{}
+	_ = valueDoc.Content[0].Decode(&value)
+	if secretConfigKeys[keyPath[len(keyPath)-1]] {
+		if s, ok := value.(string); ok {
+			value = maskSecret(s)
+		}
+	}
+	o.logf("✅ Set %s in %s\n", args[0], path)
+	if err := o.emitSuccess("config "+cmd.Name(), configValueResponse{ConfigFile: path, Key: args[0], Value: value}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+func (o *configOptions) runUnset(cmd *cobra.Command, args []string) error {
+	path, doc, err := o.loadConfigForEdit()
+	if err != nil {
+		return err
+	}
+	keyPath, err := parseConfigKeyPath(args[0])
+	if err != nil {
+		return err
+	}
+	if !unsetConfigNode(doc.Content[0], keyPath) {
+		return newCLIError("config_key_not_found", fmt.Sprintf("config key not set: %s", args[0]), ExitGeneral, nil)
+	}
+	if err := saveConfigDocument(path, doc); err != nil {
+		return newCLIError("config_write_failed", "failed to write config file", ExitGeneral, err)
+	}
+
+	o.logf("✅ Removed %s from %s\n", args[0], path)
+	if err := o.emitSuccess("config "+cmd.Name(), configValueResponse{ConfigFile: path, Key: args[0], Removed: true}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+func (o *configOptions) runValidate(cmd *cobra.Command, args []string) error {
+	path, doc, err := o.loadConfigForEdit()
+	if err != nil {
+		return err
+	}
+	cfg, err := configDocumentMap(doc)
+	if err != nil {
+		return newCLIError("invalid_config", "failed to parse config file", ExitGeneral, err)
+	}
+
+	issues := validateConfigMap(cmd.Root(), cfg)
+	if len(issues) > 0 {
+		for _, issue := range issues {
+			o.logf("❌ %s: %s\n", issue.Key, issue.Message)
+		}
+		cliErr := newCLIError("invalid_config", fmt.Sprintf("config file has %d problem(s): %s", len(issues), path), ExitGeneral, nil)
+		cliErr.Details = configValidateResponse{ConfigFile: path, Valid: false, Issues: issues}
+		return cliErr
+	}
+
+	o.logf("✅ Config file is valid: %s\n", path)
+	if err := o.emitSuccess("config "+cmd.Name(), configValidateResponse{ConfigFile: path, Valid: true, Issues: []configIssue{}}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+func (a *app) loadConfigForEdit() (string, *yaml.Node, error) {
+	path, err := a.resolveConfigWritePath()
+	if err != nil {
+		return "", nil, newCLIError("config_error", "failed to resolve config path", ExitGeneral, err)
+	}
+	doc, err := loadConfigDocument(path)
+	if err != nil {
+		return "", nil, newCLIError("invalid_config", "failed to read config file", ExitGeneral, err)
+	}
+	return path, doc, nil
+}
+
+// loadConfigDocument parses the config file into a YAML node tree, keeping
+// comments and key order intact for later writes.
+func loadConfigDocument(path string) (*yaml.Node, error) {
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+	raw, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(raw)) > 0 {
+		if err := yaml.Unmarshal(raw, doc); err != nil {
+			return nil, err
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config root must be a mapping")
+	}
+	return doc, nil
+}
+
+func saveConfigDocument(path string, doc *yaml.Node) error {
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode config YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to encode config YAML: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to ensure config directory: %w", err)
+	}
+	return os.WriteFile(path, out.Bytes(), 0o600)
+}
+
+func configDocumentMap(doc *yaml.Node) (map[string]interface{}, error) {
+	cfg := map[string]interface{}{}
+	if err := doc.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		cfg = map[string]interface{}{}
+	}
+	return cfg, nil
+}
+
+func parseConfigKeyPath(key string) ([]string, error) {
+	parts := strings.Split(strings.TrimSpace(key), ".")
+	for _, part := range parts {
+		if strings.TrimSpace(part) == "" {
+			return nil, newCLIError("invalid_argument", fmt.Sprintf("invalid config key: %q", key), ExitGeneral, nil)
+		}
+	}
+	return parts, nil
+}
+
+func lookupConfigNode(node *yaml.Node, path []string) *yaml.Node {
+	for _, key := range path {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		node = next
+	}
+	return node
+}
+
+func setConfigNode(node *yaml.Node, path []string, value *yaml.Node) error {
+	for i, key := range path {
+		if node.Kind != yaml.MappingNode {
+			return fmt.Errorf("cannot set %s: %s is not a mapping", strings.Join(path, "."), strings.Join(path[:i], "."))
+		}
+		var next *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == key {
+				if i == len(path)-1 {
+					node.Content[j+1] = value
+					return nil
+				}
+				next = node.Content[j+1]
+				break
+			}
+		}
+		if next == nil {
+			keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+			if i == len(path)-1 {
+				node.Content = append(node.Content, keyNode, value)
+				return nil
+			}
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, keyNode, next)
+		}
+		node = next
+	}
+	return nil
+}
+
+func unsetConfigNode(node *yaml.Node, path []string) bool {
+	parent := lookupConfigNode(node, path[:len(path)-1])
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return false
+	}
+	key := path[len(path)-1]
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == key {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func maskConfigSecrets(cfg map[string]interface{}) {
+	for key, value := range cfg {
+		switch v := value.(type) {
+		case string:
+			if secretConfigKeys[key] {
+				cfg[key] = maskSecret(v)
+			}
+		case map[string]interface{}:
+			if key == credentialsConfigKey {
+				for url, secret := range v {
+					if s, ok := secret.(string); ok {
+						v[url] = maskSecret(s)
+					}
+				}
+				continue
+			}
+			maskConfigSecrets(v)
+		}
+	}
+}
+
+func maskSecret(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) <= 8 {
+		return "****"
+	}
+	return value[:4] + "****" + value[len(value)-4:]
+}
@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Encrypted config values are "enc:<method>:<base64 payload>". The
+// passphrase method stores salt, nonce and AES-256-GCM ciphertext under a
+// PBKDF2-SHA256 key; the age method stores the output of the age tool.
+const (
+	encryptedValuePrefix = "enc:"
+	encryptionPassphrase = "v1"
+	encryptionAge        = "age"
+
+	passphraseSaltSize   = 16
+	passphraseIterations = 600000
+)
+
+// errWrongPassphrase is returned when a value does not decrypt with the
+// passphrase given.
+var errWrongPassphrase = errors.New("wrong passphrase or corrupted value")
+
+type configEncryptResponse struct {
+	ConfigFile string `json:"config_file"`
+	// Method is passphrase or age; empty for decrypt.
+	Method string `json:"method,omitempty"`
+	// Keys lists the config keys encrypted or decrypted.
+	Keys []string `json:"keys"`
+}
+
+func isEncryptedValue(value string) bool {
+	return strings.HasPrefix(value, encryptedValuePrefix)
+}
+
+// secretCipher encrypts and decrypts config values, asking for the
+// passphrase at most once.
+type secretCipher struct {
+	a            *app
+	ageRecipient string
+	passphrase   string
+	confirm      bool
+}
+
+func (s *secretCipher) method() string {
+	if s.ageRecipient != "" {
+		return "age"
+	}
+	return "passphrase"
+}
+
+func (s *secretCipher) encrypt(plaintext string) (string, error) {
+	if s.ageRecipient != "" {
+		out, err := runAge([]byte(plaintext), "--encrypt", "--recipient", s.ageRecipient)
+		if err != nil {
+			return "", err
+		}
+		return encryptedValuePrefix + encryptionAge + ":" + base64.StdEncoding.EncodeToString(out), nil
+	}
+	passphrase, err := s.readPassphrase()
+	if err != nil {
+		return "", err
+	}
+	salt := make([]byte, passphraseSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	gcm, err := passphraseGCM(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	payload := append(append(salt, nonce...), gcm.Seal(nil, nonce, []byte(plaintext), nil)...)
+	return encryptedValuePrefix + encryptionPassphrase + ":" + base64.StdEncoding.EncodeToString(payload), nil
+}
+
+func (s *secretCipher) decrypt(value string) (string, error) {
+	method, encoded, ok := strings.Cut(strings.TrimPrefix(value, encryptedValuePrefix), ":")
+	if !ok {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	payload, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	switch method {
+	case encryptionAge:
+		identity := strings.TrimSpace(s.a.v.GetString("age_identity"))
+		if identity == "" {
+			return "", newCLIError("age_identity_required", "the API key is encrypted with age; set ROBOTX_AGE_IDENTITY or age_identity to an age identity file", ExitAuth, nil)
+		}
+		out, err := runAge(payload, "--decrypt", "--identity", expandHome(identity))
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	case encryptionPassphrase:
+		passphrase, err := s.readPassphrase()
+		if err != nil {
+			return "", err
+		}
+		if len(payload) < passphraseSaltSize {
+			return "", fmt.Errorf("malformed encrypted value")
+		}
+		gcm, err := passphraseGCM(passphrase, payload[:passphraseSaltSize])
+		if err != nil {
+			return "", err
+		}
+		rest := payload[passphraseSaltSize:]
+		if len(rest) < gcm.NonceSize() {
+			return "", fmt.Errorf("malformed encrypted value")
+		}
+		plaintext, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], nil)
+		if err != nil {
+			return "", errWrongPassphrase
+		}
+		return string(plaintext), nil
+	}
+	return "", fmt.Errorf("unknown encryption method %q; upgrade robotx", method)
+}
+
+func passphraseGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, passphraseIterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// readPassphrase returns ROBOTX_PASSPHRASE, or prompts for the passphrase on
+// a terminal, twice when choosing a new one.
+func (s *secretCipher) readPassphrase() (string, error) {
+	if s.passphrase != "" {
+		return s.passphrase, nil
+	}
+	if env, ok := os.LookupEnv("ROBOTX_PASSPHRASE"); ok && env != "" {
+		s.passphrase = env
+		return env, nil
+	}
+	if s.a.isNonInteractive() {
+		return "", newCLIError("passphrase_required", "the config passphrase is required; set ROBOTX_PASSPHRASE", ExitAuth, nil)
+	}
+	stdin := s.a.root.InOrStdin()
+	in := bufio.NewReader(stdin)
+	prompt := func(format string) string {
+		s.a.noticef(format)
+		restore := func() {}
+		if f, ok := stdin.(*os.File); ok {
+			restore = disableEcho(f)
+		}
+		line, _ := in.ReadString('\n')
+		restore()
+		s.a.noticef("\n")
+		return strings.TrimRight(line, "\r\n")
+	}
+	passphrase := prompt("🔑 Config passphrase: ")
+	if passphrase == "" {
+		return "", newCLIError("passphrase_required", "the config passphrase cannot be empty", ExitAuth, nil)
+	}
+	if s.confirm && prompt("🔑 Repeat the passphrase: ") != passphrase {
+		return "", newCLIError("invalid_argument", "the passphrases do not match", ExitGeneral, nil)
+	}
+	s.passphrase = passphrase
+	return passphrase, nil
+}
+
+// runAge runs the age tool with input on stdin.
+func runAge(input []byte, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath("age"); err != nil {
+		return nil, newCLIError("age_not_found", "age is not installed; see https://age-encryption.org", ExitGeneral, nil)
+	}
+	cmd := exec.Command("age", args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("age: %s", msg)
+		}
+		return nil, fmt.Errorf("age: %w", err)
+	}
+	return out, nil
+}
+
+// expandHome expands a leading ~/ in path to the home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// decryptAPIKey replaces an encrypted api_key, from the config file, a
+// profile or credentials, with its plaintext. Offline commands never send
+// it, so they are not asked for the passphrase.
+func (a *app) decryptAPIKey(cmd *cobra.Command) error {
+	value := strings.TrimSpace(a.v.GetString("api_key"))
+	if !isEncryptedValue(value) || isOfflineCommand(cmd) {
+		return nil
+	}
+	plaintext, err := (&secretCipher{a: a}).decrypt(value)
+	if err != nil {
+		var cliErr *cliError
+		if errors.As(err, &cliErr) {
+			return err
+		}
+		return newCLIError("decrypt_failed", "failed to decrypt the API key in the config file", ExitAuth, err)
+	}
+	a.v.Set("api_key", plaintext)
+	return nil
+}
+
+func newConfigEncryptCmd(o *configOptions) *cobra.Command {
+	var ageRecipient string
+	cmd := &cobra.Command{
+		Use:   "encrypt",
+		Short: "Encrypt the API keys in the config file",
+		Long: `Encrypt the API keys in the config file at rest, for machines without an OS
+keychain: api_key, the keys saved by login under credentials and the
+api_key of each profile.
+
+By default they are encrypted with a passphrase, read from ROBOTX_PASSPHRASE
+or prompted for, which commands then ask for on use. With --age-recipient
+they are encrypted for an age public key instead, and decrypted with the
+identity file named by ROBOTX_AGE_IDENTITY or age_identity; this needs the
+age tool. Keys already encrypted are left alone, and keys saved by a later
+login are stored in plaintext until this is run again.`,
+		Example: `  robotx config encrypt
+  robotx config encrypt --age-recipient age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.runEncrypt(cmd, &secretCipher{a: o.app, ageRecipient: strings.TrimSpace(ageRecipient), confirm: true}, true)
+		},
+	}
+	cmd.Flags().StringVar(&ageRecipient, "age-recipient", "", "Encrypt for this age public key instead of a passphrase")
+	return cmd
+}
+
+func newConfigDecryptCmd(o *configOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "decrypt",
+		Short: "Store the API keys in the config file in plaintext again",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.runEncrypt(cmd, &secretCipher{a: o.app}, false)
+		},
+	}
+}
+
+func (o *configOptions) runEncrypt(cmd *cobra.Command, s *secretCipher, encrypt bool) error {
+	path, doc, err := o.loadConfigForEdit()
+	if err != nil {
+		return err
+	}
+	resp := configEncryptResponse{ConfigFile: path, Keys: []string{}}
+	if encrypt {
+		resp.Method = s.method()
+	}
+	for _, key := range apiKeyNodes(doc.Content[0]) {
+		node := key.node
+		if isEncryptedValue(node.Value) == encrypt || strings.TrimSpace(node.Value) == "" {
+			continue
+		}
+		var value string
+		if encrypt {
+			value, err = s.encrypt(strings.TrimSpace(node.Value))
+		} else {
+			value, err = s.decrypt(node.Value)
+		}
+		if err != nil {
+			var cliErr *cliError
+			if errors.As(err, &cliErr) {
+				return err
+			}
+			return newCLIError("decrypt_failed", fmt.Sprintf("failed to %s %s", cmd.Name(), key.path), ExitAuth, err)
+		}
+		node.Value, node.Tag, node.Style = value, "!!str", 0
+		resp.Keys = append(resp.Keys, key.path)
+	}
+
+	if len(resp.Keys) == 0 {
+		o.logf("✅ No API keys to %s in %s\n", cmd.Name(), path)
+	} else {
+		if err := saveConfigDocument(path, doc); err != nil {
+			return newCLIError("config_write_failed", "failed to write config file", ExitGeneral, err)
+		}
+		for _, key := range resp.Keys {
+			o.logf("🔐 %s: %s\n", cmd.Name(), key)
+		}
+		o.logf("✅ Updated %s\n", path)
+	}
+	if err := o.emitSuccess("config "+cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+type configKeyNode struct {
+	path string
+	node *yaml.Node
+}
+
+// apiKeyNodes returns the API key scalars of a config document: api_key,
+// credentials.<base URL> and profiles.<name>.api_key.
+func apiKeyNodes(root *yaml.Node) []configKeyNode {
+	var keys []configKeyNode
+	if node := lookupConfigNode(root, []string{"api_key"}); node != nil && node.Kind == yaml.ScalarNode {
+		keys = append(keys, configKeyNode{"api_key", node})
+	}
+	if credentials := lookupConfigNode(root, []string{credentialsConfigKey}); credentials != nil && credentials.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(credentials.Content); i += 2 {
+			if node := credentials.Content[i+1]; node.Kind == yaml.ScalarNode {
+				keys = append(keys, configKeyNode{credentialsConfigKey + "." + credentials.Content[i].Value, node})
+			}
+		}
+	}
+	if profiles := lookupConfigNode(root, []string{"profiles"}); profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			if node := lookupConfigNode(profiles.Content[i+1], []string{"api_key"}); node != nil && node.Kind == yaml.ScalarNode {
+				keys = append(keys, configKeyNode{"profiles." + profiles.Content[i].Value + ".api_key", node})
+			}
+		}
+	}
+	return keys
+}
+
+// configHasEncryptedKeys reports whether the config file at path holds an
+// encrypted API key.
+func configHasEncryptedKeys(path string) bool {
+	doc, err := loadConfigDocument(path)
+	if err != nil {
+		return false
+	}
+	for _, key := range apiKeyNodes(doc.Content[0]) {
+		if isEncryptedValue(key.node.Value) {
+			return true
+		}
+	}
+	return false
+}
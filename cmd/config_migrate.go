@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// currentConfigVersion is the config layout this CLI writes. Files without a
+// version key use layout 1: legacy top-level command settings and a single
+// api_key shared by every base URL.
+const currentConfigVersion = 2
+
+// legacyConfigMoves maps layout-1 top-level keys to their layout-2 paths.
+var legacyConfigMoves = []struct {
+	from string
+	to   []string
+}{
+	{"default_visibility", []string{"deploy", "visibility"}},
+	{"default_timeout", []string{"deploy", "timeout"}},
+	{"package_command", []string{"deploy", "package_command"}},
+}
+
+type configMigrateResponse struct {
+	ConfigFile  string   `json:"config_file"`
+	FromVersion int      `json:"from_version"`
+	ToVersion   int      `json:"to_version"`
+	Changes     []string `json:"changes"`
+	BackupFile  string   `json:"backup_file,omitempty"`
+	Migrated    bool     `json:"migrated"`
+}
+
+func newConfigMigrateCmd(o *configOptions) *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade the config file to the current layout",
+		Long: fmt.Sprintf(`Upgrade the config file to layout version %d: legacy top-level settings such
+as default_timeout move under deploy, and a top-level api_key moves under
+credentials for its base_url. The original file is kept next to it as
+<config>.<time>.bak; earlier backups are never overwritten.
+
+Other commands migrate the config file automatically on first run; use this
+command to do it explicitly or to preview the changes with --dry-run.`, currentConfigVersion),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.runMigrate(cmd, dryRun)
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only list the changes")
+	return cmd
+}
+
+func (o *configOptions) runMigrate(cmd *cobra.Command, dryRun bool) error {
+	path, doc, err := o.loadConfigForEdit()
+	if err != nil {
+		return err
+	}
+	from, changes, err := migrateConfigDocument(doc)
+	if err != nil {
+		return newCLIError("invalid_config", err.Error(), ExitGeneral, nil)
+	}
+	resp := configMigrateResponse{ConfigFile: path, FromVersion: from, ToVersion: currentConfigVersion, Changes: changes}
+	if resp.Changes == nil {
+		resp.Changes = []string{}
+	}
+
+	switch {
+	case len(changes) == 0:
+		o.logf("✅ Config file is already at version %d: %s\n", currentConfigVersion, path)
+	case dryRun:
+		for _, change := range changes {
+			o.logf("📝 %s\n", change)
+		}
+		o.logf("🔍 Dry run: %s was not changed\n", path)
+	default:
+		backup, err := writeMigratedConfig(path, doc)
+		if err != nil {
+			return newCLIError("config_write_failed", "failed to write config file", ExitGeneral, err)
+		}
+		resp.BackupFile = backup
+		resp.Migrated = true
+		for _, change := range changes {
+			o.logf("📝 %s\n", change)
+		}
+		o.logf("✅ Migrated %s from version %d to %d (backup: %s)\n", path, from, currentConfigVersion, backup)
+	}
+
+	if err := o.emitSuccess("config "+cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// migrateConfigOnFirstRun upgrades an old config file in place before a
+// command reads it. Failures only warn: the CLI still reads old layouts.
+func (a *app) migrateConfigOnFirstRun(cmd *cobra.Command) {
+	path := a.v.ConfigFileUsed()
+	if path == "" || isOfflineCommand(cmd) {
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	doc, err := loadConfigDocument(path)
+	if err != nil {
+		return
+	}
+	from, changes, err := migrateConfigDocument(doc)
+	if err != nil {
+		if !a.isJSONOutput() {
+			a.noticef("⚠️  %v\n", err)
+		}
+		return
+	}
+	if len(changes) == 0 {
+		return
+	}
+	backup, err := writeMigratedConfig(path, doc)
+	if err != nil {
+		a.noticef("⚠️  Could not migrate config file %s: %v\n", path, err)
+		return
+	}
+	if !a.isJSONOutput() {
+		a.noticef("📝 Migrated config file %s from version %d to %d (backup: %s)\n", path, from, currentConfigVersion, backup)
+	}
+	_ = a.v.ReadInConfig()
+}
+
+// migrateConfigDocument upgrades doc in place. It returns the version the
+// document had and a description of each change; no changes means the
+// document is current.
+func migrateConfigDocument(doc *yaml.Node) (int, []string, error) {
+	root := doc.Content[0]
+	version := 1
+	if node := lookupConfigNode(root, []string{"version"}); node != nil {
+		v, err := strconv.Atoi(strings.TrimSpace(node.Value))
+		if err != nil || v < 1 {
+			return 0, nil, fmt.Errorf("invalid config version %q", node.Value)
+		}
+		version = v
+	}
+	if version > currentConfigVersion {
+		return version, nil, fmt.Errorf("config file version %d is newer than this robotx supports (%d); upgrade robotx", version, currentConfigVersion)
+	}
+	if version == currentConfigVersion {
+		return version, nil, nil
+	}
+
+	var changes []string
+	for _, move := range legacyConfigMoves {
+		node := lookupConfigNode(root, []string{move.from})
+		if node == nil {
+			continue
+		}
+		to := strings.Join(move.to, ".")
+		unsetConfigNode(root, []string{move.from})
+		if lookupConfigNode(root, move.to) != nil {
+			changes = append(changes, fmt.Sprintf("removed %s (already set as %s)", move.from, to))
+			continue
+		}
+		if err := setConfigNode(root, move.to, node); err != nil {
+			return version, nil, err
+		}
+		changes = append(changes, fmt.Sprintf("moved %s to %s", move.from, to))
+	}
+
+	// A top-level key can only be scoped when the server it belongs to is
+	// known; without base_url it stays for base URL discovery.
+	keyNode := lookupConfigNode(root, []string{"api_key"})
+	baseNode := lookupConfigNode(root, []string{"base_url"})
+	if keyNode != nil && baseNode != nil && credentialKey(baseNode.Value) != "" {
+		base := strings.TrimRight(strings.TrimSpace(baseNode.Value), "/")
+		unsetConfigNode(root, []string{"api_key"})
+		credentials := lookupConfigNode(root, []string{credentialsConfigKey})
+		if credentials != nil && credentials.Kind == yaml.MappingNode && mappingHasCredential(credentials, base) {
+			changes = append(changes, fmt.Sprintf("removed api_key (credentials already has %s)", base))
+		} else {
+			if err := setConfigNode(root, []string{credentialsConfigKey, base}, keyNode); err != nil {
+				return version, nil, err
+			}
+			changes = append(changes, fmt.Sprintf("moved api_key to credentials for %s", base))
+		}
+	}
+
+	versionKey := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+	if len(root.Content) > 0 {
+		// Keep a comment at the top of the file above the new first key.
+		versionKey.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+	}
+	versionNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(currentConfigVersion)}
+	root.Content = append([]*yaml.Node{versionKey, versionNode}, root.Content...)
+	changes = append(changes, fmt.Sprintf("set version to %d", currentConfigVersion))
+	return version, changes, nil
+}
+
+func mappingHasCredential(node *yaml.Node, baseURL string) bool {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if credentialKey(node.Content[i].Value) == credentialKey(baseURL) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeMigratedConfig keeps the original file as <path>.<time>.bak and
+// writes doc. An existing backup is never overwritten.
+func writeMigratedConfig(path string, doc *yaml.Node) (string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	backup, err := writeConfigBackup(path, raw, time.Now())
+	if err != nil {
+		return "", err
+	}
+	return backup, saveConfigDocument(path, doc)
+}
+
+// writeConfigBackup writes raw to a new file named after path and now,
+// adding a counter when a backup of the same second exists.
+func writeConfigBackup(path string, raw []byte, now time.Time) (string, error) {
+	stamp := now.Format("20060102-150405")
+	for i := 0; ; i++ {
+		backup := fmt.Sprintf("%s.%s.bak", path, stamp)
+		if i > 0 {
+			backup = fmt.Sprintf("%s.%s-%d.bak", path, stamp, i)
+		}
+		f, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if _, err := f.Write(raw); err != nil {
+			f.Close()
+			return "", err
+		}
+		return backup, f.Close()
+	}
+}
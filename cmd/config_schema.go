@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type configKeySpec struct {
+	Kind        string
+	Description string
+	Children    map[string]configKeySpec
+}
+
+// baseConfigSchema lists top-level keys that are not derived from flags.
+var baseConfigSchema = map[string]configKeySpec{
+	"version":            {Kind: "int", Description: "Layout version of the config file"},
+	"base_url":           {Kind: "string", Description: "RobotX server base URL"},
+	"api_key":            {Kind: "string", Description: "RobotX API key"},
+	"api_key_command":    {Kind: "string", Description: "Shell command printing the API key, run on each use instead of storing it"},
+	"fallback_base_urls": {Kind: "list", Description: "Fallback base URLs for read requests"},
+	"discovery_url":      {Kind: "string", Description: "Server asked for base_url when only api_key is set"},
+	"service_token":      {Kind: "string", Description: "Service-account token used with auth_mode service"},
+	"age_identity":       {Kind: "string", Description: "age identity file decrypting API keys encrypted with config encrypt --age-recipient"},
+	"credentials":        {Kind: "map", Description: "API keys saved by login, keyed by base URL"},
+	"default_visibility": {Kind: "string", Description: "Legacy alias of deploy.visibility"},
+	"default_timeout":    {Kind: "int", Description: "Legacy alias of deploy.timeout"},
+	"package_command":    {Kind: "string", Description: "Shell command that builds upload archives (alias of deploy.package_command)"},
+	"profiles":           {Kind: "map", Description: "Named profiles selected with --profile"},
+}
+
+// profileSchema lists the keys accepted inside profiles.<name>.
+var profileSchema = map[string]configKeySpec{
+	"base_url":           baseConfigSchema["base_url"],
+	"api_key":            baseConfigSchema["api_key"],
+	"api_key_command":    baseConfigSchema["api_key_command"],
+	"fallback_base_urls": baseConfigSchema["fallback_base_urls"],
+	"default_visibility": baseConfigSchema["default_visibility"],
+	"default_timeout":    baseConfigSchema["default_timeout"],
+}
+
+var secretConfigKeys = map[string]bool{
+	"api_key":       true,
+	"service_token": true,
+	"slack_webhook": true,
+	"token":         true,
+}
+
+// isSecretConfigKey reports whether a dotted config key holds a secret.
+func isSecretConfigKey(key string) bool {
+	return secretConfigKeys[key[strings.LastIndex(key, ".")+1:]]
+}
+
+// configSchema returns the full schema: the base keys plus one key per flag
+// (global flags at the top level, command flags nested under the command).
+func configSchema(root *cobra.Command) map[string]configKeySpec {
+	schema := map[string]configKeySpec{}
+	for k, v := range baseConfigSchema {
+		schema[k] = v
+	}
+	for _, setting := range collectFlagSettings(root) {
+		parts := strings.Split(setting.Key, ".")
+		level := schema
+		for i, part := range parts {
+			if i == len(parts)-1 {
+				if _, exists := level[part]; !exists {
+					level[part] = configKeySpec{Kind: flagValueKind(setting.Flag), Description: setting.Description}
+				}
+				break
+			}
+			spec, ok := level[part]
+			if !ok || spec.Children == nil {
+				spec = configKeySpec{Kind: "map", Description: "Settings for the " + part + " command", Children: map[string]configKeySpec{}}
+				level[part] = spec
+			}
+			level = spec.Children
+		}
+	}
+	return schema
+}
+
+type configIssue struct {
+	Key        string `json:"key"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+func validateConfigMap(root *cobra.Command, cfg map[string]interface{}) []configIssue {
+	issues := validateConfigLevel("", cfg, configSchema(root))
+	if rawProfiles, ok := cfg["profiles"].(map[string]interface{}); ok {
+		names := sortedKeys(rawProfiles)
+		for _, name := range names {
+			profile, ok := rawProfiles[name].(map[string]interface{})
+			if !ok {
+				issues = append(issues, configIssue{Key: "profiles." + name, Message: "profile must be a mapping"})
+				continue
+			}
+			issues = append(issues, validateConfigLevel("profiles."+name+".", profile, profileSchema)...)
+		}
+	}
+	return issues
+}
+
+func validateConfigLevel(prefix string, cfg map[string]interface{}, schema map[string]configKeySpec) []configIssue {
+	var issues []configIssue
+	for _, key := range sortedKeys(cfg) {
+		spec, ok := schema[key]
+		if !ok {
+			issue := configIssue{Key: prefix + key, Message: "unknown key"}
+			if suggestion := suggestConfigKey(key, schema); suggestion != "" {
+				issue.Suggestion = prefix + suggestion
+				issue.Message = fmt.Sprintf("unknown key (did you mean %s?)", prefix+suggestion)
+			}
+			issues = append(issues, issue)
+			continue
+		}
+		if msg := checkConfigKind(spec.Kind, cfg[key]); msg != "" {
+			issues = append(issues, configIssue{Key: prefix + key, Message: msg})
+			continue
+		}
+		if child, ok := cfg[key].(map[string]interface{}); ok && spec.Children != nil {
+			issues = append(issues, validateConfigLevel(prefix+key+".", child, spec.Children)...)
+		}
+	}
+	return issues
+}
+
+func checkConfigKind(kind string, value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	switch kind {
+	case "string":
+		if _, ok := value.(string); !ok {
+			return "expected a string"
+		}
+	case "int":
+		if _, ok := value.(int); !ok {
+			return "expected an integer"
+		}
+	case "bool":
+		if _, ok := value.(bool); !ok {
+			return "expected true or false"
+		}
+	case "list":
+		switch value.(type) {
+		case []interface{}, string:
+		default:
+			return "expected a list or comma-separated string"
+		}
+	case "map":
+		if _, ok := value.(map[string]interface{}); !ok {
+			return "expected a mapping"
+		}
+	}
+	return ""
+}
+
+func suggestConfigKey(key string, schema map[string]configKeySpec) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "_", " ", "_", ".", "_").Replace(strings.TrimSpace(key)))
+	if _, ok := schema[normalized]; ok {
+		return normalized
+	}
+	best := ""
+	bestDist := 3
+	for candidate := range schema {
+		if d := levenshtein(normalized, candidate); d < bestDist || (d == bestDist && best != "" && candidate < best) {
+			best, bestDist = candidate, d
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, minInt(cur[j-1]+1, prev[j-1]+cost))
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isKnownConfigPath reports whether a dotted key path is covered by the schema.
+func isKnownConfigPath(root *cobra.Command, path []string) (bool, string) {
+	if len(path) == 0 {
+		return false, ""
+	}
+	if path[0] == "profiles" {
+		if len(path) < 3 {
+			return len(path) == 2, ""
+		}
+		if len(path) > 3 {
+			return false, ""
+		}
+		if _, ok := profileSchema[path[2]]; ok {
+			return true, ""
+		}
+		if s := suggestConfigKey(path[2], profileSchema); s != "" {
+			return false, strings.Join([]string{path[0], path[1], s}, ".")
+		}
+		return false, ""
+	}
+
+	level := configSchema(root)
+	for i, part := range path {
+		spec, ok := level[part]
+		if !ok {
+			if s := suggestConfigKey(part, level); s != "" {
+				return false, strings.Join(append(append([]string{}, path[:i]...), s), ".")
+			}
+			return false, ""
+		}
+		if i == len(path)-1 {
+			return true, ""
+		}
+		if spec.Children == nil {
+			return false, ""
+		}
+		level = spec.Children
+	}
+	return false, ""
+}
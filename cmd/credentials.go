@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// apiKeyCommandTimeout bounds api_key_command, which may wait for a password
+// manager to be unlocked.
+const apiKeyCommandTimeout = 2 * time.Minute
+
+// credentialsConfigKey maps base URLs to the API keys saved for them, so a
+// key is only ever sent to the server that issued it.
+const credentialsConfigKey = "credentials"
+
+// credentialKey normalizes a base URL for matching credentials entries.
+func credentialKey(baseURL string) string {
+	return strings.ToLower(strings.TrimRight(strings.TrimSpace(baseURL), "/"))
+}
+
+// applyScopedCredentials sets api_key to the key saved for the effective
+// base_url. An API key given by flag, environment or the selected profile
+// takes precedence; configs without a credentials entry keep using the
+// top-level api_key. Once credentials are scoped, the top-level api_key is
+// only used for the base_url saved next to it in the config file; for any
+// other server api_key is cleared, so commands fail with missing_api_key
+// rather than send the key to a server that did not issue it.
+func (a *app) applyScopedCredentials() {
+	if a.apiKeyOverridden() {
+		return
+	}
+	base := credentialKey(a.v.GetString("base_url"))
+	if base == "" {
+		return
+	}
+	saved, _ := a.v.Get(credentialsConfigKey).(map[string]interface{})
+	if len(saved) == 0 {
+		return
+	}
+	for url, key := range saved {
+		if credentialKey(url) != base || key == nil {
+			continue
+		}
+		if s := strings.TrimSpace(fmt.Sprint(key)); s != "" {
+			a.v.Set("api_key", s)
+		}
+		return
+	}
+	if a.v.GetString("api_key") == "" || credentialKey(a.configFileBaseURL()) == base {
+		return
+	}
+	a.v.Set("api_key", "")
+	if a.verbose {
+		a.logf("🔐 No API key saved for %s; run robotx login to add one\n", base)
+	}
+}
+
+// configFileBaseURL returns the base_url written in the config file, which
+// the file's top-level api_key belongs to, ignoring flags and environment.
+func (a *app) configFileBaseURL() string {
+	path := a.v.ConfigFileUsed()
+	if path == "" {
+		return ""
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var cfg struct {
+		BaseURL string `yaml:"base_url"`
+	}
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return ""
+	}
+	return cfg.BaseURL
+}
+
+// apiKeyOverridden reports whether the API key comes from --api-key,
+// ROBOTX_API_KEY, Vault or the selected profile rather than the keys saved
+// by login.
+func (a *app) apiKeyOverridden() bool {
+	if a.root.PersistentFlags().Changed("api-key") || a.vaultAPIKey {
+		return true
+	}
+	if _, ok := os.LookupEnv("ROBOTX_API_KEY"); ok {
+		return true
+	}
+	name := strings.TrimSpace(a.v.GetString("profile"))
+	return name != "" && a.v.IsSet("profiles."+name+".api_key")
+}
+
+// applyAPIKeyCommand sets api_key to the output of api_key_command, e.g.
+// "op read op://vault/robotx/key", run on every invocation so the key is
+// never written to disk. --api-key, ROBOTX_API_KEY and a profile's api_key
+// take precedence; offline commands do not run it.
+func (a *app) applyAPIKeyCommand(cmd *cobra.Command) error {
+	command := strings.TrimSpace(a.v.GetString("api_key_command"))
+	if command == "" || a.apiKeyOverridden() || isOfflineCommand(cmd) {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(cmd.Context(), apiKeyCommandTimeout)
+	defer cancel()
+	c := exec.CommandContext(ctx, "sh", "-c", command)
+	c.Stdin = a.root.InOrStdin()
+	// Stdin may be a reader that never ends, which Run would otherwise wait
+	// on after the command exits.
+	c.WaitDelay = time.Second
+	c.Stderr = a.errOut()
+	var stdout bytes.Buffer
+	c.Stdout = &stdout
+	if err := c.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", apiKeyCommandTimeout)
+		}
+		return newCLIError("api_key_command_failed", fmt.Sprintf("api_key_command %q failed", command), ExitAuth, err)
+	}
+	key := strings.TrimSpace(stdout.String())
+	if key == "" || strings.Contains(key, "\n") {
+		return newCLIError("api_key_command_failed", fmt.Sprintf("api_key_command %q must print the API key on a single line", command), ExitAuth, nil)
+	}
+	a.v.Set("api_key", key)
+	return nil
+}
+
+func hasCredential(credentials map[string]interface{}, baseURL string) bool {
+	for url := range credentials {
+		if credentialKey(url) == credentialKey(baseURL) {
+			return true
+		}
+	}
+	return false
+}
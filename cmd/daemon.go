@@ -0,0 +1,470 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+type daemonOptions struct {
+	*app
+	listen string
+	token  string
+}
+
+// Number of log lines kept per deploy.
+const daemonMaxLogLines = 5000
+
+func newDaemonCmd(a *app) *cobra.Command {
+	o := &daemonOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run a local REST API for deploys and status",
+		Long: `Run a long-lived local HTTP API so IDE plugins and scripts can trigger deploys
+and query status without paying CLI startup and auth cost on every call.
+
+By default the daemon listens on a unix socket (daemon.sock in the data
+directory, usually ~/.local/share/robotx) that only the current user can
+access. Use --listen host:port for TCP; TCP clients must send "Authorization: Bearer <token>" (generated when --token is empty).
+
+Endpoints:
+  GET    /v1/health
+  GET    /v1/status?project_id=...&build_id=...
+  POST   /v1/deploys              start a deploy (JSON body like the MCP deploy tool)
+  GET    /v1/deploys              list deploys started by this daemon
+  GET    /v1/deploys/{id}         deploy state and result
+  DELETE /v1/deploys/{id}         cancel a running deploy
+  GET    /v1/deploys/{id}/logs    deploy log (add ?follow=true to stream)
+  GET    /metrics                 Prometheus metrics for deploys run by this daemon`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVar(&o.listen, "listen", "", "Listen address: host:port, or unix:<path> (default unix socket in the data directory)")
+	cmd.Flags().StringVar(&o.token, "token", "", "Bearer token required from clients (generated for TCP listeners when empty)")
+	return cmd
+}
+
+func (o *daemonOptions) run(cmd *cobra.Command, args []string) error {
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required (use --base-url or set ROBOTX_BASE_URL)", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required (use --api-key or set ROBOTX_API_KEY)", ExitAuth, nil)
+	}
+
+	listener, token, err := o.daemonListener(o.listen, o.token)
+	if err != nil {
+		return err
+	}
+
+	o.metrics = newDeployMetrics()
+	// Collect the secret flag settings now: deploys run on copies of the
+	// app whose command tree no longer has those flags.
+	o.secrets()
+	d := &daemon{
+		app:     o.app,
+		client:  o.newAPIClient(baseURL, apiKey),
+		baseURL: baseURL,
+		deploys: map[string]*daemonDeploy{},
+	}
+	httpServer := &http.Server{
+		Handler:           d.routes(token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx := cmd.Context()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	o.logf("🌐 RobotX daemon listening on %s://%s\n", listener.Addr().Network(), listener.Addr())
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return newCLIError("daemon_error", "daemon stopped", ExitGeneral, err)
+	}
+	d.cancelAll()
+	return nil
+}
+
+// daemonListener opens the listener described by addr and returns the token
+// clients must present (empty when none is required).
+func (a *app) daemonListener(addr, token string) (net.Listener, string, error) {
+	token = strings.TrimSpace(token)
+	addr = strings.TrimSpace(addr)
+
+	if addr == "" || strings.HasPrefix(addr, "unix:") {
+		path := strings.TrimPrefix(strings.TrimPrefix(addr, "unix:"), "//")
+		if path == "" {
+			defaultPath, err := robotxDataPath("daemon.sock")
+			if err != nil {
+				return nil, "", newCLIError("daemon_error", "failed to resolve daemon socket path", ExitGeneral, err)
+			}
+			path = defaultPath
+		}
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, "", newCLIError("daemon_running", fmt.Sprintf("a daemon is already listening on %s", path), ExitGeneral, nil)
+		}
+		_ = os.Remove(path)
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, "", newCLIError("daemon_error", fmt.Sprintf("failed to listen on %s", path), ExitGeneral, err)
+		}
+		if err := os.Chmod(path, 0o600); err != nil {
+			listener.Close()
+			return nil, "", newCLIError("daemon_error", "failed to restrict daemon socket permissions", ExitGeneral, err)
+		}
+		return listener, token, nil
+	}
+
+	if token == "" {
+		generated, err := generateToken()
+		if err != nil {
+			return nil, "", newCLIError("daemon_error", "failed to generate daemon token", ExitGeneral, err)
+		}
+		token = generated
+		a.logf("🔑 Daemon bearer token: %s\n", token)
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, "", newCLIError("daemon_error", fmt.Sprintf("failed to listen on %s", addr), ExitGeneral, err)
+	}
+	return listener, token, nil
+}
+
+type daemon struct {
+	*app
+	client  *client.Client
+	baseURL string
+
+	mu      sync.Mutex
+	seq     int
+	deploys map[string]*daemonDeploy
+	order   []string
+}
+
+// daemonDeployInfo is the JSON view of a deploy started through the daemon.
+type daemonDeployInfo struct {
+	ID         string          `json:"id"`
+	Request    deployRequest   `json:"request"`
+	Status     string          `json:"status"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// daemonDeploy tracks one deploy started through the daemon.
+type daemonDeploy struct {
+	mu      sync.Mutex
+	info    daemonDeployInfo
+	logs    []string
+	updated chan struct{}
+	cancel  context.CancelFunc
+}
+
+func (d *daemon) routes(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/health", d.handleHealth)
+	mux.HandleFunc("GET /v1/status", d.handleStatus)
+	mux.HandleFunc("POST /v1/deploys", d.handleStartDeploy)
+	mux.HandleFunc("GET /v1/deploys", d.handleListDeploys)
+	mux.HandleFunc("GET /v1/deploys/{id}", d.handleGetDeploy)
+	mux.HandleFunc("DELETE /v1/deploys/{id}", d.handleCancelDeploy)
+	mux.HandleFunc("GET /v1/deploys/{id}/logs", d.handleDeployLogs)
+	mux.Handle("GET /metrics", d.metrics)
+
+	if token == "" {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			writeDaemonError(w, http.StatusUnauthorized, "unauthorized", "missing or invalid bearer token")
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (d *daemon) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeDaemonJSON(w, http.StatusOK, map[string]string{"status": "ok", "version": version, "base_url": d.baseURL})
+}
+
+func (d *daemon) handleStatus(w http.ResponseWriter, r *http.Request) {
+	projectID := strings.TrimSpace(r.URL.Query().Get("project_id"))
+	buildID := strings.TrimSpace(r.URL.Query().Get("build_id"))
+	if projectID == "" && buildID == "" {
+		writeDaemonError(w, http.StatusBadRequest, "missing_argument", "at least one of project_id or build_id is required")
+		return
+	}
+	resp, err := d.fetchStatus(d.client, d.baseURL, projectID, buildID)
+	if err != nil {
+		status := http.StatusBadGateway
+		if client.IsNotFound(err) {
+			status = http.StatusNotFound
+		}
+		writeDaemonError(w, status, "api_error", err.Error())
+		return
+	}
+	writeDaemonJSON(w, http.StatusOK, resp)
+}
+
+func (d *daemon) handleStartDeploy(w http.ResponseWriter, r *http.Request) {
+	var req deployRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDaemonError(w, http.StatusBadRequest, "invalid_request", "invalid JSON body")
+		return
+	}
+	args, err := req.args()
+	if err != nil {
+		writeDaemonError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.mu.Lock()
+	d.seq++
+	dep := &daemonDeploy{
+		info: daemonDeployInfo{
+			ID:        fmt.Sprintf("dep_%d_%d", time.Now().Unix(), d.seq),
+			Request:   req,
+			Status:    "running",
+			StartedAt: time.Now().UTC(),
+		},
+		updated: make(chan struct{}),
+		cancel:  cancel,
+	}
+	d.deploys[dep.info.ID] = dep
+	d.order = append(d.order, dep.info.ID)
+	d.mu.Unlock()
+
+	d.logf("🚀 Deploy %s started: %s\n", dep.info.ID, req.Path)
+	go d.runDeploy(ctx, dep, args)
+	writeDaemonJSON(w, http.StatusAccepted, dep.snapshot())
+}
+
+func (d *daemon) runDeploy(ctx context.Context, dep *daemonDeploy, args []string) {
+	started := time.Now()
+	stdout, stderr, err := d.deploy(ctx, &lineWriter{onLine: dep.appendLog}, args)
+	d.metrics.observeDeploy(stdout, stderr, err, ctx.Err() != nil, time.Since(started))
+
+	dep.mu.Lock()
+	now := time.Now().UTC()
+	dep.info.FinishedAt = &now
+	switch {
+	case ctx.Err() != nil:
+		dep.info.Status = "cancelled"
+		dep.info.Error = "deploy cancelled"
+	case err != nil:
+		dep.info.Status = "failed"
+		dep.info.Error = lastNonEmptyLine(stderr, err.Error())
+	default:
+		dep.info.Status = "succeeded"
+		if out := strings.TrimSpace(stdout); json.Valid([]byte(out)) {
+			dep.info.Result = json.RawMessage(out)
+		}
+	}
+	status := dep.info.Status
+	dep.notifyLocked()
+	dep.mu.Unlock()
+	dep.cancel()
+
+	d.logf("✅ Deploy %s finished: %s\n", dep.info.ID, status)
+}
+
+// deploy runs the deploy command line args in this process on the daemon's
+// configuration and client, so deploys do not load the config or resolve
+// credentials again, and returns the JSON result and the log it printed.
+// Log lines are also written to logs.
+func (d *daemon) deploy(ctx context.Context, logs io.Writer, args []string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	errOut := io.MultiWriter(&stderr, logs)
+
+	run := *d.app
+	run.root = &cobra.Command{Use: "robotx"}
+	run.root.SetIn(strings.NewReader(""))
+	run.root.SetOut(&stdout)
+	run.root.SetErr(errOut)
+	output := *d.outputMode()
+	output.json = true
+	output.nonInteractive = true
+	output.secrets = d.secrets()
+	run.output = &output
+	run.progress = nil
+	run.stepSpan = nil
+
+	o := &deployOptions{app: &run}
+	cmd := &cobra.Command{Use: "deploy"}
+	o.addFlags(cmd)
+	run.root.AddCommand(cmd)
+
+	err := d.runDeployCommand(ctx, o, cmd, args)
+	if err != nil {
+		output.writeError(errOut, err)
+	}
+	return stdout.String(), stderr.String(), err
+}
+
+// runDeployCommand parses the flags of the deploy command line args, as
+// built by deployRequest.args, into o and runs the deploy with the daemon's
+// client.
+func (d *daemon) runDeployCommand(ctx context.Context, o *deployOptions, cmd *cobra.Command, args []string) error {
+	started := time.Now()
+	if err := cmd.ParseFlags(args[1:]); err != nil {
+		return newCLIError("invalid_argument", err.Error(), ExitGeneral, err)
+	}
+	applyFlagAliases(cmd)
+	if err := o.applyFlagSettings(cmd); err != nil {
+		return err
+	}
+	if err := o.checkFlags(cmd); err != nil {
+		return err
+	}
+	absPath, err := deployPath(cmd.Flags().Args())
+	if err != nil {
+		return err
+	}
+	if err := checkDeployServer(d.client, d.baseURL); err != nil {
+		return err
+	}
+	return o.deploy(ctx, cmd, d.client, d.baseURL, absPath, started)
+}
+
+func (d *daemon) lookup(id string) *daemonDeploy {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.deploys[id]
+}
+
+func (d *daemon) handleListDeploys(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	ids := append([]string(nil), d.order...)
+	d.mu.Unlock()
+
+	items := make([]daemonDeployInfo, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		if dep := d.lookup(ids[i]); dep != nil {
+			items = append(items, dep.snapshot())
+		}
+	}
+	writeDaemonJSON(w, http.StatusOK, map[string]interface{}{"deploys": items})
+}
+
+func (d *daemon) handleGetDeploy(w http.ResponseWriter, r *http.Request) {
+	dep := d.lookup(r.PathValue("id"))
+	if dep == nil {
+		writeDaemonError(w, http.StatusNotFound, "not_found", "deploy not found")
+		return
+	}
+	writeDaemonJSON(w, http.StatusOK, dep.snapshot())
+}
+
+func (d *daemon) handleCancelDeploy(w http.ResponseWriter, r *http.Request) {
+	dep := d.lookup(r.PathValue("id"))
+	if dep == nil {
+		writeDaemonError(w, http.StatusNotFound, "not_found", "deploy not found")
+		return
+	}
+	dep.cancel()
+	writeDaemonJSON(w, http.StatusAccepted, dep.snapshot())
+}
+
+func (d *daemon) handleDeployLogs(w http.ResponseWriter, r *http.Request) {
+	dep := d.lookup(r.PathValue("id"))
+	if dep == nil {
+		writeDaemonError(w, http.StatusNotFound, "not_found", "deploy not found")
+		return
+	}
+	follow, _ := strconv.ParseBool(r.URL.Query().Get("follow"))
+	flusher, canFlush := w.(http.Flusher)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	sent := 0
+	for {
+		dep.mu.Lock()
+		lines := append([]string(nil), dep.logs[sent:]...)
+		done := dep.info.Status != "running"
+		updated := dep.updated
+		dep.mu.Unlock()
+
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+		sent += len(lines)
+		if !follow || done {
+			return
+		}
+		if canFlush {
+			flusher.Flush()
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-updated:
+		}
+	}
+}
+
+func (dep *daemonDeploy) appendLog(line string) {
+	dep.mu.Lock()
+	defer dep.mu.Unlock()
+	if len(dep.logs) < daemonMaxLogLines {
+		dep.logs = append(dep.logs, line)
+	}
+	dep.notifyLocked()
+}
+
+// notifyLocked wakes log followers. dep.mu must be held.
+func (dep *daemonDeploy) notifyLocked() {
+	close(dep.updated)
+	dep.updated = make(chan struct{})
+}
+
+func (dep *daemonDeploy) snapshot() daemonDeployInfo {
+	dep.mu.Lock()
+	defer dep.mu.Unlock()
+	return dep.info
+}
+
+func (d *daemon) cancelAll() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, dep := range d.deploys {
+		dep.cancel()
+	}
+}
+
+func writeDaemonJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeDaemonError(w http.ResponseWriter, status int, code, message string) {
+	env := errorEnvelope{Success: false}
+	env.Error.Code = code
+	env.Error.Message = message
+	writeDaemonJSON(w, status, env)
+}
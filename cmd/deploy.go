@@ -2,36 +2,27 @@ package cmd
 
 import (
 	"archive/zip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/haibingtown/robotx_cli/pkg/client"
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
-var deployCmd = &cobra.Command{
-	Use:   "deploy [project-path]",
-	Short: "Deploy a project to RobotX",
-	Long: `Deploy a project to RobotX platform. This command will:
-1. Resolve project by name (create-or-update)
-2. Package and upload source code
-3. Build locally in your current workspace
-4. Upload build artifacts to the created build
-5. Wait for build completion if needed
-5. Publish to production by default (use --publish=false to disable)`,
-	Args: cobra.MaximumNArgs(1),
-	RunE: runDeploy,
-}
-
-var (
+type deployOptions struct {
+	*app
 	projectName  string
 	visibility   string
 	publish      bool
@@ -43,11 +34,56 @@ var (
 	outputDir    string
 	versionLabel string
 	sourceRef    string
-)
+	skipBinaries bool
+	largeFileMB  int
+	pollInterval int
+	heartbeat    int
+	force        bool
+	qr           bool
+
+	// versionScheme picks the label generated for --version-label auto.
+	versionScheme       string
+	allowDuplicateLabel bool
+
+	functionsDir  string
+	skipFunctions bool
+
+	strict        bool
+	failOnWarning bool
+
+	smokePaths   []string
+	smokeExpects []string
+	smokeTimeout int
+	// warnings holds the warnings logged before the pipeline runs.
+	warnings []string
+
+	packageCommand string
+
+	audit       bool
+	auditLevel  string
+	auditTool   string
+	auditReport string
+
+	fromStdin     bool
+	archiveFormat string
+
+	lockTimeout int
+	noLock      bool
+
+	targets    []string
+	allTargets bool
+	// target is the build matrix target being deployed, and targetEnv its
+	// env overlays when it declares its own.
+	target    string
+	targetEnv map[string]map[string]string
+}
 
 var projectNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{2,61}[a-z0-9]$`)
 
 type deployResponse struct {
+	// Target is the build matrix target deployed, with --target or
+	// --all-targets.
+	Target        string `json:"target,omitempty"`
 	ProjectID     string `json:"project_id"`
 	ProjectName   string `json:"project_name,omitempty"`
 	CommitID      string `json:"commit_id,omitempty"`
@@ -56,30 +92,150 @@ type deployResponse struct {
 	VersionLabel  string `json:"version_label,omitempty"`
 	SourceRef     string `json:"source_ref,omitempty"`
 	BuildStatus   string `json:"build_status,omitempty"`
+	RawStatus     string `json:"build_raw_status,omitempty"`
 	PreviewURL    string `json:"preview_url,omitempty"`
 	ProductionURL string `json:"production_url,omitempty"`
 	Published     bool   `json:"published"`
 	Waited        bool   `json:"waited"`
 	LocalBuild    bool   `json:"local_build"`
+	Reused        bool   `json:"reused,omitempty"`
+	Deduplicated  bool   `json:"deduplicated,omitempty"`
+	// EnvChanges lists the env changes synced from robotx.yaml, by channel.
+	EnvChanges   map[string][]pipeline.EnvVarChange `json:"env_changes,omitempty"`
+	SourceDigest string                             `json:"source_digest,omitempty"`
+	LargeFiles   []largeFileEntry                   `json:"large_files,omitempty"`
+	HardLinks    []hardLinkEntry                    `json:"hard_links,omitempty"`
+	Warnings     []string                           `json:"warnings,omitempty"`
+	// SmokeTests holds the results of --smoke-test checks.
+	SmokeTests []pipeline.SmokeResult `json:"smoke_tests,omitempty"`
+	// Audit holds the findings of --audit.
+	Audit *pipeline.AuditReport `json:"audit,omitempty"`
+	// Functions lists the serverless function endpoints deployed with the build.
+	Functions []*client.FunctionEndpoint `json:"functions,omitempty"`
+	// QRPNG is a base64 PNG QR code of the production or preview URL, with --qr.
+	QRPNG string `json:"qr_png,omitempty"`
+
+	SourceArchiveBytes    int64           `json:"source_archive_bytes,omitempty"`
+	ArtifactArchiveBytes  int64           `json:"artifact_archive_bytes,omitempty"`
+	FunctionsArchiveBytes int64           `json:"functions_archive_bytes,omitempty"`
+	Timings               *commandTimings `json:"timings,omitempty"`
+}
+
+// qrURL is the URL shown as a QR code with --qr.
+func (r *deployResponse) qrURL() string {
+	return firstNonEmpty(r.ProductionURL, r.PreviewURL)
+}
+
+func newDeployCmd(a *app) *cobra.Command {
+	o := &deployOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "deploy [project-path]",
+		Short: "Deploy a project to RobotX",
+		Long: `Deploy a project to RobotX platform. This command will:
+1. Resolve project by name (create-or-update)
+2. Package and upload source code
+3. Build locally in your current workspace
+4. Upload build artifacts to the created build
+5. Wait for build completion if needed
+6. Publish to production by default (use --publish=false to disable)
+
+When the source is unchanged since the last successful build, that build is
+reused; use --force to build anyway. Size budgets, environment variables and
+build targets are read from the project's robotx.yaml; see the README for
+these, --from-stdin, --audit and --smoke-test.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: o.run,
+	}
+	o.addFlags(cmd)
+	return cmd
+}
+
+// addFlags registers the deploy flags, which release shares.
+func (o *deployOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.projectName, "name", "n", "", "Project name (create-or-update for current owner)")
+	cmd.Flags().StringVarP(&o.visibility, "visibility", "v", "private", "Project visibility (public/private)")
+	cmd.Flags().BoolVar(&o.publish, "publish", true, "Publish to production after successful build")
+	cmd.Flags().BoolVar(&o.wait, "wait", true, "Wait for build completion")
+	cmd.Flags().IntVar(&o.timeout, "timeout", 600, "Build timeout in seconds")
+	cmd.Flags().IntVar(&o.pollInterval, "poll-interval", 5, "Seconds between build status checks while waiting")
+	cmd.Flags().IntVar(&o.heartbeat, "heartbeat", 30, "Seconds between progress lines while the build status is unchanged")
+	cmd.Flags().BoolVar(&o.localBuild, "local-build", true, "Build locally and upload artifacts (must remain true; RobotX cloud build is no longer supported)")
+	cmd.Flags().StringVar(&o.installCmd, "install-command", "", "Override install command for local build")
+	cmd.Flags().StringVar(&o.buildCmd, "build-command", "", "Override build command for local build")
+	cmd.Flags().StringVar(&o.outputDir, "output-dir", "", "Override output directory for local build")
+	cmd.Flags().StringVar(&o.versionLabel, "version-label", "", "Optional build version label (e.g. v1.2.3), or auto to generate the next one")
+	cmd.Flags().StringVar(&o.versionScheme, "version-scheme", pipeline.VersionSchemePatch, "Scheme of --version-label auto (patch|minor|major|date)")
+	cmd.Flags().BoolVar(&o.allowDuplicateLabel, "allow-duplicate-label", false, "Deploy even if a recent build already has the --version-label")
+	cmd.Flags().StringVar(&o.sourceRef, "source-ref", "", "Optional source reference (e.g. tag:v1.2.3, branch:main@<sha>)")
+	cmd.Flags().BoolVar(&o.skipBinaries, "skip-binaries", false, "Leave large binary files (videos, model weights, archives) out of uploaded archives")
+	cmd.Flags().IntVar(&o.largeFileMB, "large-file-threshold", defaultLargeFileThresholdMB, "Warn about files larger than this many MB (0 disables)")
+	cmd.Flags().StringVar(&o.packageCommand, "package-command", "", "Shell command that builds the upload archive instead of the built-in zip packager")
+	cmd.Flags().BoolVar(&o.fromStdin, "from-stdin", false, "Upload the source archive read from stdin instead of packaging the project directory")
+	cmd.Flags().StringVar(&o.archiveFormat, "archive-format", archiveFormatZip, "Format of the --from-stdin archive (zip|tar.gz)")
+	cmd.Flags().BoolVar(&o.force, "force", false, "Upload and build even if the source is unchanged since the last successful build")
+	cmd.Flags().StringVar(&o.functionsDir, "functions-dir", "", "Directory of serverless functions (default: api/ or functions/ when present)")
+	cmd.Flags().BoolVar(&o.skipFunctions, "skip-functions", false, "Deploy only the static build output, even if the project has functions")
+	cmd.Flags().BoolVar(&o.qr, "qr", false, "Show a QR code of the production (or preview) URL for mobile testing")
+	cmd.Flags().BoolVar(&o.failOnWarning, "fail-on-warning", false, "Fail the deploy on warnings such as build plan notes or large files")
+	cmd.Flags().BoolVar(&o.strict, "strict", false, "Require --name and an explicit output directory, and fail on warnings")
+	cmd.Flags().StringSliceVar(&o.smokePaths, "smoke-test", nil, "Comma-separated paths to request on the preview URL after the build; each must answer 200")
+	cmd.Flags().StringArrayVar(&o.smokeExpects, "smoke-expect", nil, "PATH=REGEX: require the response body of a --smoke-test path to match (repeatable)")
+	cmd.Flags().IntVar(&o.smokeTimeout, "smoke-timeout", 30, "Seconds to wait for each smoke test request")
+	cmd.Flags().BoolVar(&o.audit, "audit", false, "Audit dependencies for known vulnerabilities before deploying")
+	cmd.Flags().StringVar(&o.auditLevel, "audit-level", pipeline.SeverityHigh, "Fail --audit on findings of this severity or higher (low|moderate|high|critical)")
+	cmd.Flags().StringVar(&o.auditTool, "audit-tool", auditToolAuto, "Tool run by --audit (auto|npm|pnpm|osv-scanner)")
+	cmd.Flags().StringVar(&o.auditReport, "audit-report", "", "Audit with the JSON output of an earlier npm audit, pnpm audit or osv-scanner run (implies --audit)")
+	cmd.Flags().IntVar(&o.lockTimeout, "lock-timeout", 300, "Seconds to wait while another deploy of the project holds its deploy lock (0 fails at once)")
+	cmd.Flags().BoolVar(&o.noLock, "no-lock", false, "Deploy without taking the project's deploy lock")
+	cmd.Flags().StringSliceVar(&o.targets, "target", nil, "Deploy these build targets of robotx.yaml, each to its own project (comma-separated)")
+	cmd.Flags().BoolVar(&o.allTargets, "all-targets", false, "Deploy every build target of robotx.yaml")
+}
+
+func (o *deployOptions) run(cmd *cobra.Command, args []string) error {
+	started := time.Now()
+	// Interrupts (Ctrl-C, or an MCP client cancelling the call) stop local
+	// build commands and build polling so temporary archives are cleaned up.
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	absPath, err := deployPath(args)
+	if err != nil {
+		return err
+	}
+	c, baseURL, err := o.deployClient(cmd)
+	if err != nil {
+		return err
+	}
+	return o.deploy(ctx, cmd, c, baseURL, absPath, started)
 }
 
-func init() {
-	rootCmd.AddCommand(deployCmd)
+// deploy deploys the project in absPath, or its selected build targets, with
+// c and prints the result.
+func (o *deployOptions) deploy(ctx context.Context, cmd *cobra.Command, c *client.Client, baseURL, absPath string, started time.Time) error {
+	targets, err := o.selectDeployTargets(cmd, absPath)
+	if err != nil {
+		return err
+	}
+	if len(targets) > 0 {
+		return o.deployTargets(ctx, cmd, c, baseURL, absPath, targets)
+	}
 
-	deployCmd.Flags().StringVarP(&projectName, "name", "n", "", "Project name (create-or-update for current owner)")
-	deployCmd.Flags().StringVarP(&visibility, "visibility", "v", "private", "Project visibility (public/private)")
-	deployCmd.Flags().BoolVar(&publish, "publish", true, "Publish to production after successful build")
-	deployCmd.Flags().BoolVar(&wait, "wait", true, "Wait for build completion")
-	deployCmd.Flags().IntVar(&timeout, "timeout", 600, "Build timeout in seconds")
-	deployCmd.Flags().BoolVar(&localBuild, "local-build", true, "Build locally and upload artifacts (must remain true; RobotX cloud build is no longer supported)")
-	deployCmd.Flags().StringVar(&installCmd, "install-command", "", "Override install command for local build")
-	deployCmd.Flags().StringVar(&buildCmd, "build-command", "", "Override build command for local build")
-	deployCmd.Flags().StringVar(&outputDir, "output-dir", "", "Override output directory for local build")
-	deployCmd.Flags().StringVar(&versionLabel, "version-label", "", "Optional build version label (e.g. v1.2.3)")
-	deployCmd.Flags().StringVar(&sourceRef, "source-ref", "", "Optional source reference (e.g. tag:v1.2.3, branch:main@<sha>)")
+	resp, err := o.deployProject(ctx, cmd, c, baseURL, absPath, started, nil)
+	if err != nil {
+		return err
+	}
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if o.qr {
+		o.printQR(resp.qrURL())
+	}
+	return nil
 }
 
-func runDeploy(cmd *cobra.Command, args []string) error {
+// deployPath returns the absolute path of the project directory given in
+// args, the current directory by default.
+func deployPath(args []string) (string, error) {
 	projectPath := "."
 	if len(args) > 0 {
 		projectPath = args[0]
@@ -87,190 +243,470 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 
 	absPath, err := filepath.Abs(projectPath)
 	if err != nil {
-		return newCLIError("invalid_project_path", "invalid project path", 1, err)
+		return "", newCLIError("invalid_project_path", "invalid project path", ExitGeneral, err)
 	}
 
 	if _, err := os.Stat(absPath); os.IsNotExist(err) {
-		return newCLIError("invalid_project_path", fmt.Sprintf("project path does not exist: %s", absPath), 1, nil)
+		return "", newCLIError("invalid_project_path", fmt.Sprintf("project path does not exist: %s", absPath), ExitGeneral, nil)
+	}
+	return absPath, nil
+}
+
+// deployClient checks the deploy flags and returns a client for a server
+// that accepts local build artifacts.
+func (o *deployOptions) deployClient(cmd *cobra.Command) (*client.Client, string, error) {
+	if err := o.checkFlags(cmd); err != nil {
+		return nil, "", err
 	}
 
-	baseURL := viper.GetString("base_url")
-	apiKey := viper.GetString("api_key")
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
 
 	if baseURL == "" {
-		return newCLIError("missing_base_url", "base URL is required (use --base-url or set ROBOTX_BASE_URL)", 1, nil)
+		return nil, "", newCLIError("missing_base_url", "base URL is required (use --base-url or set ROBOTX_BASE_URL)", ExitGeneral, nil)
 	}
 	if apiKey == "" {
-		return newCLIError("missing_api_key", "API key is required (use --api-key or set ROBOTX_API_KEY)", 1, nil)
+		return nil, "", newCLIError("missing_api_key", "API key is required (use --api-key or set ROBOTX_API_KEY)", ExitAuth, nil)
 	}
-	if !localBuild {
-		return newCLIError("unsupported_feature", "RobotX no longer supports remote build; remove --local-build=false and run the build locally", 1, nil)
+
+	c := o.newAPIClient(baseURL, apiKey)
+	if err := checkDeployServer(c, baseURL); err != nil {
+		return nil, "", err
 	}
+	return c, baseURL, nil
+}
 
-	c := client.NewClient(baseURL, apiKey)
-	usedProjectName := strings.TrimSpace(projectName)
-	var previewURL string
-	var productionURL string
+// checkFlags rejects invalid combinations of deploy flags.
+func (o *deployOptions) checkFlags(cmd *cobra.Command) error {
+	if cmd.Flags().Changed("archive-format") && !o.fromStdin {
+		return newCLIError("invalid_argument", "--archive-format only applies with --from-stdin", ExitGeneral, nil)
+	}
+	if o.fromStdin && strings.TrimSpace(o.packageCommand) != "" {
+		return newCLIError("invalid_argument", "--from-stdin cannot be combined with package_command", ExitGeneral, nil)
+	}
+	if o.lockTimeout < 0 {
+		return newCLIError("invalid_argument", "--lock-timeout cannot be negative", ExitGeneral, nil)
+	}
+	if !pipeline.ValidSeverity(o.auditLevel) {
+		return newCLIError("invalid_argument", fmt.Sprintf("invalid --audit-level %q (use %s)", o.auditLevel, strings.Join(pipeline.Severities, ", ")), ExitGeneral, nil)
+	}
+	if !validAuditTool(o.auditTool) {
+		return newCLIError("invalid_argument", fmt.Sprintf("invalid --audit-tool %q (use %s)", o.auditTool, strings.Join(auditTools, ", ")), ExitGeneral, nil)
+	}
+	if cmd.Flags().Changed("audit-tool") && strings.TrimSpace(o.auditReport) != "" {
+		return newCLIError("invalid_argument", "--audit-tool cannot be combined with --audit-report", ExitGeneral, nil)
+	}
+	if o.autoVersionLabel() {
+		if _, err := pipeline.NextVersionLabel(o.versionScheme, nil, time.Now()); err != nil {
+			return newCLIError("invalid_argument", "invalid --version-scheme: "+err.Error(), ExitGeneral, nil)
+		}
+	}
+	if !o.localBuild {
+		return newCLIError("unsupported_feature", "RobotX no longer supports remote build; remove --local-build=false and run the build locally", ExitGeneral, nil)
+	}
+	return nil
+}
+
+// checkDeployServer fails unless the server behind c accepts local build
+// artifacts.
+func checkDeployServer(c *client.Client, baseURL string) error {
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityLocalBuildArtifacts) {
+		return newCLIError("unsupported_server", "this RobotX server does not accept local build artifacts; upgrade the server or point --base-url at a newer deployment", ExitAPI, nil)
+	}
+	return nil
+}
+
+// deployProject runs the deploy pipeline for the project in absPath, or for
+// o.target of it, and records the result. source, when set, shares the
+// packaged source archive between the targets of one invocation.
+func (o *deployOptions) deployProject(ctx context.Context, cmd *cobra.Command, c *client.Client, baseURL, absPath string, started time.Time, source *sharedSource) (*deployResponse, error) {
+	usedProjectName := strings.TrimSpace(o.projectName)
 
 	if usedProjectName == "" {
+		if o.strict {
+			return nil, newCLIError("strict_mode", "--name is required with --strict instead of deriving the project name from the directory", ExitGeneral, nil)
+		}
 		usedProjectName = filepath.Base(absPath)
 	}
 	usedProjectName = strings.ToLower(strings.TrimSpace(usedProjectName))
 	if err := validateProjectName(usedProjectName); err != nil {
-		return newCLIError("invalid_project_name", err.Error(), 1, nil)
+		return nil, newCLIError("invalid_project_name", err.Error(), ExitGeneral, nil)
 	}
 
-	version := resolveBuildVersionInput()
-	if version != nil {
-		logf("🏷️  Build version label: %s\n", valueOrDash(version.VersionLabel))
-		logf("🔖 Source ref: %s\n", valueOrDash(version.SourceRef))
+	routes, err := o.loadDeployRoutes(absPath, c, baseURL)
+	if err != nil {
+		return nil, err
 	}
 
-	logf("📦 Resolving project by name (create-or-update): %s\n", usedProjectName)
-	proj, err := c.CreateProject(client.CreateProjectRequest{
-		Name:       usedProjectName,
-		Visibility: visibility,
-	})
+	functionsDir, err := o.resolveFunctionsDir(absPath, c, baseURL)
 	if err != nil {
-		return newCLIError("api_error", "failed to resolve project", 2, err)
+		return nil, err
 	}
-	usedProjectName = proj.Name
-	logf("✅ Project ready: %s\n", proj.ProjectID)
 
-	logf("📦 Packaging source code from: %s\n", absPath)
-	zipPath, err := packageSource(absPath)
+	budgets, err := loadBudgets(absPath)
 	if err != nil {
-		return newCLIError("package_failed", "failed to package source", 1, err)
+		return nil, err
 	}
-	defer os.Remove(zipPath)
-
-	if stat, statErr := os.Stat(zipPath); statErr == nil {
-		sizeMB := float64(stat.Size()) / (1024.0 * 1024.0)
-		logf("📏 Source archive size: %.2f MB\n", sizeMB)
+	envOverlays, err := o.loadDeployEnv(absPath, c, baseURL)
+	if err != nil {
+		return nil, err
 	}
-	logf("✅ Source packaged: %s\n", zipPath)
-
-	logf("⬆️  Uploading source code...\n")
-	commit, build, err := c.UploadSource(proj.ProjectID, zipPath, version)
+	smokeChecks, err := o.smokeChecks()
 	if err != nil {
-		return newCLIError("api_error", "failed to upload source", 2, err)
+		return nil, err
 	}
-	if commit != nil && commit.CommitID != "" {
-		logf("✅ Source uploaded: %s\n", commit.CommitID)
+
+	version := o.resolveBuildVersionInput()
+	if o.autoVersionLabel() {
+		o.logf("🏷️  Build version label: next %s label\n", o.versionScheme)
+		o.logf("🔖 Source ref: %s\n", valueOrDash(version.SourceRef))
+	} else if version != nil {
+		o.logf("🏷️  Build version label: %s\n", valueOrDash(version.VersionLabel))
+		o.logf("🔖 Source ref: %s\n", valueOrDash(version.SourceRef))
 	}
-	if build != nil && build.BuildID != "" {
-		logf("✅ Build created: %s\n", build.BuildID)
+
+	var stream io.Reader
+	if o.fromStdin {
+		if stream, err = sourceStream(cmd.InOrStdin(), o.archiveFormat); err != nil {
+			return nil, err
+		}
 	}
 
-	if build == nil || build.BuildID == "" {
-		return newCLIError("local_build_unsupported", "server did not return a build ID; local build upload is not supported by this server", 2, nil)
+	pkg := &deployPackager{deployOptions: o, packager: o.newPackager(absPath), source: source}
+	var steps []pipeline.Step
+	if o.audit || strings.TrimSpace(o.auditReport) != "" {
+		steps = append(steps, pipeline.Audit{Auditor: projectAuditor{o}, Threshold: o.auditLevel})
 	}
-	plan := (*client.BuildPlan)(nil)
-	if commit != nil && commit.ScannerResult != nil {
-		plan = commit.ScannerResult.BuildPlan
+	steps = append(steps, pipeline.ResolveProject{})
+	if !o.noLock && serverCapabilities(c, baseURL).Supports(client.CapabilityDeployLocks) {
+		steps = append(steps, pipeline.AcquireLock{Holder: deployLockHolder(), Timeout: time.Duration(o.lockTimeout) * time.Second})
 	}
-	if err := runLocalBuild(absPath, plan); err != nil {
-		return newCLIError("build_failed", "local build failed", 3, err)
+	if stream == nil {
+		steps = append(steps, pipeline.PackageSource{Packager: pkg}, pipeline.ReuseBuild{NewVersionLabel: o.autoVersionLabel()})
 	}
-	artifactDir := outputDir
-	if artifactDir == "" && plan != nil && strings.TrimSpace(plan.OutputDir) != "" {
-		artifactDir = strings.TrimSpace(plan.OutputDir)
+	if o.autoVersionLabel() {
+		steps = append(steps, pipeline.GenerateVersionLabel{Scheme: o.versionScheme})
+	} else if version != nil && version.VersionLabel != "" && !o.allowDuplicateLabel {
+		steps = append(steps, pipeline.CheckVersionLabel{})
 	}
-	if artifactDir == "" {
-		artifactDir = "dist"
+	if env, ok := envOverlays[client.EnvChannelPreview]; ok {
+		steps = append(steps, pipeline.SyncEnv{Channel: client.EnvChannelPreview, Env: env})
 	}
-	artifactPath := filepath.Join(absPath, artifactDir)
-	if stat, err := os.Stat(artifactPath); err != nil || !stat.IsDir() {
-		return newCLIError("build_failed", fmt.Sprintf("output directory missing: %s", artifactPath), 3, nil)
+	steps = append(steps,
+		pipeline.UploadSource{},
+		pipeline.LocalBuild{Builder: localBuilder{o}},
+		pipeline.BuildFunctions{Builder: functionsBuilder{o}},
+		pipeline.PackageArtifacts{Packager: pkg, OutputDir: o.outputDir, RequireOutputDir: o.strict},
+		pipeline.CheckBudgets{Budgets: budgets},
+		pipeline.PackageFunctions{Packager: pkg},
+		pipeline.UploadFunctions{},
+		pipeline.UploadArtifacts{},
+	)
+	if o.wait {
+		steps = append(steps, pipeline.WaitForBuild{
+			Timeout:   time.Duration(o.timeout) * time.Second,
+			Interval:  time.Duration(o.pollInterval) * time.Second,
+			Heartbeat: time.Duration(o.heartbeat) * time.Second,
+		})
 	}
-	logf("📦 Packaging build output from: %s\n", artifactPath)
-	artifactZip, err := packageDirectory(artifactPath)
-	if err != nil {
-		return newCLIError("build_failed", "failed to package build output", 3, err)
+	steps = append(steps, pipeline.SmokeTest{Checks: smokeChecks, Timeout: time.Duration(o.smokeTimeout) * time.Second})
+	if o.publish {
+		if env, ok := envOverlays[client.EnvChannelProduction]; ok {
+			steps = append(steps, pipeline.SyncEnv{Channel: client.EnvChannelProduction, Env: env})
+		}
+		steps = append(steps, pipeline.Publish{})
 	}
-	defer os.Remove(artifactZip)
-	logf("✅ Build output packaged: %s\n", artifactZip)
 
-	logf("⬆️  Uploading build artifacts...\n")
-	build, err = c.UploadBuildArtifacts(build.BuildID, artifactZip)
-	if err != nil {
-		return newCLIError("api_error", "failed to upload build artifacts", 2, err)
+	d := &pipeline.Deploy{
+		Client:       c,
+		URLs:         projectURLs{baseURL: baseURL},
+		ProjectPath:  absPath,
+		ProjectName:  usedProjectName,
+		Visibility:   o.visibility,
+		Version:      version,
+		Routes:       routes,
+		FunctionsDir: functionsDir,
+		Force:        o.force,
+		SourceStream: stream,
+		SourceFormat: o.archiveFormat,
+
+		Warnings:      o.warnings,
+		FailOnWarning: o.failOnWarning || o.strict,
 	}
-	logf("✅ Build artifacts uploaded\n")
-
-	if wait {
-		if build == nil || build.BuildID == "" {
-			return newCLIError("build_failed", "no build ID available to wait for completion", 3, nil)
-		}
-		if build.Status != "success" {
-			logf("⏳ Waiting for build to complete (timeout: %ds)...\n", timeout)
-			build, err = waitForBuild(c, proj.ProjectID, build.BuildID, timeout)
-			if err != nil {
-				return newCLIError("build_failed", "build failed", 3, err)
-			}
-		}
-
-		if build.Status == "success" {
-			logf("✅ Local build completed successfully!\n")
-			previewURL = resolvePreviewURL(baseURL, proj, build)
-			if previewURL != "" {
-				logf("🌐 Preview URL: %s\n", previewURL)
-			}
-		} else {
-			logf("❌ Build failed with status: %s\n", build.Status)
-			return newCLIError("build_failed", fmt.Sprintf("build failed with status: %s", build.Status), 3, nil)
-		}
-	} else if build != nil && build.Status == "success" {
-		logf("✅ Local build completed successfully!\n")
-		previewURL = resolvePreviewURL(baseURL, proj, build)
-		if previewURL != "" {
-			logf("🌐 Preview URL: %s\n", previewURL)
+	if d.FailOnWarning && len(o.warnings) > 0 {
+		return nil, warningsError(o.warnings)
+	}
+	// The state file records a single project, so the targets of a build
+	// matrix, each deployed to a project of its own, leave it alone.
+	var st *deployState
+	if o.target == "" {
+		st = loadDeployState(absPath)
+		if st.SourceDigest != "" && st.lastBuildID() != "" {
+			d.PreviousDigest = st.SourceDigest
+			d.PreviousBuildID = st.lastBuildID()
 		}
 	}
+	emitters := pipeline.Emitters{o.deployEventLogger(steps), o.traceEmitter()}
+	if o.deployEvents != nil {
+		emitters = append(emitters, o.deployEvents(steps))
+	}
+	err = pipeline.New(emitters, steps...).Run(ctx, d)
+	if lockErr := d.ReleaseLock(); lockErr != nil {
+		o.logf("⚠️  Failed to release the deploy lock; it expires on its own, or run 'robotx unlock': %v\n", lockErr)
+	}
+	for _, archive := range pkg.archives {
+		os.Remove(archive)
+	}
+	if err != nil {
+		return nil, o.withWaitResume(deployStepError(ctx, err), err, d, o.publish, o.timeout)
+	}
 
-	if publish && build != nil && build.Status == "success" {
-		logf("🚀 Publishing to production...\n")
-		publicPath, err := c.PublishBuild(proj.ProjectID, build.BuildID)
-		if err != nil {
-			return newCLIError("publish_failed", "failed to publish", 4, err)
-		}
-		logf("✅ Published successfully!\n")
+	build := d.Build
+	previewURL := d.PreviewURL
+	productionURL := d.ProductionURL
+	if previewURL == "" && build != nil && build.Status == "success" {
+		previewURL = resolvePreviewURL(baseURL, d.Project, build)
+	}
+	if productionURL == "" && o.publish && build != nil && build.Status == "success" {
+		productionURL = resolvePublishURL(baseURL, d.Project)
+	}
 
-		productionURL = strings.TrimSpace(publicPath)
-		if productionURL == "" {
-			productionURL = resolvePublishURL(baseURL, proj)
+	if st != nil {
+		if st.ProjectID != d.Project.ProjectID {
+			st = &deployState{ProjectID: d.Project.ProjectID, path: st.path}
 		}
-		if productionURL != "" {
-			logf("🌐 Production URL: %s\n", productionURL)
+		st.ProjectName = d.ProjectName
+		st.SourceDigest = d.SourceDigest
+		st.recordBuild(build)
+		if d.ProductionURL != "" {
+			st.recordPublish(build.BuildID, productionURL)
 		}
+		st.save(o.app)
 	}
+	o.recordHistory(historyEntry{
+		Command:       cmd.Name(),
+		ProjectID:     d.Project.ProjectID,
+		ProjectName:   d.ProjectName,
+		BuildID:       safeBuildID(build),
+		PreviewURL:    previewURL,
+		ProductionURL: productionURL,
+		Args:          historyArgs(cmd, absPath),
+	})
 
-	if previewURL == "" && build != nil && build.Status == "success" {
-		previewURL = resolvePreviewURL(baseURL, proj, build)
+	timings := deployTimings(d, time.Since(started))
+	if o.verbose {
+		o.logf("⏱️  Timings: %s\n", timings)
 	}
-	if productionURL == "" && publish && build != nil && build.Status == "success" {
-		productionURL = resolvePublishURL(baseURL, proj)
+
+	var qrPNG string
+	if o.qr {
+		qrPNG = o.qrPNG(firstNonEmpty(productionURL, previewURL))
 	}
 
-	if err := emitSuccess(cmd.Name(), deployResponse{
-		ProjectID:     proj.ProjectID,
-		ProjectName:   usedProjectName,
-		CommitID:      safeCommitID(commit),
+	return &deployResponse{
+		Target:        o.target,
+		ProjectID:     d.Project.ProjectID,
+		ProjectName:   d.ProjectName,
+		CommitID:      safeCommitID(d.Commit),
 		BuildID:       safeBuildID(build),
 		VersionSeq:    safeBuildVersionSeq(build),
 		VersionLabel:  safeBuildVersionLabel(build),
 		SourceRef:     safeBuildSourceRef(build, version),
 		BuildStatus:   safeBuildStatus(build),
+		RawStatus:     safeBuildRawStatus(build),
 		PreviewURL:    previewURL,
 		ProductionURL: productionURL,
-		Published:     publish && productionURL != "",
-		Waited:        wait,
-		LocalBuild:    localBuild,
-	}); err != nil {
-		return newCLIError("output_error", "failed to render JSON output", 1, err)
+		Published:     o.publish && productionURL != "",
+		Waited:        o.wait,
+		LocalBuild:    o.localBuild,
+		LargeFiles:    pkg.largeFiles,
+		HardLinks:     pkg.hardLinks,
+		Warnings:      d.Warnings,
+		SmokeTests:    d.SmokeResults,
+		Audit:         d.AuditReport,
+		Reused:        d.Reused,
+		Deduplicated:  d.Deduplicated,
+		EnvChanges:    d.EnvChanges,
+		SourceDigest:  d.SourceDigest,
+		QRPNG:         qrPNG,
+		Functions:     d.Functions,
+
+		SourceArchiveBytes:    d.SourceArchiveSize,
+		ArtifactArchiveBytes:  d.ArtifactArchiveSize,
+		FunctionsArchiveBytes: d.FunctionsArchiveSize,
+		Timings:               timings,
+	}, nil
+}
+
+// deployStepError converts a pipeline failure into the CLI error for the step
+// that failed.
+func deployStepError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return newCLIError("cancelled", "deploy cancelled", ExitCancelled, err)
+	}
+	var stepErr *pipeline.StepError
+	if !errors.As(err, &stepErr) {
+		return err
+	}
+	cause := stepErr.Err
+	var buildFailed *pipeline.BuildFailedError
+	var warnings *pipeline.WarningsError
+	var overBudget *pipeline.BudgetExceededError
+	var smokeFailed *pipeline.SmokeTestError
+	var locked *client.DeployLockedError
+	var duplicateLabel *pipeline.DuplicateVersionLabelError
+	var vulnerable *pipeline.VulnerabilitiesFoundError
+	switch {
+	case errors.As(cause, &vulnerable):
+		cliErr := newCLIError("vulnerabilities_found", "dependency audit failed: "+cause.Error(), ExitBuild, nil)
+		cliErr.Details = vulnerable
+		return cliErr
+	case errors.As(cause, &duplicateLabel):
+		cliErr := newCLIError("duplicate_version_label", cause.Error()+"; choose another --version-label or pass --allow-duplicate-label", ExitGeneral, nil)
+		cliErr.Details = duplicateLabel
+		return cliErr
+	case errors.Is(cause, pipeline.ErrLockLost):
+		return newCLIError("lock_lost", cause.Error()+"; another deploy may have taken the project, so this one was stopped; deploy again", ExitLocked, nil)
+	case errors.As(cause, &locked):
+		cliErr := newCLIError("deploy_locked", "another deploy of this project is in progress: "+cause.Error()+"; retry later, raise --lock-timeout, or run 'robotx unlock' if that deploy is dead", ExitLocked, nil)
+		if locked.Lock != nil {
+			cliErr.Details = locked.Lock
+		}
+		return cliErr
+	case errors.As(cause, &smokeFailed):
+		cliErr := newCLIError("smoke_test_failed", cause.Error(), ExitBuild, nil)
+		cliErr.Details = map[string]interface{}{"smoke_tests": smokeFailed.Results}
+		return cliErr
+	case errors.As(cause, &overBudget):
+		cliErr := newCLIError("budget_exceeded", "build output exceeds size budget: "+overBudget.Error(), ExitBuild, nil)
+		cliErr.Details = budgetsBreakdown{File: projectConfigFileName, BudgetExceededError: overBudget}
+		return cliErr
+	case errors.As(cause, &warnings):
+		return warningsError(warnings.Warnings)
+	case errors.Is(cause, pipeline.ErrImplicitOutputDir):
+		return newCLIError("strict_mode", cause.Error()+"; pass --output-dir with --strict", ExitGeneral, nil)
+	case errors.Is(cause, pipeline.ErrNoBuild):
+		return newCLIError("local_build_unsupported", cause.Error(), ExitAPI, nil)
+	case errors.Is(cause, pipeline.ErrOutputDirMissing):
+		cliErr := newCLIError("build_failed", cause.Error(), ExitBuild, nil)
+		cliErr.Details = &buildFailure{
+			Category:    "output_dir_missing",
+			Summary:     "The build finished but left no output directory to upload",
+			Suggestions: []string{"Pass the directory the build writes to with --output-dir"},
+		}
+		return cliErr
+	case errors.As(cause, &buildFailed):
+		return newCLIError("build_failed", cause.Error(), ExitBuild, nil)
+	case errors.Is(cause, pipeline.ErrBuildTimeout):
+		return newCLIError("build_timeout", cause.Error(), ExitBuild, nil)
+	}
+	switch stepErr.Step {
+	case pipeline.StepAudit:
+		return newCLIError("audit_failed", "failed to audit dependencies", ExitGeneral, cause)
+	case pipeline.StepResolveProject:
+		return newCLIError("api_error", "failed to resolve project", ExitAPI, cause)
+	case pipeline.StepLock:
+		return newCLIError("api_error", "failed to take the deploy lock", ExitAPI, cause)
+	case pipeline.StepPackageSource:
+		return newCLIError("package_failed", "failed to package source", ExitGeneral, cause)
+	case pipeline.StepVersionLabel:
+		return newCLIError("api_error", "failed to generate the version label", ExitAPI, cause)
+	case pipeline.StepCheckVersionLabel:
+		return newCLIError("api_error", "failed to check the version label", ExitAPI, cause)
+	case pipeline.StepSyncPreviewEnv:
+		return newCLIError("api_error", "failed to sync the preview env", ExitAPI, cause)
+	case pipeline.StepUploadSource:
+		return newCLIError("api_error", "failed to upload source", ExitAPI, cause)
+	case pipeline.StepBuild:
+		cliErr := newCLIError("build_failed", "local build failed", ExitBuild, cause)
+		var failure *buildFailureError
+		if errors.As(cause, &failure) {
+			cliErr.Details = failure.analysis
+		}
+		return cliErr
+	case pipeline.StepBuildFunctions:
+		cliErr := newCLIError("build_failed", "functions build failed", ExitBuild, cause)
+		var failure *buildFailureError
+		if errors.As(cause, &failure) {
+			cliErr.Details = failure.analysis
+		}
+		return cliErr
+	case pipeline.StepPackageArtifacts:
+		return newCLIError("build_failed", "failed to package build output", ExitBuild, cause)
+	case pipeline.StepCheckBudgets:
+		return newCLIError("budget_check_failed", "failed to check size budgets", ExitGeneral, cause)
+	case pipeline.StepPackageFunctions:
+		return newCLIError("package_failed", "failed to package functions", ExitGeneral, cause)
+	case pipeline.StepUploadFunctions:
+		return newCLIError("api_error", "failed to upload functions", ExitAPI, cause)
+	case pipeline.StepUploadArtifacts:
+		return newCLIError("api_error", "failed to upload build artifacts", ExitAPI, cause)
+	case pipeline.StepWait:
+		return newCLIError("build_failed", "build failed", ExitBuild, cause)
+	case pipeline.StepSmokeTest:
+		return newCLIError("smoke_test_failed", "failed to run smoke test", ExitBuild, cause)
+	case pipeline.StepSyncProductionEnv:
+		return newCLIError("api_error", "failed to sync the production env", ExitAPI, cause)
+	case pipeline.StepPublish:
+		return newCLIError("publish_failed", "failed to publish", ExitPublish, cause)
 	}
+	return err
+}
 
-	return nil
+// smokeChecks parses --smoke-test and --smoke-expect. An expectation belongs
+// to the longest listed path followed by "=", so paths may contain "=".
+func (o *deployOptions) smokeChecks() ([]pipeline.SmokeCheck, error) {
+	var checks []pipeline.SmokeCheck
+	for _, path := range o.smokePaths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		checks = append(checks, pipeline.SmokeCheck{Path: path})
+	}
+	if len(checks) > 0 && !o.wait {
+		return nil, newCLIError("invalid_argument", "--smoke-test requires --wait", ExitGeneral, nil)
+	}
+	for _, expect := range o.smokeExpects {
+		if !strings.HasPrefix(expect, "/") {
+			expect = "/" + expect
+		}
+		match := -1
+		for i, check := range checks {
+			if strings.HasPrefix(expect, check.Path+"=") && (match < 0 || len(check.Path) > len(checks[match].Path)) {
+				match = i
+			}
+		}
+		if match < 0 {
+			return nil, newCLIError("invalid_argument", fmt.Sprintf("--smoke-expect %q must start with a --smoke-test path followed by '='", expect), ExitGeneral, nil)
+		}
+		if checks[match].Pattern != nil {
+			return nil, newCLIError("invalid_argument", fmt.Sprintf("--smoke-expect is set twice for %s", checks[match].Path), ExitGeneral, nil)
+		}
+		re, err := regexp.Compile(expect[len(checks[match].Path)+1:])
+		if err != nil {
+			return nil, newCLIError("invalid_argument", fmt.Sprintf("invalid --smoke-expect pattern for %s: %v", checks[match].Path, err), ExitGeneral, nil)
+		}
+		checks[match].Pattern = re
+	}
+	return checks, nil
+}
+
+// warnf logs a warning found before the pipeline runs.
+func (o *deployOptions) warnf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	o.warnings = append(o.warnings, message)
+	o.logf("⚠️  %s\n", message)
+}
+
+// warningsError fails a deploy run with --fail-on-warning or --strict.
+func warningsError(warnings []string) error {
+	cliErr := newCLIError("warnings_as_errors", fmt.Sprintf("deploy stopped on %d warning(s); warnings are errors with --fail-on-warning and --strict", len(warnings)), ExitGeneral, nil)
+	cliErr.Details = map[string][]string{"warnings": warnings}
+	return cliErr
 }
 
 func safeCommitID(commit *client.SourceCommit) string {
@@ -294,6 +730,13 @@ func safeBuildStatus(build *client.Build) string {
 	return build.Status
 }
 
+func safeBuildRawStatus(build *client.Build) string {
+	if build == nil {
+		return ""
+	}
+	return build.RawStatus
+}
+
 func safeBuildVersionSeq(build *client.Build) int64 {
 	if build == nil {
 		return 0
@@ -329,9 +772,18 @@ func validateProjectName(name string) error {
 	return nil
 }
 
-func resolveBuildVersionInput() *client.BuildVersionInput {
-	label := strings.TrimSpace(versionLabel)
-	ref := strings.TrimSpace(sourceRef)
+// versionLabelAuto is the --version-label that asks for a generated label.
+const versionLabelAuto = "auto"
+
+// autoVersionLabel reports whether the version label is generated from the
+// labels of the project's recent builds.
+func (o *deployOptions) autoVersionLabel() bool {
+	return strings.TrimSpace(o.versionLabel) == versionLabelAuto
+}
+
+func (o *deployOptions) resolveBuildVersionInput() *client.BuildVersionInput {
+	label := strings.TrimSpace(o.versionLabel)
+	ref := strings.TrimSpace(o.sourceRef)
 	if label == "" && ref == "" {
 		return nil
 	}
@@ -356,16 +808,17 @@ func resolvePreviewURL(baseURL string, project *client.Project, build *client.Bu
 	return projectPreviewURL(project, baseURL)
 }
 
-func packageSource(projectPath string) (string, error) {
+func packageSource(projectPath string, opts packageOptions) (string, *packageReport, error) {
 	tmpFile, err := os.CreateTemp("", "robotx-source-*.zip")
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 	defer tmpFile.Close()
 
 	zipWriter := zip.NewWriter(tmpFile)
 	defer zipWriter.Close()
 
+	report := &packageReport{}
 	err = filepath.Walk(projectPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -386,6 +839,9 @@ func packageSource(projectPath string) (string, error) {
 		if info.IsDir() {
 			return nil
 		}
+		if report.inspect(path, relPath, info, opts) {
+			return nil
+		}
 
 		zipFile, err := zipWriter.Create(relPath)
 		if err != nil {
@@ -404,22 +860,23 @@ func packageSource(projectPath string) (string, error) {
 
 	if err != nil {
 		os.Remove(tmpFile.Name())
-		return "", err
+		return "", nil, err
 	}
 
-	return tmpFile.Name(), nil
+	return tmpFile.Name(), report, nil
 }
 
-func packageDirectory(root string) (string, error) {
-	tmpFile, err := os.CreateTemp("", "robotx-artifacts-*.zip")
+func packageDirectory(root string, kind pipeline.ArchiveKind, opts packageOptions) (string, *packageReport, error) {
+	tmpFile, err := os.CreateTemp("", fmt.Sprintf("robotx-%s-*.zip", kind))
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 	defer tmpFile.Close()
 
 	zipWriter := zip.NewWriter(tmpFile)
 	defer zipWriter.Close()
 
+	report := &packageReport{}
 	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -431,6 +888,9 @@ func packageDirectory(root string) (string, error) {
 		if err != nil {
 			return err
 		}
+		if report.inspect(path, relPath, info, opts) {
+			return nil
+		}
 		zipFile, err := zipWriter.Create(relPath)
 		if err != nil {
 			return err
@@ -446,10 +906,10 @@ func packageDirectory(root string) (string, error) {
 
 	if err != nil {
 		os.Remove(tmpFile.Name())
-		return "", err
+		return "", nil, err
 	}
 
-	return tmpFile.Name(), nil
+	return tmpFile.Name(), report, nil
 }
 
 func shouldSkip(path string) bool {
@@ -463,6 +923,7 @@ func shouldSkip(path string) bool {
 		"__pycache__",
 		".venv",
 		"venv",
+		deployStateDir,
 	}
 
 	for _, skip := range skipDirs {
@@ -474,9 +935,18 @@ func shouldSkip(path string) bool {
 	return false
 }
 
-func runLocalBuild(projectPath string, plan *client.BuildPlan) error {
-	install := strings.TrimSpace(installCmd)
-	build := strings.TrimSpace(buildCmd)
+// localBuilder runs the install and build commands in the project directory.
+// Flags take precedence over the server's build plan, which takes precedence
+// over npm defaults for projects with a package.json.
+type localBuilder struct {
+	*deployOptions
+}
+
+func (o localBuilder) Build(ctx context.Context, d *pipeline.Deploy) error {
+	projectPath := d.ProjectPath
+	plan := d.Plan()
+	install := strings.TrimSpace(o.installCmd)
+	build := strings.TrimSpace(o.buildCmd)
 
 	if install == "" && plan != nil && strings.TrimSpace(plan.InstallCommand) != "" {
 		install = strings.TrimSpace(plan.InstallCommand)
@@ -492,35 +962,77 @@ func runLocalBuild(projectPath string, plan *client.BuildPlan) error {
 		build = "npm run build"
 	}
 
-	if plan != nil && !plan.NeedsBuild && installCmd == "" && buildCmd == "" {
+	if plan != nil && !plan.NeedsBuild && o.installCmd == "" && o.buildCmd == "" {
 		install = ""
 		build = ""
 	}
 
+	var buildLog io.Writer
+	if (install != "" || build != "") && d.Build != nil {
+		if f, err := createBuildLog(projectPath, d.Build.BuildID); err == nil {
+			defer f.Close()
+			buildLog = f
+		} else {
+			d.Logf(pipeline.LevelWarn, "Could not save build log: %v", err)
+		}
+	}
+
 	if install != "" {
-		logf("🛠️  Running %s\n", install)
-		if err := runShell(projectPath, install); err != nil {
+		d.Logf(pipeline.LevelInfo, "Running %s", install)
+		if err := o.runBuildCommand(ctx, projectPath, install, buildLog); err != nil {
 			return fmt.Errorf("install failed: %w", err)
 		}
 	}
 	if build != "" {
-		logf("🛠️  Running %s\n", build)
-		if err := runShell(projectPath, build); err != nil {
+		d.Logf(pipeline.LevelInfo, "Running %s", build)
+		if err := o.runBuildCommand(ctx, projectPath, build, buildLog); err != nil {
 			return fmt.Errorf("build failed: %w", err)
 		}
 	}
 	return nil
 }
 
-func runShell(dir, command string) error {
-	cmd := exec.Command("sh", "-lc", command)
+// runBuildCommand runs an install or build command, appending its output to
+// buildLog when it is not nil. When it fails, the tail of its output is
+// analyzed and suggestions for known failures are printed.
+func (o localBuilder) runBuildCommand(ctx context.Context, dir, command string, buildLog io.Writer) error {
+	output := newTailBuffer(buildLogTailBytes)
+	var capture io.Writer = output
+	if buildLog != nil {
+		fmt.Fprintf(buildLog, "%s%s\n", buildLogCommandPrefix, command)
+		capture = io.MultiWriter(output, buildLog)
+	}
+	err := o.runShell(ctx, dir, command, capture)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	analysis := analyzeBuildFailure(output.String(), command)
+	o.logf("🔍 %s (%s)\n", analysis.Summary, analysis.Category)
+	if analysis.MatchedLine != "" {
+		o.logf("   %s\n", analysis.MatchedLine)
+	}
+	for _, suggestion := range analysis.Suggestions {
+		o.logf("💡 %s\n", suggestion)
+	}
+	return &buildFailureError{err: err, analysis: analysis}
+}
+
+// runShell runs command in dir, copying its output to the log and to capture
+// when it is not nil.
+func (a *app) runShell(ctx context.Context, dir, command string, capture io.Writer) error {
+	cmd := exec.CommandContext(ctx, "sh", "-lc", command)
+	setInterruptGroup(cmd)
+	cmd.WaitDelay = 10 * time.Second
 	cmd.Dir = dir
-	if isJSONOutput() {
-		cmd.Stdout = os.Stderr
-	} else {
-		cmd.Stdout = os.Stdout
+	cmd.Stdout = a.logWriter()
+	cmd.Stderr = a.errOut()
+	if capture != nil {
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, capture)
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, capture)
+	}
+	if a.isNonInteractive() {
+		cmd.Env = append(os.Environ(), noColorEnv()...)
 	}
-	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
@@ -529,28 +1041,144 @@ func fileExists(path string) bool {
 	return err == nil
 }
 
-func waitForBuild(c *client.Client, projectID, buildID string, timeoutSec int) (*client.Build, error) {
-	start := time.Now()
-	timeout := time.Duration(timeoutSec) * time.Second
+// deployStepIcons prefixes informational deploy events in text output.
+var deployStepIcons = map[string]string{
+	pipeline.StepAudit:             "🛡️ ",
+	pipeline.StepResolveProject:    "📦",
+	pipeline.StepLock:              "🔒",
+	pipeline.StepPackageSource:     "📦",
+	pipeline.StepReuseBuild:        "♻️ ",
+	pipeline.StepVersionLabel:      "🏷️ ",
+	pipeline.StepCheckVersionLabel: "🏷️ ",
+	pipeline.StepSyncPreviewEnv:    "🔧",
+	pipeline.StepUploadSource:      "⬆️ ",
+	pipeline.StepBuild:             "🛠️ ",
+	pipeline.StepBuildFunctions:    "🛠️ ",
+	pipeline.StepPackageArtifacts:  "📦",
+	pipeline.StepCheckBudgets:      "📏",
+	pipeline.StepPackageFunctions:  "📦",
+	pipeline.StepUploadFunctions:   "⬆️ ",
+	pipeline.StepUploadArtifacts:   "⬆️ ",
+	pipeline.StepWait:              "⏳",
+	pipeline.StepSmokeTest:         "🩺",
+	pipeline.StepSyncProductionEnv: "🔧",
+	pipeline.StepPublish:           "🚀",
+}
 
-	for {
-		if time.Since(start) > timeout {
-			return nil, fmt.Errorf("build timeout after %d seconds", timeoutSec)
+// deployEventLogger renders the events of a pipeline running steps. On a
+// terminal they are shown as a step checklist updated in place; otherwise
+// each event is logged as a line and upload progress is logged in 25% steps.
+func (a *app) deployEventLogger(steps []pipeline.Step) pipeline.Emitter {
+	if w := a.liveProgressWriter(); w != nil {
+		a.progress = newStepProgress(a, w, len(steps))
+		return a.progress
+	}
+	var lastStep int64
+	return pipeline.EmitterFunc(func(e pipeline.Event) {
+		switch e.Type {
+		case pipeline.EventStepStarted:
+			lastStep = 0
+		case pipeline.EventLog, pipeline.EventURL:
+			fmt.Fprint(a.logWriter(), a.deployEventLine(e))
+		case pipeline.EventProgress:
+			if e.Total <= 0 {
+				return
+			}
+			if step := e.Sent * 4 / e.Total; step > lastStep {
+				lastStep = step
+				a.logf("⬆️  Upload progress: %d%%\n", step*25)
+			}
 		}
+	})
+}
 
-		build, err := c.GetBuild(projectID, buildID)
-		if err != nil {
-			return nil, err
+// deployEventLine formats a log or URL event of a pipeline as a log line.
+func (a *app) deployEventLine(e pipeline.Event) string {
+	if e.Type == pipeline.EventURL {
+		label := "Preview URL"
+		if e.Name == "production" {
+			label = "Production URL"
 		}
+		return a.formatLog("🌐 %s: %s\n", a.tr(label), verbatim(e.Message))
+	}
+	icon := deployStepIcons[e.Step]
+	switch e.Level {
+	case pipeline.LevelSuccess:
+		icon = "✅"
+	case pipeline.LevelWarn:
+		icon = "⚠️ "
+	case pipeline.LevelError:
+		icon = "❌"
+	}
+	return a.formatLog("%s %s\n", icon, a.tr(e.Message))
+}
 
-		switch build.Status {
-		case "success", "failed":
-			return build, nil
-		case "queued", "running":
-			logf("⏳ Build status: %s (elapsed: %ds)\n", build.Status, int(time.Since(start).Seconds()))
-			time.Sleep(5 * time.Second)
-		default:
-			return nil, fmt.Errorf("unknown build status: %s", build.Status)
-		}
+// deployPackager adapts the configured packager to the pipeline, reporting
+// large files and remembering the archives to remove after the deploy. With
+// a shared source, the source archive is packaged once for all targets and
+// removed by the caller.
+type deployPackager struct {
+	*deployOptions
+	packager   packager
+	source     *sharedSource
+	archives   []string
+	largeFiles []largeFileEntry
+	hardLinks  []hardLinkEntry
+}
+
+func (p *deployPackager) Package(ctx context.Context, d *pipeline.Deploy, kind pipeline.ArchiveKind, root string) (string, error) {
+	if kind == pipeline.ArchiveSource && p.source != nil && p.source.path != "" {
+		p.logf("♻️  Reusing the source archive packaged for target %s\n", p.source.target)
+		p.addReport(d, "Source archive", p.source.report)
+		return p.source.path, nil
+	}
+	opts := packageOptions{
+		SkipBinaries:       p.skipBinaries,
+		LargeFileThreshold: int64(p.largeFileMB) * 1024 * 1024,
+	}
+	path, report, err := p.packager.Package(ctx, kind, root, opts)
+	if err != nil {
+		return "", err
+	}
+	if kind == pipeline.ArchiveSource && p.source != nil {
+		*p.source = sharedSource{path: path, report: report, target: p.target}
+	} else {
+		p.archives = append(p.archives, path)
 	}
+	label := "Source archive"
+	switch kind {
+	case pipeline.ArchiveArtifacts:
+		label = "Build output"
+	case pipeline.ArchiveFunctions:
+		label = "Functions"
+	}
+	p.logLargeFiles(label, report)
+	p.addReport(d, label, report)
+	return path, nil
+}
+
+// addReport adds the large files and hard links of an archive to the deploy
+// result and warnings.
+func (p *deployPackager) addReport(d *pipeline.Deploy, label string, report *packageReport) {
+	p.largeFiles = append(p.largeFiles, report.LargeFiles...)
+	p.hardLinks = append(p.hardLinks, report.HardLinks...)
+	if n := len(report.LargeFiles); n > 0 {
+		d.Warnings = append(d.Warnings, fmt.Sprintf("%s contains %d large file(s)", label, n))
+	}
+	if n := len(report.HardLinks); n > 0 {
+		d.Warnings = append(d.Warnings, fmt.Sprintf("%s contains %d hard link(s)", label, n))
+	}
+}
+
+// projectURLs resolves deploy URLs against the configured base URL.
+type projectURLs struct {
+	baseURL string
+}
+
+func (u projectURLs) PreviewURL(project *client.Project, build *client.Build) string {
+	return resolvePreviewURL(u.baseURL, project, build)
+}
+
+func (u projectURLs) ProductionURL(project *client.Project) string {
+	return resolvePublishURL(u.baseURL, project)
 }
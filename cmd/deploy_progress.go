@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
+)
+
+// deployStepLabels names the deploy steps in the step checklist.
+var deployStepLabels = map[string]string{
+	pipeline.StepAudit:             "Audit dependencies",
+	pipeline.StepResolveProject:    "Resolve project",
+	pipeline.StepLock:              "Take deploy lock",
+	pipeline.StepPackageSource:     "Package source",
+	pipeline.StepReuseBuild:        "Check for a reusable build",
+	pipeline.StepVersionLabel:      "Generate version label",
+	pipeline.StepCheckVersionLabel: "Check version label",
+	pipeline.StepSyncPreviewEnv:    "Sync preview env",
+	pipeline.StepUploadSource:      "Upload source",
+	pipeline.StepBuild:             "Build",
+	pipeline.StepBuildFunctions:    "Build functions",
+	pipeline.StepPackageArtifacts:  "Package build output",
+	pipeline.StepCheckBudgets:      "Check size budgets",
+	pipeline.StepPackageFunctions:  "Package functions",
+	pipeline.StepUploadFunctions:   "Upload functions",
+	pipeline.StepUploadArtifacts:   "Upload build output",
+	pipeline.StepWait:              "Wait for build",
+	pipeline.StepSmokeTest:         "Smoke test",
+	pipeline.StepSyncProductionEnv: "Sync production env",
+	pipeline.StepPublish:           "Publish",
+}
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+const (
+	spinnerInterval = 100 * time.Millisecond
+	// maxStepDetail caps the status shown next to the spinner so the line
+	// does not wrap, which would break rewriting it in place.
+	maxStepDetail = 60
+)
+
+// liveProgressWriter returns the terminal that deploy progress may be
+// redrawn in place on, or nil when events must be logged line by line: in
+// JSON, non-interactive and accessible mode, and when logs are not written
+// to a terminal.
+func (a *app) liveProgressWriter() io.Writer {
+	mode := a.outputMode()
+	if mode.json || mode.nonInteractive || mode.accessible {
+		return nil
+	}
+	f, ok := a.logWriter().(*os.File)
+	if !ok || !isTerminal(f) {
+		return nil
+	}
+	return f
+}
+
+// stepProgress renders a pipeline as a step checklist: the running step is
+// a spinner line redrawn in place, with upload progress and informational
+// logs such as the build status shown on it instead of separate lines, and
+// each finished step is left as a checked line. Results, warnings, errors
+// and URLs are printed above the spinner. Skipped steps are not counted.
+type stepProgress struct {
+	a     *app
+	w     io.Writer
+	total int
+
+	mu      sync.Mutex
+	index   int
+	label   string
+	detail  string
+	started time.Time
+	frame   int
+	stop    chan struct{}
+}
+
+func newStepProgress(a *app, w io.Writer, total int) *stepProgress {
+	return &stepProgress{a: a, w: w, total: total}
+}
+
+func (p *stepProgress) Emit(e pipeline.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch e.Type {
+	case pipeline.EventStepSkipped:
+		p.total--
+	case pipeline.EventStepStarted:
+		p.index++
+		p.label = p.a.tr(firstNonEmpty(deployStepLabels[e.Step], e.Step))
+		p.detail = ""
+		p.started = e.Time
+		p.startSpinner()
+	case pipeline.EventStepFinished:
+		p.finish("✅", e.Time)
+	case pipeline.EventStepFailed:
+		p.finish("❌", e.Time)
+	case pipeline.EventProgress:
+		if e.Total > 0 {
+			p.detail = fmt.Sprintf("%d%%", e.Sent*100/e.Total)
+			p.draw()
+		}
+	case pipeline.EventLog:
+		// Progress notes such as build status polls only update the
+		// spinner line; results, warnings and errors are kept.
+		if e.Level == pipeline.LevelInfo {
+			p.detail = p.a.tr(e.Message)
+			p.draw()
+			return
+		}
+		p.print(p.a.deployEventLine(e))
+	case pipeline.EventURL:
+		p.print(p.a.deployEventLine(e))
+	}
+}
+
+// startSpinner draws the running step and redraws it until the step ends.
+// Called with p.mu held.
+func (p *stepProgress) startSpinner() {
+	stop := make(chan struct{})
+	p.stop = stop
+	p.draw()
+	go func() {
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			p.mu.Lock()
+			select {
+			case <-stop:
+			default:
+				p.frame++
+				p.draw()
+			}
+			p.mu.Unlock()
+		}
+	}()
+}
+
+// finish replaces the spinner line with the checked step. Called with p.mu
+// held.
+func (p *stepProgress) finish(mark string, at time.Time) {
+	if p.stop == nil {
+		return
+	}
+	close(p.stop)
+	p.stop = nil
+	fmt.Fprintf(p.w, "\r\033[K%s %s %s (%s)\n", mark, p.counter(), p.label, at.Sub(p.started).Round(100*time.Millisecond))
+}
+
+// printLine writes a line logged while the pipeline runs, such as a
+// packaging warning, above the spinner line.
+func (p *stepProgress) printLine(line string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.print(line)
+}
+
+// print writes a log line above the spinner line. Called with p.mu held.
+func (p *stepProgress) print(line string) {
+	if p.stop == nil {
+		fmt.Fprint(p.w, line)
+		return
+	}
+	fmt.Fprint(p.w, "\r\033[K"+line)
+	p.draw()
+}
+
+// draw rewrites the spinner line. Called with p.mu held.
+func (p *stepProgress) draw() {
+	if p.stop == nil {
+		return
+	}
+	line := fmt.Sprintf("%s %s %s", spinnerFrames[p.frame%len(spinnerFrames)], p.counter(), p.label)
+	if detail := []rune(p.detail); len(detail) > 0 {
+		if len(detail) > maxStepDetail {
+			detail = append(detail[:maxStepDetail-1], '…')
+		}
+		line += " · " + string(detail)
+	} else {
+		line += fmt.Sprintf(" (%ds)", int(time.Since(p.started).Seconds()))
+	}
+	fmt.Fprint(p.w, "\r\033[K"+line)
+}
+
+func (p *stepProgress) counter() string {
+	width := len(fmt.Sprint(p.total))
+	return fmt.Sprintf("[%*d/%d]", width, p.index, p.total)
+}
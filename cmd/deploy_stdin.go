@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Archive formats accepted by deploy --from-stdin.
+const (
+	archiveFormatZip   = "zip"
+	archiveFormatTarGz = "tar.gz"
+)
+
+// archiveMagic holds the leading bytes of each --archive-format.
+var archiveMagic = map[string][]byte{
+	archiveFormatZip:   []byte("PK\x03\x04"),
+	archiveFormatTarGz: {0x1f, 0x8b},
+}
+
+// sourceStream checks that in carries an archive of the given format and
+// returns a reader that still yields it from the first byte. Only the
+// leading bytes are read, so the archive is never buffered whole.
+func sourceStream(in io.Reader, format string) (io.Reader, error) {
+	magic, ok := archiveMagic[format]
+	if !ok {
+		return nil, newCLIError("invalid_argument", fmt.Sprintf("invalid --archive-format %q (expected %s or %s)", format, archiveFormatZip, archiveFormatTarGz), ExitGeneral, nil)
+	}
+	if f, ok := in.(*os.File); ok && isTerminal(f) {
+		return nil, newCLIError("missing_argument", "--from-stdin needs an archive piped to stdin, e.g. tar -czf - . | robotx deploy --from-stdin --archive-format tar.gz", ExitGeneral, nil)
+	}
+	r := bufio.NewReader(in)
+	head, err := r.Peek(len(magic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, newCLIError("invalid_archive", "failed to read the source archive from stdin", ExitGeneral, err)
+	}
+	if len(head) == 0 {
+		return nil, newCLIError("invalid_archive", "stdin is empty; pipe the source archive into robotx deploy --from-stdin", ExitGeneral, nil)
+	}
+	if !bytes.Equal(head, magic) {
+		return nil, newCLIError("invalid_archive", fmt.Sprintf("stdin is not a %s archive (check --archive-format)", format), ExitGeneral, nil)
+	}
+	return r, nil
+}
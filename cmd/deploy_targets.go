@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+// deployTarget is a build target under "targets" in robotx.yaml: one site
+// built from the shared source with its own commands and output directory
+// and deployed to its own project. Empty settings fall back to the flags.
+type deployTarget struct {
+	Name           string `yaml:"name"`
+	InstallCommand string `yaml:"install_command"`
+	BuildCommand   string `yaml:"build_command"`
+	OutputDir      string `yaml:"output_dir"`
+	// Env replaces the top-level env overlays for this target.
+	Env map[string]map[string]string `yaml:"env"`
+
+	// key is the target's name in robotx.yaml.
+	key string
+}
+
+// deployTargetsResponse is the output of deploy with several targets.
+type deployTargetsResponse struct {
+	Targets []*deployResponse `json:"targets"`
+}
+
+// sharedSource is the source archive packaged for the first target and
+// uploaded for the others too.
+type sharedSource struct {
+	path   string
+	report *packageReport
+	target string
+}
+
+// loadDeployTargets reads the build targets of the project in projectPath,
+// or none when it has no robotx.yaml.
+func loadDeployTargets(projectPath string) (map[string]*deployTarget, error) {
+	cfg, err := loadProjectConfig(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	for key, target := range cfg.Targets {
+		if target == nil {
+			target = &deployTarget{}
+			cfg.Targets[key] = target
+		}
+		target.key = key
+	}
+	return cfg.Targets, nil
+}
+
+// selectDeployTargets returns the targets chosen with --target or
+// --all-targets, in the order given or by name, or nil for a plain deploy.
+func (o *deployOptions) selectDeployTargets(cmd *cobra.Command, projectPath string) ([]*deployTarget, error) {
+	if len(o.targets) == 0 && !o.allTargets {
+		return nil, nil
+	}
+	if len(o.targets) > 0 && o.allTargets {
+		return nil, newCLIError("invalid_argument", "--target cannot be combined with --all-targets", ExitGeneral, nil)
+	}
+	if cmd.Flags().Changed("name") {
+		return nil, newCLIError("invalid_argument", "--name cannot be combined with build targets; set the name of each target in "+projectConfigFileName, ExitGeneral, nil)
+	}
+	if o.fromStdin {
+		return nil, newCLIError("invalid_argument", "--from-stdin cannot be combined with build targets", ExitGeneral, nil)
+	}
+
+	defined, err := loadDeployTargets(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(defined) == 0 {
+		return nil, newCLIError("invalid_project_config", fmt.Sprintf("%s defines no build targets", filepath.Join(projectPath, projectConfigFileName)), ExitGeneral, nil)
+	}
+	names := make([]string, 0, len(defined))
+	for name := range defined {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	selected := o.targets
+	if o.allTargets {
+		selected = names
+	}
+	var targets []*deployTarget
+	seen := map[string]bool{}
+	for _, name := range selected {
+		name = strings.TrimSpace(name)
+		target, ok := defined[name]
+		if !ok {
+			return nil, newCLIError("invalid_argument", fmt.Sprintf("unknown build target %q (defined: %s)", name, strings.Join(names, ", ")), ExitGeneral, nil)
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if o.strict && strings.TrimSpace(target.Name) == "" {
+			return nil, newCLIError("strict_mode", fmt.Sprintf("build target %s needs a name with --strict instead of deriving it from the directory", name), ExitGeneral, nil)
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// forTarget returns the options of the deploy of target: its settings over
+// the flags, and a project name derived from the directory and the target
+// when it has none.
+func (o *deployOptions) forTarget(target *deployTarget, projectPath string) *deployOptions {
+	to := *o
+	to.target = target.key
+	to.warnings = append([]string(nil), o.warnings...)
+	to.projectName = firstNonEmpty(strings.TrimSpace(target.Name), filepath.Base(projectPath)+"-"+target.key)
+	to.installCmd = firstNonEmpty(target.InstallCommand, o.installCmd)
+	to.buildCmd = firstNonEmpty(target.BuildCommand, o.buildCmd)
+	to.outputDir = firstNonEmpty(target.OutputDir, o.outputDir)
+	to.targetEnv = target.Env
+	return &to
+}
+
+// deployTargets deploys each target in turn, stopping at the first failure,
+// and writes the results of all of them.
+func (o *deployOptions) deployTargets(ctx context.Context, cmd *cobra.Command, c *client.Client, baseURL, projectPath string, targets []*deployTarget) error {
+	source := &sharedSource{}
+	defer func() {
+		if source.path != "" {
+			os.Remove(source.path)
+		}
+	}()
+
+	var results []*deployResponse
+	for i, target := range targets {
+		to := o.forTarget(target, projectPath)
+		o.logf("🎯 Target %s (%d/%d): %s\n", target.key, i+1, len(targets), to.projectName)
+		resp, err := to.deployProject(ctx, cmd, c, baseURL, projectPath, time.Now(), source)
+		if err != nil {
+			if len(results) > 0 {
+				o.logf("⚠️  Target %s failed after %d of %d target(s) were deployed\n", target.key, len(results), len(targets))
+			}
+			return err
+		}
+		results = append(results, resp)
+	}
+
+	var data interface{} = deployTargetsResponse{Targets: results}
+	if len(results) == 1 {
+		data = results[0]
+	}
+	if err := o.emitSuccess(cmd.Name(), data); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if o.qr {
+		for _, resp := range results {
+			o.printQR(resp.qrURL())
+		}
+	}
+	return nil
+}
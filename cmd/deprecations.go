@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// flagAliasAnnotation marks the hidden flags that keep the old name of a
+// renamed flag working. They share the Value of the new flag and have no
+// config key of their own.
+const flagAliasAnnotation = "robotx_flag_alias"
+
+// deprecation marks a command or flag for removal. A renamed command or flag
+// lists its new name as Replacement: the old name keeps working as a hidden
+// alias until it is removed, so automation has a release cycle to migrate.
+type deprecation struct {
+	// Command is the command path below robotx, e.g. "status"; empty for
+	// global flags.
+	Command string `json:"command,omitempty"`
+	// Flag is the deprecated flag of Command; empty when the command itself
+	// is deprecated.
+	Flag string `json:"flag,omitempty"`
+	// Replacement is the flag or command (path below robotx) to use instead.
+	Replacement string `json:"replacement,omitempty"`
+	RemoveIn    string `json:"remove_in,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// deprecations lists every deprecated command and flag. Renamed commands
+// must keep their parent: only the last word of Command may change.
+var deprecations = []deprecation{
+	{Command: "status", Flag: "logs", Reason: "build logs are no longer available"},
+}
+
+// code is the warning and error code of a use of d.
+func (d deprecation) code() string {
+	if d.Flag != "" {
+		return "deprecated_flag"
+	}
+	return "deprecated_command"
+}
+
+// message describes d in English, e.g. "flag --logs of robotx status is
+// deprecated: build logs are no longer available".
+func (d deprecation) message(tr func(string) string) string {
+	var b strings.Builder
+	switch {
+	case d.Flag != "" && d.Command != "":
+		fmt.Fprintf(&b, tr("flag --%s of robotx %s is deprecated"), d.Flag, d.Command)
+	case d.Flag != "":
+		fmt.Fprintf(&b, tr("flag --%s is deprecated"), d.Flag)
+	default:
+		fmt.Fprintf(&b, tr("command robotx %s is deprecated"), d.Command)
+	}
+	if d.RemoveIn != "" {
+		fmt.Fprintf(&b, tr(" and will be removed in %s"), d.RemoveIn)
+	}
+	if d.Reason != "" {
+		b.WriteString(": " + tr(d.Reason))
+	}
+	switch {
+	case d.Replacement != "" && d.Flag != "":
+		fmt.Fprintf(&b, tr("; use --%s instead"), d.Replacement)
+	case d.Replacement != "":
+		fmt.Fprintf(&b, tr("; use robotx %s instead"), d.Replacement)
+	}
+	return b.String()
+}
+
+// registerDeprecations adds the aliases of renamed commands and flags to the
+// command tree. It panics on entries that do not match the tree, so a bad
+// entry fails on the first run of any command.
+func registerDeprecations(root *cobra.Command) {
+	for _, d := range deprecations {
+		if d.Replacement == "" {
+			continue
+		}
+		if d.Flag == "" {
+			if existing, _ := findCommandPath(root, d.Command); existing != nil {
+				continue
+			}
+			target, ok := findCommandPath(root, d.Replacement)
+			oldParent, oldName := splitCommandPath(d.Command)
+			newParent, _ := splitCommandPath(d.Replacement)
+			if !ok || oldParent != newParent {
+				panic(fmt.Sprintf("deprecated command %q: replacement %q not found under the same parent", d.Command, d.Replacement))
+			}
+			target.Aliases = append(target.Aliases, oldName)
+			continue
+		}
+
+		cmd, ok := findCommandPath(root, d.Command)
+		if !ok {
+			panic(fmt.Sprintf("deprecated flag %q: command %q not found", d.Flag, d.Command))
+		}
+		flags := cmd.Flags()
+		if d.Command == "" {
+			flags = root.PersistentFlags()
+		}
+		if flags.Lookup(d.Flag) != nil {
+			continue
+		}
+		target := flags.Lookup(d.Replacement)
+		if target == nil {
+			panic(fmt.Sprintf("deprecated flag %q: replacement --%s not found", d.Flag, d.Replacement))
+		}
+		flags.AddFlag(&pflag.Flag{
+			Name:        d.Flag,
+			Usage:       fmt.Sprintf("Deprecated alias of --%s", d.Replacement),
+			Value:       target.Value,
+			DefValue:    target.DefValue,
+			NoOptDefVal: target.NoOptDefVal,
+			Hidden:      true,
+			Annotations: map[string][]string{flagAliasAnnotation: {d.Replacement}},
+		})
+	}
+}
+
+// findCommandPath returns the command at path below root, by name.
+func findCommandPath(root *cobra.Command, path string) (*cobra.Command, bool) {
+	cmd := root
+	for _, name := range strings.Fields(path) {
+		var next *cobra.Command
+		for _, child := range cmd.Commands() {
+			if child.Name() == name {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return nil, false
+		}
+		cmd = next
+	}
+	return cmd, true
+}
+
+// splitCommandPath splits a command path below robotx into the path of its
+// parent and its name.
+func splitCommandPath(path string) (parent, name string) {
+	words := strings.Fields(path)
+	if len(words) == 0 {
+		return "", ""
+	}
+	return strings.Join(words[:len(words)-1], " "), words[len(words)-1]
+}
+
+// applyFlagAliases marks the new flag of every renamed flag given by its old
+// name as changed, so config and environment settings do not override it.
+func applyFlagAliases(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if names, ok := f.Annotations[flagAliasAnnotation]; ok && f.Changed {
+			if target := cmd.Flags().Lookup(names[0]); target != nil {
+				target.Changed = true
+			}
+		}
+	})
+}
+
+type warningEnvelope struct {
+	Warning struct {
+		Code    string      `json:"code"`
+		Message string      `json:"message"`
+		Details interface{} `json:"details,omitempty"`
+	} `json:"warning"`
+}
+
+// checkDeprecations warns on stderr about every deprecated command and flag
+// the invocation uses, as one JSON object per line in JSON mode. With
+// --strict-deprecations the first one is an error instead.
+func (a *app) checkDeprecations(cmd *cobra.Command) error {
+	var names []string
+	for c := cmd; c != nil && c.HasParent(); c = c.Parent() {
+		name := c.Name()
+		if c == cmd && c.CalledAs() != "" {
+			name = c.CalledAs()
+		}
+		names = append([]string{name}, names...)
+	}
+	calledPath := strings.Join(names, " ")
+	commandPath := strings.TrimSpace(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()))
+
+	for _, d := range deprecations {
+		used := false
+		if d.Flag == "" {
+			used = calledPath == d.Command || strings.HasPrefix(calledPath, d.Command+" ")
+		} else if d.Command == "" || d.Command == commandPath {
+			used = cmd.Flags().Changed(d.Flag)
+		}
+		if !used {
+			continue
+		}
+		message := d.message(a.tr)
+		if a.strictDeprecations {
+			cliErr := newCLIError(d.code(), message+" (--strict-deprecations)", ExitGeneral, nil)
+			cliErr.Details = d
+			return cliErr
+		}
+		if a.isJSONOutput() {
+			var w warningEnvelope
+			w.Warning.Code = d.code()
+			w.Warning.Message = message
+			w.Warning.Details = d
+			enc := json.NewEncoder(a.errOut())
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(w)
+			continue
+		}
+		a.noticef("⚠️  %s\n", message)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultDiscoveryURL is the hosted server that knows which regional server
+// each hosted-cloud API key belongs to.
+const defaultDiscoveryURL = "https://api.robotx.xin"
+
+const discoveryCacheTTL = 24 * time.Hour
+
+// Commands that never talk to a server, so credentials are not resolved for
+// them.
+var offlineCommands = map[string]bool{
+	"help":            true,
+	"completion":      true,
+	"config":          true,
+	"ci":              true,
+	"env-vars":        true,
+	"explain-exit":    true,
+	"examples":        true,
+	"schema":          true,
+	"preview-archive": true,
+}
+
+func isOfflineCommand(cmd *cobra.Command) bool {
+	parts := commandKeyParts(cmd)
+	return len(parts) > 0 && offlineCommands[strings.ReplaceAll(parts[0], "_", "-")]
+}
+
+type cachedDiscovery struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	BaseURL   string    `json:"base_url"`
+	Region    string    `json:"region,omitempty"`
+}
+
+// discoverBaseURL sets base_url from the discovery endpoint when only an API
+// key is configured. An explicit base_url always wins, and a failed lookup
+// is not an error: commands then report missing_base_url as before.
+func (a *app) discoverBaseURL(cmd *cobra.Command) {
+	if strings.TrimSpace(a.v.GetString("base_url")) != "" {
+		return
+	}
+	apiKey := strings.TrimSpace(a.v.GetString("api_key"))
+	if apiKey == "" {
+		return
+	}
+	if isOfflineCommand(cmd) {
+		return
+	}
+	discoveryURL := strings.TrimRight(firstNonEmpty(strings.TrimSpace(a.v.GetString("discovery_url")), defaultDiscoveryURL), "/")
+
+	// Cache by a hash so the API key itself is never written to disk here.
+	sum := sha256.Sum256([]byte(discoveryURL + "\n" + apiKey))
+	key := hex.EncodeToString(sum[:])
+	path, pathErr := robotxDataPath("discovery.json")
+	cache := map[string]cachedDiscovery{}
+	if pathErr == nil {
+		if raw, err := os.ReadFile(path); err == nil {
+			_ = json.Unmarshal(raw, &cache)
+		}
+		if entry, ok := cache[key]; ok && entry.BaseURL != "" && time.Since(entry.FetchedAt) < discoveryCacheTTL {
+			a.v.Set("base_url", entry.BaseURL)
+			return
+		}
+	}
+
+	c := client.NewClient(discoveryURL, apiKey)
+	a.dumpHTTP(c)
+	if a.verbose {
+		c.SetObserver(a.observeRequest)
+	}
+	d, err := c.Discover()
+	if err == nil {
+		if u, parseErr := url.Parse(d.BaseURL); parseErr != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			err = fmt.Errorf("invalid base_url %q in discovery response", d.BaseURL)
+		}
+	}
+	if err != nil {
+		if a.verbose {
+			a.logf("⚠️  Base URL discovery via %s failed: %v\n", discoveryURL, err)
+		}
+		return
+	}
+	a.v.Set("base_url", d.BaseURL)
+	if a.verbose {
+		a.logf("🧭 Discovered base URL %s for the API key\n", d.BaseURL)
+	}
+	if pathErr == nil {
+		cache[key] = cachedDiscovery{FetchedAt: time.Now(), BaseURL: d.BaseURL, Region: d.Region}
+		if raw, err := json.MarshalIndent(cache, "", "  "); err == nil {
+			_ = os.WriteFile(path, raw, 0o600)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
+
+	"github.com/spf13/cobra"
+)
+
+// envChannels are the sections accepted under "env" in robotx.yaml, in the
+// order they are synced.
+var envChannels = []string{client.EnvChannelPreview, client.EnvChannelProduction}
+
+type envOptions struct {
+	*app
+	projectID   string
+	projectPath string
+	channel     string
+	failOnDiff  bool
+}
+
+type envDiffResponse struct {
+	ProjectID string           `json:"project_id"`
+	File      string           `json:"file"`
+	Channels  []envChannelDiff `json:"channels"`
+	InSync    bool             `json:"in_sync"`
+}
+
+// envChannelDiff lists the changes a sync would make to a channel.
+type envChannelDiff struct {
+	Channel string                  `json:"channel"`
+	Changes []pipeline.EnvVarChange `json:"changes"`
+}
+
+func newEnvCmd(a *app) *cobra.Command {
+	o := &envOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "Compare runtime env variables with robotx.yaml",
+		Long: `Runtime environment variables can be declared per channel in robotx.yaml:
+
+  env:
+    production:
+      API_URL: https://api.example.com
+    preview:
+      API_URL: https://staging-api.example.com
+
+deploy syncs the preview section before creating the build and the
+production section before publishing, and publish syncs the production
+section, so the variables live in the repository instead of drifting in the
+web UI. Each section is authoritative: variables missing from it are removed
+from the channel. A channel without a section is left alone.`,
+	}
+
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show what syncing robotx.yaml would change",
+		Long: `Show the env variables a deploy would add, change or remove to make each
+channel match robotx.yaml. Values from robotx.yaml are shown; the values
+currently set on the server are not, since they may be secrets.
+
+--fail-on-diff exits with code 8 when a channel differs, for CI checks.`,
+		Example: `  robotx env diff
+  robotx env diff --channel production --fail-on-diff`,
+		Args: cobra.NoArgs,
+		RunE: o.runDiff,
+	}
+	cmd.AddCommand(diffCmd)
+
+	cmd.PersistentFlags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	cmd.PersistentFlags().StringVar(&o.projectPath, "project-path", ".", "Directory holding robotx.yaml")
+	diffCmd.Flags().StringVar(&o.channel, "channel", "", "Only compare this channel (production|preview)")
+	diffCmd.Flags().BoolVar(&o.failOnDiff, "fail-on-diff", false, "Exit with code 8 when a channel differs from robotx.yaml")
+	return cmd
+}
+
+func (o *envOptions) runDiff(cmd *cobra.Command, args []string) error {
+	if o.channel != "" && o.channel != client.EnvChannelProduction && o.channel != client.EnvChannelPreview {
+		return newCLIError("invalid_argument", fmt.Sprintf("invalid --channel %q (use production or preview)", o.channel), ExitGeneral, nil)
+	}
+	overlays, err := loadEnvOverlays(o.projectPath)
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(o.projectPath, projectConfigFileName)
+	if len(overlays) == 0 {
+		return newCLIError("invalid_project_config", fmt.Sprintf("%s declares no env", file), ExitGeneral, nil)
+	}
+
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	projectID, _ := stateProjectID(o.projectID)
+	if projectID == "" {
+		return newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+	}
+	c := o.newAPIClient(baseURL, apiKey)
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityChannelEnv) {
+		return newCLIError("unsupported_server", "this RobotX server does not support per-channel env", ExitAPI, nil)
+	}
+
+	resp := envDiffResponse{ProjectID: projectID, File: file, Channels: []envChannelDiff{}, InSync: true}
+	for _, channel := range envChannels {
+		env, ok := overlays[channel]
+		if !ok || (o.channel != "" && channel != o.channel) {
+			continue
+		}
+		current, err := c.GetChannelEnv(projectID, channel)
+		if err != nil {
+			if client.IsNotFound(err) {
+				return newCLIError("unsupported_server", "this RobotX server does not support per-channel env", ExitAPI, err)
+			}
+			return newCLIError("api_error", fmt.Sprintf("failed to get the %s env", channel), ExitAPI, err)
+		}
+		changes := pipeline.DiffEnv(current, env)
+		if changes == nil {
+			changes = []pipeline.EnvVarChange{}
+		}
+		resp.Channels = append(resp.Channels, envChannelDiff{Channel: channel, Changes: changes})
+		if len(changes) > 0 {
+			resp.InSync = false
+		}
+	}
+
+	if err := o.emitSuccess("env diff", resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if !o.isJSONOutput() {
+		o.printEnvDiff(resp)
+	}
+	if o.failOnDiff && !resp.InSync {
+		cliErr := newCLIError("env_drift", fmt.Sprintf("the env of %s differs from %s", projectID, file), ExitDrift, nil)
+		cliErr.Details = resp
+		return cliErr
+	}
+	return nil
+}
+
+func (o *envOptions) printEnvDiff(resp envDiffResponse) {
+	w := o.out()
+	for _, diff := range resp.Channels {
+		if len(diff.Changes) == 0 {
+			fmt.Fprintln(w, o.formatLog("%s: up to date", diff.Channel))
+			continue
+		}
+		fmt.Fprintf(w, "%s:\n", diff.Channel)
+		for _, change := range diff.Changes {
+			switch change.Action {
+			case pipeline.EnvAdd:
+				fmt.Fprintf(w, "  + %s=%s\n", change.Key, change.Value)
+			case pipeline.EnvChange:
+				fmt.Fprintf(w, "  ~ %s=%s\n", change.Key, change.Value)
+			case pipeline.EnvRemove:
+				fmt.Fprintf(w, "  - %s\n", change.Key)
+			}
+		}
+	}
+}
+
+// loadEnvOverlays reads the per-channel env of the project in projectPath,
+// or none when it has no robotx.yaml or no env.
+func loadEnvOverlays(projectPath string) (map[string]map[string]string, error) {
+	cfg, err := loadProjectConfig(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Env, validateEnvOverlays(cfg.Env)
+}
+
+// validateEnvOverlays refuses unknown channels and names that cannot be
+// environment variables.
+func validateEnvOverlays(overlays map[string]map[string]string) error {
+	var unknown []string
+	for channel, env := range overlays {
+		if channel != client.EnvChannelProduction && channel != client.EnvChannelPreview {
+			unknown = append(unknown, channel)
+			continue
+		}
+		if env == nil {
+			overlays[channel] = map[string]string{}
+		}
+		for key := range env {
+			if key == "" || strings.ContainsAny(key, "= \t\n") {
+				return newCLIError("invalid_project_config", fmt.Sprintf("invalid env variable name %q in env.%s of %s", key, channel, projectConfigFileName), ExitGeneral, nil)
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return newCLIError("invalid_project_config", fmt.Sprintf("unknown env channel(s) %s in %s (use production or preview)", strings.Join(unknown, ", "), projectConfigFileName), ExitGeneral, nil)
+	}
+	return nil
+}
+
+// loadDeployEnv returns the env overlays synced by the deploy: the target's
+// own when it declares them, else the project's. They are ignored with a
+// warning when the server has no per-channel env.
+func (o *deployOptions) loadDeployEnv(projectPath string, c *client.Client, baseURL string) (map[string]map[string]string, error) {
+	overlays := o.targetEnv
+	if overlays != nil {
+		if err := validateEnvOverlays(overlays); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if overlays, err = loadEnvOverlays(projectPath); err != nil {
+			return nil, err
+		}
+	}
+	if len(overlays) == 0 {
+		return nil, nil
+	}
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityChannelEnv) {
+		o.warnf("This RobotX server does not support per-channel env; env in %s is ignored", projectConfigFileName)
+		return nil, nil
+	}
+	return overlays, nil
+}
+
+// syncProductionEnv syncs the production env declared in the robotx.yaml of
+// the project in projectPath before a publish, and returns the changes made,
+// or nil when there is nothing to sync.
+func (a *app) syncProductionEnv(c *client.Client, baseURL, projectPath, projectID string) ([]pipeline.EnvVarChange, error) {
+	overlays, err := loadEnvOverlays(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	env, ok := overlays[client.EnvChannelProduction]
+	if !ok {
+		return nil, nil
+	}
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityChannelEnv) {
+		a.logf("⚠️  This RobotX server does not support per-channel env; env in %s is ignored\n", projectConfigFileName)
+		return nil, nil
+	}
+	changes, err := pipeline.SyncChannelEnv(c, projectID, client.EnvChannelProduction, env)
+	if err != nil {
+		return nil, newCLIError("api_error", "failed to sync the production env", ExitAPI, err)
+	}
+	if len(changes) == 0 {
+		a.logf("🔧 The production env is up to date (%d variables)\n", len(env))
+	} else {
+		added, changed, removed := pipeline.CountEnvChanges(changes)
+		a.logf("✅ Synced production env: %d added, %d changed, %d removed\n", added, changed, removed)
+	}
+	return changes, nil
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+type envVarEntry struct {
+	EnvVar      string `json:"env_var"`
+	ConfigKey   string `json:"config_key"`
+	Command     string `json:"command,omitempty"`
+	Value       string `json:"value"`
+	Source      string `json:"source"`
+	Description string `json:"description"`
+}
+
+type envVarsResponse struct {
+	ConfigFile string        `json:"config_file,omitempty"`
+	Variables  []envVarEntry `json:"variables"`
+}
+
+func newEnvVarsCmd(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "env-vars",
+		Short: "List environment variables and config keys with their effective values",
+		Long: `List every setting that can be provided through a ROBOTX_* environment variable
+or the config file, together with its effective value and where it came from
+(flag, env, config, or default).
+
+Precedence is: command-line flag > environment variable > config file > default.`,
+		Args: cobra.NoArgs,
+		RunE: a.runEnvVars,
+	}
+}
+
+func (a *app) runEnvVars(cmd *cobra.Command, args []string) error {
+	configEntry := envVarEntry{
+		EnvVar:      "ROBOTX_CONFIG",
+		ConfigKey:   "-",
+		Description: "Config file path (same as --config)",
+	}
+	switch {
+	case cmd.Flags().Changed("config"):
+		configEntry.Value, configEntry.Source = a.cfgFile, "flag"
+	case os.Getenv("ROBOTX_CONFIG") != "":
+		configEntry.Value, configEntry.Source = os.Getenv("ROBOTX_CONFIG"), "env ROBOTX_CONFIG"
+	default:
+		configEntry.Source = "default"
+		if path, err := resolveDefaultConfigPath(); err == nil {
+			configEntry.Value = path
+		}
+	}
+	entries := []envVarEntry{configEntry}
+
+	for _, setting := range collectFlagSettings(cmd.Root()) {
+		entry := envVarEntry{
+			EnvVar:      setting.EnvVar,
+			ConfigKey:   setting.Key,
+			Command:     setting.Command,
+			Description: setting.Description,
+		}
+		switch {
+		case setting.Flag.Changed:
+			entry.Value, entry.Source = setting.Flag.Value.String(), "flag"
+		default:
+			if value, source, ok := a.lookupFlagSetting(setting.Key); ok {
+				entry.Value, entry.Source = value, source
+			} else {
+				entry.Value, entry.Source = setting.Flag.DefValue, "default"
+			}
+		}
+		if isSecretConfigKey(setting.Key) && entry.Value != "" {
+			entry.Value = maskSecret(entry.Value)
+		}
+		if entry.Value == "[]" {
+			entry.Value = ""
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := a.emitSuccess(cmd.Name(), envVarsResponse{ConfigFile: entries[0].Value, Variables: entries}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if a.isJSONOutput() {
+		return nil
+	}
+
+	w := tabwriter.NewWriter(a.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ENV_VAR\tCONFIG_KEY\tVALUE\tSOURCE\tDESCRIPTION")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.EnvVar, entry.ConfigKey, valueOrDash(entry.Value), entry.Source, entry.Description)
+	}
+	_ = w.Flush()
+	return nil
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
+
+	"github.com/spf13/cobra"
+)
+
+type eventsOptions struct {
+	*app
+	projectID    string
+	buildID      string
+	follow       bool
+	pollInterval int
+}
+
+type eventsResponse struct {
+	ProjectID string               `json:"project_id"`
+	BuildID   string               `json:"build_id"`
+	Events    []*client.BuildEvent `json:"events"`
+}
+
+func newEventsCmd(a *app) *cobra.Command {
+	o := &eventsOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Show the structured events of a build",
+		Long: `Show the structured events the server records while it runs a build: steps
+starting and finishing with their duration, cache hits and misses, and the
+produced artifacts with their size.
+
+--follow keeps printing new events until the build finishes or the command
+is interrupted. With JSON output, --follow waits for the build to finish
+and then writes all its events.
+
+Inside a deployed project directory, --project-id and --build-id default to
+the project and last build recorded in .robotx/state.json.`,
+		Example: `  robotx events
+  robotx events --build-id build_456 --follow`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	cmd.Flags().StringVarP(&o.buildID, "build-id", "b", "", "Build ID (default: last build from .robotx/state.json)")
+	cmd.Flags().BoolVarP(&o.follow, "follow", "f", false, "Keep printing new events until the build finishes")
+	cmd.Flags().IntVar(&o.pollInterval, "poll-interval", 2, "Seconds between checks for new events with --follow")
+	return cmd
+}
+
+func (o *eventsOptions) run(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	if o.pollInterval <= 0 {
+		return newCLIError("invalid_argument", "--poll-interval must be positive", ExitGeneral, nil)
+	}
+	projectID, st := stateProjectID(o.projectID)
+	buildID := o.buildID
+	if buildID == "" {
+		buildID = st.lastBuildID()
+	}
+	if projectID == "" || buildID == "" {
+		return newCLIError("missing_argument", "--project-id and --build-id are required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityBuildEvents) {
+		return newCLIError("unsupported_server", "this RobotX server does not record build events; use 'robotx logs' for the build output", ExitAPI, nil)
+	}
+
+	resp := eventsResponse{ProjectID: projectID, BuildID: buildID, Events: []*client.BuildEvent{}}
+	var w io.Writer
+	if !o.isJSONOutput() {
+		w = o.out()
+		fmt.Fprintf(w, buildEventFormat, "TIME", "TYPE", "STEP", "DETAIL")
+	}
+	var after int64
+	for {
+		events, err := c.ListBuildEvents(projectID, buildID, after)
+		if err != nil {
+			if client.IsNotFound(err) {
+				return newCLIError("not_found", fmt.Sprintf("build %s not found", buildID), ExitNotFound, err)
+			}
+			return newCLIError("api_error", "failed to get build events", ExitAPI, err)
+		}
+		final := false
+		for _, e := range events {
+			after = max(after, e.Seq)
+			final = final || e.Final()
+			resp.Events = append(resp.Events, e)
+			if w != nil {
+				writeBuildEvent(w, e)
+			}
+		}
+		if !o.follow || final {
+			break
+		}
+		if len(events) == 0 {
+			// Servers that send no build_finished event: stop once the
+			// build is over and no more events came.
+			if build, err := c.GetBuild(projectID, buildID); err == nil && client.BuildStatusTerminal(build.Status) {
+				break
+			}
+		}
+		if !sleepContext(ctx, time.Duration(o.pollInterval)*time.Second) {
+			break
+		}
+	}
+
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// buildEventFormat lays out the events table. The columns have fixed widths
+// rather than a tabwriter's so rows printed by --follow stay aligned.
+const buildEventFormat = "%-8s  %-14s  %-10s  %s\n"
+
+// writeBuildEvent writes e as a row of the events table.
+func writeBuildEvent(w io.Writer, e *client.BuildEvent) {
+	fmt.Fprintf(w, buildEventFormat, e.Time.Local().Format("15:04:05"), valueOrDash(e.Type), valueOrDash(e.Step), valueOrDash(buildEventDetail(e)))
+}
+
+// buildEventDetail summarizes the fields of e that matter for its type.
+func buildEventDetail(e *client.BuildEvent) string {
+	detail := ""
+	switch e.Type {
+	case client.BuildEventStepFinished, client.BuildEventBuildFinished:
+		detail = e.Status
+		if e.DurationMS > 0 {
+			detail = fmt.Sprintf("%s in %s", valueOrDash(e.Status), (time.Duration(e.DurationMS) * time.Millisecond).Round(100*time.Millisecond))
+		}
+	case client.BuildEventCacheHit, client.BuildEventCacheMiss:
+		detail = e.CacheKey
+	case client.BuildEventArtifact:
+		detail = e.Name
+		if e.SizeBytes > 0 {
+			detail = fmt.Sprintf("%s (%s)", valueOrDash(e.Name), pipeline.FormatBytes(e.SizeBytes))
+		}
+	}
+	if e.Message != "" {
+		if detail == "" {
+			return e.Message
+		}
+		return detail + ": " + e.Message
+	}
+	return detail
+}
+
+// sleepContext waits for d and reports false if ctx was done first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
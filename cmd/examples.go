@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+// exampleTemplates holds the workflow snippets printed by robotx examples.
+// They use [[ ]] delimiters so GitHub Actions expressions stay literal.
+//
+//go:embed examples/*.tmpl
+var exampleTemplates embed.FS
+
+type exampleInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	file        string
+}
+
+var examples = []exampleInfo{
+	{Name: "github-actions", Description: "GitHub Actions workflow deploying on every push to main", file: "github-actions.tmpl"},
+	{Name: "dockerfile", Description: "Dockerfile for a CI image that builds and deploys the project", file: "dockerfile.tmpl"},
+	{Name: "cron", Description: "Script that redeploys the latest commit from cron", file: "cron.tmpl"},
+	{Name: "claude-desktop", Description: "MCP server entry for claude_desktop_config.json", file: "claude-desktop.tmpl"},
+}
+
+// exampleData parameterizes the example templates.
+type exampleData struct {
+	ProjectName    string
+	ProjectDir     string
+	BaseURL        string
+	ActionRef      string
+	ReleaseVersion string
+	Executable     string
+}
+
+type examplesListResponse struct {
+	Examples []exampleInfo `json:"examples"`
+}
+
+type exampleResponse struct {
+	exampleInfo
+	Content string `json:"content"`
+}
+
+type examplesOptions struct {
+	*app
+	name string
+}
+
+func newExamplesCmd(a *app) *cobra.Command {
+	o := &examplesOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "examples [name]",
+		Short: "Print ready-to-copy workflow examples",
+		Long: `Print a ready-to-copy snippet for using robotx from other tools, filled in
+with the current project: its name, directory and the configured base URL.
+Without a name, the available examples are listed.
+
+API keys are never written into the snippets; they read ROBOTX_API_KEY or a
+CI secret instead.`,
+		Example: `  robotx examples
+  robotx examples github-actions > .github/workflows/robotx-deploy.yml
+  robotx examples cron --name my-app`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: exampleNames(),
+		RunE:      o.run,
+	}
+	cmd.Flags().StringVarP(&o.name, "name", "n", "", "Project name used in the snippet (default: the deployed project or the directory name)")
+	return cmd
+}
+
+func (o *examplesOptions) run(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return o.list(cmd)
+	}
+	var example *exampleInfo
+	for i := range examples {
+		if examples[i].Name == args[0] {
+			example = &examples[i]
+		}
+	}
+	if example == nil {
+		return newCLIError("invalid_argument", fmt.Sprintf("unknown example %q (available: %s)", args[0], strings.Join(exampleNames(), ", ")), ExitGeneral, nil)
+	}
+
+	content, err := renderTemplate(exampleTemplates, "examples/"+example.file, o.exampleData())
+	if err != nil {
+		return newCLIError("render_failed", "failed to render example", ExitGeneral, err)
+	}
+	if err := o.emitSuccess(cmd.Name(), exampleResponse{exampleInfo: *example, Content: content}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if !o.isJSONOutput() {
+		fmt.Fprint(o.out(), content)
+	}
+	return nil
+}
+
+func (o *examplesOptions) list(cmd *cobra.Command) error {
+	if err := o.emitSuccess(cmd.Name(), examplesListResponse{Examples: examples}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if o.isJSONOutput() {
+		return nil
+	}
+	w := tabwriter.NewWriter(o.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tDESCRIPTION")
+	for _, e := range examples {
+		fmt.Fprintf(w, "%s\t%s\n", e.Name, e.Description)
+	}
+	w.Flush()
+	return nil
+}
+
+// exampleData describes the current project: the name from --name, the
+// deploy state or the deploy.name setting, falling back to the directory
+// name like deploy does. The directory is the deployed project's root when
+// run from one of its subdirectories.
+func (o *examplesOptions) exampleData() exampleData {
+	dir, err := os.Getwd()
+	if err != nil {
+		dir = "."
+	}
+	stateName := ""
+	if _, st := stateProjectID(o.workspaceProjectID); st != nil {
+		stateName = st.ProjectName
+		// The state file is <project>/.robotx/state.json.
+		dir = filepath.Dir(filepath.Dir(st.path))
+	}
+	name := firstNonEmpty(strings.TrimSpace(o.name), stateName, o.v.GetString("deploy.name"), strings.ToLower(filepath.Base(dir)))
+
+	data := exampleData{
+		ProjectName:    name,
+		ProjectDir:     dir,
+		BaseURL:        firstNonEmpty(strings.TrimRight(o.v.GetString("base_url"), "/"), defaultDiscoveryURL),
+		ActionRef:      "main",
+		ReleaseVersion: "latest",
+		Executable:     "robotx",
+	}
+	if v := strings.TrimPrefix(version, "v"); v != "" && v != "dev" {
+		data.ActionRef = "v" + v
+		data.ReleaseVersion = "v" + v
+	}
+	if exe, err := os.Executable(); err == nil {
+		data.Executable = exe
+	}
+	return data
+}
+
+// renderTemplate executes the embedded template at path with [[ ]]
+// delimiters.
+func renderTemplate(fsys embed.FS, path string, data interface{}) (string, error) {
+	raw, err := fsys.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New(filepath.Base(path)).Delims("[[", "]]").Parse(string(raw))
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func exampleNames() []string {
+	names := make([]string, len(examples))
+	for i, e := range examples {
+		names[i] = e.Name
+	}
+	return names
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+type explainExitResponse struct {
+	Codes []exitCodeInfo `json:"codes"`
+}
+
+func newExplainExitCmd(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "explain-exit [code]",
+		Short: "Explain robotx exit codes",
+		Long:  "Print the meaning of an exit code, or of all exit codes when none is given.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  a.runExplainExit,
+	}
+}
+
+func (a *app) runExplainExit(cmd *cobra.Command, args []string) error {
+	codes := exitCodes
+	if len(args) == 1 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return newCLIError("invalid_argument", fmt.Sprintf("exit code must be a number: %q", args[0]), ExitGeneral, nil)
+		}
+		info, ok := lookupExitCode(ExitCode(n))
+		if !ok {
+			return newCLIError("invalid_argument", fmt.Sprintf("unknown exit code %d (run 'robotx explain-exit' to list all)", n), ExitGeneral, nil)
+		}
+		codes = []exitCodeInfo{info}
+	}
+
+	if err := a.emitSuccess(cmd.Name(), explainExitResponse{Codes: codes}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if a.isJSONOutput() {
+		return nil
+	}
+	w := tabwriter.NewWriter(a.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CODE\tNAME\tMEANING")
+	for _, info := range codes {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", info.Code, info.Name, info.Meaning)
+	}
+	w.Flush()
+	return nil
+}
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
+
+	"github.com/spf13/cobra"
+)
+
+type filesOptions struct {
+	*app
+	projectID  string
+	target     string
+	buildID    string
+	dest       string
+	outputFile string
+}
+
+type filesResponse struct {
+	ProjectID string               `json:"project_id"`
+	Target    string               `json:"target,omitempty"`
+	File      *client.ArtifactFile `json:"file"`
+	Deleted   bool                 `json:"deleted,omitempty"`
+	// For files get, OutputFile is the local copy written with
+	// --output-file; without it the content is inlined, base64-encoded when
+	// it is not UTF-8 text.
+	OutputFile    string `json:"output_file,omitempty"`
+	Content       string `json:"content,omitempty"`
+	ContentBase64 string `json:"content_base64,omitempty"`
+}
+
+func newFilesCmd(a *app) *cobra.Command {
+	o := &filesOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "files",
+		Short: "Patch single files of a deployed project",
+		Long: `Replace, read or remove single files of a deployed artifact without a full
+rebuild, e.g. to fix robots.txt or a typo in a static page.
+
+Files of the build published to production are changed by default; use
+--target preview for the latest preview build or --build-id for a given
+build. Paths are absolute paths of the site, like /robots.txt. A later
+deploy replaces the patched files with the new build.
+
+Inside a deployed project directory --project-id defaults to the recorded
+project.`,
+	}
+
+	putCmd := &cobra.Command{
+		Use:   "put <local-file>",
+		Short: "Upload a file to a deployment",
+		Example: `  robotx files put robots.txt
+  robotx files put dist/banner.html --dest /promo/index.html --target preview`,
+		Args: cobra.ExactArgs(1),
+		RunE: o.runPut,
+	}
+	getCmd := &cobra.Command{
+		Use:   "get <path>",
+		Short: "Print a file of a deployment",
+		Example: `  robotx files get /robots.txt
+  robotx files get /logo.png -o logo.png`,
+		Args: cobra.ExactArgs(1),
+		RunE: o.runGet,
+	}
+	deleteCmd := &cobra.Command{
+		Use:     "delete <path>",
+		Aliases: []string{"rm"},
+		Short:   "Remove a file from a deployment",
+		Args:    cobra.ExactArgs(1),
+		RunE:    o.runDelete,
+	}
+	cmd.AddCommand(putCmd, getCmd, deleteCmd)
+
+	cmd.PersistentFlags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	cmd.PersistentFlags().StringVar(&o.target, "target", client.FileTargetProduction, "Deployment whose files to change (production|preview)")
+	cmd.PersistentFlags().StringVar(&o.buildID, "build-id", "", "Change the files of this build instead of --target")
+	putCmd.Flags().StringVar(&o.dest, "dest", "", "Path of the file on the site (default: /<local file name>)")
+	getCmd.Flags().StringVarP(&o.outputFile, "output-file", "o", "", "Write the file here instead of to stdout")
+	return cmd
+}
+
+// filesClient checks the common settings of the files subcommands and
+// returns a client for a server that supports patching artifact files.
+func (o *filesOptions) filesClient() (*client.Client, string, error) {
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return nil, "", newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return nil, "", newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	if o.target != client.FileTargetProduction && o.target != client.FileTargetPreview {
+		return nil, "", newCLIError("invalid_argument", fmt.Sprintf("invalid --target %q (use production or preview)", o.target), ExitGeneral, nil)
+	}
+	projectID, _ := stateProjectID(o.projectID)
+	if projectID == "" {
+		return nil, "", newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityArtifactFiles) {
+		return nil, "", newCLIError("unsupported_server", "this RobotX server does not support patching deployed files", ExitAPI, nil)
+	}
+	return c, projectID, nil
+}
+
+// fileRef returns the file at sitePath of the selected deployment.
+func (o *filesOptions) fileRef(sitePath string) client.FileRef {
+	return client.FileRef{Path: sitePath, Target: o.target, BuildID: strings.TrimSpace(o.buildID)}
+}
+
+// targetName describes the selected deployment in messages.
+func (o *filesOptions) targetName() string {
+	if id := strings.TrimSpace(o.buildID); id != "" {
+		return "build " + id
+	}
+	return o.target
+}
+
+func (o *filesOptions) runPut(cmd *cobra.Command, args []string) error {
+	local := args[0]
+	stat, err := os.Stat(local)
+	if err != nil {
+		return newCLIError("invalid_argument", fmt.Sprintf("cannot read %s", local), ExitGeneral, err)
+	}
+	if !stat.Mode().IsRegular() {
+		return newCLIError("invalid_argument", fmt.Sprintf("%s is not a regular file", local), ExitGeneral, nil)
+	}
+	sitePath, err := normalizeSitePath(firstNonEmpty(strings.TrimSpace(o.dest), "/"+filepath.Base(local)))
+	if err != nil {
+		return err
+	}
+
+	c, projectID, err := o.filesClient()
+	if err != nil {
+		return err
+	}
+	o.logf("⬆️  Uploading %s to %s of %s (%s)...\n", local, sitePath, o.targetName(), pipeline.FormatBytes(stat.Size()))
+	file, err := c.PutArtifactFile(projectID, o.fileRef(sitePath), local)
+	if err != nil {
+		if client.IsNotFound(err) {
+			return newCLIError("unsupported_server", "this RobotX server does not support patching deployed files", ExitAPI, err)
+		}
+		return newCLIError("api_error", fmt.Sprintf("failed to upload %s", sitePath), ExitAPI, err)
+	}
+	o.logf("✅ Updated %s\n", file.Path)
+	if file.URL != "" {
+		o.logf("🌐 %s\n", verbatim(file.URL))
+	}
+	return o.emitFiles(cmd, filesResponse{ProjectID: projectID, Target: o.responseTarget(), File: file})
+}
+
+func (o *filesOptions) runGet(cmd *cobra.Command, args []string) error {
+	sitePath, err := normalizeSitePath(args[0])
+	if err != nil {
+		return err
+	}
+	c, projectID, err := o.filesClient()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	if o.outputFile == "" && !o.isJSONOutput() {
+		w = o.out()
+	}
+	file, err := c.GetArtifactFile(projectID, o.fileRef(sitePath), w)
+	if err != nil {
+		if client.IsNotFound(err) {
+			return newCLIError("not_found", fmt.Sprintf("%s not found in %s", sitePath, o.targetName()), ExitNotFound, err)
+		}
+		return newCLIError("api_error", fmt.Sprintf("failed to download %s", sitePath), ExitAPI, err)
+	}
+
+	resp := filesResponse{ProjectID: projectID, Target: o.responseTarget(), File: file}
+	switch {
+	case o.outputFile != "":
+		if err := os.WriteFile(o.outputFile, buf.Bytes(), 0o644); err != nil {
+			return newCLIError("write_failed", "failed to write "+o.outputFile, ExitGeneral, err)
+		}
+		resp.OutputFile = o.outputFile
+		o.logf("✅ Saved %s to %s (%s)\n", sitePath, o.outputFile, pipeline.FormatBytes(file.Size))
+	case utf8.Valid(buf.Bytes()):
+		resp.Content = buf.String()
+	default:
+		resp.ContentBase64 = base64.StdEncoding.EncodeToString(buf.Bytes())
+	}
+	return o.emitFiles(cmd, resp)
+}
+
+func (o *filesOptions) runDelete(cmd *cobra.Command, args []string) error {
+	sitePath, err := normalizeSitePath(args[0])
+	if err != nil {
+		return err
+	}
+	c, projectID, err := o.filesClient()
+	if err != nil {
+		return err
+	}
+	if err := o.confirm(fmt.Sprintf("Remove %s from %s of %s", sitePath, o.targetName(), projectID)); err != nil {
+		return err
+	}
+	if err := c.DeleteArtifactFile(projectID, o.fileRef(sitePath)); err != nil {
+		if client.IsNotFound(err) {
+			return newCLIError("not_found", fmt.Sprintf("%s not found in %s", sitePath, o.targetName()), ExitNotFound, err)
+		}
+		return newCLIError("api_error", fmt.Sprintf("failed to remove %s", sitePath), ExitAPI, err)
+	}
+	o.logf("🗑️  Removed %s\n", sitePath)
+	return o.emitFiles(cmd, filesResponse{ProjectID: projectID, Target: o.responseTarget(), File: &client.ArtifactFile{Path: sitePath}, Deleted: true})
+}
+
+func (o *filesOptions) responseTarget() string {
+	if strings.TrimSpace(o.buildID) != "" {
+		return ""
+	}
+	return o.target
+}
+
+func (o *filesOptions) emitFiles(cmd *cobra.Command, resp filesResponse) error {
+	if err := o.emitSuccess("files "+cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// normalizeSitePath turns p into a clean absolute path of a file on the
+// site, refusing directories and paths that climb out of the site root.
+func normalizeSitePath(p string) (string, error) {
+	p = strings.TrimSpace(strings.ReplaceAll(p, "\\", "/"))
+	if p == "" || strings.HasSuffix(p, "/") {
+		return "", newCLIError("invalid_argument", fmt.Sprintf("invalid path %q: name a file, like /robots.txt", p), ExitGeneral, nil)
+	}
+	for _, segment := range strings.Split(p, "/") {
+		if segment == ".." {
+			return "", newCLIError("invalid_argument", fmt.Sprintf("invalid path %q: '..' is not allowed", p), ExitGeneral, nil)
+		}
+	}
+	return path.Clean("/" + p), nil
+}
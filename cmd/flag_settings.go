@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Commands whose flags are not read from the environment or config file.
+var settingsExemptCommands = map[string]bool{
+	"help":       true,
+	"completion": true,
+	"config":     true,
+	"env-vars":   true,
+}
+
+// Flags that are never read from the environment or config file.
+var settingsExemptFlags = map[string]bool{
+	"help":    true,
+	"version": true,
+	"config":  true,
+}
+
+// Config keys that differ from the flag-derived name.
+var flagKeyOverrides = map[string]string{
+	"fallback-base-url": "fallback_base_urls",
+}
+
+// Legacy top-level config keys still honoured for command flags.
+var legacyFlagKeys = map[string]string{
+	"deploy.visibility":      "default_visibility",
+	"deploy.timeout":         "default_timeout",
+	"deploy.package_command": "package_command",
+}
+
+// Commands whose flags fall back to the settings of another command, so
+// that e.g. release honours deploy.install_command.
+var inheritedFlagSettings = map[string]string{
+	"release": "deploy",
+}
+
+type flagSetting struct {
+	Key         string
+	EnvVar      string
+	Flag        *pflag.Flag
+	Command     string
+	Description string
+}
+
+// flagConfigKey returns the config key for a flag: global flags map to
+// top-level keys, command flags to "<command>.<flag>".
+func flagConfigKey(cmd *cobra.Command, f *pflag.Flag) string {
+	if _, alias := f.Annotations[flagAliasAnnotation]; settingsExemptFlags[f.Name] || alias {
+		return ""
+	}
+	name := strings.ReplaceAll(f.Name, "-", "_")
+	if override, ok := flagKeyOverrides[f.Name]; ok {
+		name = override
+	}
+	if isGlobalFlag(cmd, f) {
+		return name
+	}
+	parts := commandKeyParts(cmd)
+	if len(parts) == 0 || settingsExemptCommands[parts[0]] {
+		return ""
+	}
+	return strings.Join(append(parts, name), ".")
+}
+
+func isGlobalFlag(cmd *cobra.Command, f *pflag.Flag) bool {
+	return cmd.Root().PersistentFlags().Lookup(f.Name) == f
+}
+
+func commandKeyParts(cmd *cobra.Command) []string {
+	var parts []string
+	for c := cmd; c != nil && c.HasParent(); c = c.Parent() {
+		parts = append([]string{strings.ReplaceAll(c.Name(), "-", "_")}, parts...)
+	}
+	return parts
+}
+
+func configKeyEnvVar(key string) string {
+	return "ROBOTX_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// applyFlagSettings fills flags that were not given on the command line from
+// ROBOTX_* environment variables and the config file, in that order.
+func (a *app) applyFlagSettings(cmd *cobra.Command) error {
+	var firstErr error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if firstErr != nil || f.Changed {
+			return
+		}
+		key := flagConfigKey(cmd, f)
+		if key == "" {
+			return
+		}
+		value, source, ok := a.lookupFlagSetting(key)
+		if !ok {
+			return
+		}
+		if err := f.Value.Set(value); err != nil {
+			firstErr = newCLIError("invalid_config", fmt.Sprintf("invalid value %q for --%s from %s", value, f.Name, source), ExitGeneral, err)
+		}
+	})
+	return firstErr
+}
+
+func (a *app) lookupFlagSetting(key string) (value string, source string, ok bool) {
+	keys := []string{key}
+	if command, rest, found := strings.Cut(key, "."); found && inheritedFlagSettings[command] != "" {
+		keys = append(keys, inheritedFlagSettings[command]+"."+rest)
+	}
+	for _, k := range keys {
+		envVar := configKeyEnvVar(k)
+		if v, set := os.LookupEnv(envVar); set {
+			return v, "env " + envVar, true
+		}
+	}
+	for _, k := range keys {
+		for _, candidate := range []string{k, legacyFlagKeys[k]} {
+			if candidate == "" || !a.v.IsSet(candidate) {
+				continue
+			}
+			raw := a.v.Get(candidate)
+			if raw == nil {
+				continue
+			}
+			return configValueString(raw), "config " + candidate, true
+		}
+	}
+	if a.workspaceProjectID != "" && strings.HasSuffix(key, ".project_id") {
+		return a.workspaceProjectID, "workspace " + projectConfigFileName, true
+	}
+	return "", "", false
+}
+
+func configValueString(raw interface{}) string {
+	switch v := raw.(type) {
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, ",")
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// collectFlagSettings lists every flag that can be set from env or config.
+func collectFlagSettings(root *cobra.Command) []flagSetting {
+	var settings []flagSetting
+	seen := map[string]bool{}
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		flags := c.LocalFlags()
+		if c == root {
+			flags = c.PersistentFlags()
+		}
+		flags.VisitAll(func(f *pflag.Flag) {
+			key := flagConfigKey(c, f)
+			if key == "" || seen[key] {
+				return
+			}
+			seen[key] = true
+			settings = append(settings, flagSetting{
+				Key:         key,
+				EnvVar:      configKeyEnvVar(key),
+				Flag:        f,
+				Command:     strings.TrimSpace(strings.TrimPrefix(c.CommandPath(), root.Name())),
+				Description: f.Usage,
+			})
+		})
+		for _, child := range c.Commands() {
+			if settingsExemptCommands[child.Name()] {
+				continue
+			}
+			walk(child)
+		}
+	}
+	walk(root)
+	return settings
+}
+
+func flagValueKind(f *pflag.Flag) string {
+	switch f.Value.Type() {
+	case "bool":
+		return "bool"
+	case "int", "int64", "int32", "uint":
+		return "int"
+	case "stringSlice", "stringArray":
+		return "list"
+	default:
+		return "string"
+	}
+}
+
+const requiredFlagAnnotation = "robotx_required"
+
+// markFlagsRequired marks flags that must be provided by flag, env, or config.
+// Unlike cobra's MarkFlagRequired it is checked after env/config are applied.
+func markFlagsRequired(cmd *cobra.Command, names ...string) {
+	for _, name := range names {
+		_ = cmd.Flags().SetAnnotation(name, requiredFlagAnnotation, []string{"true"})
+	}
+}
+
+func validateRequiredFlags(cmd *cobra.Command) error {
+	var missing []string
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if _, ok := f.Annotations[requiredFlagAnnotation]; ok && strings.TrimSpace(f.Value.String()) == "" {
+			missing = append(missing, f.Name)
+		}
+	})
+	if len(missing) == 0 {
+		return nil
+	}
+	return newCLIError("missing_argument", fmt.Sprintf("required flag(s) %q not set", strings.Join(missing, `", "`)), ExitGeneral, nil)
+}
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+// flagKeyPattern limits feature flag keys to names that are safe in URLs and
+// environment variables.
+var flagKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]{0,63}$`)
+
+type featureFlagsOptions struct {
+	*app
+	projectID  string
+	rawStrings bool
+}
+
+type featureFlagsResponse struct {
+	ProjectID string                 `json:"project_id"`
+	Flags     map[string]interface{} `json:"flags"`
+	Removed   []string               `json:"removed,omitempty"`
+}
+
+func newFlagsCmd(a *app) *cobra.Command {
+	o := &featureFlagsOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "flags",
+		Short: "Manage feature flags of a deployed project",
+		Long: `Manage project-level feature flags. Deployed apps read them at runtime, so
+features can be toggled without a redeploy.
+
+Values "true" and "false" are stored as booleans and numeric values as
+numbers; pass --string to store every value as text.
+
+Inside a deployed project directory --project-id defaults to the recorded
+project.`,
+	}
+
+	setCmd := &cobra.Command{
+		Use:   "set <key=value>...",
+		Short: "Create or update feature flags",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  o.runSet,
+	}
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List feature flags",
+		Args:  cobra.NoArgs,
+		RunE:  o.runList,
+	}
+	removeCmd := &cobra.Command{
+		Use:     "remove <key>...",
+		Aliases: []string{"rm"},
+		Short:   "Remove feature flags",
+		Args:    cobra.MinimumNArgs(1),
+		RunE:    o.runRemove,
+	}
+	cmd.AddCommand(setCmd, listCmd, removeCmd)
+
+	cmd.PersistentFlags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	setCmd.Flags().BoolVar(&o.rawStrings, "string", false, "Store values as strings instead of booleans and numbers")
+	return cmd
+}
+
+// flagsClient checks the common settings of the flags subcommands and returns
+// a client for a server that supports feature flags.
+func (o *featureFlagsOptions) flagsClient() (*client.Client, string, error) {
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return nil, "", newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return nil, "", newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	projectID, _ := stateProjectID(o.projectID)
+	if projectID == "" {
+		return nil, "", newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityFeatureFlags) {
+		return nil, "", newCLIError("unsupported_server", "this RobotX server does not support feature flags", ExitAPI, nil)
+	}
+	return c, projectID, nil
+}
+
+func (o *featureFlagsOptions) runSet(cmd *cobra.Command, args []string) error {
+	flags := make(map[string]interface{}, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return newCLIError("invalid_argument", fmt.Sprintf("expected key=value, got %q", arg), ExitGeneral, nil)
+		}
+		if !flagKeyPattern.MatchString(key) {
+			return newCLIError("invalid_argument", fmt.Sprintf("invalid flag key %q: use letters, digits, '_', '.' or '-', starting with a letter (max 64)", key), ExitGeneral, nil)
+		}
+		flags[key] = o.parseFlagValue(value)
+	}
+
+	c, projectID, err := o.flagsClient()
+	if err != nil {
+		return err
+	}
+	updated, err := c.SetFlags(projectID, flags)
+	if err != nil {
+		return newCLIError("api_error", "failed to set feature flags", ExitAPI, err)
+	}
+	if updated == nil {
+		updated = flags
+	}
+	for _, key := range sortedFlagKeys(flags) {
+		o.logf("🚩 %s = %s\n", key, formatFlagValue(flags[key]))
+	}
+	if err := o.emitSuccess("flags "+cmd.Name(), featureFlagsResponse{ProjectID: projectID, Flags: updated}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+func (o *featureFlagsOptions) runList(cmd *cobra.Command, args []string) error {
+	c, projectID, err := o.flagsClient()
+	if err != nil {
+		return err
+	}
+	flags, err := c.ListFlags(projectID)
+	if err != nil {
+		return newCLIError("api_error", "failed to list feature flags", ExitAPI, err)
+	}
+	if flags == nil {
+		flags = map[string]interface{}{}
+	}
+
+	if err := o.emitSuccess("flags "+cmd.Name(), featureFlagsResponse{ProjectID: projectID, Flags: flags}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if o.isJSONOutput() {
+		return nil
+	}
+	if len(flags) == 0 {
+		fmt.Fprintln(o.out(), "No feature flags.")
+		return nil
+	}
+	w := tabwriter.NewWriter(o.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE")
+	for _, key := range sortedFlagKeys(flags) {
+		fmt.Fprintf(w, "%s\t%s\n", key, formatFlagValue(flags[key]))
+	}
+	_ = w.Flush()
+	return nil
+}
+
+func (o *featureFlagsOptions) runRemove(cmd *cobra.Command, args []string) error {
+	c, projectID, err := o.flagsClient()
+	if err != nil {
+		return err
+	}
+	if err := o.confirm(fmt.Sprintf("Remove feature flag(s) %s of %s", strings.Join(args, ", "), projectID)); err != nil {
+		return err
+	}
+	for _, key := range args {
+		if err := c.DeleteFlag(projectID, key); err != nil {
+			if client.IsNotFound(err) {
+				return newCLIError("not_found", fmt.Sprintf("feature flag %s not found", key), ExitNotFound, err)
+			}
+			return newCLIError("api_error", fmt.Sprintf("failed to remove feature flag %s", key), ExitAPI, err)
+		}
+		o.logf("🗑️  Removed %s\n", key)
+	}
+
+	flags, err := c.ListFlags(projectID)
+	if err != nil {
+		return newCLIError("api_error", "failed to list feature flags", ExitAPI, err)
+	}
+	if flags == nil {
+		flags = map[string]interface{}{}
+	}
+	if err := o.emitSuccess("flags "+cmd.Name(), featureFlagsResponse{ProjectID: projectID, Flags: flags, Removed: args}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// parseFlagValue stores true/false as booleans and numeric values as numbers
+// unless --string is set.
+func (o *featureFlagsOptions) parseFlagValue(value string) interface{} {
+	if o.rawStrings {
+		return value
+	}
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil && !strings.ContainsAny(value, "xXnN") {
+		return f
+	}
+	return value
+}
+
+func formatFlagValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(value)
+}
+
+func sortedFlagKeys(flags map[string]interface{}) []string {
+	keys := make([]string, 0, len(flags))
+	for key := range flags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
+)
+
+// functionsDirs are the directories detected as serverless functions, in
+// order of preference.
+var functionsDirs = []string{"api", "functions"}
+
+// resolveFunctionsDir returns the functions directory to deploy, relative to
+// projectPath, or "" when the deploy is static only.
+func (o *deployOptions) resolveFunctionsDir(projectPath string, c *client.Client, baseURL string) (string, error) {
+	explicit := strings.TrimSpace(o.functionsDir)
+	if o.skipFunctions {
+		if explicit != "" {
+			return "", newCLIError("invalid_argument", "--functions-dir and --skip-functions cannot be combined", ExitGeneral, nil)
+		}
+		return "", nil
+	}
+
+	dir := ""
+	if explicit != "" {
+		dir = filepath.Clean(explicit)
+		if filepath.IsAbs(dir) || dir == "." || strings.HasPrefix(dir, "..") {
+			return "", newCLIError("invalid_argument", "--functions-dir must be a subdirectory of the project", ExitGeneral, nil)
+		}
+		if stat, err := os.Stat(filepath.Join(projectPath, dir)); err != nil || !stat.IsDir() {
+			return "", newCLIError("invalid_argument", fmt.Sprintf("functions directory does not exist: %s", dir), ExitGeneral, nil)
+		}
+	} else {
+		for _, candidate := range functionsDirs {
+			if stat, err := os.Stat(filepath.Join(projectPath, candidate)); err == nil && stat.IsDir() {
+				dir = candidate
+				break
+			}
+		}
+	}
+	if dir == "" {
+		return "", nil
+	}
+
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityFunctions) {
+		if explicit != "" {
+			return "", newCLIError("unsupported_server", "this RobotX server does not support serverless functions", ExitAPI, nil)
+		}
+		o.warnf("This RobotX server does not support serverless functions; %s/ is deployed as static files only", dir)
+		return "", nil
+	}
+	o.logf("🧩 Functions directory: %s/\n", dir)
+	return dir, nil
+}
+
+// functionsBuilder installs and builds the functions directory when it has a
+// package.json: npm install, then npm run build if a build script exists.
+type functionsBuilder struct {
+	*deployOptions
+}
+
+func (o functionsBuilder) Build(ctx context.Context, d *pipeline.Deploy) error {
+	dir := filepath.Join(d.ProjectPath, d.FunctionsDir)
+	manifest, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		d.Logf(pipeline.LevelInfo, "No package.json in %s/; packaging functions as they are", d.FunctionsDir)
+		return nil
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	_ = json.Unmarshal(manifest, &pkg)
+
+	var buildLog io.Writer
+	if d.Build != nil {
+		if f, err := appendBuildLog(d.ProjectPath, d.Build.BuildID); err == nil {
+			defer f.Close()
+			buildLog = f
+		}
+	}
+	builder := localBuilder{o.deployOptions}
+	d.Logf(pipeline.LevelInfo, "Running npm install in %s/", d.FunctionsDir)
+	if err := builder.runBuildCommand(ctx, dir, "npm install", buildLog); err != nil {
+		return fmt.Errorf("functions install failed: %w", err)
+	}
+	if pkg.Scripts["build"] != "" {
+		d.Logf(pipeline.LevelInfo, "Running npm run build in %s/", d.FunctionsDir)
+		if err := builder.runBuildCommand(ctx, dir, "npm run build", buildLog); err != nil {
+			return fmt.Errorf("functions build failed: %w", err)
+		}
+	}
+	return nil
+}
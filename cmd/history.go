@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// historyLimit caps the number of entries kept in the history file of the
+// data directory.
+const historyLimit = 100
+
+// historyEntry is one recorded deploy, publish or rollback. Args is the
+// command line that repeats it, without global flags.
+type historyEntry struct {
+	Time          time.Time `json:"time"`
+	Command       string    `json:"command"`
+	ProjectID     string    `json:"project_id,omitempty"`
+	ProjectName   string    `json:"project_name,omitempty"`
+	BuildID       string    `json:"build_id,omitempty"`
+	PreviewURL    string    `json:"preview_url,omitempty"`
+	ProductionURL string    `json:"production_url,omitempty"`
+	Args          []string  `json:"args"`
+}
+
+// loadHistory returns recorded entries, oldest first. Unreadable lines are
+// skipped.
+func loadHistory() []historyEntry {
+	path, err := robotxDataPath("history")
+	if err != nil {
+		return nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entries []historyEntry
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e historyEntry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil && e.Command != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// recordHistory appends e to the history file, keeping the newest
+// historyLimit entries. History is best effort and never fails a command.
+func (a *app) recordHistory(e historyEntry) {
+	path, err := robotxDataPath("history")
+	if err != nil {
+		return
+	}
+	e.Time = time.Now().UTC()
+	entries := append(loadHistory(), e)
+	if len(entries) > historyLimit {
+		entries = entries[len(entries)-historyLimit:]
+	}
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		a.logf("⚠️  Could not record command history: %v\n", err)
+	}
+}
+
+// historyArgs returns the command name followed by positional and the local
+// flags set on cmd, for replaying it with `robotx recent --rerun`.
+func historyArgs(cmd *cobra.Command, positional ...string) []string {
+	args := append([]string{cmd.Name()}, positional...)
+	local := cmd.LocalFlags()
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if local.Lookup(f.Name) != nil {
+			args = appendFlagArgs(args, f)
+		}
+	})
+	return args
+}
+
+// appendFlagArgs appends f as --name=value arguments, one per element for
+// slice flags.
+func appendFlagArgs(args []string, f *pflag.Flag) []string {
+	if slice, ok := f.Value.(pflag.SliceValue); ok {
+		for _, v := range slice.GetSlice() {
+			args = append(args, "--"+f.Name+"="+v)
+		}
+		return args
+	}
+	return append(args, "--"+f.Name+"="+f.Value.String())
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+// startHTTPDump picks the file that --debug-http-dump writes this run's HTTP
+// exchanges to: <data>/http-dumps/<time>-<command>.log. Failing to create
+// the directory only disables the dump.
+func (a *app) startHTTPDump(cmd *cobra.Command) {
+	if !a.httpDump || a.httpDumpPath != "" {
+		return
+	}
+	dir, err := robotxDataPath("http-dumps")
+	if err == nil {
+		err = os.MkdirAll(dir, 0o700)
+	}
+	if err != nil {
+		a.noticef("⚠️  HTTP dump disabled: %v\n", err)
+		return
+	}
+	name := fmt.Sprintf("%s-%s.log", time.Now().Format("20060102-150405"), strings.ReplaceAll(cmd.CommandPath(), " ", "-"))
+	a.httpDumpPath = filepath.Join(dir, name)
+	a.noticef("📝 Writing HTTP exchanges to %s (credentials redacted)\n", a.httpDumpPath)
+}
+
+// dumpHTTP makes c write its exchanges to the --debug-http-dump file.
+func (a *app) dumpHTTP(c *client.Client) {
+	if a.httpDumpPath != "" {
+		c.SetHTTPDump(a.httpDumpPath)
+	}
+}
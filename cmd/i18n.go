@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	langEnglish = "en"
+	langChinese = "zh-CN"
+)
+
+// catalogs maps each supported language to its translations. English is the
+// source language and needs no catalog.
+var catalogs = map[string]map[string]string{
+	langChinese: zhCNMessages,
+}
+
+// messageCatalog translates user-facing messages. Translations are keyed by
+// the English text, the format string for logf and the message for errors,
+// so untranslated messages fall back to English unchanged. Error codes and
+// JSON field names are never translated.
+type messageCatalog struct {
+	messages map[string]string
+	patterns []catalogPattern
+}
+
+// catalogPattern matches an already formatted message against a format
+// string of the catalog, for messages formatted before they reach the
+// output, such as error messages and deploy pipeline logs.
+type catalogPattern struct {
+	re          *regexp.Regexp
+	translation string
+	literal     int
+}
+
+// formatVerb matches the fmt verbs of a format string.
+var formatVerb = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*\d*(\.\d+)?[a-zA-Z]`)
+
+func newMessageCatalog(lang string) *messageCatalog {
+	messages := catalogs[lang]
+	if messages == nil {
+		return nil
+	}
+	c := &messageCatalog{messages: messages}
+	for key, translation := range messages {
+		if !formatVerb.MatchString(key) {
+			continue
+		}
+		var expr strings.Builder
+		expr.WriteString("^")
+		literal := 0
+		for i, part := range formatVerb.Split(strings.TrimSuffix(key, "\n"), -1) {
+			if i > 0 {
+				expr.WriteString("(.*?)")
+			}
+			part = strings.ReplaceAll(part, "%%", "%")
+			expr.WriteString(regexp.QuoteMeta(part))
+			literal += len(part)
+		}
+		expr.WriteString("$")
+		c.patterns = append(c.patterns, catalogPattern{
+			re:          regexp.MustCompile(expr.String()),
+			translation: strings.TrimSuffix(translation, "\n"),
+			literal:     literal,
+		})
+	}
+	// Prefer the most specific pattern when several match.
+	sort.Slice(c.patterns, func(i, j int) bool {
+		if c.patterns[i].literal != c.patterns[j].literal {
+			return c.patterns[i].literal > c.patterns[j].literal
+		}
+		return c.patterns[i].re.String() < c.patterns[j].re.String()
+	})
+	return c
+}
+
+// format translates a format string before it is formatted.
+func (c *messageCatalog) format(format string) string {
+	if c == nil {
+		return format
+	}
+	if translation, ok := c.messages[format]; ok {
+		return translation
+	}
+	return format
+}
+
+// message translates a formatted message, substituting the values of the
+// English message into the translation.
+func (c *messageCatalog) message(message string) string {
+	if c == nil || message == "" {
+		return message
+	}
+	if translation, ok := c.messages[message]; ok {
+		return translation
+	}
+	for _, p := range c.patterns {
+		values := p.re.FindStringSubmatch(message)
+		if values == nil {
+			continue
+		}
+		next := 0
+		translated := formatVerb.ReplaceAllStringFunc(p.translation, func(verb string) string {
+			index := next
+			if m := formatVerb.FindStringSubmatch(verb); m[1] != "" {
+				n, _ := strconv.Atoi(strings.Trim(m[1], "[]"))
+				index = n - 1
+			}
+			next = index + 1
+			if index < 0 || index+1 >= len(values) {
+				return verb
+			}
+			return values[index+1]
+		})
+		return strings.ReplaceAll(translated, "%%", "%")
+	}
+	return message
+}
+
+// normalizeLang maps a language tag such as zh, zh_CN or zh-CN.UTF-8 to a
+// supported language, reporting false for unsupported ones.
+func normalizeLang(raw string) (string, bool) {
+	tag := strings.TrimSpace(raw)
+	if i := strings.IndexAny(tag, ".@"); i >= 0 {
+		tag = tag[:i]
+	}
+	tag = strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+	switch {
+	case tag == "", tag == "c", tag == "posix", tag == "en", strings.HasPrefix(tag, "en-"):
+		return langEnglish, true
+	case tag == "zh", tag == "zh-cn", tag == "zh-hans", tag == "zh-sg", strings.HasPrefix(tag, "zh-hans-"):
+		return langChinese, true
+	}
+	return "", false
+}
+
+// resolveLanguage selects the message catalog from --lang, ROBOTX_LANG or
+// the lang config key. It runs after resolveOutput.
+func (a *app) resolveLanguage() error {
+	raw := a.v.GetString("lang")
+	lang, ok := normalizeLang(raw)
+	if !ok {
+		return newCLIError("invalid_argument", fmt.Sprintf("unsupported language %q (supported: en, zh-CN)", raw), ExitGeneral, nil)
+	}
+	a.output.catalog = newMessageCatalog(lang)
+	return nil
+}
+
+// tr translates a user-facing message into the selected language.
+func (a *app) tr(message string) string {
+	return a.outputMode().catalog.message(message)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// isNonInteractive reports whether the CLI must avoid prompts, browser
+// launches, and colored output. It is enabled by --non-interactive,
+// ROBOTX_AGENT=1, or when stdin/stdout is not a terminal.
+func (a *app) isNonInteractive() bool {
+	return a.outputMode().nonInteractive
+}
+
+// envNonInteractive reports whether the environment alone requires
+// non-interactive behaviour.
+func envNonInteractive() bool {
+	if envTruthy("ROBOTX_AGENT") || envTruthy("ROBOTX_NON_INTERACTIVE") {
+		return true
+	}
+	return !isTerminal(os.Stdout) || !isTerminal(os.Stdin)
+}
+
+// confirm guards a destructive action described by action (e.g. "Publish
+// build b1 of proj_1 to production"). It passes with --yes or ROBOTX_YES=1,
+// prompts on a terminal otherwise, and fails with confirmation_required when
+// it cannot prompt.
+func (a *app) confirm(action string) error {
+	if a.assumeYes || envTruthy("ROBOTX_YES") {
+		return nil
+	}
+	if a.isNonInteractive() {
+		return newCLIError("confirmation_required", fmt.Sprintf("%s requires confirmation; pass --yes to proceed", action), ExitGeneral, nil)
+	}
+	a.noticef("⚠️  %s. Continue? [y/N]: ", action)
+	answer, _ := bufio.NewReader(a.root.InOrStdin()).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return newCLIError("cancelled", "aborted: not confirmed", ExitCancelled, nil)
+}
+
+func isTerminal(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+func envTruthy(name string) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(name))) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// noColorEnv returns environment overrides that disable colored output in
+// child processes such as local build commands.
+func noColorEnv() []string {
+	return []string{"NO_COLOR=1", "FORCE_COLOR=0", "npm_config_color=false"}
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// templatePattern matches the template variables of robotx.yaml values:
+// ${GIT_SHA}, ${env:NAME}, ${date:LAYOUT} and the like. "$${" escapes a
+// literal "${".
+var templatePattern = regexp.MustCompile(`\$?\$\{([^{}]*)\}`)
+
+// templateVars expands the template variables of robotx.yaml. Git values
+// are looked up once, in the directory of the file.
+type templateVars struct {
+	dir string
+	now time.Time
+	git map[string]string
+}
+
+func newTemplateVars(dir string) *templateVars {
+	return &templateVars{dir: dir, now: time.Now().UTC(), git: map[string]string{}}
+}
+
+// expand replaces the template variables in s. Other ${...} sequences, such
+// as shell variables in build commands, are left alone.
+func (t *templateVars) expand(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var firstErr error
+	out := templatePattern.ReplaceAllStringFunc(s, func(match string) string {
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+		value, ok, err := t.lookup(match[2 : len(match)-1])
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %w", match, err)
+		}
+		if !ok {
+			return match
+		}
+		return value
+	})
+	return out, firstErr
+}
+
+// lookup returns the value of a template variable, and false for names
+// that are not template variables.
+func (t *templateVars) lookup(name string) (string, bool, error) {
+	switch {
+	case strings.HasPrefix(name, "env:"):
+		key := strings.TrimPrefix(name, "env:")
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			return "", true, fmt.Errorf("environment variable %s is not set", key)
+		}
+		return value, true, nil
+	case strings.HasPrefix(name, "date:"):
+		layout := strings.TrimPrefix(name, "date:")
+		if layout == "" {
+			return "", true, fmt.Errorf("date layout is empty (e.g. ${date:20060102})")
+		}
+		return t.now.Format(layout), true, nil
+	case name == "GIT_SHA", name == "GIT_SHORT_SHA", name == "GIT_BRANCH":
+		value, err := t.gitValue(name)
+		return value, true, err
+	}
+	return "", false, nil
+}
+
+// gitCIVariables are the CI variables used when git cannot tell, e.g. in a
+// shallow checkout without the git binary or on a detached HEAD.
+var gitCIVariables = map[string][]string{
+	"GIT_SHA":    {"GITHUB_SHA", "CI_COMMIT_SHA", "CIRCLE_SHA1"},
+	"GIT_BRANCH": {"GITHUB_HEAD_REF", "GITHUB_REF_NAME", "CI_COMMIT_REF_NAME", "CIRCLE_BRANCH"},
+}
+
+func (t *templateVars) gitValue(name string) (string, error) {
+	if value, ok := t.git[name]; ok {
+		return value, nil
+	}
+	var args []string
+	switch name {
+	case "GIT_SHA":
+		args = []string{"rev-parse", "HEAD"}
+	case "GIT_SHORT_SHA":
+		args = []string{"rev-parse", "--short", "HEAD"}
+	case "GIT_BRANCH":
+		args = []string{"rev-parse", "--abbrev-ref", "HEAD"}
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = t.dir
+	out, err := cmd.Output()
+	value := strings.TrimSpace(string(out))
+	if err != nil || value == "" || value == "HEAD" {
+		value = ""
+		ciName := name
+		if name == "GIT_SHORT_SHA" {
+			ciName = "GIT_SHA"
+		}
+		for _, key := range gitCIVariables[ciName] {
+			if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+				value = v
+				break
+			}
+		}
+		if name == "GIT_SHORT_SHA" && len(value) > 7 {
+			value = value[:7]
+		}
+	}
+	if value == "" {
+		return "", fmt.Errorf("cannot determine %s: not a git checkout and no CI variable set", name)
+	}
+	t.git[name] = value
+	return value, nil
+}
+
+// expandNode expands the template variables in the scalar values of a YAML
+// document, leaving keys alone.
+func (t *templateVars) expandNode(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		value, err := t.expand(node.Value)
+		if err != nil {
+			return err
+		}
+		node.Value = value
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := t.expandNode(node.Content[i]); err != nil {
+				return err
+			}
+		}
+	default:
+		for _, child := range node.Content {
+			if err := t.expandNode(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decodeProjectYAML decodes the robotx.yaml in dir into out after expanding
+// its template variables.
+func decodeProjectYAML(raw []byte, dir string, out interface{}) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return newCLIError("invalid_project_config", "failed to parse "+projectConfigFileName, ExitGeneral, err)
+	}
+	if err := newTemplateVars(dir).expandNode(&doc); err != nil {
+		return newCLIError("invalid_project_config", fmt.Sprintf("failed to expand %s: %v", projectConfigFileName, err), ExitGeneral, nil)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	if err := doc.Decode(out); err != nil {
+		return newCLIError("invalid_project_config", "failed to parse "+projectConfigFileName, ExitGeneral, err)
+	}
+	return nil
+}
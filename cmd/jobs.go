@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+type jobsOptions struct {
+	*app
+	projectID string
+	name      string
+	schedule  string
+	path      string
+	method    string
+}
+
+type jobsListResponse struct {
+	ProjectID string        `json:"project_id"`
+	Jobs      []*client.Job `json:"jobs"`
+}
+
+type jobRemoveResponse struct {
+	ProjectID string `json:"project_id"`
+	JobID     string `json:"job_id"`
+	Removed   bool   `json:"removed"`
+}
+
+func newJobsCmd(a *app) *cobra.Command {
+	o := &jobsOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "Manage scheduled jobs of a project",
+		Long: `Manage scheduled jobs: the platform requests a path of the deployed project
+on a cron schedule, e.g. a function under api/ that cleans up data every
+five minutes.
+
+Schedules use the five cron fields minute, hour, day of month, month and day
+of week (UTC), such as "*/5 * * * *", or @hourly, @daily, @weekly, @monthly.
+
+Inside a deployed project directory --project-id defaults to the recorded
+project.`,
+	}
+
+	addCmd := &cobra.Command{
+		Use:   "add",
+		Short: "Schedule requests to a path of the project",
+		Args:  cobra.NoArgs,
+		RunE:  o.runAdd,
+	}
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the project's scheduled jobs",
+		Args:  cobra.NoArgs,
+		RunE:  o.runList,
+	}
+	removeCmd := &cobra.Command{
+		Use:     "remove <job-id>",
+		Aliases: []string{"rm"},
+		Short:   "Remove a scheduled job",
+		Args:    cobra.ExactArgs(1),
+		RunE:    o.runRemove,
+	}
+	cmd.AddCommand(addCmd, listCmd, removeCmd)
+
+	cmd.PersistentFlags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	addCmd.Flags().StringVar(&o.schedule, "schedule", "", `Cron schedule, e.g. "*/5 * * * *" (required)`)
+	addCmd.Flags().StringVar(&o.path, "path", "", "Path to request, e.g. /api/cron (required)")
+	addCmd.Flags().StringVar(&o.method, "method", http.MethodGet, "HTTP method of the request")
+	addCmd.Flags().StringVar(&o.name, "name", "", "Optional job name")
+	markFlagsRequired(addCmd, "schedule", "path")
+	return cmd
+}
+
+// jobsClient checks the common settings of the jobs subcommands and returns a
+// client for a server that supports scheduled jobs.
+func (o *jobsOptions) jobsClient() (*client.Client, string, error) {
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return nil, "", newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return nil, "", newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	projectID, _ := stateProjectID(o.projectID)
+	if projectID == "" {
+		return nil, "", newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityScheduledJobs) {
+		return nil, "", newCLIError("unsupported_server", "this RobotX server does not support scheduled jobs", ExitAPI, nil)
+	}
+	return c, projectID, nil
+}
+
+func (o *jobsOptions) runAdd(cmd *cobra.Command, args []string) error {
+	schedule := strings.Join(strings.Fields(o.schedule), " ")
+	if err := validateCronSchedule(schedule); err != nil {
+		return newCLIError("invalid_argument", fmt.Sprintf("invalid --schedule: %v", err), ExitGeneral, nil)
+	}
+	if !strings.HasPrefix(o.path, "/") {
+		return newCLIError("invalid_argument", "--path must start with /", ExitGeneral, nil)
+	}
+	method := strings.ToUpper(strings.TrimSpace(o.method))
+	switch method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return newCLIError("invalid_argument", fmt.Sprintf("unsupported --method %q", o.method), ExitGeneral, nil)
+	}
+
+	c, projectID, err := o.jobsClient()
+	if err != nil {
+		return err
+	}
+	job, err := c.CreateJob(projectID, client.CreateJobRequest{Name: o.name, Schedule: schedule, Method: method, Path: o.path})
+	if err != nil {
+		return newCLIError("api_error", "failed to create job", ExitAPI, err)
+	}
+	o.logf("⏰ Scheduled %s %s (%s) as job %s\n", method, o.path, schedule, job.JobID)
+	if job.NextRunAt != nil {
+		o.logf("   Next run: %s\n", formatBuildTimePtr(job.NextRunAt))
+	}
+	if err := o.emitSuccess("jobs "+cmd.Name(), job); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+func (o *jobsOptions) runList(cmd *cobra.Command, args []string) error {
+	c, projectID, err := o.jobsClient()
+	if err != nil {
+		return err
+	}
+	jobs, err := c.ListJobs(projectID)
+	if err != nil {
+		return newCLIError("api_error", "failed to list jobs", ExitAPI, err)
+	}
+	if jobs == nil {
+		jobs = []*client.Job{}
+	}
+
+	if err := o.emitSuccess("jobs "+cmd.Name(), jobsListResponse{ProjectID: projectID, Jobs: jobs}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if o.isJSONOutput() {
+		return nil
+	}
+	if len(jobs) == 0 {
+		fmt.Fprintln(o.out(), "No scheduled jobs.")
+		return nil
+	}
+	w := tabwriter.NewWriter(o.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JOB_ID\tNAME\tSCHEDULE\tMETHOD\tPATH\tLAST_STATUS\tLAST_RUN\tNEXT_RUN")
+	for _, job := range jobs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			job.JobID,
+			valueOrDash(job.Name),
+			job.Schedule,
+			valueOrDash(job.Method),
+			job.Path,
+			valueOrDash(job.LastStatus),
+			formatBuildTimePtr(job.LastRunAt),
+			formatBuildTimePtr(job.NextRunAt),
+		)
+	}
+	_ = w.Flush()
+	return nil
+}
+
+func (o *jobsOptions) runRemove(cmd *cobra.Command, args []string) error {
+	jobID := args[0]
+	c, projectID, err := o.jobsClient()
+	if err != nil {
+		return err
+	}
+	if err := o.confirm(fmt.Sprintf("Remove job %s of %s", jobID, projectID)); err != nil {
+		return err
+	}
+	if err := c.DeleteJob(projectID, jobID); err != nil {
+		if client.IsNotFound(err) {
+			return newCLIError("not_found", fmt.Sprintf("job %s not found", jobID), ExitNotFound, err)
+		}
+		return newCLIError("api_error", "failed to remove job", ExitAPI, err)
+	}
+	o.logf("🗑️  Removed job %s\n", jobID)
+	if err := o.emitSuccess("jobs "+cmd.Name(), jobRemoveResponse{ProjectID: projectID, JobID: jobID, Removed: true}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// cronFields describes the five fields of a cron schedule: name, range and
+// accepted names.
+var cronFields = []struct {
+	name     string
+	min, max int
+	names    []string
+}{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{"day of week", 0, 7, []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+var cronMacros = map[string]bool{"@yearly": true, "@annually": true, "@monthly": true, "@weekly": true, "@daily": true, "@midnight": true, "@hourly": true}
+
+// validateCronSchedule checks a five-field cron expression or macro.
+func validateCronSchedule(schedule string) error {
+	if strings.HasPrefix(schedule, "@") {
+		if !cronMacros[schedule] {
+			return fmt.Errorf("unknown macro %s", schedule)
+		}
+		return nil
+	}
+	fields := strings.Fields(schedule)
+	if len(fields) != len(cronFields) {
+		return fmt.Errorf("expected 5 fields (minute hour day-of-month month day-of-week), got %d", len(fields))
+	}
+	for i, field := range fields {
+		spec := cronFields[i]
+		for _, part := range strings.Split(field, ",") {
+			if err := validateCronPart(part, spec.min, spec.max, spec.names); err != nil {
+				return fmt.Errorf("%s field %q: %v", spec.name, field, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateCronPart(part string, min, max int, names []string) error {
+	value := func(s string) (int, error) {
+		for i, name := range names {
+			if strings.EqualFold(s, name) {
+				return i + min, nil
+			}
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < min || n > max {
+			return 0, fmt.Errorf("%q is not in %d-%d", s, min, max)
+		}
+		return n, nil
+	}
+
+	rangePart, step, hasStep := strings.Cut(part, "/")
+	if hasStep {
+		if n, err := strconv.Atoi(step); err != nil || n <= 0 {
+			return fmt.Errorf("invalid step %q", step)
+		}
+	}
+	if rangePart == "*" {
+		return nil
+	}
+	lo, hi, isRange := strings.Cut(rangePart, "-")
+	first, err := value(lo)
+	if err != nil {
+		return err
+	}
+	if !isRange {
+		return nil
+	}
+	last, err := value(hi)
+	if err != nil {
+		return err
+	}
+	if first > last {
+		return fmt.Errorf("range %s is reversed", rangePart)
+	}
+	return nil
+}
@@ -0,0 +1,480 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// licenseUnknown is the license of a package whose package.json could not be
+// found or names none.
+const licenseUnknown = "UNKNOWN"
+
+// licensesMetadataKey is the build metadata entry --attach stores the report
+// under.
+const licensesMetadataKey = "licenses"
+
+// licenseLockfiles are the lockfiles licenses reads, in this order.
+var licenseLockfiles = []string{"package-lock.json", "npm-shrinkwrap.json", "pnpm-lock.yaml", "yarn.lock"}
+
+type licensesOptions struct {
+	*app
+	path      string
+	allow     []string
+	deny      []string
+	skipDev   bool
+	attach    bool
+	projectID string
+	buildID   string
+}
+
+type licensePackage struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	License string `json:"license"`
+	Dev     bool   `json:"dev,omitempty"`
+	// Denied is set for packages the --allow and --deny lists refuse, with
+	// the reason.
+	Denied bool   `json:"denied,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type licensesResponse struct {
+	Path      string   `json:"path"`
+	Lockfiles []string `json:"lockfiles"`
+	// Licenses counts the packages by license.
+	Licenses map[string]int    `json:"licenses"`
+	Packages []*licensePackage `json:"packages"`
+	Denied   []*licensePackage `json:"denied"`
+	// AttachedBuildID is the build the report was attached to with --attach.
+	AttachedBuildID string `json:"attached_build_id,omitempty"`
+}
+
+func newLicensesCmd(a *app) *cobra.Command {
+	o := &licensesOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "licenses",
+		Short: "List the licenses of a project's dependencies",
+		Long: `List the licenses of the dependencies in the lockfiles of a project
+(package-lock.json, npm-shrinkwrap.json, pnpm-lock.yaml and yarn.lock). The
+license of each package is read from the lockfile when it records one, and
+otherwise from the package's package.json under node_modules, so install the
+dependencies first; packages without one are listed as UNKNOWN.
+
+--deny refuses packages under the given licenses, and --allow refuses those
+under any license not listed, UNKNOWN included. SPDX expressions are
+honoured: "MIT OR GPL-3.0" is allowed when either license is, and
+"MIT AND GPL-3.0" only when both are. Both lists are usually kept in the
+config file as licenses.allow and licenses.deny. Refused packages fail the
+command with licenses_denied.
+
+--attach stores the report on a build, by default the last one deployed from
+the project directory, so it can be audited later.`,
+		Example: `  robotx licenses --path .
+  robotx licenses --deny GPL-3.0,AGPL-3.0 --skip-dev
+  robotx licenses --allow MIT,ISC,Apache-2.0,BSD-3-Clause --attach`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+	cmd.Flags().StringVar(&o.path, "path", ".", "Project directory to scan")
+	cmd.Flags().StringSliceVar(&o.allow, "allow", nil, "Licenses allowed; packages under any other license are refused (comma-separated SPDX IDs)")
+	cmd.Flags().StringSliceVar(&o.deny, "deny", nil, "Licenses refused (comma-separated SPDX IDs)")
+	cmd.Flags().BoolVar(&o.skipDev, "skip-dev", false, "Leave out dev dependencies, as far as the lockfile records them")
+	cmd.Flags().BoolVar(&o.attach, "attach", false, "Attach the report to the build as metadata")
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID for --attach (default: from .robotx/state.json)")
+	cmd.Flags().StringVarP(&o.buildID, "build-id", "b", "", "Build ID for --attach (default: last build from .robotx/state.json)")
+	return cmd
+}
+
+func (o *licensesOptions) run(cmd *cobra.Command, args []string) error {
+	absPath, err := filepath.Abs(o.path)
+	if err != nil {
+		return newCLIError("invalid_project_path", "invalid project path", ExitGeneral, err)
+	}
+	if (cmd.Flags().Changed("project-id") || cmd.Flags().Changed("build-id")) && !o.attach {
+		return newCLIError("invalid_argument", "--project-id and --build-id only apply with --attach", ExitGeneral, nil)
+	}
+
+	resp := licensesResponse{Path: absPath, Lockfiles: []string{}, Licenses: map[string]int{}, Packages: []*licensePackage{}, Denied: []*licensePackage{}}
+	seen := map[string]bool{}
+	for _, name := range licenseLockfiles {
+		lockfile := filepath.Join(absPath, name)
+		if !fileExists(lockfile) {
+			continue
+		}
+		packages, err := readLockfilePackages(absPath, name)
+		if err != nil {
+			return newCLIError("invalid_lockfile", fmt.Sprintf("failed to read %s", name), ExitGeneral, err)
+		}
+		resp.Lockfiles = append(resp.Lockfiles, name)
+		for _, pkg := range packages {
+			key := pkg.Name + "@" + pkg.Version
+			if seen[key] || (o.skipDev && pkg.Dev) {
+				continue
+			}
+			seen[key] = true
+			resp.Packages = append(resp.Packages, pkg)
+		}
+	}
+	if len(resp.Lockfiles) == 0 {
+		return newCLIError("no_lockfile", fmt.Sprintf("no lockfile in %s (looked for %s)", absPath, strings.Join(licenseLockfiles, ", ")), ExitGeneral, nil)
+	}
+	sort.Slice(resp.Packages, func(i, j int) bool {
+		if resp.Packages[i].Name != resp.Packages[j].Name {
+			return resp.Packages[i].Name < resp.Packages[j].Name
+		}
+		return resp.Packages[i].Version < resp.Packages[j].Version
+	})
+	for _, pkg := range resp.Packages {
+		resp.Licenses[pkg.License]++
+		if reason := o.licenseRefusal(pkg.License); reason != "" {
+			pkg.Denied = true
+			pkg.Reason = reason
+			resp.Denied = append(resp.Denied, pkg)
+		}
+	}
+
+	if o.attach {
+		buildID, err := o.attachReport(absPath, resp)
+		if err != nil {
+			return err
+		}
+		resp.AttachedBuildID = buildID
+	}
+
+	if len(resp.Denied) > 0 {
+		if !o.isJSONOutput() {
+			o.printLicenses(resp)
+		}
+		cliErr := newCLIError("licenses_denied", fmt.Sprintf("%d package(s) have refused licenses", len(resp.Denied)), ExitGeneral, nil)
+		cliErr.Details = resp
+		return cliErr
+	}
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if !o.isJSONOutput() {
+		o.printLicenses(resp)
+	}
+	return nil
+}
+
+func (o *licensesOptions) printLicenses(resp licensesResponse) {
+	w := tabwriter.NewWriter(o.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSION\tLICENSE\tDENIED")
+	for _, pkg := range resp.Packages {
+		denied := "-"
+		if pkg.Denied {
+			denied = pkg.Reason
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", pkg.Name, valueOrDash(pkg.Version), pkg.License, denied)
+	}
+	_ = w.Flush()
+
+	licenses := make([]string, 0, len(resp.Licenses))
+	for license := range resp.Licenses {
+		licenses = append(licenses, license)
+	}
+	sort.Slice(licenses, func(i, j int) bool {
+		if resp.Licenses[licenses[i]] != resp.Licenses[licenses[j]] {
+			return resp.Licenses[licenses[i]] > resp.Licenses[licenses[j]]
+		}
+		return licenses[i] < licenses[j]
+	})
+	parts := make([]string, 0, len(licenses))
+	for _, license := range licenses {
+		parts = append(parts, fmt.Sprintf("%s (%d)", license, resp.Licenses[license]))
+	}
+	fmt.Fprintf(o.out(), "\n%d package(s) from %s: %s\n", len(resp.Packages), strings.Join(resp.Lockfiles, ", "), strings.Join(parts, ", "))
+}
+
+// licenseRefusal returns why the --allow and --deny lists refuse a package
+// under license, or "" when they accept it.
+func (o *licensesOptions) licenseRefusal(license string) string {
+	expr := strings.TrimSpace(strings.NewReplacer("(", " ", ")", " ").Replace(license))
+	// AND binds tighter than OR in SPDX expressions; this handles the common
+	// flat forms "A OR B" and "A AND B".
+	if strings.Contains(expr, " AND ") {
+		for _, id := range strings.Split(expr, " AND ") {
+			if reason := o.licenseIDRefusal(strings.TrimSpace(id)); reason != "" {
+				return reason
+			}
+		}
+		return ""
+	}
+	var reason string
+	for _, id := range strings.Split(expr, " OR ") {
+		if reason = o.licenseIDRefusal(strings.TrimSpace(id)); reason == "" {
+			return ""
+		}
+	}
+	return reason
+}
+
+func (o *licensesOptions) licenseIDRefusal(id string) string {
+	for _, denied := range o.deny {
+		if strings.EqualFold(id, strings.TrimSpace(denied)) {
+			return "denied " + id
+		}
+	}
+	if len(o.allow) == 0 {
+		return ""
+	}
+	for _, allowed := range o.allow {
+		if strings.EqualFold(id, strings.TrimSpace(allowed)) {
+			return ""
+		}
+	}
+	return id + " not allowed"
+}
+
+// attachReport stores the report as metadata of the build and returns its
+// ID.
+func (o *licensesOptions) attachReport(absPath string, resp licensesResponse) (string, error) {
+	st := loadDeployState(absPath)
+	projectID := firstNonEmpty(o.projectID, st.ProjectID)
+	buildID := o.buildID
+	if buildID == "" && projectID == st.ProjectID {
+		buildID = st.lastBuildID()
+	}
+	if projectID == "" || buildID == "" {
+		return "", newCLIError("missing_argument", "--project-id and --build-id are required with --attach outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return "", newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return "", newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	c := o.newAPIClient(baseURL, apiKey)
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityBuildMetadata) {
+		return "", newCLIError("unsupported_server", "this RobotX server does not store build metadata", ExitAPI, nil)
+	}
+	report := map[string]interface{}{
+		"lockfiles": resp.Lockfiles,
+		"licenses":  resp.Licenses,
+		"packages":  resp.Packages,
+		"denied":    resp.Denied,
+	}
+	if err := c.SetBuildMetadata(projectID, buildID, licensesMetadataKey, report); err != nil {
+		switch {
+		case errors.Is(err, client.ErrUnsupported):
+			return "", newCLIError("unsupported_server", "this RobotX server does not store build metadata", ExitAPI, err)
+		case client.IsNotFound(err):
+			return "", newCLIError("not_found", fmt.Sprintf("build %s not found", buildID), ExitNotFound, err)
+		}
+		return "", newCLIError("api_error", "failed to attach the license report", ExitAPI, err)
+	}
+	o.logf("📎 Attached the license report to build %s\n", buildID)
+	return buildID, nil
+}
+
+// readLockfilePackages lists the packages of the lockfile name in dir.
+func readLockfilePackages(dir, name string) ([]*licensePackage, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	switch name {
+	case "pnpm-lock.yaml":
+		return pnpmLockPackages(dir, raw)
+	case "yarn.lock":
+		return yarnLockPackages(dir, raw), nil
+	}
+	return npmLockPackages(dir, raw)
+}
+
+// npmLockPackages reads package-lock.json and npm-shrinkwrap.json: the
+// "packages" map of lockfile version 2 and later, keyed by install path, or
+// the nested "dependencies" of version 1.
+func npmLockPackages(dir string, raw []byte) ([]*licensePackage, error) {
+	var lock struct {
+		Packages map[string]struct {
+			Name    string          `json:"name"`
+			Version string          `json:"version"`
+			License json.RawMessage `json:"license"`
+			Dev     bool            `json:"dev"`
+			Link    bool            `json:"link"`
+		} `json:"packages"`
+		Dependencies map[string]npmLockDependency `json:"dependencies"`
+	}
+	if err := json.Unmarshal(raw, &lock); err != nil {
+		return nil, err
+	}
+	var packages []*licensePackage
+	if lock.Packages != nil {
+		for path, entry := range lock.Packages {
+			i := strings.LastIndex(path, "node_modules/")
+			if i < 0 || entry.Link {
+				continue
+			}
+			pkg := &licensePackage{Name: firstNonEmpty(entry.Name, path[i+len("node_modules/"):]), Version: entry.Version, Dev: entry.Dev}
+			pkg.License = licenseField(entry.License, nil)
+			if pkg.License == "" {
+				pkg.License = installedLicense(filepath.Join(dir, filepath.FromSlash(path)))
+			}
+			packages = append(packages, pkg)
+		}
+		return packages, nil
+	}
+	var walk func(base string, deps map[string]npmLockDependency)
+	walk = func(base string, deps map[string]npmLockDependency) {
+		for name, dep := range deps {
+			path := filepath.Join(base, "node_modules", filepath.FromSlash(name))
+			packages = append(packages, &licensePackage{Name: name, Version: dep.Version, Dev: dep.Dev, License: installedLicense(path)})
+			walk(path, dep.Dependencies)
+		}
+	}
+	walk(dir, lock.Dependencies)
+	return packages, nil
+}
+
+type npmLockDependency struct {
+	Version      string                       `json:"version"`
+	Dev          bool                         `json:"dev"`
+	Dependencies map[string]npmLockDependency `json:"dependencies"`
+}
+
+// pnpmLockPackages reads pnpm-lock.yaml, whose package keys are
+// "/name/version" (v5), "/name@version" (v6) or "name@version" (v9), with
+// peer dependencies in parentheses.
+func pnpmLockPackages(dir string, raw []byte) ([]*licensePackage, error) {
+	var lock struct {
+		Packages map[string]struct {
+			Name    string `yaml:"name"`
+			Version string `yaml:"version"`
+			Dev     bool   `yaml:"dev"`
+		} `yaml:"packages"`
+	}
+	if err := yaml.Unmarshal(raw, &lock); err != nil {
+		return nil, err
+	}
+	var packages []*licensePackage
+	for key, entry := range lock.Packages {
+		key = strings.TrimPrefix(key, "/")
+		if i := strings.Index(key, "("); i > 0 {
+			key = key[:i]
+		}
+		name, version := splitPackageSpec(key)
+		if version == "" {
+			if i := strings.LastIndex(key, "/"); i > 0 {
+				name, version = key[:i], key[i+1:]
+			}
+		}
+		name = firstNonEmpty(entry.Name, name)
+		version = firstNonEmpty(entry.Version, version)
+		storeDir := strings.ReplaceAll(name, "/", "+") + "@" + version
+		license := installedLicense(filepath.Join(dir, "node_modules", ".pnpm", storeDir, "node_modules", filepath.FromSlash(name)))
+		if license == licenseUnknown {
+			license = installedVersionLicense(filepath.Join(dir, "node_modules", filepath.FromSlash(name)), version)
+		}
+		packages = append(packages, &licensePackage{Name: name, Version: version, Dev: entry.Dev, License: license})
+	}
+	return packages, nil
+}
+
+// yarnLockPackages reads yarn.lock of yarn 1 and yarn 2 and later. Entries
+// start with an unindented line of the specifiers they resolve, followed by
+// an indented version line.
+func yarnLockPackages(dir string, raw []byte) []*licensePackage {
+	var packages []*licensePackage
+	var name string
+	scanner := bufio.NewScanner(strings.NewReader(string(raw)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case !strings.HasPrefix(line, " "):
+			spec := strings.Trim(strings.TrimSpace(strings.SplitN(strings.TrimSuffix(line, ":"), ",", 2)[0]), `"`)
+			name, _ = splitPackageSpec(spec)
+			if name == "__metadata" {
+				name = ""
+			}
+		case name != "" && strings.HasPrefix(strings.TrimSpace(line), "version"):
+			version := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "version"))
+			version = strings.Trim(strings.TrimPrefix(version, ":"), ` "`)
+			packages = append(packages, &licensePackage{
+				Name:    name,
+				Version: version,
+				License: installedVersionLicense(filepath.Join(dir, "node_modules", filepath.FromSlash(name)), version),
+			})
+			name = ""
+		}
+	}
+	return packages
+}
+
+// splitPackageSpec splits "name@range", where name may be scoped.
+func splitPackageSpec(spec string) (string, string) {
+	i := strings.LastIndex(spec, "@")
+	if i <= 0 {
+		return spec, ""
+	}
+	return spec[:i], spec[i+1:]
+}
+
+// installedVersionLicense is installedLicense for a package installed at
+// pkgDir only when it is the given version, since node_modules holds one
+// version of a package at the top level.
+func installedVersionLicense(pkgDir, version string) string {
+	raw, err := os.ReadFile(filepath.Join(pkgDir, "package.json"))
+	if err != nil {
+		return licenseUnknown
+	}
+	var manifest struct {
+		Version  string          `json:"version"`
+		License  json.RawMessage `json:"license"`
+		Licenses json.RawMessage `json:"licenses"`
+	}
+	if json.Unmarshal(raw, &manifest) != nil || (version != "" && manifest.Version != version) {
+		return licenseUnknown
+	}
+	return firstNonEmpty(licenseField(manifest.License, manifest.Licenses), licenseUnknown)
+}
+
+// installedLicense reads the license of the package installed at pkgDir.
+func installedLicense(pkgDir string) string {
+	return installedVersionLicense(pkgDir, "")
+}
+
+// licenseField reads the license of a package.json: an SPDX expression, the
+// legacy {"type": ...} object, or the legacy "licenses" list, joined with OR.
+func licenseField(license, licenses json.RawMessage) string {
+	var id string
+	if json.Unmarshal(license, &id) == nil && strings.TrimSpace(id) != "" {
+		return strings.TrimSpace(id)
+	}
+	var typed struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(license, &typed) == nil && typed.Type != "" {
+		return typed.Type
+	}
+	var list []struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(licenses, &list) == nil {
+		var ids []string
+		for _, l := range list {
+			if l.Type != "" {
+				ids = append(ids, l.Type)
+			}
+		}
+		return strings.Join(ids, " OR ")
+	}
+	return ""
+}
@@ -15,29 +15,32 @@ import (
 	"strings"
 	"time"
 
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 )
 
-var loginCmd = &cobra.Command{
-	Use:   "login",
-	Short: "Login via browser and save credentials",
-	Long: `Start a device-code login flow, open browser for web authorization,
-poll for API key token, and save credentials to config file.`,
-	RunE: runLogin,
-}
-
-var (
-	loginTimeoutSec int
-	loginNoBrowser  bool
+type loginOptions struct {
+	*app
+	timeoutSec      int
+	noBrowser       bool
+	qr              bool
 	deviceStartPath string
 	devicePollPath  string
-)
+	ssoOrg          string
+	ssoStartPath    string
+	ssoTokenPath    string
+}
 
 type loginResponse struct {
 	BaseURL    string `json:"base_url"`
 	ConfigFile string `json:"config_file"`
+	Method     string `json:"method"`
+	SSOOrg     string `json:"sso_org,omitempty"`
+	// Identity is the account the new token authenticates as; nil when the
+	// server has no whoami endpoint.
+	Identity *client.Identity `json:"identity,omitempty"`
 }
 
 type deviceStartResponse struct {
@@ -76,58 +79,136 @@ func (e *devicePollError) Error() string {
 	return "device poll failed"
 }
 
-func init() {
-	rootCmd.AddCommand(loginCmd)
-
-	loginCmd.Flags().IntVar(&loginTimeoutSec, "timeout", 180, "Login timeout in seconds")
-	loginCmd.Flags().BoolVar(&loginNoBrowser, "no-browser", false, "Do not auto-open browser; only print verification URL")
-	loginCmd.Flags().StringVar(&deviceStartPath, "device-start-path", "/api/auth/device/start", "Device login start API path or full URL")
-	loginCmd.Flags().StringVar(&devicePollPath, "device-poll-path", "/api/auth/device/poll", "Device login poll API path or full URL")
+func newLoginCmd(a *app) *cobra.Command {
+	o := &loginOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Login via browser and save credentials",
+		Long: `Start a device-code login flow, open browser for web authorization,
+poll for API key token, and save credentials to config file.
+
+With --sso <org-slug> the organization's identity provider is used instead:
+the browser is sent through the OIDC flow and redirected back to a temporary
+listener on 127.0.0.1, and the authorization code is exchanged for an API
+token. The browser must run on the same machine as the CLI.`,
+		RunE: o.run,
+	}
+
+	cmd.Flags().IntVar(&o.timeoutSec, "timeout", 180, "Login timeout in seconds")
+	cmd.Flags().BoolVar(&o.noBrowser, "no-browser", false, "Do not auto-open browser; only print verification URL (automatic over SSH or without a display)")
+	cmd.Flags().BoolVar(&o.qr, "qr", false, "Show a QR code of the verification URL to authorize from a phone")
+	cmd.Flags().StringVar(&o.deviceStartPath, "device-start-path", "/api/auth/device/start", "Device login start API path or full URL")
+	cmd.Flags().StringVar(&o.devicePollPath, "device-poll-path", "/api/auth/device/poll", "Device login poll API path or full URL")
+	cmd.Flags().StringVar(&o.ssoOrg, "sso", "", "Log in through the SSO identity provider of this organization (slug)")
+	cmd.Flags().StringVar(&o.ssoStartPath, "sso-authorize-path", "/api/auth/sso/authorize", "SSO authorization path or full URL")
+	cmd.Flags().StringVar(&o.ssoTokenPath, "sso-token-path", "/api/auth/sso/token", "SSO code exchange API path or full URL")
+	return cmd
 }
 
-func runLogin(cmd *cobra.Command, args []string) error {
-	if loginTimeoutSec <= 0 {
-		return newCLIError("invalid_argument", "--timeout must be greater than 0", 1, nil)
+func (o *loginOptions) run(cmd *cobra.Command, args []string) error {
+	if o.timeoutSec <= 0 {
+		return newCLIError("invalid_argument", "--timeout must be greater than 0", ExitGeneral, nil)
 	}
 
-	base := strings.TrimSpace(viper.GetString("base_url"))
+	base := strings.TrimSpace(o.v.GetString("base_url"))
 	if base == "" {
-		return newCLIError("missing_base_url", "base URL is required (use --base-url or set ROBOTX_BASE_URL)", 1, nil)
+		return newCLIError("missing_base_url", "base URL is required (use --base-url or set ROBOTX_BASE_URL)", ExitGeneral, nil)
 	}
 	base = strings.TrimRight(base, "/")
 
-	startURL, err := resolveEndpoint(base, strings.TrimSpace(deviceStartPath))
+	method := "device"
+	org := strings.TrimSpace(o.ssoOrg)
+	var apiKey string
+	var err error
+	if org != "" {
+		method = "sso"
+		apiKey, err = o.ssoLogin(cmd.Context(), base, org)
+	} else {
+		apiKey, err = o.deviceLogin(base)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Check the token before saving it, so a broken token fails here rather
+	// than on the first deploy.
+	identity, err := o.newAPIClient(base, apiKey).VerifyAuth()
 	if err != nil {
-		return newCLIError("invalid_argument", "invalid --device-start-path", 1, err)
+		if client.IsUnauthorized(err) {
+			return newCLIError("login_failed", "the issued token was rejected by the server; credentials were not saved", ExitAuth, err)
+		}
+		return newCLIError("login_failed", "failed to verify the issued token; credentials were not saved", ExitAPI, err)
 	}
-	pollURL, err := resolveEndpoint(base, strings.TrimSpace(devicePollPath))
+
+	configPath, err := o.resolveConfigWritePath()
 	if err != nil {
-		return newCLIError("invalid_argument", "invalid --device-poll-path", 1, err)
+		return newCLIError("config_error", "failed to resolve config path", ExitGeneral, err)
+	}
+	if err := writeCredentialsToConfig(configPath, base, apiKey); err != nil {
+		return newCLIError("config_write_failed", "failed to write credentials to config", ExitGeneral, err)
 	}
 
-	logf("🔐 Starting RobotX device login flow...\n")
+	if identity != nil {
+		o.logf("👤 Logged in as %s\n", formatIdentity(identity))
+	}
+	o.logf("✅ Login successful. Credentials saved to: %s\n", configPath)
+	if configHasEncryptedKeys(configPath) {
+		o.logf("💡 The new API key is stored in plaintext; run 'robotx config encrypt' to encrypt it like the others\n")
+	}
+	if err := o.emitSuccess(cmd.Name(), loginResponse{
+		BaseURL:    base,
+		ConfigFile: configPath,
+		Method:     method,
+		SSOOrg:     org,
+		Identity:   identity,
+	}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// deviceLogin runs the device-code flow and returns the issued API key.
+func (o *loginOptions) deviceLogin(base string) (string, error) {
+	startURL, err := resolveEndpoint(base, strings.TrimSpace(o.deviceStartPath))
+	if err != nil {
+		return "", newCLIError("invalid_argument", "invalid --device-start-path", ExitGeneral, err)
+	}
+	pollURL, err := resolveEndpoint(base, strings.TrimSpace(o.devicePollPath))
+	if err != nil {
+		return "", newCLIError("invalid_argument", "invalid --device-poll-path", ExitGeneral, err)
+	}
+
+	o.logf("🔐 Starting RobotX device login flow...\n")
 	startResp, err := startDeviceLogin(startURL)
 	if err != nil {
-		return newCLIError("login_start_failed", "failed to start device login", 2, err)
+		return "", newCLIError("login_start_failed", "failed to start device login", ExitAPI, err)
 	}
 	if strings.TrimSpace(startResp.DeviceCode) == "" {
-		return newCLIError("login_start_failed", "device login response missing device_code", 2, nil)
+		return "", newCLIError("login_start_failed", "device login response missing device_code", ExitAPI, nil)
 	}
 
 	verificationURL := buildVerificationURL(base, startResp)
 	if verificationURL == "" {
-		return newCLIError("login_start_failed", "device login response missing verification URL", 2, nil)
+		return "", newCLIError("login_start_failed", "device login response missing verification URL", ExitAPI, nil)
 	}
 
-	logf("🧾 User Code: %s\n", valueOrDash(startResp.UserCode))
-	logf("🌐 Verification URL: %s\n", verificationURL)
-	if loginNoBrowser {
-		logf("🧭 Open the URL above in your browser and complete login.\n")
+	headless := headlessSession()
+	if headless && !o.isJSONOutput() {
+		o.printDeviceCode(base, startResp, verificationURL)
+	} else {
+		o.logf("🧾 User Code: %s\n", valueOrDash(startResp.UserCode))
+		o.logf("🌐 Verification URL: %s\n", verbatim(verificationURL))
+	}
+	if o.qr {
+		o.printQR(verificationURL)
+	}
+	if o.noBrowser || o.isNonInteractive() || headless {
+		o.logf("🧭 Open the URL above in your browser and complete login.\n")
 	} else if err := openBrowser(verificationURL); err != nil {
-		logf("⚠️  Failed to open browser automatically: %v\n", err)
-		logf("🧭 Open the URL above in your browser and complete login.\n")
+		o.logf("⚠️  Failed to open browser automatically: %v\n", err)
+		o.logf("🧭 Open the URL above in your browser and complete login.\n")
 	} else {
-		logf("🧭 Browser opened. Complete login to continue...\n")
+		o.logf("🧭 Browser opened. Complete login to continue...\n")
 	}
 
 	interval := time.Duration(startResp.Interval) * time.Second
@@ -135,28 +216,12 @@ func runLogin(cmd *cobra.Command, args []string) error {
 		interval = 5 * time.Second
 	}
 
-	logf("⏳ Waiting for authorization...\n")
-	apiKey, err := pollForDeviceToken(pollURL, startResp.DeviceCode, interval, time.Duration(loginTimeoutSec)*time.Second)
-	if err != nil {
-		return newCLIError("login_failed", "device login failed", 2, err)
-	}
-
-	configPath, err := resolveConfigWritePath()
+	o.logf("⏳ Waiting for authorization...\n")
+	apiKey, err := pollForDeviceToken(pollURL, startResp.DeviceCode, interval, time.Duration(o.timeoutSec)*time.Second)
 	if err != nil {
-		return newCLIError("config_error", "failed to resolve config path", 1, err)
+		return "", newCLIError("login_failed", "device login failed", ExitAPI, err)
 	}
-	if err := writeCredentialsToConfig(configPath, base, apiKey); err != nil {
-		return newCLIError("config_write_failed", "failed to write credentials to config", 1, err)
-	}
-
-	logf("✅ Login successful. Credentials saved to: %s\n", configPath)
-	if err := emitSuccess(cmd.Name(), loginResponse{
-		BaseURL:    base,
-		ConfigFile: configPath,
-	}); err != nil {
-		return newCLIError("output_error", "failed to render JSON output", 1, err)
-	}
-	return nil
+	return apiKey, nil
 }
 
 func startDeviceLogin(startURL string) (*deviceStartResponse, error) {
@@ -165,6 +230,7 @@ func startDeviceLogin(startURL string) (*deviceStartResponse, error) {
 		return nil, fmt.Errorf("failed to create device-start request: %w", err)
 	}
 	req.Header.Set("Accept", "application/json")
+	req.Header.Set(client.APIVersionHeader, client.APIVersion)
 
 	httpClient := &http.Client{Timeout: 20 * time.Second}
 	resp, err := httpClient.Do(req)
@@ -177,6 +243,9 @@ func startDeviceLogin(startURL string) (*deviceStartResponse, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read device-start response: %w", err)
 	}
+	if err := client.CheckAPIVersion(resp, rawBody); err != nil {
+		return nil, err
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return nil, fmt.Errorf("device-start API error (status %d): %s", resp.StatusCode, compactForError(rawBody))
 	}
@@ -249,6 +318,7 @@ func pollDeviceToken(pollURL, deviceCode string) (string, error) {
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	req.Header.Set(client.APIVersionHeader, client.APIVersion)
 
 	httpClient := &http.Client{Timeout: 20 * time.Second}
 	resp, err := httpClient.Do(req)
@@ -261,6 +331,9 @@ func pollDeviceToken(pollURL, deviceCode string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to read device poll response: %w", err)
 	}
+	if err := client.CheckAPIVersion(resp, rawBody); err != nil {
+		return "", err
+	}
 
 	var parsed devicePollResponse
 	_ = json.Unmarshal(rawBody, &parsed)
@@ -427,9 +500,9 @@ func compactForError(raw []byte) string {
 	return s
 }
 
-func resolveConfigWritePath() (string, error) {
-	if strings.TrimSpace(cfgFile) != "" {
-		return strings.TrimSpace(cfgFile), nil
+func (a *app) resolveConfigWritePath() (string, error) {
+	if strings.TrimSpace(a.cfgFile) != "" {
+		return strings.TrimSpace(a.cfgFile), nil
 	}
 	return resolveDefaultConfigPath()
 }
@@ -449,8 +522,27 @@ func writeCredentialsToConfig(path, baseURL, apiKey string) error {
 	if cfg == nil {
 		cfg = map[string]interface{}{}
 	}
+	credentials, _ := cfg[credentialsConfigKey].(map[string]interface{})
+	if credentials == nil {
+		credentials = map[string]interface{}{}
+	}
+	// Move a legacy top-level key under the server it was saved for, so it
+	// is not sent to the new base URL.
+	if oldKey, ok := cfg["api_key"].(string); ok && strings.TrimSpace(oldKey) != "" {
+		if oldBase, ok := cfg["base_url"].(string); ok && credentialKey(oldBase) != "" && !hasCredential(credentials, oldBase) {
+			credentials[strings.TrimRight(strings.TrimSpace(oldBase), "/")] = strings.TrimSpace(oldKey)
+		}
+	}
+	delete(cfg, "api_key")
+	for url := range credentials {
+		if credentialKey(url) == credentialKey(baseURL) {
+			delete(credentials, url)
+		}
+	}
+	credentials[strings.TrimRight(strings.TrimSpace(baseURL), "/")] = strings.TrimSpace(apiKey)
+	cfg[credentialsConfigKey] = credentials
+	cfg["version"] = currentConfigVersion
 	cfg["base_url"] = strings.TrimSpace(baseURL)
-	cfg["api_key"] = strings.TrimSpace(apiKey)
 
 	out, err := yaml.Marshal(cfg)
 	if err != nil {
@@ -466,6 +558,37 @@ func writeCredentialsToConfig(path, baseURL, apiKey string) error {
 	return nil
 }
 
+// headlessSession reports whether no local browser can be opened: an SSH
+// session, or a Unix desktop-less session without DISPLAY/WAYLAND_DISPLAY.
+func headlessSession() bool {
+	if os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "" {
+		return true
+	}
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		return false
+	}
+	return os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == ""
+}
+
+// printDeviceCode prints the short verification URL and a boxed user code
+// that is easy to read and type on another device. Accessible mode gets
+// plain lines instead of the box.
+func (o *loginOptions) printDeviceCode(base string, startResp *deviceStartResponse, verificationURL string) {
+	short := resolveURLAgainstBase(base, strings.TrimSpace(startResp.VerificationURI))
+	code := strings.TrimSpace(startResp.UserCode)
+	if short == "" || code == "" || o.isAccessible() {
+		o.logf("🧾 User Code: %s\n", valueOrDash(code))
+		o.logf("🌐 Verification URL: %s\n", verbatim(verificationURL))
+		return
+	}
+	spaced := strings.Join(strings.Split(code, ""), " ")
+	bar := strings.Repeat("─", len([]rune(spaced))+6)
+	o.logf("\n🖥️  No browser available here. On any device, open:\n\n      %s\n\n", verbatim(short))
+	o.logf("   and enter the code:\n\n      ┌%s┐\n      │   %s   │\n      └%s┘\n\n", bar, spaced, bar)
+	o.logf("🌐 Or open the full link: %s\n", verbatim(verificationURL))
+}
+
 func openBrowser(target string) error {
 	var cmd *exec.Cmd
 	switch runtime.GOOS {
@@ -479,6 +602,15 @@ func openBrowser(target string) error {
 	return cmd.Start()
 }
 
+// formatIdentity names an account, adding the organization when known.
+func formatIdentity(identity *client.Identity) string {
+	name := valueOrDash(firstNonEmpty(identity.Username, identity.Email, identity.UserID))
+	if identity.Org != "" {
+		return fmt.Sprintf("%s (%s)", name, identity.Org)
+	}
+	return name
+}
+
 func firstNonEmpty(values ...string) string {
 	for _, value := range values {
 		if strings.TrimSpace(value) != "" {
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+)
+
+var orgSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+const ssoCallbackPath = "/callback"
+
+type ssoCallback struct {
+	code string
+	err  error
+}
+
+// ssoLogin runs the OIDC authorization-code flow with PKCE through a loopback
+// redirect and returns the API token the server issues for the code.
+func (o *loginOptions) ssoLogin(ctx context.Context, base, org string) (string, error) {
+	if !orgSlugPattern.MatchString(org) {
+		return "", newCLIError("invalid_argument", fmt.Sprintf("invalid --sso organization slug %q", org), ExitGeneral, nil)
+	}
+	authorizeURL, err := resolveEndpoint(base, strings.TrimSpace(o.ssoStartPath))
+	if err != nil {
+		return "", newCLIError("invalid_argument", "invalid --sso-authorize-path", ExitGeneral, err)
+	}
+	tokenURL, err := resolveEndpoint(base, strings.TrimSpace(o.ssoTokenPath))
+	if err != nil {
+		return "", newCLIError("invalid_argument", "invalid --sso-token-path", ExitGeneral, err)
+	}
+
+	state, err := randomURLToken()
+	if err != nil {
+		return "", newCLIError("login_start_failed", "failed to generate SSO state", ExitGeneral, err)
+	}
+	verifier, err := randomURLToken()
+	if err != nil {
+		return "", newCLIError("login_start_failed", "failed to generate PKCE verifier", ExitGeneral, err)
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", newCLIError("login_start_failed", "failed to start the local SSO callback listener", ExitGeneral, err)
+	}
+	redirectURI := fmt.Sprintf("http://%s%s", listener.Addr().String(), ssoCallbackPath)
+
+	results := make(chan ssoCallback, 1)
+	server := &http.Server{Handler: ssoCallbackHandler(state, results), ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = server.Serve(listener) }()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	u, err := url.Parse(authorizeURL)
+	if err != nil {
+		return "", newCLIError("invalid_argument", "invalid --sso-authorize-path", ExitGeneral, err)
+	}
+	q := u.Query()
+	q.Set("org", org)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("state", state)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+	loginURL := u.String()
+
+	o.logf("🔐 Starting SSO login for organization %s...\n", org)
+	o.logf("🌐 Login URL: %s\n", verbatim(loginURL))
+	if headlessSession() {
+		o.logf("⚠️  No local browser: the login redirects to %s, so open the URL in a browser that can reach it (e.g. forward port %d over SSH) or log in without --sso.\n", redirectURI, listener.Addr().(*net.TCPAddr).Port)
+	} else if o.noBrowser || o.isNonInteractive() {
+		o.logf("🧭 Open the URL above in a browser on this machine and complete login.\n")
+	} else if err := openBrowser(loginURL); err != nil {
+		o.logf("⚠️  Failed to open browser automatically: %v\n", err)
+		o.logf("🧭 Open the URL above in a browser on this machine and complete login.\n")
+	} else {
+		o.logf("🧭 Browser opened. Complete login with your identity provider to continue...\n")
+	}
+
+	o.logf("⏳ Waiting for the identity provider to redirect back...\n")
+	timer := time.NewTimer(time.Duration(o.timeoutSec) * time.Second)
+	defer timer.Stop()
+	var code string
+	select {
+	case res := <-results:
+		if res.err != nil {
+			return "", newCLIError("login_failed", "SSO login failed", ExitAuth, res.err)
+		}
+		code = res.code
+	case <-timer.C:
+		return "", newCLIError("login_failed", fmt.Sprintf("SSO login timed out after %d seconds", o.timeoutSec), ExitAuth, nil)
+	case <-ctx.Done():
+		return "", newCLIError("cancelled", "SSO login cancelled", ExitCancelled, ctx.Err())
+	}
+
+	apiKey, err := exchangeSSOCode(tokenURL, org, code, verifier, redirectURI)
+	if err != nil {
+		return "", newCLIError("login_failed", "failed to exchange the SSO authorization code", ExitAPI, err)
+	}
+	return apiKey, nil
+}
+
+// ssoCallbackHandler accepts the first redirect carrying the expected state
+// and reports its code or error on results.
+func ssoCallbackHandler(state string, results chan<- ssoCallback) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ssoCallbackPath, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("state") != state {
+			http.Error(w, "Login state does not match; start the login again.", http.StatusBadRequest)
+			return
+		}
+		res := ssoCallback{code: strings.TrimSpace(q.Get("code"))}
+		switch {
+		case q.Get("error") != "":
+			res.err = fmt.Errorf("identity provider returned %s: %s", q.Get("error"), q.Get("error_description"))
+		case res.code == "":
+			res.err = errors.New("callback is missing the authorization code")
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		message := "Login complete. You can close this tab and return to the terminal."
+		if res.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			message = "Login failed: " + res.err.Error()
+		}
+		fmt.Fprintf(w, "<!doctype html><title>RobotX CLI</title><p>%s</p>\n", html.EscapeString(message))
+
+		select {
+		case results <- res:
+		default:
+		}
+	})
+	return mux
+}
+
+func exchangeSSOCode(tokenURL, org, code, verifier, redirectURI string) (string, error) {
+	body, err := json.Marshal(map[string]string{
+		"org":           org,
+		"code":          code,
+		"code_verifier": verifier,
+		"redirect_uri":  redirectURI,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode SSO token payload: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, tokenURL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create SSO token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set(client.APIVersionHeader, client.APIVersion)
+
+	httpClient := &http.Client{Timeout: 20 * time.Second}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("SSO token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rawBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read SSO token response: %w", err)
+	}
+	if err := client.CheckAPIVersion(resp, rawBody); err != nil {
+		return "", err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("SSO token API error (status %d): %s", resp.StatusCode, compactForError(rawBody))
+	}
+	token := extractAPIKey(rawBody)
+	if token == "" {
+		return "", fmt.Errorf("SSO token response missing access token: %s", compactForError(rawBody))
+	}
+	return token, nil
+}
+
+// randomURLToken returns 32 random bytes encoded for use in URLs.
+func randomURLToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -1,40 +1,243 @@
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
-var logsCmd = &cobra.Command{
-	Use:   "logs [build-id]",
-	Short: "Deprecated: build logs are unavailable",
-	Long:  "Deprecated: RobotX no longer provides remote build logs because build execution happens locally.",
-	Args:  cobra.MaximumNArgs(1),
-	RunE:  runLogs,
-}
-
-var (
-	logsProjectID string
-	logsBuildID   string
-	logsFollow    bool
+	"github.com/spf13/cobra"
 )
 
+// logsFollowInterval is how often logs --follow checks for new output.
+const logsFollowInterval = 500 * time.Millisecond
+
+type logsOptions struct {
+	*app
+	projectID string
+	buildID   string
+	follow    bool
+	grep      string
+	level     string
+	latest    int
+}
+
 type logsResponse struct {
 	ProjectID string `json:"project_id,omitempty"`
 	BuildID   string `json:"build_id"`
+	Path      string `json:"path,omitempty"`
 	Logs      string `json:"logs"`
 }
 
-func init() {
-	rootCmd.AddCommand(logsCmd)
+type multiLogsResponse struct {
+	ProjectID string          `json:"project_id"`
+	Builds    []buildLogEntry `json:"builds"`
+}
+
+// buildLogEntry is the log of one of the builds shown by logs --latest.
+// Available is false for builds without a local log, e.g. ones built on
+// another machine.
+type buildLogEntry struct {
+	BuildID    string `json:"build_id"`
+	VersionSeq int64  `json:"version_seq,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Available  bool   `json:"available"`
+	Path       string `json:"path,omitempty"`
+	Logs       string `json:"logs,omitempty"`
+}
+
+func newLogsCmd(a *app) *cobra.Command {
+	o := &logsOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "logs [build-id]",
+		Short: "Show the output of a local build",
+		Long: `Show the install and build output of a local build.
+
+Builds run locally, so their output is kept in the project directory that ran
+the deploy (.robotx/logs/<build-id>.log, the last 20 builds). Run this command
+from inside that directory; without a build ID it shows the last build.
+
+--grep and --level filter lines as they are read, so large logs need not be
+dumped first. Levels are guessed from each line's text.
+
+--latest N shows the logs of the project's last N builds one after another,
+each under a header with its build ID, version and status, to compare a
+failing build with the previous passing one.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (optional)")
+	cmd.Flags().StringVarP(&o.buildID, "build-id", "b", "", "Build ID")
+	cmd.Flags().BoolVarP(&o.follow, "follow", "f", false, "Keep printing new output until interrupted")
+	cmd.Flags().StringVar(&o.grep, "grep", "", "Only show lines matching this regular expression")
+	cmd.Flags().StringVar(&o.level, "level", "", "Only show lines at or above this level: info, warn or error")
+	cmd.Flags().IntVar(&o.latest, "latest", 0, "Show the logs of the project's last N builds")
+	return cmd
+}
+
+func (o *logsOptions) run(cmd *cobra.Command, args []string) error {
+	buildID := o.buildID
+	if len(args) > 0 {
+		buildID = args[0]
+	}
+	filter, err := newLogFilter(o.grep, o.level)
+	if err != nil {
+		return err
+	}
+	if o.follow && o.isJSONOutput() {
+		return newCLIError("invalid_argument", "--follow cannot be combined with JSON output", ExitGeneral, nil)
+	}
+	if o.latest < 0 {
+		return newCLIError("invalid_argument", "--latest must be positive", ExitGeneral, nil)
+	}
+	if o.latest > 0 && (buildID != "" || o.follow) {
+		return newCLIError("invalid_argument", "--latest cannot be combined with a build ID or --follow", ExitGeneral, nil)
+	}
+
+	projectID, st := stateProjectID(o.projectID)
+	if st == nil {
+		return newCLIError("not_found", "no local build logs found; run this command inside the project directory that ran the deploy", ExitNotFound, nil)
+	}
+	if o.latest > 0 {
+		return o.runLatest(cmd, projectID, st, filter)
+	}
+	if buildID == "" {
+		buildID = st.lastBuildID()
+	}
+	if buildID == "" {
+		return newCLIError("missing_argument", "build ID is required", ExitGeneral, nil)
+	}
+	path := st.buildLogPath(buildID)
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return newCLIError("not_found", fmt.Sprintf("no local build log for build %s", buildID), ExitNotFound, nil)
+		}
+		return newCLIError("read_failed", "failed to read build log", ExitGeneral, err)
+	}
+	defer file.Close()
+
+	if !o.isJSONOutput() {
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return streamLog(ctx, file, o.out(), filter, o.follow)
+	}
+
+	var logs strings.Builder
+	if err := streamLog(cmd.Context(), file, &logs, filter, false); err != nil {
+		return err
+	}
+	if err := o.emitSuccess(cmd.Name(), logsResponse{
+		ProjectID: projectID,
+		BuildID:   buildID,
+		Path:      path,
+		Logs:      logs.String(),
+	}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// runLatest prints the logs of the project's last o.latest builds, newest
+// first.
+func (o *logsOptions) runLatest(cmd *cobra.Command, projectID string, st *deployState, filter *logFilter) error {
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	builds, err := c.ListBuildsForProject(projectID, o.latest)
+	if err != nil {
+		return newCLIError("api_error", "failed to list project builds", ExitAPI, err)
+	}
+	if len(builds) > o.latest {
+		builds = builds[:o.latest]
+	}
+
+	resp := multiLogsResponse{ProjectID: projectID, Builds: []buildLogEntry{}}
+	for i, b := range builds {
+		entry := buildLogEntry{BuildID: b.BuildID, VersionSeq: b.VersionSeq, Status: b.Status}
+		path := st.buildLogPath(b.BuildID)
+		file, err := os.Open(path)
+		if err == nil {
+			entry.Available = true
+			entry.Path = path
+		}
+
+		if !o.isJSONOutput() {
+			if i > 0 {
+				fmt.Fprintln(o.out())
+			}
+			fmt.Fprintf(o.out(), "==> %s (#%s, %s) <==\n", b.BuildID, formatBuildVersionSeq(b.VersionSeq), valueOrDash(b.StatusText()))
+			if !entry.Available {
+				fmt.Fprintln(o.out(), "(no local log)")
+			}
+		}
+		if !entry.Available {
+			resp.Builds = append(resp.Builds, entry)
+			continue
+		}
+
+		var logs strings.Builder
+		var w io.Writer = o.out()
+		if o.isJSONOutput() {
+			w = &logs
+		}
+		err = streamLog(cmd.Context(), file, w, filter, false)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		entry.Logs = logs.String()
+		resp.Builds = append(resp.Builds, entry)
+	}
 
-	logsCmd.Flags().StringVarP(&logsProjectID, "project-id", "p", "", "Project ID (optional)")
-	logsCmd.Flags().StringVarP(&logsBuildID, "build-id", "b", "", "Build ID")
-	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Follow logs in realtime (not implemented yet)")
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
 }
 
-func runLogs(cmd *cobra.Command, args []string) error {
-	_ = cmd
-	_ = args
-	_ = logsProjectID
-	_ = logsBuildID
-	_ = logsFollow
-	return newCLIError("unsupported_feature", "build logs are unavailable because RobotX no longer runs remote builds", 1, nil)
+// streamLog copies the lines of r that pass filter to w. With follow it keeps
+// waiting for new lines until ctx is done.
+func streamLog(ctx context.Context, r io.Reader, w io.Writer, filter *logFilter, follow bool) error {
+	reader := bufio.NewReader(r)
+	var partial string
+	for {
+		chunk, err := reader.ReadString('\n')
+		partial += chunk
+		if err == nil {
+			if line := strings.TrimRight(partial, "\r\n"); filter.match(line) {
+				fmt.Fprintln(w, line)
+			}
+			partial = ""
+			continue
+		}
+		if !errors.Is(err, io.EOF) {
+			return newCLIError("read_failed", "failed to read build log", ExitGeneral, err)
+		}
+		if !follow {
+			if partial != "" && filter.match(partial) {
+				fmt.Fprintln(w, partial)
+			}
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(logsFollowInterval):
+		}
+	}
 }
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+// maxMaintenanceMessage bounds the message shown on the maintenance page.
+const maxMaintenanceMessage = 500
+
+type maintenanceOptions struct {
+	*app
+	projectID string
+	message   string
+}
+
+type maintenanceResponse struct {
+	ProjectID   string `json:"project_id"`
+	Maintenance bool   `json:"maintenance"`
+	Message     string `json:"message,omitempty"`
+}
+
+func newMaintenanceCmd(a *app) *cobra.Command {
+	o := &maintenanceOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "maintenance",
+		Short: "Put a project's production site into maintenance mode",
+		Long: `Serve a maintenance page on the project's production URL, e.g. during a data
+migration, and switch back afterwards. The published build stays published,
+so "maintenance off" restores it without a redeploy. Previews are not affected.
+
+Inside a deployed project directory --project-id defaults to the recorded
+project. status shows whether maintenance mode is on.`,
+	}
+
+	onCmd := &cobra.Command{
+		Use:   "on",
+		Short: "Show the maintenance page on production",
+		Args:  cobra.NoArgs,
+		RunE:  o.runOn,
+	}
+	offCmd := &cobra.Command{
+		Use:   "off",
+		Short: "Serve the published build on production again",
+		Args:  cobra.NoArgs,
+		RunE:  o.runOff,
+	}
+	cmd.AddCommand(onCmd, offCmd)
+
+	cmd.PersistentFlags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	onCmd.Flags().StringVarP(&o.message, "message", "m", "", "Message shown on the maintenance page")
+	return cmd
+}
+
+// maintenanceClient checks the common settings of the maintenance
+// subcommands and returns a client for a server that supports them.
+func (o *maintenanceOptions) maintenanceClient() (*client.Client, string, error) {
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return nil, "", newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return nil, "", newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	projectID, _ := stateProjectID(o.projectID)
+	if projectID == "" {
+		return nil, "", newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityMaintenanceMode) {
+		return nil, "", newCLIError("unsupported_server", "this RobotX server does not support maintenance mode", ExitAPI, nil)
+	}
+	return c, projectID, nil
+}
+
+func (o *maintenanceOptions) runOn(cmd *cobra.Command, args []string) error {
+	message := strings.TrimSpace(o.message)
+	if utf8.RuneCountInString(message) > maxMaintenanceMessage {
+		return newCLIError("invalid_argument", fmt.Sprintf("--message must be at most %d characters", maxMaintenanceMessage), ExitGeneral, nil)
+	}
+	c, projectID, err := o.maintenanceClient()
+	if err != nil {
+		return err
+	}
+	if err := o.confirm(fmt.Sprintf("Replace production of %s with the maintenance page", projectID)); err != nil {
+		return err
+	}
+	mode, err := c.EnableMaintenance(projectID, message)
+	if err != nil {
+		if client.IsNotFound(err) {
+			return newCLIError("not_found", fmt.Sprintf("project %s not found", projectID), ExitNotFound, err)
+		}
+		return newCLIError("api_error", "failed to turn on maintenance mode", ExitAPI, err)
+	}
+	o.logf("🚧 Production of %s now shows the maintenance page\n", projectID)
+
+	if err := o.emitSuccess("maintenance "+cmd.Name(), maintenanceResponse{ProjectID: projectID, Maintenance: true, Message: mode.Message}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+func (o *maintenanceOptions) runOff(cmd *cobra.Command, args []string) error {
+	c, projectID, err := o.maintenanceClient()
+	if err != nil {
+		return err
+	}
+	if err := c.DisableMaintenance(projectID); err != nil {
+		if client.IsNotFound(err) {
+			return newCLIError("not_found", fmt.Sprintf("project %s not found", projectID), ExitNotFound, err)
+		}
+		return newCLIError("api_error", "failed to turn off maintenance mode", ExitAPI, err)
+	}
+	o.logf("✅ Production of %s serves the published build again\n", projectID)
+
+	if err := o.emitSuccess("maintenance "+cmd.Name(), maintenanceResponse{ProjectID: projectID}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// formatMaintenance describes a project's maintenance mode for status.
+func formatMaintenance(m *client.MaintenanceMode) string {
+	switch {
+	case m == nil:
+		return "-"
+	case !m.Enabled:
+		return "off"
+	case m.Message != "":
+		return fmt.Sprintf("on (%q)", m.Message)
+	}
+	return "on"
+}
@@ -1,36 +1,559 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"os"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/haibingtown/robotx_cli/pkg/client"
+	"github.com/haibingtown/robotx_cli/pkg/mcp"
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
 	"github.com/spf13/cobra"
 )
 
-var mcpCmd = &cobra.Command{
-	Use:   "mcp",
-	Short: "Run as MCP (Model Context Protocol) server",
-	Long:  `Run RobotX CLI as an MCP server for integration with Claude Desktop and other MCP-compatible tools.`,
-	RunE:  runMCP,
+const mcpResourceScheme = "robotx://"
+
+type mcpOptions struct {
+	*app
+	listen string
+	token  string
+}
+
+func newMCPCmd(a *app) *cobra.Command {
+	o := &mcpOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Run as MCP (Model Context Protocol) server",
+		Long: `Run RobotX CLI as an MCP server over stdio for integration with Claude Desktop
+and other MCP-compatible tools.
+
+With --listen the server is served over HTTP instead, for remote agent
+frameworks: streamable HTTP at /mcp and legacy HTTP+SSE at /sse. Requests must
+carry "Authorization: Bearer <token>"; when --token is not set a random token
+is generated and printed on startup.
+
+Tools: deploy, list_projects, list_versions, get_status, publish.
+Resources:
+  robotx://projects
+  robotx://project/<project_id>
+  robotx://project/<project_id>/urls
+  robotx://project/<project_id>/builds
+  robotx://project/<project_id>/build/<build_id>`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVar(&o.listen, "listen", "", "Serve MCP over HTTP/SSE on this address (e.g. :8931) instead of stdio")
+	cmd.Flags().StringVar(&o.token, "token", "", "Bearer token required from HTTP clients (generated when empty)")
+	return cmd
+}
+
+func (o *mcpOptions) run(cmd *cobra.Command, args []string) error {
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required (use --base-url or set ROBOTX_BASE_URL)", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required (use --api-key or set ROBOTX_API_KEY)", ExitAuth, nil)
+	}
+
+	// stdout carries the JSON-RPC stream; route every diagnostic to stderr.
+	o.outputFormat = "json"
+
+	server := o.newMCPServer(baseURL, apiKey)
+	if strings.TrimSpace(o.listen) != "" {
+		return o.serveMCPHTTP(cmd.Context(), server, o.listen, o.token)
+	}
+	fmt.Fprintln(o.errOut(), "RobotX MCP server listening on stdio")
+	if err := server.ServeStdio(cmd.Context(), cmd.InOrStdin(), o.out()); err != nil && err != context.Canceled {
+		return newCLIError("mcp_error", "MCP server stopped", ExitGeneral, err)
+	}
+	return nil
+}
+
+func (a *app) serveMCPHTTP(ctx context.Context, server *mcp.Server, addr, token string) error {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		generated, err := generateToken()
+		if err != nil {
+			return newCLIError("mcp_error", "failed to generate MCP token", ExitGeneral, err)
+		}
+		token = generated
+		a.logf("🔑 MCP bearer token: %s\n", token)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return newCLIError("mcp_error", fmt.Sprintf("failed to listen on %s", addr), ExitGeneral, err)
+	}
+	a.metrics = newDeployMetrics()
+	mux := http.NewServeMux()
+	mux.Handle("/", server.HTTPHandler(token))
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		got := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		a.metrics.ServeHTTP(w, r)
+	})
+	httpServer := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	a.logf("🌐 RobotX MCP server listening on http://%s/mcp (SSE: /sse, metrics: /metrics)\n", listener.Addr())
+	if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return newCLIError("mcp_error", "MCP server stopped", ExitGeneral, err)
+	}
+	return nil
+}
+
+// generateToken returns a random bearer token for local servers.
+func generateToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// deployRequest holds deploy options accepted by the MCP tool and the daemon.
+type deployRequest struct {
+	Path         string `json:"path"`
+	Name         string `json:"name,omitempty"`
+	Publish      *bool  `json:"publish,omitempty"`
+	Wait         *bool  `json:"wait,omitempty"`
+	VersionLabel string `json:"version_label,omitempty"`
+	SourceRef    string `json:"source_ref,omitempty"`
+	Timeout      int    `json:"timeout,omitempty"`
+	SkipBinaries bool   `json:"skip_binaries,omitempty"`
+	Force        bool   `json:"force,omitempty"`
+}
+
+// args returns the equivalent deploy command line.
+func (in deployRequest) args() ([]string, error) {
+	if strings.TrimSpace(in.Path) == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	args := []string{"deploy", in.Path}
+	args = appendStringArg(args, "--name", in.Name)
+	args = appendStringArg(args, "--version-label", in.VersionLabel)
+	args = appendStringArg(args, "--source-ref", in.SourceRef)
+	if in.Publish != nil {
+		args = append(args, "--publish="+strconv.FormatBool(*in.Publish))
+	}
+	if in.Wait != nil {
+		args = append(args, "--wait="+strconv.FormatBool(*in.Wait))
+	}
+	if in.Timeout > 0 {
+		args = append(args, "--timeout", strconv.Itoa(in.Timeout))
+	}
+	if in.SkipBinaries {
+		args = append(args, "--skip-binaries")
+	}
+	if in.Force {
+		args = append(args, "--force")
+	}
+	return args, nil
+}
+
+func (a *app) newMCPServer(baseURL, apiKey string) *mcp.Server {
+	server := mcp.NewServer("robotx", version)
+	a.registerMCPTools(server)
+	server.SetResourceProvider(&mcpResources{
+		client:  a.newAPIClient(baseURL, apiKey),
+		baseURL: baseURL,
+	})
+	return server
+}
+
+func (a *app) registerMCPTools(server *mcp.Server) {
+	server.AddTool(mcp.Tool{
+		Name:        "deploy",
+		Description: "Package, build locally, upload, and (by default) publish a project directory to RobotX.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"path":          stringProp("Project directory to deploy"),
+			"name":          stringProp("Project name (create-or-update)"),
+			"publish":       boolProp("Publish to production after a successful build (default true)"),
+			"wait":          boolProp("Wait for build completion (default true)"),
+			"version_label": stringProp("Optional build version label, or auto to generate the next one"),
+			"source_ref":    stringProp("Optional source reference"),
+			"timeout":       intProp("Build timeout in seconds"),
+			"skip_binaries": boolProp("Leave large binary files out of uploaded archives"),
+			"force":         boolProp("Upload and build even if the source is unchanged since the last successful build"),
+		}, "path"),
+	}, func(ctx context.Context, raw json.RawMessage) (*mcp.ToolResult, error) {
+		var in deployRequest
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		args, err := in.args()
+		if err != nil {
+			return nil, err
+		}
+		return a.runCLIToolWithProgress(ctx, newDeployProgress(), args...)
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "list_projects",
+		Description: "List projects for the current account.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"limit": intProp("Maximum number of projects"),
+		}),
+	}, func(ctx context.Context, raw json.RawMessage) (*mcp.ToolResult, error) {
+		var in struct {
+			Limit int `json:"limit"`
+		}
+		_ = json.Unmarshal(raw, &in)
+		args := []string{"projects"}
+		if in.Limit > 0 {
+			args = append(args, "--limit", strconv.Itoa(in.Limit))
+		}
+		return a.runCLITool(ctx, args...)
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "list_versions",
+		Description: "List recent build versions for a project, newest first. Pass next_cursor from the result as cursor to get the next page.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"project_id": stringProp("Project ID"),
+			"limit":      intProp("Maximum number of versions"),
+			"cursor":     stringProp("Cursor from a previous page's next_cursor"),
+		}, "project_id"),
+	}, func(ctx context.Context, raw json.RawMessage) (*mcp.ToolResult, error) {
+		var in struct {
+			ProjectID string `json:"project_id"`
+			Limit     int    `json:"limit"`
+			Cursor    string `json:"cursor"`
+		}
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		args := []string{"versions", "--project-id", in.ProjectID}
+		if in.Limit > 0 {
+			args = append(args, "--limit", strconv.Itoa(in.Limit))
+		}
+		if in.Cursor != "" {
+			args = append(args, "--cursor", in.Cursor)
+		}
+		return a.runCLITool(ctx, args...)
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "get_status",
+		Description: "Get project and/or build status with preview and production URLs.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"project_id": stringProp("Project ID"),
+			"build_id":   stringProp("Build ID"),
+		}),
+	}, func(ctx context.Context, raw json.RawMessage) (*mcp.ToolResult, error) {
+		var in struct {
+			ProjectID string `json:"project_id"`
+			BuildID   string `json:"build_id"`
+		}
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		args := []string{"status"}
+		args = appendStringArg(args, "--project-id", in.ProjectID)
+		args = appendStringArg(args, "--build-id", in.BuildID)
+		return a.runCLITool(ctx, args...)
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "publish",
+		Description: "Publish a build to production.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"project_id": stringProp("Project ID"),
+			"build_id":   stringProp("Build ID"),
+		}, "project_id", "build_id"),
+	}, func(ctx context.Context, raw json.RawMessage) (*mcp.ToolResult, error) {
+		var in struct {
+			ProjectID string `json:"project_id"`
+			BuildID   string `json:"build_id"`
+		}
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		return a.runCLITool(ctx, "publish", "--project-id", in.ProjectID, "--build-id", in.BuildID)
+	})
+}
+
+// runCLITool runs a robotx command in-process with --json and returns its
+// JSON envelope.
+func (a *app) runCLITool(ctx context.Context, args ...string) (*mcp.ToolResult, error) {
+	return a.runCLIToolWithProgress(ctx, nil, args...)
+}
+
+// runCLIToolWithProgress is runCLITool that also forwards the events of the
+// deploy pipeline as MCP progress notifications. Cancelling ctx cancels the
+// command.
+func (a *app) runCLIToolWithProgress(ctx context.Context, progress *deployProgress, args ...string) (*mcp.ToolResult, error) {
+	var events func([]pipeline.Step) pipeline.Emitter
+	if progress != nil {
+		events = func(steps []pipeline.Step) pipeline.Emitter {
+			progress.steps += len(steps)
+			return pipeline.EmitterFunc(func(e pipeline.Event) {
+				if value, message, ok := progress.next(e); ok {
+					mcp.ReportProgress(ctx, value, progress.total(), message)
+				}
+			})
+		}
+	}
+	started := time.Now()
+	stdout, stderr, err := a.runCommand(ctx, nil, events, args...)
+	if len(args) > 0 && args[0] == "deploy" {
+		a.metrics.observeDeploy(stdout, stderr, err, ctx.Err() != nil, time.Since(started))
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return mcp.TextResult(lastNonEmptyLine(stderr, err.Error()), true), nil
+	}
+	return mcp.TextResult(strings.TrimSpace(stdout), false), nil
 }
 
-func init() {
-	rootCmd.AddCommand(mcpCmd)
+// runCommand runs a robotx command on a fresh command tree in this process,
+// with --json --non-interactive and the current credentials, and returns
+// what it printed. Log lines are also written to logs when set, and the
+// events of a deploy pipeline go to the emitter events returns for its
+// steps. The command reads no input.
+func (a *app) runCommand(ctx context.Context, logs io.Writer, events func([]pipeline.Step) pipeline.Emitter, args ...string) (string, string, error) {
+	child := newApp()
+	child.deployEvents = events
+	full := []string{"--json", "--non-interactive",
+		"--base-url", a.v.GetString("base_url"),
+		"--api-key", a.v.GetString("api_key"),
+	}
+	if strings.TrimSpace(a.cfgFile) != "" {
+		full = append(full, "--config", a.cfgFile)
+	}
+	full = append(full, args...)
+
+	var stdout, stderr bytes.Buffer
+	var errOut io.Writer = &stderr
+	if logs != nil {
+		errOut = io.MultiWriter(&stderr, logs)
+	}
+	child.root.SetArgs(full)
+	child.root.SetIn(strings.NewReader(""))
+	child.root.SetOut(&stdout)
+	child.root.SetErr(errOut)
+	if err := child.root.ExecuteContext(ctx); err != nil {
+		output := child.outputMode()
+		output.json = true
+		output.secrets = child.secrets()
+		output.writeError(errOut, err)
+		return stdout.String(), stderr.String(), err
+	}
+	return stdout.String(), stderr.String(), nil
 }
 
-func runMCP(cmd *cobra.Command, args []string) error {
-	fmt.Fprintln(os.Stderr, "MCP server mode is not yet implemented.")
-	fmt.Fprintln(os.Stderr, "For now, use the CLI commands directly:")
-	fmt.Fprintln(os.Stderr, "  robotx deploy --help")
-	fmt.Fprintln(os.Stderr, "  robotx versions --help")
-	fmt.Fprintln(os.Stderr, "  robotx status --help")
-	fmt.Fprintln(os.Stderr, "  robotx publish --help")
+// lineWriter invokes onLine for every complete, non-empty line written.
+type lineWriter struct {
+	pending []byte
+	onLine  func(string)
+}
 
-	return newCLIError("not_implemented", "MCP mode not yet implemented", 1, nil)
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimSpace(string(w.pending[:i])); line != "" {
+			w.onLine(line)
+		}
+		w.pending = w.pending[i+1:]
+	}
+	return len(p), nil
 }
 
-// Future MCP implementation would handle:
-// - tools/list: List available tools (deploy, versions, status, publish)
-// - tools/call: Execute tool with parameters
-// - resources/list: List available resources (projects, builds)
-// - resources/read: Read resource details
+// deployProgress converts deploy pipeline events into a strictly increasing
+// progress value where each step spans one unit.
+type deployProgress struct {
+	steps int
+	done  int
+	last  float64
+}
+
+func newDeployProgress() *deployProgress {
+	return &deployProgress{last: -1}
+}
+
+func (p *deployProgress) total() float64 {
+	return float64(p.steps)
+}
+
+// next returns the progress value and message to report for e, if any.
+func (p *deployProgress) next(e pipeline.Event) (float64, string, bool) {
+	value := float64(p.done)
+	message := e.Message
+	switch e.Type {
+	case pipeline.EventStepStarted:
+		message = e.Step
+	case pipeline.EventStepFinished, pipeline.EventStepSkipped:
+		p.done++
+		return 0, "", false
+	case pipeline.EventProgress:
+		if e.Total <= 0 {
+			return 0, "", false
+		}
+		value += float64(e.Sent) / float64(e.Total) * 0.99
+		message = ""
+	case pipeline.EventLog, pipeline.EventURL:
+	default:
+		return 0, "", false
+	}
+	if value <= p.last {
+		// Stay within the current step while still increasing.
+		value = p.last + (float64(p.done+1)-p.last)/2
+	}
+	p.last = value
+	return value, message, true
+}
+
+func lastNonEmptyLine(text, fallback string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			return line
+		}
+	}
+	return fallback
+}
+
+func appendStringArg(args []string, flag, value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return args
+	}
+	return append(args, flag, value)
+}
+
+func objectSchema(props map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringProp(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+func boolProp(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "boolean", "description": description}
+}
+
+func intProp(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "integer", "description": description}
+}
+
+// mcpResources exposes projects and builds as robotx:// resources.
+type mcpResources struct {
+	client  *client.Client
+	baseURL string
+}
+
+func (r *mcpResources) ListResources(ctx context.Context) ([]mcp.Resource, error) {
+	resources := []mcp.Resource{{
+		URI:         mcpResourceScheme + "projects",
+		Name:        "Projects",
+		Description: "Projects for the current account",
+		MimeType:    "application/json",
+	}}
+	projects, err := r.client.ListProjects(50)
+	if err != nil {
+		return nil, err
+	}
+	for _, project := range projects {
+		resources = append(resources, mcp.Resource{
+			URI:         mcpResourceScheme + "project/" + project.ProjectID,
+			Name:        valueOrDash(project.Name),
+			Description: fmt.Sprintf("RobotX project %s", project.ProjectID),
+			MimeType:    "application/json",
+		})
+	}
+	return resources, nil
+}
+
+func (r *mcpResources) ResourceTemplates() []mcp.ResourceTemplate {
+	return []mcp.ResourceTemplate{
+		{URITemplate: mcpResourceScheme + "project/{project_id}", Name: "Project", MimeType: "application/json"},
+		{URITemplate: mcpResourceScheme + "project/{project_id}/urls", Name: "Project URLs", MimeType: "application/json"},
+		{URITemplate: mcpResourceScheme + "project/{project_id}/builds", Name: "Project builds", MimeType: "application/json"},
+		{URITemplate: mcpResourceScheme + "project/{project_id}/build/{build_id}", Name: "Build", MimeType: "application/json"},
+	}
+}
+
+func (r *mcpResources) ReadResource(ctx context.Context, uri string) ([]mcp.ResourceContents, error) {
+	if !strings.HasPrefix(uri, mcpResourceScheme) {
+		return nil, resourceNotFound(uri)
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(uri, mcpResourceScheme), "/"), "/")
+
+	var payload interface{}
+	var err error
+	switch {
+	case len(parts) == 1 && parts[0] == "projects":
+		payload, err = r.client.ListProjects(50)
+	case len(parts) == 2 && parts[0] == "project":
+		payload, err = r.client.GetProject(parts[1])
+	case len(parts) == 3 && parts[0] == "project" && parts[2] == "urls":
+		var project *client.Project
+		project, err = r.client.GetProject(parts[1])
+		if err == nil {
+			payload = statusURLs{
+				PreviewURL:    projectPreviewURL(project, r.baseURL),
+				ProductionURL: resolvePublishURL(r.baseURL, project),
+			}
+		}
+	case len(parts) == 3 && parts[0] == "project" && parts[2] == "builds":
+		payload, err = r.client.ListBuildsForProject(parts[1], 20)
+	case len(parts) == 4 && parts[0] == "project" && parts[2] == "build":
+		payload, err = r.client.GetBuild(parts[1], parts[3])
+	default:
+		return nil, resourceNotFound(uri)
+	}
+	if err != nil {
+		if client.IsNotFound(err) {
+			return nil, resourceNotFound(uri)
+		}
+		return nil, err
+	}
+
+	raw, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{{URI: uri, MimeType: "application/json", Text: string(raw)}}, nil
+}
+
+func resourceNotFound(uri string) error {
+	return &mcp.Error{Code: mcp.CodeNotFound, Message: fmt.Sprintf("resource not found: %s", uri)}
+}
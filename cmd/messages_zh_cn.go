@@ -0,0 +1,340 @@
+package cmd
+
+// zhCNMessages is the Simplified Chinese catalog. Keys are the English
+// format strings or messages exactly as the commands write them; a
+// translation must use the same fmt verbs, reordered with explicit indexes
+// such as %[2]s where needed.
+var zhCNMessages = map[string]string{
+	// Common errors.
+	"base URL is required": "需要配置服务地址（base URL）",
+	"base URL is required (use --base-url or set ROBOTX_BASE_URL)": "需要配置服务地址（使用 --base-url 或设置 ROBOTX_BASE_URL）",
+	"API key is required": "需要配置 API Key",
+	"API key is required (use --api-key or set ROBOTX_API_KEY)":                     "需要配置 API Key（使用 --api-key 或设置 ROBOTX_API_KEY）",
+	"API key was rejected by the server":                                            "服务端拒绝了该 API Key",
+	"--project-id is required outside a deployed project directory":                 "不在已部署的项目目录中时必须指定 --project-id",
+	"--project-id and --build-id are required outside a deployed project directory": "不在已部署的项目目录中时必须指定 --project-id 和 --build-id",
+	"at least one of --project-id or --build-id is required":                        "至少需要指定 --project-id 或 --build-id 之一",
+	"build ID is required":                                 "需要指定构建 ID",
+	"failed to render JSON output":                         "生成 JSON 输出失败",
+	"failed to write CSV output":                           "写入 CSV 输出失败",
+	"failed to resolve config path":                        "无法确定配置文件路径",
+	"failed to write config file":                          "写入配置文件失败",
+	"failed to parse config file":                          "解析配置文件失败",
+	"failed to get project":                                "获取项目失败",
+	"failed to list projects":                              "获取项目列表失败",
+	"failed to list builds":                                "获取构建列表失败",
+	"failed to list project builds":                        "获取项目构建列表失败",
+	"failed to get build":                                  "获取构建失败",
+	"failed to list feature flags":                         "获取功能开关失败",
+	"failed to read build log":                             "读取构建日志失败",
+	"failed to publish":                                    "发布失败",
+	"failed to stage build":                                "暂存构建失败",
+	"failed to commit the staged build":                    "切换到暂存构建失败",
+	"failed to discard the staged build":                   "丢弃暂存构建失败",
+	"failed to package source":                             "打包源码失败",
+	"failed to package functions":                          "打包云函数失败",
+	"failed to create project":                             "创建项目失败",
+	"failed to run smoke test":                             "运行冒烟测试失败",
+	"failed to exchange the service token for credentials": "使用服务令牌换取凭证失败",
+	"--auth-mode service requires ROBOTX_SERVICE_TOKEN":    "--auth-mode service 需要设置 ROBOTX_SERVICE_TOKEN",
+	"invalid --output value (expected text or json)":       "--output 取值无效（应为 text 或 json）",
+	"profile not found in config: %s":                      "配置文件中不存在该 profile：%s",
+	"project %s not found":                                 "项目 %s 不存在",
+	"a project named %s already exists (%s)":               "名为 %s 的项目已存在（%s）",
+	"as a repository secret":                               "GitHub 仓库 secret",
+	"as a masked CI/CD variable":                           "GitLab masked CI/CD 变量",
+	"to a CircleCI context named robotx":                   "CircleCI 中名为 robotx 的 context",
+	"no schema for %q (run 'robotx schema' for the list)":  "没有 %q 的 schema（运行 'robotx schema' 查看列表）",
+	"⚠️  The server has deprecated the API version of this robotx (%s); upgrade soon with: %s\n":                       "⚠️  服务端已弃用此 robotx（%s）使用的 API 版本；请尽快升级：%s\n",
+	"⚠️  The server has deprecated the API version of this robotx (%s) and stops serving it on %s; upgrade with: %s\n": "⚠️  服务端已弃用此 robotx（%s）使用的 API 版本，将于 %s 停止服务；请升级：%s\n",
+	"   Details: %s\n":                       "   详情：%s\n",
+	"⬆️  Uploading %s to %s of %s (%s)...\n": "⬆️  正在上传 %s 到 %[3]s 的 %[2]s（%[4]s）...\n",
+	"✅ Updated %s\n":                         "✅ 已更新 %s\n",
+	"✅ Saved %s to %s (%s)\n":                "✅ 已将 %s 保存到 %s（%s）\n",
+	"%s not found in %s":                     "%[2]s 中不存在 %[1]s",
+	"this RobotX server does not support patching deployed files": "该 RobotX 服务端不支持修改已部署的文件",
+	"🧹 Purging the CDN cache of %s...\n":                          "🧹 正在刷新 %s 的 CDN 缓存...\n",
+	"🧹 Purging %s from the CDN cache of %s...\n":                  "🧹 正在从 %[2]s 的 CDN 缓存中刷新 %[1]s...\n",
+	"✅ Cache purge %s (%s)\n":                                     "✅ 缓存刷新状态：%s（%s）\n",
+	"✅ Cache purged\n":                                            "✅ 缓存已刷新\n",
+	"⚠️  Skipping cache purge: this RobotX server does not support purging the CDN cache\n": "⚠️  跳过缓存刷新：该 RobotX 服务端不支持刷新 CDN 缓存\n",
+	"⚠️  Failed to purge the CDN cache: %v\n":                                               "⚠️  刷新 CDN 缓存失败：%v\n",
+	"this RobotX server does not support purging the CDN cache":                             "该 RobotX 服务端不支持刷新 CDN 缓存",
+	"failed to purge the CDN cache":                                                         "刷新 CDN 缓存失败",
+	"--purge only applies to publishes that change production":                              "--purge 仅适用于会改变生产环境的发布",
+	"🔎 %d matches in %d of %d builds since %s\n":                                            "🔎 自 %[4]s 起，%[3]d 个构建中有 %[2]d 个共匹配 %[1]d 处\n",
+	"🔎 %d matches in %d builds since %s\n":                                                  "🔎 自 %[3]s 起，%[2]d 个构建共匹配 %[1]d 处\n",
+	"ℹ️  %d builds in the window have no local log: %s\n":                                   "ℹ️  时间窗口内有 %d 个构建没有本地日志：%s\n",
+	"failed to search build logs":                                                           "搜索构建日志失败",
+	"this RobotX server does not search build logs and no local build logs were found; run this command inside the project directory that ran the deploys": "该 RobotX 服务端不支持搜索构建日志，且未找到本地构建日志；请在执行部署的项目目录中运行此命令",
+	"this RobotX server does not record build events; use 'robotx logs' for the build output":                                                              "该 RobotX 服务端不记录构建事件；请使用 'robotx logs' 查看构建输出",
+	"failed to get build events": "获取构建事件失败",
+	"⚠️  %s has no /index.html at its root but a single %s/ directory; the runtime serves the archive root, so package the contents of %s/ instead\n": "⚠️  %[1]s 根目录下没有 /index.html，但有唯一的 %[2]s/ 目录；运行时以归档根目录提供服务，请改为打包 %[2]s/ 中的内容\n",
+	"⚠️  %s has no /index.html at its root\n": "⚠️  %s 根目录下没有 /index.html\n",
+	"📦 Serving %s (%d files)\n":               "📦 正在提供 %s（%d 个文件）\n",
+	"🌐 Preview: %s (Ctrl-C to stop)\n":        "🌐 预览地址：%s（按 Ctrl-C 停止）\n",
+	"%s is not a readable zip archive":        "%s 不是可读取的 zip 归档",
+	"%s contains no files":                    "%s 中没有文件",
+	"🔍 Hashing files in %s...\n":              "🔍 正在计算 %s 中文件的哈希...\n",
+	"✅ %s matches build %s (%d files)\n":      "✅ %s 与构建 %s 一致（%d 个文件）\n",
+	"⚠️  %s differs from build %s: %d added, %d removed, %d changed, %d unchanged\n": "⚠️  %s 与构建 %s 不一致：新增 %d 个，删除 %d 个，修改 %d 个，未变 %d 个\n",
+	"%s differs from build %s":                                          "%s 与构建 %s 不一致",
+	"this RobotX server does not provide artifact manifests":            "该 RobotX 服务端不提供产物清单",
+	"nothing is published yet; pass --build-id to compare with a build": "尚未发布任何构建；请通过 --build-id 指定要比较的构建",
+	"Take deploy lock":                       "获取部署锁",
+	"Deploy lock renewal failed (%d/%d): %v": "部署锁续期失败（%d/%d）：%v",
+	"deploy lock lost: %d renewals in a row failed: %v; another deploy may have taken the project, so this one was stopped; deploy again": "部署锁已丢失：连续 %d 次续期失败：%v；其他部署可能已接管该项目，本次部署已停止，请重新部署",
+	"Version label %s was generated without the deploy lock; a concurrent deploy may pick the same label":                                 "版本标签 %s 是在未持有部署锁时生成的，并发部署可能得到相同标签",
+	"Deploy lock acquired":                       "已获取部署锁",
+	"%s; waiting up to %s for it to be released": "%s；最多等待 %s 直到锁被释放",
+	"--lock-timeout cannot be negative":          "--lock-timeout 不能为负数",
+	"⚠️  Failed to release the deploy lock; it expires on its own, or run 'robotx unlock': %v\n": "⚠️  释放部署锁失败；锁会自动过期，也可运行 'robotx unlock'：%v\n",
+	"this RobotX server does not lock deploys":                                                   "该 RobotX 服务端不支持部署锁",
+	"failed to get the deploy lock":                                                              "获取部署锁信息失败",
+	"failed to release the deploy lock":                                                          "释放部署锁失败",
+	"failed to take the deploy lock":                                                             "获取部署锁失败",
+	"Release the deploy lock of %s held by %s":                                                   "释放 %s 由 %s 持有的部署锁",
+	"🔓 %s is not locked\n":                                                                       "🔓 %s 未加锁\n",
+	"🔓 Released the deploy lock of %s\n":                                                         "🔓 已释放 %s 的部署锁\n",
+	"🎯 Target %s (%d/%d): %s\n":                                                                  "🎯 构建目标 %s（%d/%d）：%s\n",
+	"⚠️  Target %s failed after %d of %d target(s) were deployed\n":                              "⚠️  构建目标 %s 失败，%[3]d 个目标中已部署 %[2]d 个\n",
+	"♻️  Reusing the source archive packaged for target %s\n":                                    "♻️  复用为构建目标 %s 打包的源码归档\n",
+	"--target cannot be combined with --all-targets":                                             "--target 不能与 --all-targets 同时使用",
+	"--from-stdin cannot be combined with build targets":                                         "--from-stdin 不能与构建目标同时使用",
+	"unknown build target %q (defined: %s)":                                                      "未知的构建目标 %q（已定义：%s）",
+	"%s defines no build targets":                                                                "%s 未定义任何构建目标",
+	"Sync preview env":                                                                           "同步预览环境变量",
+	"Sync production env":                                                                        "同步生产环境变量",
+	"Synced %s env: %d added, %d changed, %d removed":                                            "已同步 %s 环境变量：新增 %d 个，修改 %d 个，删除 %d 个",
+	"The %s env is up to date (%d variables)":                                                    "%s 环境变量已是最新（%d 个变量）",
+	"✅ Synced production env: %d added, %d changed, %d removed\n":                                "✅ 已同步 production 环境变量：新增 %d 个，修改 %d 个，删除 %d 个\n",
+	"🔧 The production env is up to date (%d variables)\n":                                        "🔧 production 环境变量已是最新（%d 个变量）\n",
+	"%s: up to date":                                                                  "%s：已是最新",
+	"failed to sync the preview env":                                                  "同步预览环境变量失败",
+	"failed to sync the production env":                                               "同步生产环境变量失败",
+	"this RobotX server does not support per-channel env":                             "该 RobotX 服务端不支持按渠道设置环境变量",
+	"This RobotX server does not support per-channel env; env in %s is ignored":       "该 RobotX 服务端不支持按渠道设置环境变量，已忽略 %s 中的 env",
+	"⚠️  This RobotX server does not support per-channel env; env in %s is ignored\n": "⚠️  该 RobotX 服务端不支持按渠道设置环境变量，已忽略 %s 中的 env\n",
+	"%s declares no env":                                                              "%s 未声明 env",
+	"the env of %s differs from %s":                                                   "%s 的环境变量与 %s 不一致",
+	"unknown env channel(s) %s in %s (use production or preview)":                     "%[2]s 中存在未知的 env 渠道 %[1]s（可用 production 或 preview）",
+	"failed to expand %s: %v":                                                         "展开 %s 失败：%v",
+	"Generate version label":                                                          "生成版本标签",
+	"Version label: %s":                                                               "版本标签：%s",
+	"🏷️  Build version label: next %s label\n":                                        "🏷️  构建版本标签：按 %s 方案自动生成\n",
+	"failed to generate the version label":                                            "生成版本标签失败",
+	"Check version label":                                                             "检查版本标签",
+	"Version label %s belongs to build %s of the same source; retrying it":            "版本标签 %s 属于相同源码的构建 %s；继续重试",
+	"failed to check the version label":                                               "检查版本标签失败",
+	"🏷️  Releasing %s (%s)\n":                                                         "🏷️  发布 %s（%s）\n",
+	"⚠️  This RobotX server does not store release notes; they are only included in the output\n":           "⚠️  当前 RobotX 服务端不保存发布说明；仅包含在输出中\n",
+	"⚠️  Failed to attach the release notes: %v\n":                                                          "⚠️  附加发布说明失败：%v\n",
+	"📝 Attached the release notes of %s to build %s\n":                                                      "📝 已将 %s 的发布说明附加到构建 %s\n",
+	"⏳ Publish request %s created for build %s; production is unchanged until it is approved\n":             "⏳ 已创建发布请求 %s（构建 %s）；审批通过前生产环境保持不变\n",
+	"👉 Run 'robotx approvals approve %s' to publish, or 'robotx approvals reject %s'\n":                     "👉 运行 'robotx approvals approve %s' 发布，或 'robotx approvals reject %s' 拒绝\n",
+	"✅ Approved publish request %s\n":                                                                       "✅ 已批准发布请求 %s\n",
+	"🚫 Rejected publish request %s; build %s was not published\n":                                           "🚫 已拒绝发布请求 %s；构建 %s 未发布\n",
+	"⚠️  Failed to post the publish request to Slack: %v\n":                                                 "⚠️  发布请求发送到 Slack 失败：%v\n",
+	"💬 Posted publish request %s to Slack\n":                                                                "💬 已将发布请求 %s 发送到 Slack\n",
+	"⏳ Waiting up to %s for publish request %s to be decided...\n":                                          "⏳ 最多等待 %s，直到发布请求 %s 被处理...\n",
+	"✅ Publish request %s approved by %s\n":                                                                 "✅ 发布请求 %s 已由 %s 批准\n",
+	"📎 Attached the license report to build %s\n":                                                           "📎 已将许可证报告附加到构建 %s\n",
+	"✅ No API keys to %s in %s\n":                                                                           "✅ %[2]s 中没有需要 %[1]s 的 API Key\n",
+	"🔐 %s: %s\n":                                                                                            "🔐 %s：%s\n",
+	"💡 The new API key is stored in plaintext; run 'robotx config encrypt' to encrypt it like the others\n": "💡 新的 API Key 以明文保存；运行 'robotx config encrypt' 将其与其他 Key 一样加密\n",
+	"🔑 Config passphrase: ":                                                                                 "🔑 配置口令：",
+	"🔑 Repeat the passphrase: ":                                                                             "🔑 再次输入口令：",
+	"🔐 Using %s from Vault secret %s\n":                                                                     "🔐 使用 Vault secret %[2]s 中的 %[1]s\n",
+	"--vault-path requires VAULT_TOKEN (or a token saved by vault login)":                                   "--vault-path 需要 VAULT_TOKEN（或 vault login 保存的 Token）",
+	"flag --%s of robotx %s is deprecated":                                                                  "robotx %[2]s 的 --%[1]s 参数已弃用",
+	"flag --%s is deprecated":                                                                               "--%s 参数已弃用",
+	"command robotx %s is deprecated":                                                                       "robotx %s 命令已弃用",
+	" and will be removed in %s":                                                                            "，将在 %s 中移除",
+	"; use --%s instead":                                                                                    "；请改用 --%s",
+	"; use robotx %s instead":                                                                               "；请改用 robotx %s",
+	"build logs are no longer available":                                                                    "build 日志已不再提供",
+	"🔐 No API key saved for %s; run robotx login to add one\n":                                              "🔐 %s 没有保存的 API Key；请运行 robotx login 添加\n",
+	"⚠️  %s contains %d hard link(s), each uploaded as a separate copy:\n":                                  "⚠️  %s 包含 %d 个硬链接，每个都会作为单独的副本上传：\n",
+	"   - %s (hard link of %s)\n":                                                                           "   - %s（%s 的硬链接）\n",
+	"unknown CI provider %q (supported: %s)":                                                                "未知的 CI 平台 %q（支持：%s）",
+	"%s already exists; use --force to overwrite it":                                                        "%s 已存在；使用 --force 覆盖",
+	"✅ Wrote %s (%s project)\n":                                                                             "✅ 已写入 %s（%s 项目）\n",
+	"👉 Add ROBOTX_API_KEY %s, then commit the file\n":                                                       "👉 请添加 ROBOTX_API_KEY（%s），然后提交该文件\n",
+	"project has no successful build to compare with":                                                       "项目没有可供比较的成功构建",
+	"project %s has no successful build to publish":                                                         "项目 %s 没有可发布的成功构建",
+	"no earlier successful build to roll back to":                                                           "没有可回滚的更早的成功构建",
+	"unknown command %q (run 'robotx --help' for the list of commands)":                                     "未知命令 %q（运行 'robotx --help' 查看命令列表）",
+	"unknown command %q; did you mean '%s'?":                                                                "未知命令 %q；你是不是想运行 '%s'？",
+	"unknown command %q; did you mean one of: %s?":                                                          "未知命令 %q；你是不是想运行以下命令之一：%s？",
+	"unsupported language %q (supported: en, zh-CN)":                                                        "不支持的语言 %q（支持：en、zh-CN）",
+	"invalid base URL %q: %v (use the server's root URL, e.g. https://robotx.example.com)":                  "服务地址 %q 无效：%v（请使用服务端根地址，例如 https://robotx.example.com）",
+	"build logs are unavailable because RobotX no longer runs remote builds":                                "RobotX 已不再执行远程构建，因此没有构建日志",
+	"no local build logs found; run this command inside the project directory that ran the deploy":          "未找到本地构建日志；请在执行过部署的项目目录中运行此命令",
+	"RobotX no longer supports remote build; remove --local-build=false and run the build locally":          "RobotX 已不再支持远程构建；请去掉 --local-build=false 并在本地构建",
+	"this RobotX server does not accept local build artifacts; upgrade the server or point --base-url at a newer deployment": "该 RobotX 服务端不接受本地构建产物；请升级服务端或将 --base-url 指向更新的部署",
+	"this RobotX server does not support analytics":                       "该 RobotX 服务端不支持访问统计",
+	"this RobotX server does not support copying builds between projects": "该 RobotX 服务端不支持在项目间复制构建",
+	"this RobotX server does not support deleting builds":                 "该 RobotX 服务端不支持删除构建",
+	"this RobotX server does not support feature flags":                   "该 RobotX 服务端不支持功能开关",
+	"this RobotX server does not support maintenance mode":                "该 RobotX 服务端不支持维护模式",
+	"this RobotX server does not support pinning builds":                  "该 RobotX 服务端不支持固定构建",
+	"this RobotX server does not support preview protection":              "该 RobotX 服务端不支持预览保护",
+	"this RobotX server does not support project environment variables":   "该 RobotX 服务端不支持项目环境变量",
+	"this RobotX server does not support scheduled jobs":                  "该 RobotX 服务端不支持定时任务",
+	"this RobotX server does not support serverless functions":            "该 RobotX 服务端不支持云函数",
+	"this RobotX server does not support service-account tokens":          "该 RobotX 服务端不支持服务账号令牌",
+	"this RobotX server does not support share links":                     "该 RobotX 服务端不支持分享链接",
+	"this RobotX server does not support staged publishing":               "该 RobotX 服务端不支持分阶段发布",
+
+	// Deploy pipeline.
+	"Resolving project by name (create-or-update): %s": "按名称查找项目（不存在则创建）：%s",
+	"Project ready: %s":                                                           "项目已就绪：%s",
+	"Packaging source code from: %s":                                              "正在打包源码：%s",
+	"Source archive size: %.2f MB":                                                "源码包大小：%.2f MB",
+	"Source packaged: %s":                                                         "源码已打包：%s",
+	"Could not check latest commit, deploying anyway: %v":                         "无法检查最新提交，继续部署：%v",
+	"Build %s of the earlier upload did not succeed; uploading again":             "先前上传的构建 %s 未成功，重新上传",
+	"Source unchanged but the latest build did not succeed; building again":       "源码未变更但最近一次构建未成功，重新构建",
+	"Source unchanged but a new version label was requested; building again":      "源码未变更但需要新的版本标签，重新构建",
+	"Source unchanged but build %s is not labeled %s; building again":             "源码未变更但构建 %s 没有标签 %s，重新构建",
+	"Source unchanged since commit %s; reusing build %s (use --force to rebuild)": "自提交 %s 以来源码未变更，复用构建 %s（使用 --force 强制重新构建）",
+	"Streaming %s source archive...":                                              "正在流式上传 %s 源码包...",
+	"Uploading source code...":                                                    "正在上传源码...",
+	"Source uploaded: %s":                                                         "源码已上传：%s",
+	"Build plan: %s":                                                              "构建计划：%s",
+	"Source already uploaded by an earlier attempt: %s":                           "源码已由先前的尝试上传：%s",
+	"Build %s already succeeded; reusing it":                                      "构建 %s 已成功，直接复用",
+	"Build created: %s":                                                           "构建已创建：%s",
+	"Packaging build output from: %s":                                             "正在打包构建产物：%s",
+	"Build output packaged: %s":                                                   "构建产物已打包：%s",
+	"Packaging functions from: %s":                                                "正在打包云函数：%s",
+	"Functions packaged: %s":                                                      "云函数已打包：%s",
+	"Uploading functions...":                                                      "正在上传云函数...",
+	"Function %s: %s":                                                             "云函数 %s：%s",
+	"Uploading build artifacts...":                                                "正在上传构建产物...",
+	"Build artifacts uploaded":                                                    "构建产物已上传",
+	"Waiting for build to complete (timeout: %ds)...":                             "等待构建完成（超时：%d 秒）...",
+	"Build failed with status: %s":                                                "构建失败，状态：%s",
+	"Previous builds of this project took about %ds":                              "该项目以往的构建约需 %d 秒",
+	"Build status: %s (elapsed: %ds, about %ds remaining)":                        "构建状态：%s（已用时：%d 秒，预计还需 %d 秒）",
+	"Build status: %s (elapsed: %ds, longer than the usual %ds)":                  "构建状态：%s（已用时：%d 秒，超过通常的 %d 秒）",
+	"Build status: %s (elapsed: %ds)":                                             "构建状态：%s（已用时：%d 秒）",
+	"Local build completed successfully!":                                         "本地构建成功！",
+	"Publishing to production...":                                                 "正在发布到生产环境...",
+	"Published successfully!":                                                     "发布成功！",
+	"Smoke testing %d path(s) on %s":                                              "正在对 %[2]s 的 %[1]d 个路径进行冒烟测试",
+	"Build output: %s in %d file(s)":                                              "构建产物：%[2]d 个文件，共 %[1]s",
+	"Size budgets met":                                                            "体积预算已满足",
+	"%s budget exceeded: %s is %s (limit %s)":                                     "超出 %s 预算：%s 为 %s（上限 %s）",
+	"Size budgets not checked: the server has no artifact manifest for reused build %s": "未检查体积预算：服务端没有复用构建 %s 的产物清单",
+	"Size budgets not checked: the artifact manifest of reused build %s lists no files": "未检查体积预算：复用构建 %s 的产物清单中没有文件",
+	"Reading audit report %s":                                  "正在读取审计报告 %s",
+	"Running %s %s":                                            "正在运行 %s %s",
+	"Dependency audit (%s): no vulnerabilities":                "依赖审计（%s）：未发现漏洞",
+	"Dependency audit (%s): %s, none of %s severity or higher": "依赖审计（%s）：%s，没有 %s 及以上级别的漏洞",
+	"Dependency audit (%s): %s":                                "依赖审计（%s）：%s",
+	"... and %d more":                                          "... 以及另外 %d 个",
+	"Audit dependencies":                                       "审计依赖",
+	"Resolve project":                                          "确定项目",
+	"Package source":                                           "打包源码",
+	"Check for a reusable build":                               "检查可复用的构建",
+	"Upload source":                                            "上传源码",
+	"Build":                                                    "构建",
+	"Build functions":                                          "构建云函数",
+	"Package build output":                                     "打包构建产物",
+	"Check size budgets":                                       "检查体积预算",
+	"Package functions":                                        "打包云函数",
+	"Upload functions":                                         "上传云函数",
+	"Upload build output":                                      "上传构建产物",
+	"Wait for build":                                           "等待构建",
+	"Smoke test":                                               "冒烟测试",
+	"Publish":                                                  "发布",
+	"Preview URL":                                              "预览地址",
+	"Production URL":                                           "生产地址",
+
+	// Command output.
+	"⬆️  Upload progress: %d%%\n":         "⬆️  上传进度：%d%%\n",
+	"🏷️  Build version label: %s\n":       "🏷️  构建版本标签：%s\n",
+	"🔖 Source ref: %s\n":                  "🔖 源码引用：%s\n",
+	"🧭 Routes: %s\n":                      "🧭 路由：%s\n",
+	"🧩 Functions directory: %s/\n":        "🧩 云函数目录：%s/\n",
+	"🧩 Running package command: %s\n":     "🧩 正在执行打包命令：%s\n",
+	"⏱️  Timings: %s\n":                   "⏱️  耗时：%s\n",
+	"⚠️  %s contains %d large file(s):\n": "⚠️  %s 包含 %d 个大文件：\n",
+	"💡 Use --skip-binaries to leave large binary files out of the upload.\n":                         "💡 使用 --skip-binaries 可在上传时排除大型二进制文件。\n",
+	"⚠️  --skip-binaries is not applied to archives built by package_command\n":                      "⚠️  --skip-binaries 不适用于 package_command 生成的压缩包\n",
+	"⚠️  Could not save deploy state to %s: %v\n":                                                    "⚠️  无法将部署状态保存到 %s：%v\n",
+	"⚠️  Could not record command history: %v\n":                                                     "⚠️  无法记录命令历史：%v\n",
+	"⚠️  Could not export traces: %v\n":                                                              "⚠️  无法导出链路追踪数据：%v\n",
+	"⏸️  Build %s keeps running on the server. Resume waiting with:\n   %s\n":                        "⏸️  构建 %s 仍在服务端运行。可通过以下命令继续等待：\n   %s\n",
+	"⏳ Waiting for build %s of %s\n":                                                                 "⏳ 正在等待 %[2]s 的构建 %[1]s\n",
+	"\n🔨 Fetching build information...\n":                                                            "\n🔨 正在获取构建信息...\n",
+	"📦 Fetching project information...\n":                                                            "📦 正在获取项目信息...\n",
+	"📋 Listing projects...\n":                                                                        "📋 正在列出项目...\n",
+	"📋 Listing recent versions for project: %s\n":                                                    "📋 正在列出项目的最近版本：%s\n",
+	"📊 Computing build stats for project %s over the last %d days\n":                                 "📊 正在统计项目 %s 最近 %d 天的构建数据\n",
+	"🚀 Publishing build %s to production...\n":                                                       "🚀 正在将构建 %s 发布到生产环境...\n",
+	"🟦 Staging build %s...\n":                                                                        "🟦 正在暂存构建 %s...\n",
+	"✅ Build staged; production is unchanged\n":                                                      "✅ 构建已暂存；生产环境未变更\n",
+	"👉 Run 'robotx publish --commit' to switch production, or 'robotx publish --abort' to discard\n": "👉 运行 'robotx publish --commit' 切换生产环境，或运行 'robotx publish --abort' 丢弃\n",
+	"🚀 Switching production to the staged build...\n":                                                "🚀 正在将生产环境切换到暂存构建...\n",
+	"🗑️  Staged build discarded; production is unchanged\n":                                          "🗑️  已丢弃暂存构建；生产环境未变更\n",
+	"✅ Published successfully!\n":                                                                    "✅ 发布成功！\n",
+	"🌐 Production URL: %s\n":                                                                         "🌐 生产地址：%s\n",
+	"🌐 Staging URL: %s\n":                                                                            "🌐 暂存地址：%s\n",
+	"⏪ Rolling back from %s to %s\n":                                                                 "⏪ 正在从 %s 回滚到 %s\n",
+	"📌 Pinned build %s\n":                                                                            "📌 已固定构建 %s\n",
+	"✅ Unpinned build %s\n":                                                                          "✅ 已取消固定构建 %s\n",
+	"🧹 Deleted %d build(s), kept %d\n":                                                               "🧹 已删除 %d 个构建，保留 %d 个\n",
+	"🧹 Would delete %d build(s) and keep %d (dry run)\n":                                             "🧹 将删除 %d 个构建并保留 %d 个（演练）\n",
+	"✅ Nothing to prune; keeping %d build(s)\n":                                                      "✅ 无需清理；保留 %d 个构建\n",
+	"❌ Failed to delete %s: %v\n":                                                                    "❌ 删除 %s 失败：%v\n",
+	"🔒 Previews of %s now require a password\n":                                                      "🔒 %s 的预览现在需要密码\n",
+	"🔒 Previews of %s now require basic auth as %s\n":                                                "🔒 %s 的预览现在需要以 %s 进行基本认证\n",
+	"🔓 Previews of %s no longer require a password\n":                                                "🔓 %s 的预览不再需要密码\n",
+	"🚧 Production of %s now shows the maintenance page\n":                                            "🚧 %s 的生产环境现在显示维护页面\n",
+	"✅ Production of %s serves the published build again\n":                                          "✅ %s 的生产环境已恢复为已发布的构建\n",
+	"📦 Created %s (%s) from %s\n":                                                                    "📦 已从 %[3]s 创建 %[1]s（%[2]s）\n",
+	"🚩 Copied %d feature flag(s)\n":                                                                  "🚩 已复制 %d 个功能开关\n",
+	"🔐 Copied %d environment variable(s)\n":                                                          "🔐 已复制 %d 个环境变量\n",
+	"♻️  Copied build %s as %s\n":                                                                    "♻️  已将构建 %s 复制为 %s\n",
+	"⚠️  Preview protection is not copied; run 'robotx protect --project-id %s' to set a password\n": "⚠️  预览保护未被复制；运行 'robotx protect --project-id %s' 设置密码\n",
+	"⏰ Scheduled %s %s (%s) as job %s\n":                                                             "⏰ 已将 %s %s（%s）安排为任务 %s\n",
+	"🗑️  Removed job %s\n":                                                                           "🗑️  已删除任务 %s\n",
+	"📡 Pinging %s...\n":                                                                              "📡 正在检测 %s...\n",
+	"✅ Server reachable in %dms\n":                                                                   "✅ 服务端可访问，耗时 %dms\n",
+	"✅ API key accepted\n":                                                                           "✅ API Key 有效\n",
+	"⚠️  No API key configured; skipping authentication check\n":                                     "⚠️  未配置 API Key；跳过认证检查\n",
+	"👤 Logged in as %s\n":                                                                            "👤 当前登录身份：%s\n",
+	"🔐 Starting RobotX device login flow...\n":                                                       "🔐 正在启动 RobotX 设备登录流程...\n",
+	"🔐 Starting SSO login for organization %s...\n":                                                  "🔐 正在为组织 %s 启动 SSO 登录...\n",
+	"🌐 Login URL: %s\n":                                                                              "🌐 登录地址：%s\n",
+	"🌐 Verification URL: %s\n":                                                                       "🌐 验证地址：%s\n",
+	"🌐 Or open the full link: %s\n":                                                                  "🌐 或打开完整链接：%s\n",
+	"🧾 User Code: %s\n":                                                                              "🧾 用户验证码：%s\n",
+	"⏳ Waiting for authorization...\n":                                                               "⏳ 正在等待授权...\n",
+	"⏳ Waiting for the identity provider to redirect back...\n":                                      "⏳ 正在等待身份提供方回调...\n",
+	"🧭 Browser opened. Complete login to continue...\n":                                              "🧭 已打开浏览器，请完成登录以继续...\n",
+	"🧭 Browser opened. Complete login with your identity provider to continue...\n":                  "🧭 已打开浏览器，请在身份提供方完成登录以继续...\n",
+	"🧭 Open the URL above in your browser and complete login.\n":                                     "🧭 请在浏览器中打开上面的地址并完成登录。\n",
+	"🧭 Open the URL above in a browser on this machine and complete login.\n":                        "🧭 请在本机浏览器中打开上面的地址并完成登录。\n",
+	"⚠️  Failed to open browser automatically: %v\n":                                                 "⚠️  无法自动打开浏览器：%v\n",
+	"⚠️  Cannot render a QR code: %v\n":                                                              "⚠️  无法生成二维码：%v\n",
+	"✅ Login successful. Credentials saved to: %s\n":                                                 "✅ 登录成功，凭证已保存到：%s\n",
+	"🔁 Retrying the command...\n":                                                                    "🔁 正在重试命令...\n",
+	"🔁 Rerunning: robotx %s\n":                                                                       "🔁 正在重新运行：robotx %s\n",
+	"🔑 Using short-lived service credentials\n":                                                      "🔑 正在使用短期服务凭证\n",
+	"🔑 Using short-lived service credentials valid until %s\n":                                       "🔑 正在使用短期服务凭证，有效期至 %s\n",
+	"🧭 Discovered base URL %s for the API key\n":                                                     "🧭 已为该 API Key 发现服务地址 %s\n",
+	"⚠️  Base URL discovery via %s failed: %v\n":                                                     "⚠️  通过 %s 发现服务地址失败：%v\n",
+	"🔧 Using base URL %s (normalized from %q)\n":                                                     "🔧 使用服务地址 %s（由 %q 规范化）\n",
+	"📄 Config file: %s\n":                                                                            "📄 配置文件：%s\n",
+	"✅ Config file is valid: %s\n":                                                                   "✅ 配置文件有效：%s\n",
+	"✅ Config file is already at version %d: %s\n":                                                   "✅ 配置文件已是版本 %d：%s\n",
+	"✅ Migrated %s from version %d to %d (backup: %s)\n":                                             "✅ 已将 %s 从版本 %d 迁移到 %d（备份：%s）\n",
+	"✅ Set %s in %s\n":                                                                               "✅ 已在 %[2]s 中设置 %[1]s\n",
+	"✅ Removed %s from %s\n":                                                                         "✅ 已从 %[2]s 中删除 %[1]s\n",
+	"🔍 Dry run: %s was not changed\n":                                                                "🔍 演练：%s 未被修改\n",
+	"🚀 Deploy %s started: %s\n":                                                                      "🚀 部署 %s 已开始：%s\n",
+	"✅ Deploy %s finished: %s\n":                                                                     "✅ 部署 %s 已完成：%s\n",
+}
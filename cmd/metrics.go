@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Histogram buckets, in seconds, for build wait and deploy durations.
+var durationBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+// deployMetrics aggregates deploys run by the daemon or the MCP HTTP server
+// and serves them in the Prometheus text exposition format.
+type deployMetrics struct {
+	mu          sync.Mutex
+	deploys     map[string]int64
+	failures    map[string]int64
+	uploadBytes int64
+	buildWait   histogram
+	duration    histogram
+}
+
+type histogram struct {
+	counts []int64
+	sum    float64
+	count  int64
+}
+
+func newDeployMetrics() *deployMetrics {
+	return &deployMetrics{
+		deploys:   map[string]int64{},
+		failures:  map[string]int64{},
+		buildWait: histogram{counts: make([]int64, len(durationBuckets))},
+		duration:  histogram{counts: make([]int64, len(durationBuckets))},
+	}
+}
+
+func (h *histogram) observe(seconds float64) {
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// observeDeploy records a finished deploy from its JSON output:
+// the success envelope on stdout or the error envelope on stderr.
+func (m *deployMetrics) observeDeploy(stdout, stderr string, err error, cancelled bool, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.duration.observe(elapsed.Seconds())
+	switch {
+	case cancelled:
+		m.deploys["cancelled"]++
+	case err != nil:
+		m.deploys["failed"]++
+		m.failures[deployErrorCode(stderr)]++
+	default:
+		m.deploys["succeeded"]++
+		var envelope struct {
+			Data deployResponse `json:"data"`
+		}
+		if json.Unmarshal([]byte(strings.TrimSpace(stdout)), &envelope) == nil {
+			m.uploadBytes += envelope.Data.SourceArchiveBytes + envelope.Data.ArtifactArchiveBytes
+			if t := envelope.Data.Timings; t != nil && !envelope.Data.Reused {
+				m.buildWait.observe(float64(t.BuildWaitMS) / 1000)
+			}
+		}
+	}
+}
+
+// deployErrorCode returns the error code of the JSON error envelope in a
+// failed command's stderr.
+func deployErrorCode(stderr string) string {
+	var payload errorEnvelope
+	if json.Unmarshal([]byte(lastNonEmptyLine(stderr, "")), &payload) == nil && payload.Error.Code != "" {
+		return payload.Error.Code
+	}
+	return "general_error"
+}
+
+func (m *deployMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("# HELP robotx_deploys_total Deploys finished, by status.\n")
+	b.WriteString("# TYPE robotx_deploys_total counter\n")
+	for _, status := range []string{"succeeded", "failed", "cancelled"} {
+		fmt.Fprintf(&b, "robotx_deploys_total{status=%q} %d\n", status, m.deploys[status])
+	}
+
+	b.WriteString("# HELP robotx_deploy_failures_total Failed deploys, by error code.\n")
+	b.WriteString("# TYPE robotx_deploy_failures_total counter\n")
+	codes := make([]string, 0, len(m.failures))
+	for code := range m.failures {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		fmt.Fprintf(&b, "robotx_deploy_failures_total{code=%q} %d\n", code, m.failures[code])
+	}
+
+	b.WriteString("# HELP robotx_upload_bytes_total Bytes of source and artifact archives uploaded by successful deploys.\n")
+	b.WriteString("# TYPE robotx_upload_bytes_total counter\n")
+	fmt.Fprintf(&b, "robotx_upload_bytes_total %d\n", m.uploadBytes)
+
+	writeHistogram(&b, "robotx_build_wait_seconds", "Time spent waiting for builds to finish.", m.buildWait)
+	writeHistogram(&b, "robotx_deploy_duration_seconds", "Wall time of deploys.", m.duration)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write([]byte(b.String()))
+}
+
+func writeHistogram(b *strings.Builder, name, help string, h histogram) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s histogram\n", name)
+	for i, bound := range durationBuckets {
+		fmt.Fprintf(b, "%s_bucket{le=%q} %d\n", name, strconv.FormatFloat(bound, 'f', -1, 64), h.counts[i])
+	}
+	fmt.Fprintf(b, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(b, "%s_sum %s\n", name, strconv.FormatFloat(h.sum, 'f', -1, 64))
+	fmt.Fprintf(b, "%s_count %d\n", name, h.count)
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type openOptions struct {
+	*app
+	projectID  string
+	buildID    string
+	production bool
+	noBrowser  bool
+	qr         bool
+}
+
+type openResponse struct {
+	ProjectID string `json:"project_id,omitempty"`
+	BuildID   string `json:"build_id,omitempty"`
+	// Target is "preview", "build_preview" or "production".
+	Target string `json:"target"`
+	URL    string `json:"url"`
+	Opened bool   `json:"opened"`
+	QRPNG  string `json:"qr_png,omitempty"`
+}
+
+func newOpenCmd(a *app) *cobra.Command {
+	o := &openOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "open",
+		Short: "Open a project's preview or production URL in the browser",
+		Long: `Open the project's preview URL in the browser.
+
+With --build-id, open the preview of that specific build instead, e.g. to QA
+an older version listed by versions. With --production, open the production
+URL. Use --no-browser to only print the URL, and --qr to show a QR code of
+it for opening on a phone.`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	cmd.Flags().StringVarP(&o.buildID, "build-id", "b", "", "Open the preview of this build")
+	cmd.Flags().BoolVar(&o.production, "production", false, "Open the production URL")
+	cmd.Flags().BoolVar(&o.noBrowser, "no-browser", false, "Print the URL without opening a browser")
+	cmd.Flags().BoolVar(&o.qr, "qr", false, "Show a QR code of the URL (base64 PNG qr_png in JSON)")
+	return cmd
+}
+
+func (o *openOptions) run(cmd *cobra.Command, args []string) error {
+	if o.production && o.buildID != "" {
+		return newCLIError("invalid_argument", "--production and --build-id cannot be combined", ExitGeneral, nil)
+	}
+
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	projectID, _ := stateProjectID(o.projectID)
+	if projectID == "" && o.buildID == "" {
+		return newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	resp := openResponse{ProjectID: projectID, BuildID: o.buildID}
+	if o.buildID != "" {
+		build, err := c.GetBuild(projectID, o.buildID)
+		if err != nil {
+			return newCLIError("api_error", "failed to get build", ExitAPI, err)
+		}
+		if resp.ProjectID == "" {
+			resp.ProjectID = build.ProjectID
+		}
+		resp.Target = "build_preview"
+		resp.URL = buildPreviewURL(baseURL, build)
+		if resp.URL == "" {
+			return newCLIError("no_preview", "the server did not return a preview path for build "+o.buildID, ExitNotFound, nil)
+		}
+	} else {
+		project, err := c.GetProject(projectID)
+		if err != nil {
+			return newCLIError("api_error", "failed to get project", ExitAPI, err)
+		}
+		if o.production {
+			resp.Target = "production"
+			resp.URL = resolvePublishURL(baseURL, project)
+		} else {
+			resp.Target = "preview"
+			resp.URL = projectPreviewURL(project, baseURL)
+		}
+	}
+
+	o.logf("🌐 %s\n", verbatim(resp.URL))
+	if o.qr {
+		o.printQR(resp.URL)
+		resp.QRPNG = o.qrPNG(resp.URL)
+	}
+	if !o.noBrowser && !o.isNonInteractive() {
+		if err := openBrowser(resp.URL); err != nil {
+			o.logf("⚠️  Failed to open browser automatically: %v\n", err)
+		} else {
+			resp.Opened = true
+		}
+	}
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
@@ -1,18 +1,83 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"strings"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+	"github.com/haibingtown/robotx_cli/pkg/redact"
 )
 
+// ExitCode is the process exit status of a robotx command. The values are a
+// stable contract for scripts; add new codes, never renumber existing ones.
+type ExitCode int
+
+const (
+	ExitOK          ExitCode = 0
+	ExitGeneral     ExitCode = 1
+	ExitAPI         ExitCode = 2
+	ExitBuild       ExitCode = 3
+	ExitPublish     ExitCode = 4
+	ExitAuth        ExitCode = 5
+	ExitNotFound    ExitCode = 6
+	ExitRateLimited ExitCode = 7
+	ExitDrift       ExitCode = 8
+	ExitLocked      ExitCode = 9
+	ExitCancelled   ExitCode = 130
+)
+
+type exitCodeInfo struct {
+	Code    ExitCode `json:"code"`
+	Name    string   `json:"name"`
+	Meaning string   `json:"meaning"`
+}
+
+// exitCodes documents every ExitCode, in --help and explain-exit.
+var exitCodes = []exitCodeInfo{
+	{ExitOK, "ok", "Success"},
+	{ExitGeneral, "general", "Invalid arguments or configuration, or another general error"},
+	{ExitAPI, "api", "API or network error"},
+	{ExitBuild, "build", "Build failed or timed out"},
+	{ExitPublish, "publish", "Publish failed, or its approval was rejected or timed out"},
+	{ExitAuth, "auth", "Missing, invalid or insufficient credentials"},
+	{ExitNotFound, "not_found", "Project, build or other resource not found"},
+	{ExitRateLimited, "rate_limited", "Rate limited by the server; retry later"},
+	{ExitDrift, "drift", "Production differs from the latest successful build (verify-drift --fail-on-drift), or local files from the deployed artifact (verify --fail-on-diff), or the env from robotx.yaml (env diff --fail-on-diff)"},
+	{ExitLocked, "locked", "Another deploy of the project holds its deploy lock"},
+	{ExitCancelled, "cancelled", "Cancelled by an interrupt or by declining a confirmation"},
+}
+
+func lookupExitCode(code ExitCode) (exitCodeInfo, bool) {
+	for _, info := range exitCodes {
+		if info.Code == code {
+			return info, true
+		}
+	}
+	return exitCodeInfo{}, false
+}
+
+// exitCodesHelp renders the exit code table for the root command's help.
+func exitCodesHelp() string {
+	var b strings.Builder
+	b.WriteString("Exit codes:\n")
+	for _, info := range exitCodes {
+		fmt.Fprintf(&b, "  %-4d %s\n", info.Code, info.Meaning)
+	}
+	b.WriteString("\nRun 'robotx explain-exit <code>' for details.")
+	return b.String()
+}
+
 type cliError struct {
 	Code     string      `json:"code"`
 	Message  string      `json:"message"`
 	Details  interface{} `json:"details,omitempty"`
-	ExitCode int         `json:"-"`
+	ExitCode ExitCode    `json:"-"`
 	Err      error       `json:"-"`
 }
 
@@ -33,7 +98,7 @@ func (e *cliError) Unwrap() error {
 	return e.Err
 }
 
-func newCLIError(code, message string, exitCode int, err error) *cliError {
+func newCLIError(code, message string, exitCode ExitCode, err error) *cliError {
 	return &cliError{
 		Code:     code,
 		Message:  message,
@@ -42,12 +107,68 @@ func newCLIError(code, message string, exitCode int, err error) *cliError {
 	}
 }
 
-func isJSONOutput() bool {
-	if outputJSON || strings.EqualFold(outputFormat, "json") {
-		return true
+// outputController decides how an invocation renders logs, results and
+// errors. It is resolved once in PersistentPreRunE, after flags, environment
+// and config are applied, so logf, emitSuccess and HandleError agree.
+type outputController struct {
+	json           bool
+	nonInteractive bool
+	accessible     bool
+	catalog        *messageCatalog
+	// secrets are the credential values redacted from errors.
+	secrets []string
+}
+
+// resolveOutput validates --output and fixes the output mode for the rest of
+// the invocation.
+func (a *app) resolveOutput() error {
+	if a.outputJSON {
+		a.outputFormat = "json"
 	}
+	a.outputFormat = strings.ToLower(strings.TrimSpace(a.outputFormat))
+	if a.outputFormat == "" {
+		a.outputFormat = "text"
+	}
+	if a.outputFormat != "text" && a.outputFormat != "json" {
+		return newCLIError("invalid_output_format", "invalid --output value (expected text or json)", ExitGeneral, nil)
+	}
+	a.output = &outputController{
+		json:           a.outputFormat == "json" || envOutputJSON(),
+		nonInteractive: a.nonInteractive || envNonInteractive(),
+		accessible:     a.accessible || envAccessible(),
+	}
+	return nil
+}
+
+// outputMode returns the resolved output mode. Before resolveOutput has run,
+// for example while the config is loading or when flag parsing failed, it is
+// derived from the flags parsed so far.
+func (a *app) outputMode() *outputController {
+	if a.output != nil {
+		return a.output
+	}
+	return &outputController{
+		json:           a.outputJSON || strings.EqualFold(strings.TrimSpace(a.outputFormat), "json") || envOutputJSON(),
+		nonInteractive: a.nonInteractive || envNonInteractive(),
+		accessible:     a.accessible || envAccessible(),
+	}
+}
+
+func (a *app) isJSONOutput() bool {
+	return a.outputMode().json
+}
+
+func envOutputJSON() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv("ROBOTX_OUTPUT")), "json")
+}
 
-	args := os.Args[1:]
+// argsRequestJSON reports whether JSON output was requested on the command
+// line or in the environment. It is only consulted for errors raised before
+// the command tree could parse its flags.
+func argsRequestJSON(args []string) bool {
+	if envOutputJSON() {
+		return true
+	}
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
 		if arg == "--json" {
@@ -64,19 +185,49 @@ func isJSONOutput() bool {
 	return false
 }
 
-func logWriter() *os.File {
-	if isJSONOutput() {
-		return os.Stderr
+func (a *app) out() io.Writer {
+	return a.root.OutOrStdout()
+}
+
+func (a *app) errOut() io.Writer {
+	return a.root.ErrOrStderr()
+}
+
+func (a *app) logWriter() io.Writer {
+	if a.isJSONOutput() {
+		return a.errOut()
 	}
-	return os.Stdout
+	return a.out()
 }
 
-func logf(format string, args ...interface{}) {
-	fmt.Fprintf(logWriter(), format, args...)
+func (a *app) logf(format string, args ...interface{}) {
+	line := a.formatLog(format, args...)
+	if a.progress != nil {
+		a.progress.printLine(line)
+		return
+	}
+	fmt.Fprint(a.logWriter(), line)
 }
 
-func logln(args ...interface{}) {
-	fmt.Fprintln(logWriter(), args...)
+// noticef writes a notice to stderr in every output mode, such as warnings
+// raised before the command runs.
+func (a *app) noticef(format string, args ...interface{}) {
+	fmt.Fprint(a.errOut(), a.formatLog(format, args...))
+}
+
+// formatLog formats a log line in the selected language, with text markers
+// in accessible mode.
+func (a *app) formatLog(format string, args ...interface{}) string {
+	mode := a.outputMode()
+	s := fmt.Sprintf(mode.catalog.format(format), a.redactArgs(args)...)
+	if mode.accessible {
+		s = textMarkers(s)
+	}
+	return s
+}
+
+func (a *app) logln(args ...interface{}) {
+	fmt.Fprintln(a.logWriter(), args...)
 }
 
 type successEnvelope struct {
@@ -85,11 +236,11 @@ type successEnvelope struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
-func emitSuccess(command string, data interface{}) error {
-	if !isJSONOutput() {
+func (a *app) emitSuccess(command string, data interface{}) error {
+	if !a.isJSONOutput() {
 		return nil
 	}
-	enc := json.NewEncoder(os.Stdout)
+	enc := json.NewEncoder(a.out())
 	enc.SetEscapeHTML(false)
 	return enc.Encode(successEnvelope{
 		Success: true,
@@ -107,30 +258,84 @@ type errorEnvelope struct {
 	} `json:"error"`
 }
 
+// outputError carries the output mode of the invocation that failed from
+// Execute to HandleError.
+type outputError struct {
+	err    error
+	output *outputController
+}
+
+func (e *outputError) Error() string {
+	return e.err.Error()
+}
+
+func (e *outputError) Unwrap() error {
+	return e.err
+}
+
+// HandleError prints err for the process started by Execute and returns its
+// exit code.
 func HandleError(err error) int {
 	if err == nil {
 		return 0
 	}
+	var pluginErr *pluginExitError
+	if errors.As(err, &pluginErr) {
+		return pluginErr.code
+	}
 
+	output := &outputController{
+		json:           argsRequestJSON(os.Args[1:]),
+		nonInteractive: envNonInteractive() || containsArg(os.Args[1:], "--non-interactive"),
+	}
+	var outErr *outputError
+	if errors.As(err, &outErr) && outErr.output != nil {
+		output = outErr.output
+	}
+	return output.writeError(os.Stderr, err)
+}
+
+// writeError renders err to w in the controller's mode and returns the exit
+// code for it.
+func (c *outputController) writeError(w io.Writer, err error) int {
 	code, message, details, exitCode := classifyError(err)
-	if isJSONOutput() {
-		enc := json.NewEncoder(os.Stderr)
+	message = redact.String(c.localizeError(err, message), c.secrets...)
+	details = redactDetails(details, c.secrets)
+	switch {
+	case c.json:
+		enc := json.NewEncoder(w)
 		enc.SetEscapeHTML(false)
 		payload := errorEnvelope{Success: false}
 		payload.Error.Code = code
 		payload.Error.Message = message
 		payload.Error.Details = details
 		_ = enc.Encode(payload)
-	} else {
-		fmt.Fprintf(os.Stderr, "Error: %s\n", message)
+	case c.nonInteractive:
+		fmt.Fprintf(w, "Error [%s]: %s\n", code, message)
+	default:
+		fmt.Fprintf(w, "Error: %s\n", message)
 	}
-	return exitCode
+	return int(exitCode)
 }
 
-func classifyError(err error) (code string, message string, details interface{}, exitCode int) {
+// localizeError translates the message of a CLI error, keeping the text of
+// the underlying error it wraps.
+func (c *outputController) localizeError(err error, message string) string {
+	var cliErr *cliError
+	if c.catalog == nil || !errors.As(err, &cliErr) || !strings.HasPrefix(message, cliErr.Message) {
+		return message
+	}
+	return c.catalog.message(cliErr.Message) + strings.TrimPrefix(message, cliErr.Message)
+}
+
+func classifyError(err error) (code string, message string, details interface{}, exitCode ExitCode) {
+	if client.IsAPIVersionUnsupported(err) {
+		versionErr := unsupportedVersionError(err)
+		return versionErr.Code, versionErr.Message, versionErr.Details, versionErr.ExitCode
+	}
 	var cliErr *cliError
 	if errors.As(err, &cliErr) {
-		return cliErr.Code, cliErr.Error(), cliErr.Details, cliErr.ExitCode
+		return cliErr.Code, cliErr.Error(), cliErr.Details, refineExitCode(err, cliErr.ExitCode)
 	}
 
 	message = strings.TrimSpace(err.Error())
@@ -141,12 +346,41 @@ func classifyError(err error) (code string, message string, details interface{},
 	lowerMsg := strings.ToLower(message)
 	switch {
 	case strings.Contains(lowerMsg, "build failed"), strings.Contains(lowerMsg, "build timeout"), strings.Contains(lowerMsg, "unknown build status"):
-		return "build_failed", message, nil, 3
+		return "build_failed", message, nil, ExitBuild
 	case strings.Contains(lowerMsg, "publish"):
-		return "publish_failed", message, nil, 4
+		return "publish_failed", message, nil, refineExitCode(err, ExitPublish)
 	case strings.Contains(lowerMsg, "api error"), strings.Contains(lowerMsg, "request failed"):
-		return "api_error", message, nil, 2
+		return "api_error", message, nil, refineExitCode(err, ExitAPI)
 	default:
-		return "general_error", message, nil, 1
+		return "general_error", message, nil, refineExitCode(err, ExitGeneral)
 	}
 }
+
+// refineExitCode replaces the generic exit code of err with a more specific
+// one when the cause is known: an interrupt, a credentials problem, rate
+// limiting, or a missing resource. Build failures keep ExitBuild.
+func refineExitCode(err error, code ExitCode) ExitCode {
+	var cliErr *cliError
+	switch {
+	case code == ExitBuild:
+		return code
+	case errors.Is(err, context.Canceled), errors.As(err, &cliErr) && cliErr.Code == "cancelled":
+		return ExitCancelled
+	case client.IsUnauthorized(err):
+		return ExitAuth
+	case client.IsStatus(err, http.StatusTooManyRequests):
+		return ExitRateLimited
+	case client.IsStatus(err, http.StatusNotFound) && (code == ExitGeneral || code == ExitAPI):
+		return ExitNotFound
+	}
+	return code
+}
+
+func containsArg(args []string, want string) bool {
+	for _, arg := range args {
+		if arg == want || strings.HasPrefix(arg, want+"=") && arg != want+"=false" {
+			return true
+		}
+	}
+	return false
+}
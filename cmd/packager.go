@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
+)
+
+// packager produces the zip archive uploaded for a directory. The caller
+// removes the returned archive when done.
+type packager interface {
+	Package(ctx context.Context, kind pipeline.ArchiveKind, root string, opts packageOptions) (string, *packageReport, error)
+}
+
+// newPackager returns the external command packager when package_command is
+// configured, and the built-in zip walker otherwise.
+func (o *deployOptions) newPackager(projectPath string) packager {
+	if command := strings.TrimSpace(o.packageCommand); command != "" {
+		return &commandPackager{app: o.app, command: command, dir: projectPath}
+	}
+	return zipPackager{}
+}
+
+// zipPackager walks the directory and zips it.
+type zipPackager struct{}
+
+func (zipPackager) Package(ctx context.Context, kind pipeline.ArchiveKind, root string, opts packageOptions) (string, *packageReport, error) {
+	if kind == pipeline.ArchiveSource {
+		return packageSource(root, opts)
+	}
+	return packageDirectory(root, kind, opts)
+}
+
+// commandPackager delegates packaging to a shell command run in the project
+// directory. The command receives ROBOTX_PACKAGE_KIND (source or artifacts),
+// ROBOTX_PACKAGE_ROOT (directory to package) and ROBOTX_PACKAGE_OUTPUT (a
+// suggested archive path). It either writes the archive to
+// ROBOTX_PACKAGE_OUTPUT or, failing that, prints the archive path as the last
+// line of stdout.
+type commandPackager struct {
+	*app
+	command string
+	dir     string
+}
+
+func (p *commandPackager) Package(ctx context.Context, kind pipeline.ArchiveKind, root string, opts packageOptions) (string, *packageReport, error) {
+	tmpFile, err := os.CreateTemp("", fmt.Sprintf("robotx-%s-*.zip", kind))
+	if err != nil {
+		return "", nil, err
+	}
+	output := tmpFile.Name()
+	tmpFile.Close()
+
+	archive, err := p.run(ctx, kind, root, output)
+	if err == nil {
+		err = adoptArchive(archive, output)
+	}
+	if err != nil {
+		os.Remove(output)
+		return "", nil, err
+	}
+
+	report, err := zipArchiveReport(output, opts)
+	if err != nil {
+		os.Remove(output)
+		return "", nil, fmt.Errorf("package command output is not a zip archive: %w", err)
+	}
+	if opts.SkipBinaries && len(report.LargeFiles) > 0 {
+		p.logf("⚠️  --skip-binaries is not applied to archives built by package_command\n")
+	}
+	return output, report, nil
+}
+
+func (p *commandPackager) run(ctx context.Context, kind pipeline.ArchiveKind, root, output string) (string, error) {
+	p.logf("🧩 Running package command: %s\n", p.command)
+	c := exec.CommandContext(ctx, "sh", "-lc", p.command)
+	setInterruptGroup(c)
+	c.WaitDelay = 10 * time.Second
+	c.Dir = p.dir
+	c.Env = append(os.Environ(),
+		"ROBOTX_PACKAGE_KIND="+string(kind),
+		"ROBOTX_PACKAGE_ROOT="+root,
+		"ROBOTX_PACKAGE_OUTPUT="+output,
+	)
+	if p.isNonInteractive() {
+		c.Env = append(c.Env, noColorEnv()...)
+	}
+	var stdout bytes.Buffer
+	c.Stdout = io.MultiWriter(&stdout, p.logWriter())
+	c.Stderr = p.errOut()
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("package command failed: %w", err)
+	}
+
+	if info, err := os.Stat(output); err == nil && info.Size() > 0 {
+		return output, nil
+	}
+	archive := lastNonEmptyLine(stdout.String(), "")
+	if archive == "" {
+		return output, nil
+	}
+	if !filepath.IsAbs(archive) {
+		archive = filepath.Join(p.dir, archive)
+	}
+	return archive, nil
+}
+
+// adoptArchive copies the archive produced by the package command to output,
+// so that removing output afterwards never deletes files in the project.
+func adoptArchive(archive, output string) error {
+	info, err := os.Stat(archive)
+	if err != nil {
+		return fmt.Errorf("package command archive not found: %w", err)
+	}
+	if info.Size() == 0 {
+		return fmt.Errorf("package command produced no archive (write it to $ROBOTX_PACKAGE_OUTPUT or print its path on the last line of stdout)")
+	}
+	if filepath.Clean(archive) == filepath.Clean(output) {
+		return nil
+	}
+
+	src, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// zipArchiveReport lists large entries of an existing zip archive.
+func zipArchiveReport(path string, opts packageOptions) (*packageReport, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	report := &packageReport{}
+	if opts.LargeFileThreshold <= 0 {
+		return report, nil
+	}
+	for _, f := range reader.File {
+		size := int64(f.UncompressedSize64)
+		if f.FileInfo().IsDir() || size < opts.LargeFileThreshold {
+			continue
+		}
+		report.LargeFiles = append(report.LargeFiles, largeFileEntry{
+			Path:      f.Name,
+			SizeBytes: size,
+			Binary:    binaryExtensions[strings.ToLower(filepath.Ext(f.Name))],
+		})
+	}
+	return report, nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// legacyDataDirName and legacyConfigFileName are the pre-XDG locations in the
+// home directory. They are still used when they exist.
+const (
+	legacyDataDirName    = ".robotx"
+	legacyConfigFileName = ".robotx.yaml"
+)
+
+// xdgDir returns $<envVar> when it is an absolute path, as the XDG base
+// directory spec requires, and otherwise ~/<fallback>.
+func xdgDir(envVar, fallback string) (string, error) {
+	if dir := os.Getenv(envVar); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, fallback), nil
+}
+
+// robotxDataDir returns the directory used for CLI state such as caches:
+// $XDG_DATA_HOME/robotx (~/.local/share/robotx), or ~/.robotx when only
+// that exists.
+func robotxDataDir() (string, error) {
+	base, err := xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, "robotx")
+	if dirExists(dir) {
+		return dir, nil
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if legacy := filepath.Join(home, legacyDataDirName); dirExists(legacy) {
+			return legacy, nil
+		}
+	}
+	return dir, nil
+}
+
+// robotxDataPath joins elem onto the data directory, creating parent directories.
+func robotxDataPath(elem ...string) (string, error) {
+	dir, err := robotxDataDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(append([]string{dir}, elem...)...)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// resolveDefaultConfigPath returns $XDG_CONFIG_HOME/robotx/config.yaml
+// (~/.config/robotx/config.yaml), or ~/.robotx.yaml when only that exists.
+func resolveDefaultConfigPath() (string, error) {
+	base, err := xdgDir("XDG_CONFIG_HOME", ".config")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(base, "robotx", "config.yaml")
+	if !fileExists(path) {
+		if home, err := os.UserHomeDir(); err == nil {
+			if legacy := filepath.Join(home, legacyConfigFileName); fileExists(legacy) {
+				path = legacy
+			}
+		}
+	}
+	if dirExists(path) {
+		return "", fmt.Errorf("config path is a directory: %s", path)
+	}
+	return path, nil
+}
+
+func dirExists(path string) bool {
+	stat, err := os.Stat(path)
+	return err == nil && stat.IsDir()
+}
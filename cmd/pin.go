@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+type pinOptions struct {
+	*app
+	projectID string
+	buildID   string
+	pinned    bool
+}
+
+type pinResponse struct {
+	ProjectID string `json:"project_id"`
+	BuildID   string `json:"build_id"`
+	Pinned    bool   `json:"pinned"`
+}
+
+func newPinCmd(a *app) *cobra.Command {
+	return newPinCommand(a, true, "pin [build-id]", "Protect a build from pruning",
+		`Pin a build so it is never deleted, by prune or by server-side garbage
+collection, e.g. to keep release versions. Pinned builds are marked in the
+PINNED column of versions.`)
+}
+
+func newUnpinCmd(a *app) *cobra.Command {
+	return newPinCommand(a, false, "unpin [build-id]", "Allow a pinned build to be pruned again",
+		`Remove the pin from a build so prune may delete it again.`)
+}
+
+func newPinCommand(a *app, pinned bool, use, short, long string) *cobra.Command {
+	o := &pinOptions{app: a, pinned: pinned}
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Long: long + `
+
+Inside a deployed project directory --project-id defaults to the recorded
+project.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	cmd.Flags().StringVarP(&o.buildID, "build-id", "b", "", "Build ID")
+	return cmd
+}
+
+func (o *pinOptions) run(cmd *cobra.Command, args []string) error {
+	buildID := o.buildID
+	if len(args) > 0 {
+		buildID = args[0]
+	}
+	if buildID == "" {
+		return newCLIError("missing_argument", "--build-id is required", ExitGeneral, nil)
+	}
+
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	projectID, _ := stateProjectID(o.projectID)
+	if projectID == "" {
+		return newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityPinBuilds) {
+		return newCLIError("unsupported_server", "this RobotX server does not support pinning builds", ExitAPI, nil)
+	}
+	build, err := c.SetBuildPinned(projectID, buildID, o.pinned)
+	if err != nil {
+		return newCLIError("api_error", fmt.Sprintf("failed to %s build", cmd.Name()), ExitAPI, err)
+	}
+	if o.pinned {
+		o.logf("📌 Pinned build %s\n", buildID)
+	} else {
+		o.logf("✅ Unpinned build %s\n", buildID)
+	}
+
+	if err := o.emitSuccess(cmd.Name(), pinResponse{
+		ProjectID: projectID,
+		BuildID:   buildID,
+		Pinned:    build.Pinned,
+	}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+func formatPinned(pinned bool) string {
+	if pinned {
+		return "yes"
+	}
+	return "-"
+}
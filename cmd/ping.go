@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+type pingResponse struct {
+	BaseURL       string           `json:"base_url"`
+	LatencyMS     int64            `json:"latency_ms"`
+	ServerStatus  string           `json:"server_status,omitempty"`
+	ServerVersion string           `json:"server_version,omitempty"`
+	Features      []string         `json:"features,omitempty"`
+	Authenticated bool             `json:"authenticated"`
+	Identity      *client.Identity `json:"identity,omitempty"`
+}
+
+func newPingCmd(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "ping",
+		Short: "Check server health, latency, and authentication",
+		Long:  `Call the RobotX health endpoint, measure latency, verify the configured API key, and print the server version and feature flags.`,
+		RunE:  a.runPing,
+	}
+}
+
+func (a *app) runPing(cmd *cobra.Command, args []string) error {
+	baseURL := a.v.GetString("base_url")
+	apiKey := a.v.GetString("api_key")
+
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required (use --base-url or set ROBOTX_BASE_URL)", ExitGeneral, nil)
+	}
+
+	c := a.newAPIClient(baseURL, apiKey)
+	resp := pingResponse{BaseURL: baseURL}
+
+	a.logf("📡 Pinging %s...\n", baseURL)
+	start := time.Now()
+	health, err := c.Health()
+	resp.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		return newCLIError("api_error", "health check failed", ExitAPI, err)
+	}
+	resp.ServerStatus = health.Status
+	resp.ServerVersion = health.Version
+	resp.Features = health.Features
+	a.logf("✅ Server reachable in %dms\n", resp.LatencyMS)
+
+	if apiKey == "" {
+		a.logf("⚠️  No API key configured; skipping authentication check\n")
+	} else {
+		identity, err := c.VerifyAuth()
+		if err != nil {
+			if client.IsUnauthorized(err) {
+				return newCLIError("unauthorized", "API key was rejected by the server", ExitAuth, err)
+			}
+			return newCLIError("api_error", "failed to verify API key", ExitAPI, err)
+		}
+		resp.Authenticated = true
+		resp.Identity = identity
+		a.logf("✅ API key accepted\n")
+	}
+
+	if err := a.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if a.isJSONOutput() {
+		return nil
+	}
+
+	w := tabwriter.NewWriter(a.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, a.formatLog("\n📋 Server Information:\n"))
+	fmt.Fprintf(w, "Base URL:\t%s\n", resp.BaseURL)
+	fmt.Fprintf(w, "Latency:\t%dms\n", resp.LatencyMS)
+	fmt.Fprintf(w, "Status:\t%s\n", valueOrDash(resp.ServerStatus))
+	fmt.Fprintf(w, "Version:\t%s\n", valueOrDash(resp.ServerVersion))
+	fmt.Fprintf(w, "Features:\t%s\n", valueOrDash(strings.Join(resp.Features, ", ")))
+	fmt.Fprintf(w, "Authenticated:\t%t\n", resp.Authenticated)
+	if resp.Identity != nil {
+		fmt.Fprintf(w, "Identity:\t%s\n", valueOrDash(firstNonEmpty(resp.Identity.Username, resp.Identity.Email, resp.Identity.UserID)))
+	}
+	_ = w.Flush()
+
+	return nil
+}
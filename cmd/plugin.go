@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Executables named pluginPrefix+"<name>" on PATH become "robotx <name>".
+const pluginPrefix = "robotx-"
+
+type pluginInfo struct {
+	Name     string   `json:"name"`
+	Path     string   `json:"path"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type pluginListResponse struct {
+	Plugins []pluginInfo `json:"plugins"`
+}
+
+// pluginExitError carries a plugin's exit status back to main without
+// printing an additional error.
+type pluginExitError struct {
+	code int
+}
+
+func (e *pluginExitError) Error() string {
+	return fmt.Sprintf("plugin exited with status %d", e.code)
+}
+
+func newPluginCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plugin",
+		Short: "Manage CLI plugins",
+		Long: `Plugins extend the CLI without forking it: any executable named robotx-<name>
+on PATH can be run as "robotx <name>". Built-in commands always take precedence.
+
+Plugins receive the resolved settings through environment variables:
+ROBOTX_BASE_URL, ROBOTX_API_KEY, ROBOTX_CONFIG, ROBOTX_PROFILE, ROBOTX_OUTPUT,
+ROBOTX_NON_INTERACTIVE and ROBOTX_CLI (path of the robotx executable).`,
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List plugins found on PATH",
+		Args:  cobra.NoArgs,
+		RunE:  a.runPluginList,
+	})
+	return cmd
+}
+
+func (a *app) runPluginList(cmd *cobra.Command, args []string) error {
+	plugins := discoverPlugins(cmd.Root())
+	if err := a.emitSuccess("plugin list", pluginListResponse{Plugins: plugins}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if a.isJSONOutput() {
+		return nil
+	}
+
+	if len(plugins) == 0 {
+		fmt.Fprintf(a.out(), "No plugins found (looking for %s* executables on PATH)\n", pluginPrefix)
+		return nil
+	}
+	w := tabwriter.NewWriter(a.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPATH")
+	for _, p := range plugins {
+		fmt.Fprintf(w, "%s\t%s\n", p.Name, p.Path)
+	}
+	w.Flush()
+	for _, p := range plugins {
+		for _, warning := range p.Warnings {
+			fmt.Fprint(a.out(), a.formatLog("⚠️  %s: %s\n", p.Name, warning))
+		}
+	}
+	return nil
+}
+
+// discoverPlugins scans PATH for plugin executables. Later entries with the
+// same name are reported as shadowed.
+func discoverPlugins(root *cobra.Command) []pluginInfo {
+	var plugins []pluginInfo
+	index := map[string]int{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || entry.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutableFile(path) {
+				continue
+			}
+			if i, seen := index[name]; seen {
+				plugins[i].Warnings = append(plugins[i].Warnings, fmt.Sprintf("shadows %s", path))
+				continue
+			}
+			info := pluginInfo{Name: name, Path: path}
+			if isBuiltinCommand(root, name) {
+				info.Warnings = append(info.Warnings, "ignored: conflicts with built-in command")
+			}
+			index[name] = len(plugins)
+			plugins = append(plugins, info)
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+func pluginName(file string) (string, bool) {
+	if !strings.HasPrefix(file, pluginPrefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(file, pluginPrefix)
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".exe" && ext != ".bat" && ext != ".cmd" {
+			return "", false
+		}
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return name, name != ""
+}
+
+func isExecutableFile(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode()&0o111 != 0
+}
+
+func isBuiltinCommand(root *cobra.Command, name string) bool {
+	for _, c := range root.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return name == "help"
+}
+
+func findPlugin(root *cobra.Command, name string) (string, bool) {
+	if strings.ContainsAny(name, `/\`) || isBuiltinCommand(root, name) {
+		return "", false
+	}
+	for _, p := range discoverPlugins(root) {
+		if p.Name == name {
+			return p.Path, true
+		}
+	}
+	return "", false
+}
+
+// registerPluginCommand adds a command for the plugin named by the first
+// positional argument when it is not a built-in command, and returns the
+// arguments to execute. Global flags given before the plugin name are parsed
+// as usual; everything after it is passed to the plugin untouched.
+func (a *app) registerPluginCommand(args []string) ([]string, error) {
+	root := a.root
+	if _, _, err := root.Find(args); err == nil {
+		return args, nil
+	}
+	i := firstPositionalArg(root.PersistentFlags(), args)
+	if i < 0 {
+		return args, nil
+	}
+	name := args[i]
+	path, ok := findPlugin(root, name)
+	if !ok {
+		return args, nil
+	}
+	if err := root.PersistentFlags().Parse(args[:i]); err != nil {
+		return nil, err
+	}
+	root.AddCommand(&cobra.Command{
+		Use:                name,
+		Short:              "Plugin " + path,
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, pluginArgs []string) error {
+			return a.runPlugin(path, pluginArgs)
+		},
+	})
+	return append([]string{name}, args[i+1:]...), nil
+}
+
+// firstPositionalArg returns the index of the first argument that is neither
+// a flag nor a flag value, or -1.
+func firstPositionalArg(flags *pflag.FlagSet, args []string) int {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			if i+1 < len(args) {
+				return i + 1
+			}
+			return -1
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return i
+		}
+		if strings.Contains(arg, "=") {
+			continue
+		}
+		var f *pflag.Flag
+		if strings.HasPrefix(arg, "--") {
+			f = flags.Lookup(strings.TrimPrefix(arg, "--"))
+		} else if len(arg) == 2 {
+			f = flags.ShorthandLookup(arg[1:])
+		}
+		if f != nil && f.NoOptDefVal == "" {
+			i++ // skip the flag value
+		}
+	}
+	return -1
+}
+
+func (a *app) runPlugin(path string, args []string) error {
+	c := exec.Command(path, args...)
+	c.Stdin = a.root.InOrStdin()
+	c.Stdout = a.out()
+	c.Stderr = a.errOut()
+	c.Env = append(os.Environ(), a.pluginEnv()...)
+
+	// The plugin shares the terminal and handles Ctrl-C itself.
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code := exitErr.ExitCode()
+			if code < 0 {
+				code = 1
+			}
+			return &pluginExitError{code: code}
+		}
+		return newCLIError("plugin_failed", fmt.Sprintf("failed to run plugin %s", path), ExitGeneral, err)
+	}
+	return nil
+}
+
+func (a *app) pluginEnv() []string {
+	env := []string{
+		"ROBOTX_BASE_URL=" + a.v.GetString("base_url"),
+		"ROBOTX_API_KEY=" + a.v.GetString("api_key"),
+		"ROBOTX_OUTPUT=" + a.outputFormat,
+	}
+	if used := a.v.ConfigFileUsed(); used != "" {
+		env = append(env, "ROBOTX_CONFIG="+used)
+	}
+	if a.profileName != "" {
+		env = append(env, "ROBOTX_PROFILE="+a.profileName)
+	}
+	if a.isNonInteractive() {
+		env = append(env, "ROBOTX_NON_INTERACTIVE=1")
+	}
+	if exe, err := os.Executable(); err == nil {
+		env = append(env, "ROBOTX_CLI="+exe)
+	}
+	return env
+}
@@ -0,0 +1,390 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Special pages of the static runtime.
+const (
+	siteIndexPage    = "/index.html"
+	siteNotFoundPage = "/404.html"
+)
+
+type previewArchiveOptions struct {
+	*app
+	host        string
+	port        int
+	projectPath string
+}
+
+type previewArchiveResponse struct {
+	Archive string `json:"archive"`
+	URL     string `json:"url"`
+	Files   int    `json:"files"`
+	// Routes is the routes file applied to requests, if any.
+	Routes       string `json:"routes,omitempty"`
+	NotFoundPage bool   `json:"not_found_page"`
+}
+
+func newPreviewArchiveCmd(a *app) *cobra.Command {
+	o := &previewArchiveOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "preview-archive <archive.zip>",
+		Short: "Serve a build archive locally the way the static runtime does",
+		Long: `Serve the contents of a build archive on a local port exactly as the static
+runtime serves a deployed build, to check an artifact before publishing it.
+
+Requests are answered like in production:
+  - redirects, then rewrites, of ` + routesFileName + ` (from --project-path)
+    apply first, and its header rules are added to the response
+  - a path names a file of the archive, or a directory with an index.html
+  - a missing path gets /404.html with status 404 when the archive has one;
+    otherwise paths without an extension fall back to /index.html, for
+    single-page apps, and other paths get a plain 404
+
+Each request is logged with the file that answered it. Stop with Ctrl-C.`,
+		Example: `  robotx preview-archive dist.zip
+  robotx preview-archive build.zip --port 3000 --project-path ./web`,
+		Args: cobra.ExactArgs(1),
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVar(&o.host, "host", "127.0.0.1", "Address to listen on")
+	cmd.Flags().IntVar(&o.port, "port", 8080, "Port to listen on")
+	cmd.Flags().StringVar(&o.projectPath, "project-path", ".", "Project directory whose "+routesFileName+" is applied")
+	return cmd
+}
+
+func (o *previewArchiveOptions) run(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	archive := args[0]
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return newCLIError("invalid_argument", fmt.Sprintf("%s is not a readable zip archive", archive), ExitGeneral, err)
+	}
+	defer reader.Close()
+	site := newArchiveSite(&reader.Reader)
+	if len(site.files) == 0 {
+		return newCLIError("invalid_argument", fmt.Sprintf("%s contains no files", archive), ExitGeneral, nil)
+	}
+
+	resp := previewArchiveResponse{Archive: archive, Files: len(site.files), NotFoundPage: site.files[siteNotFoundPage] != nil}
+	cfg, issues, err := loadRoutesConfig(o.projectPath)
+	if err != nil {
+		return newCLIError("invalid_routes", "failed to read "+routesFileName, ExitGeneral, err)
+	}
+	if len(issues) > 0 {
+		for _, issue := range issues {
+			o.logf("❌ %s\n", issue)
+		}
+		cliErr := newCLIError("invalid_routes", fmt.Sprintf("%s has %d problem(s); run 'robotx routes validate' for details", routesFileName, len(issues)), ExitGeneral, nil)
+		cliErr.Details = routesValidateResponse{File: filepath.Join(o.projectPath, routesFileName), Issues: issues}
+		return cliErr
+	}
+	if cfg != nil {
+		site.routes = compileSiteRoutes(cfg)
+		resp.Routes = filepath.Join(o.projectPath, routesFileName)
+	}
+	if site.files[siteIndexPage] == nil {
+		if dir := site.singleTopDir(); dir != "" {
+			o.logf("⚠️  %s has no /index.html at its root but a single %s/ directory; the runtime serves the archive root, so package the contents of %s/ instead\n", archive, dir, dir)
+		} else {
+			o.logf("⚠️  %s has no /index.html at its root\n", archive)
+		}
+	}
+
+	addr := net.JoinHostPort(o.host, strconv.Itoa(o.port))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return newCLIError("listen_failed", fmt.Sprintf("failed to listen on %s", addr), ExitGeneral, err)
+	}
+	resp.URL = "http://" + listener.Addr().String()
+	site.logf = o.logf
+	httpServer := &http.Server{
+		Handler:           site,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	o.logf("📦 Serving %s (%d files)\n", archive, len(site.files))
+	if cfg != nil {
+		o.logf("🧭 Routes: %s\n", cfg.ruleCounts())
+	}
+	o.logf("🌐 Preview: %s (Ctrl-C to stop)\n", resp.URL)
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return newCLIError("listen_failed", "preview server stopped", ExitGeneral, err)
+	}
+	return nil
+}
+
+// archiveSite serves the files of an archive with the rules of the static
+// runtime.
+type archiveSite struct {
+	files  map[string]*zip.File
+	routes *siteRoutes
+	logf   func(format string, args ...interface{})
+}
+
+func newArchiveSite(r *zip.Reader) *archiveSite {
+	s := &archiveSite{files: map[string]*zip.File{}}
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name := path.Clean("/" + strings.ReplaceAll(f.Name, "\\", "/"))
+		s.files[name] = f
+	}
+	return s
+}
+
+// singleTopDir returns the directory holding every file of the archive, if
+// the files are all in one.
+func (s *archiveSite) singleTopDir() string {
+	top := ""
+	for name := range s.files {
+		dir, _, ok := strings.Cut(strings.TrimPrefix(name, "/"), "/")
+		if !ok || (top != "" && dir != top) {
+			return ""
+		}
+		top = dir
+	}
+	return top
+}
+
+func (s *archiveSite) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		s.log(r, http.StatusMethodNotAllowed, "")
+		return
+	}
+	reqPath := r.URL.Path
+	if !strings.HasPrefix(reqPath, "/") {
+		reqPath = "/" + reqPath
+	}
+
+	target := reqPath
+	if s.routes != nil {
+		if dest, status, ok := s.routes.redirect(reqPath); ok {
+			http.Redirect(w, r, dest, status)
+			s.log(r, status, "redirect to "+dest)
+			return
+		}
+		if dest, ok := s.routes.rewrite(reqPath); ok {
+			target = dest
+		}
+		for name, value := range s.routes.headers(reqPath) {
+			w.Header().Set(name, value)
+		}
+	}
+
+	name, status, how := s.resolve(target)
+	if name == "" {
+		http.NotFound(w, r)
+		s.log(r, http.StatusNotFound, "")
+		return
+	}
+	if target != reqPath {
+		how = strings.TrimPrefix(how+", rewritten from "+reqPath, ", ")
+	}
+	if err := s.serveFile(w, r, name, status); err != nil {
+		http.Error(w, "failed to read "+name, http.StatusInternalServerError)
+		s.log(r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	detail := name
+	if how != "" {
+		detail += " (" + how + ")"
+	}
+	s.log(r, status, detail)
+}
+
+// resolve returns the file answering p, the status to answer with and how
+// it was found when not by name; name is empty for a plain 404.
+func (s *archiveSite) resolve(p string) (name string, status int, how string) {
+	clean := path.Clean(p)
+	if s.files[clean] != nil && !strings.HasSuffix(p, "/") {
+		return clean, http.StatusOK, ""
+	}
+	if index := path.Join(clean, "index.html"); s.files[index] != nil {
+		return index, http.StatusOK, ""
+	}
+	if s.files[siteNotFoundPage] != nil {
+		return siteNotFoundPage, http.StatusNotFound, "404 page"
+	}
+	if path.Ext(clean) == "" && s.files[siteIndexPage] != nil {
+		return siteIndexPage, http.StatusOK, "index fallback"
+	}
+	return "", http.StatusNotFound, ""
+}
+
+func (s *archiveSite) serveFile(w http.ResponseWriter, r *http.Request, name string, status int) error {
+	f := s.files[name]
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	content, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return err
+	}
+	if status == http.StatusOK {
+		http.ServeContent(w, r, name, f.Modified, bytes.NewReader(content))
+		return nil
+	}
+	if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+		w.Header().Set("Content-Type", ctype)
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(content)
+	}
+	return nil
+}
+
+func (s *archiveSite) log(r *http.Request, status int, detail string) {
+	if s.logf == nil {
+		return
+	}
+	if detail == "" {
+		s.logf("%s %s → %d\n", r.Method, r.URL.RequestURI(), status)
+		return
+	}
+	s.logf("%s %s → %d %s\n", r.Method, r.URL.RequestURI(), status, detail)
+}
+
+// siteRoutes are the rules of a routes file compiled for matching.
+type siteRoutes struct {
+	headerRules []compiledRoute
+	redirects   []compiledRoute
+	rewrites    []compiledRoute
+}
+
+type compiledRoute struct {
+	pattern     *regexp.Regexp
+	destination string
+	status      int
+	headers     map[string]string
+}
+
+// routeParam matches the :name segments of route sources and destinations.
+var routeParam = regexp.MustCompile(`:[A-Za-z_][A-Za-z0-9_]*`)
+
+func compileSiteRoutes(cfg *routesConfig) *siteRoutes {
+	routes := &siteRoutes{}
+	for _, rule := range cfg.Headers {
+		routes.headerRules = append(routes.headerRules, compiledRoute{pattern: compileRouteSource(rule.Source), headers: rule.Headers})
+	}
+	for _, rule := range cfg.Redirects {
+		routes.redirects = append(routes.redirects, compiledRoute{pattern: compileRouteSource(rule.Source), destination: rule.Destination, status: rule.Status})
+	}
+	for _, rule := range cfg.Rewrites {
+		routes.rewrites = append(routes.rewrites, compiledRoute{pattern: compileRouteSource(rule.Source), destination: rule.Destination})
+	}
+	return routes
+}
+
+// compileRouteSource turns a source pattern into a regular expression where
+// :name captures one segment and a trailing * the rest of the path.
+func compileRouteSource(source string) *regexp.Regexp {
+	prefix, wildcard := strings.CutSuffix(source, "*")
+	var expr strings.Builder
+	expr.WriteString("^")
+	last := 0
+	for _, loc := range routeParam.FindAllStringIndex(prefix, -1) {
+		expr.WriteString(regexp.QuoteMeta(prefix[last:loc[0]]))
+		fmt.Fprintf(&expr, "(?P<%s>[^/]+)", prefix[loc[0]+1:loc[1]])
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(prefix[last:]))
+	if wildcard {
+		expr.WriteString("(?P<splat>.*)")
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String())
+}
+
+// apply matches p against the rule and returns its destination with the
+// captured segments and rest of the path substituted.
+func (r compiledRoute) apply(p string) (string, bool) {
+	m := r.pattern.FindStringSubmatch(p)
+	if m == nil {
+		return "", false
+	}
+	dest := r.destination
+	for i, name := range r.pattern.SubexpNames() {
+		switch {
+		case name == "splat":
+			dest = strings.ReplaceAll(dest, "*", m[i])
+		case name != "":
+			dest = strings.ReplaceAll(dest, ":"+name, m[i])
+		}
+	}
+	return dest, true
+}
+
+// redirect returns the destination and status of the first redirect
+// matching p.
+func (s *siteRoutes) redirect(p string) (string, int, bool) {
+	for _, rule := range s.redirects {
+		if dest, ok := rule.apply(p); ok {
+			return dest, rule.status, true
+		}
+	}
+	return "", 0, false
+}
+
+// rewrite returns the path served for p by the first rewrite matching it.
+func (s *siteRoutes) rewrite(p string) (string, bool) {
+	for _, rule := range s.rewrites {
+		if dest, ok := rule.apply(p); ok {
+			return dest, true
+		}
+	}
+	return "", false
+}
+
+// headers returns the headers of every header rule matching p, later rules
+// overriding earlier ones.
+func (s *siteRoutes) headers(p string) map[string]string {
+	headers := map[string]string{}
+	for _, rule := range s.headerRules {
+		if rule.pattern.MatchString(p) {
+			for name, value := range rule.headers {
+				headers[name] = value
+			}
+		}
+	}
+	return headers
+}
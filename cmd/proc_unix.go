@@ -0,0 +1,34 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setInterruptGroup runs c in its own process group and makes context
+// cancellation interrupt the whole group, so shells and their children
+// (npm, bundlers) stop together.
+func setInterruptGroup(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	c.Cancel = func() error {
+		return syscall.Kill(-c.Process.Pid, syscall.SIGINT)
+	}
+}
+
+// disableEcho turns off echo on the terminal f, for reading a passphrase,
+// and returns a function that turns it back on.
+func disableEcho(f *os.File) func() {
+	off := exec.Command("stty", "-echo")
+	off.Stdin = f
+	if off.Run() != nil {
+		return func() {}
+	}
+	return func() {
+		on := exec.Command("stty", "echo")
+		on.Stdin = f
+		_ = on.Run()
+	}
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setInterruptGroup keeps the default cancellation behaviour (kill) on
+// Windows, which has no process-group signals.
+func setInterruptGroup(c *exec.Cmd) {}
+
+// disableEcho leaves echo on: Windows consoles have no stty, so a prompted
+// passphrase is visible while typed; ROBOTX_PASSPHRASE avoids the prompt.
+func disableEcho(f *os.File) func() { return func() {} }
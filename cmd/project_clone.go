@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
+
+	"github.com/spf13/cobra"
+)
+
+type projectCloneOptions struct {
+	*app
+	from       string
+	name       string
+	visibility string
+	includeEnv bool
+	publish    bool
+	timeout    int
+}
+
+type projectCloneResponse struct {
+	SourceProjectID string `json:"source_project_id"`
+	ProjectID       string `json:"project_id"`
+	ProjectName     string `json:"project_name"`
+	Visibility      string `json:"visibility,omitempty"`
+	// FlagsCopied and EnvCopied count the copied feature flags and
+	// environment variables; values are not echoed.
+	FlagsCopied   int    `json:"flags_copied"`
+	EnvCopied     int    `json:"env_copied"`
+	SourceBuildID string `json:"source_build_id,omitempty"`
+	BuildID       string `json:"build_id,omitempty"`
+	ProductionURL string `json:"production_url,omitempty"`
+	Published     bool   `json:"published"`
+}
+
+func newProjectCloneCmd(a *app) *cobra.Command {
+	o := &projectCloneOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "clone",
+		Short: "Create a copy of a project",
+		Long: `Create a new project from an existing one, e.g. a staging twin of production.
+
+The new project gets the source project's visibility and feature flags. With
+--include-env the project's runtime environment variables are copied too, and
+with --publish the latest successful build of the source project is copied
+into the new project and published, without building again.
+
+Preview protection passwords, maintenance mode and scheduled jobs are not
+copied.`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVar(&o.from, "from", "", "ID of the project to copy")
+	cmd.Flags().StringVarP(&o.name, "name", "n", "", "Name of the new project")
+	cmd.Flags().StringVar(&o.visibility, "visibility", "", "Visibility of the new project (default: same as the source)")
+	cmd.Flags().BoolVar(&o.includeEnv, "include-env", false, "Copy the runtime environment variables")
+	cmd.Flags().BoolVar(&o.publish, "publish", false, "Copy the latest successful build and publish it")
+	cmd.Flags().IntVar(&o.timeout, "timeout", 600, "Seconds to wait for the copied build with --publish")
+	markFlagsRequired(cmd, "from", "name")
+	return cmd
+}
+
+func (o *projectCloneOptions) run(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	name := strings.ToLower(strings.TrimSpace(o.name))
+	if err := validateProjectName(name); err != nil {
+		return newCLIError("invalid_project_name", err.Error(), ExitGeneral, nil)
+	}
+
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	caps := serverCapabilities(c, baseURL)
+	if o.includeEnv && !caps.Supports(client.CapabilityProjectEnv) {
+		return newCLIError("unsupported_server", "this RobotX server does not support project environment variables", ExitAPI, nil)
+	}
+	if o.publish && !caps.Supports(client.CapabilityCopyBuilds) {
+		return newCLIError("unsupported_server", "this RobotX server does not support copying builds between projects", ExitAPI, nil)
+	}
+
+	source, err := c.GetProject(o.from)
+	if err != nil {
+		if client.IsNotFound(err) {
+			return newCLIError("not_found", fmt.Sprintf("project %s not found", o.from), ExitNotFound, err)
+		}
+		return newCLIError("api_error", "failed to get project", ExitAPI, err)
+	}
+	// Creating a project is create-or-update by name, so refuse names in use
+	// instead of overwriting an existing project's settings.
+	projects, err := c.ListProjects(0)
+	if err != nil {
+		return newCLIError("api_error", "failed to list projects", ExitAPI, err)
+	}
+	for _, p := range projects {
+		if p.Name == name {
+			return newCLIError("project_exists", fmt.Sprintf("a project named %s already exists (%s)", name, p.ProjectID), ExitGeneral, nil)
+		}
+	}
+
+	var sourceBuild *client.Build
+	if o.publish {
+		builds, err := c.ListBuildsForProject(source.ProjectID, 0)
+		if err != nil {
+			return newCLIError("api_error", "failed to list project builds", ExitAPI, err)
+		}
+		for _, b := range sortBuildsNewestFirst(builds) {
+			if normalizedBuildStatus(b.Status) == "success" {
+				sourceBuild = b
+				break
+			}
+		}
+		if sourceBuild == nil {
+			return newCLIError("no_successful_build", fmt.Sprintf("project %s has no successful build to publish", source.ProjectID), ExitGeneral, nil)
+		}
+	}
+
+	visibility := firstNonEmpty(o.visibility, source.Visibility)
+	project, err := c.CreateProject(client.CreateProjectRequest{Name: name, Visibility: visibility})
+	if err != nil {
+		return newCLIError("api_error", "failed to create project", ExitAPI, err)
+	}
+	o.logf("📦 Created %s (%s) from %s\n", project.Name, project.ProjectID, source.ProjectID)
+	resp := projectCloneResponse{
+		SourceProjectID: source.ProjectID,
+		ProjectID:       project.ProjectID,
+		ProjectName:     project.Name,
+		Visibility:      firstNonEmpty(project.Visibility, visibility),
+	}
+
+	if caps.Supports(client.CapabilityFeatureFlags) {
+		flags, err := c.ListFlags(source.ProjectID)
+		if err != nil && !client.IsNotFound(err) {
+			return o.cloneError("failed to read feature flags", resp, err)
+		}
+		if len(flags) > 0 {
+			if _, err := c.SetFlags(project.ProjectID, flags); err != nil {
+				return o.cloneError("failed to copy feature flags", resp, err)
+			}
+			resp.FlagsCopied = len(flags)
+			o.logf("🚩 Copied %d feature flag(s)\n", len(flags))
+		}
+	}
+	if o.includeEnv {
+		env, err := c.GetProjectEnv(source.ProjectID)
+		if err != nil {
+			return o.cloneError("failed to read environment variables", resp, err)
+		}
+		if len(env) > 0 {
+			if err := c.SetProjectEnv(project.ProjectID, env); err != nil {
+				return o.cloneError("failed to copy environment variables", resp, err)
+			}
+			resp.EnvCopied = len(env)
+			o.logf("🔐 Copied %d environment variable(s)\n", len(env))
+		}
+	}
+	if source.PreviewProtection != nil && source.PreviewProtection.Enabled {
+		o.logf("⚠️  Preview protection is not copied; run 'robotx protect --project-id %s' to set a password\n", project.ProjectID)
+	}
+
+	if o.publish {
+		build, err := c.CopyBuild(project.ProjectID, sourceBuild.BuildID)
+		if err != nil {
+			return o.cloneError("failed to copy build", resp, err)
+		}
+		o.logf("♻️  Copied build %s as %s\n", sourceBuild.BuildID, build.BuildID)
+		resp.SourceBuildID = sourceBuild.BuildID
+		resp.BuildID = build.BuildID
+
+		d := &pipeline.Deploy{
+			Client:  c,
+			URLs:    projectURLs{baseURL: baseURL},
+			Project: project,
+			Build:   build,
+		}
+		steps := []pipeline.Step{pipeline.WaitForBuild{Timeout: time.Duration(o.timeout) * time.Second}, pipeline.Publish{}}
+		if err := pipeline.New(pipeline.Emitters{o.deployEventLogger(steps), o.traceEmitter()}, steps...).Run(ctx, d); err != nil {
+			cliErr := deployStepError(ctx, err)
+			var e *cliError
+			if errors.As(cliErr, &e) && e.Details == nil {
+				e.Details = resp
+			}
+			return cliErr
+		}
+		resp.ProductionURL = d.ProductionURL
+		resp.Published = d.ProductionURL != ""
+	}
+
+	if err := o.emitSuccess("projects "+cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// cloneError reports a failure after the new project was created, with the
+// partial result as details so the project can be fixed or removed.
+func (o *projectCloneOptions) cloneError(message string, resp projectCloneResponse, err error) error {
+	cliErr := newCLIError("api_error", fmt.Sprintf("%s; project %s was created", message, resp.ProjectID), ExitAPI, err)
+	cliErr.Details = resp
+	return cliErr
+}
@@ -46,3 +46,20 @@ func resolvePublishURL(fallbackBaseURL string, project *client.Project) string {
 	}
 	return fmt.Sprintf("%s/%s", baseURL, projectID)
 }
+
+// buildPreviewURL returns the preview URL of a single build from its preview
+// path, which servers may give as an absolute URL or a path under baseURL.
+func buildPreviewURL(baseURL string, build *client.Build) string {
+	if build == nil {
+		return ""
+	}
+	previewPath := strings.TrimSpace(build.PreviewPath)
+	if previewPath == "" || strings.HasPrefix(previewPath, "http://") || strings.HasPrefix(previewPath, "https://") {
+		return previewPath
+	}
+	baseURL = strings.TrimSuffix(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		return ""
+	}
+	return baseURL + "/" + strings.TrimPrefix(previewPath, "/")
+}
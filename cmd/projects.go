@@ -2,76 +2,109 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"sync"
 	"text/tabwriter"
 
 	"github.com/haibingtown/robotx_cli/pkg/client"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
-var projectsCmd = &cobra.Command{
-	Use:   "projects",
-	Short: "List projects",
-	Long:  `List projects for the current account.`,
-	RunE:  runProjects,
+type projectsOptions struct {
+	*app
+	limit       int
+	details     bool
+	concurrency int
 }
 
-var (
-	projectsLimit int
-)
-
 type projectsResponse struct {
 	Limit    int               `json:"limit,omitempty"`
-	Projects []*client.Project `json:"projects"`
+	Projects []*projectListing `json:"projects"`
+}
+
+// projectListing is a project with the details fetched by --details.
+type projectListing struct {
+	*client.Project
+	LatestBuild  *client.Build `json:"latest_build,omitempty"`
+	DetailsError string        `json:"details_error,omitempty"`
 }
 
-func init() {
-	rootCmd.AddCommand(projectsCmd)
+func newProjectsCmd(a *app) *cobra.Command {
+	o := &projectsOptions{app: a}
+	cmd := &cobra.Command{
+		Use:     "projects",
+		Aliases: []string{"project"},
+		Short:   "List projects",
+		Long: `List projects for the current account.
 
-	projectsCmd.Flags().IntVar(&projectsLimit, "limit", 50, "Number of projects to list (max enforced by server)")
+With --details the latest build of each project is fetched as well, with up
+to --concurrency requests in flight. "projects clone" copies a project.`,
+		RunE: o.run,
+	}
+	cmd.AddCommand(newProjectCloneCmd(a))
+
+	cmd.Flags().IntVar(&o.limit, "limit", 50, "Number of projects to list (max enforced by server)")
+	cmd.Flags().BoolVar(&o.details, "details", false, "Fetch the latest build status of each project")
+	cmd.Flags().IntVar(&o.concurrency, "concurrency", 4, "Maximum parallel requests for --details")
+	return cmd
 }
 
-func runProjects(cmd *cobra.Command, args []string) error {
-	baseURL := viper.GetString("base_url")
-	apiKey := viper.GetString("api_key")
+func (o *projectsOptions) run(cmd *cobra.Command, args []string) error {
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
 
 	if baseURL == "" {
-		return newCLIError("missing_base_url", "base URL is required", 1, nil)
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
 	}
 	if apiKey == "" {
-		return newCLIError("missing_api_key", "API key is required", 1, nil)
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+
+	if o.concurrency < 1 {
+		return newCLIError("invalid_argument", "--concurrency must be at least 1", ExitGeneral, nil)
 	}
 
-	c := client.NewClient(baseURL, apiKey)
-	logf("📋 Listing projects...\n")
-	projects, err := c.ListProjects(projectsLimit)
+	c := o.newCachedAPIClient(baseURL, apiKey)
+	o.logf("📋 Listing projects...\n")
+	projects, err := c.ListProjects(o.limit)
 	if err != nil {
-		return newCLIError("api_error", "failed to list projects", 2, err)
+		return newCLIError("api_error", "failed to list projects", ExitAPI, err)
+	}
+
+	listings := make([]*projectListing, len(projects))
+	for i, project := range projects {
+		listings[i] = &projectListing{Project: project}
+	}
+	if o.details {
+		o.enrichProjects(c, listings)
 	}
 
 	resp := projectsResponse{
-		Limit:    projectsLimit,
-		Projects: projects,
+		Limit:    o.limit,
+		Projects: listings,
 	}
-	if err := emitSuccess(cmd.Name(), resp); err != nil {
-		return newCLIError("output_error", "failed to render JSON output", 1, err)
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
 	}
-	if isJSONOutput() {
+	if o.isJSONOutput() {
 		return nil
 	}
 
 	if len(projects) == 0 {
-		fmt.Fprintln(os.Stdout, "No projects found.")
+		fmt.Fprintln(o.out(), "No projects found.")
 		return nil
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "PROJECT_ID\tNAME\tVISIBILITY\tCREATED_AT\tUPDATED_AT\tPREVIEW_URL\tPRODUCTION_URL")
-	for _, project := range projects {
+	w := tabwriter.NewWriter(o.out(), 0, 0, 2, ' ', 0)
+	header := "PROJECT_ID\tNAME\tVISIBILITY\tCREATED_AT\tUPDATED_AT\tPREVIEW_URL\tPRODUCTION_URL"
+	if o.details {
+		header += "\tLATEST_BUILD\tBUILD_STATUS"
+	}
+	fmt.Fprintln(w, header)
+	for _, listing := range listings {
+		project := listing.Project
 		fmt.Fprintf(
 			w,
-			"%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			"%s\t%s\t%s\t%s\t%s\t%s\t%s",
 			project.ProjectID,
 			valueOrDash(project.Name),
 			valueOrDash(project.Visibility),
@@ -80,8 +113,51 @@ func runProjects(cmd *cobra.Command, args []string) error {
 			valueOrDash(projectPreviewURL(project, baseURL)),
 			valueOrDash(resolvePublishURL(baseURL, project)),
 		)
+		if o.details {
+			switch {
+			case listing.DetailsError != "":
+				fmt.Fprint(w, "\t?\terror")
+			case listing.LatestBuild == nil:
+				fmt.Fprint(w, "\t-\t-")
+			default:
+				fmt.Fprintf(w, "\t%s (#%s)\t%s", listing.LatestBuild.BuildID, formatBuildVersionSeq(listing.LatestBuild.VersionSeq), listing.LatestBuild.StatusText())
+			}
+		}
+		fmt.Fprintln(w)
 	}
 	_ = w.Flush()
+	for _, listing := range listings {
+		if listing.DetailsError != "" {
+			o.logf("⚠️  %s: %s\n", listing.ProjectID, listing.DetailsError)
+		}
+	}
 
 	return nil
 }
+
+// enrichProjects fetches the latest build of each project with at most
+// o.concurrency requests in flight. Failures are recorded per project so one
+// unavailable project does not fail the listing.
+func (o *projectsOptions) enrichProjects(c *client.Client, listings []*projectListing) {
+	sem := make(chan struct{}, o.concurrency)
+	var wg sync.WaitGroup
+	for _, listing := range listings {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(listing *projectListing) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			builds, err := c.ListBuildsForProject(listing.ProjectID, 1)
+			if err != nil {
+				listing.DetailsError = err.Error()
+				return
+			}
+			if sorted := sortBuildsNewestFirst(builds); len(sorted) > 0 {
+				listing.LatestBuild = sorted[0]
+			}
+		}(listing)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bufio"
+	"strings"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+type protectOptions struct {
+	*app
+	projectID     string
+	username      string
+	password      string
+	passwordStdin bool
+	off           bool
+}
+
+type protectResponse struct {
+	ProjectID string `json:"project_id"`
+	Protected bool   `json:"protected"`
+	Username  string `json:"username,omitempty"`
+}
+
+func newProtectCmd(a *app) *cobra.Command {
+	o := &protectOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "protect",
+		Short: "Require a password to open a project's previews",
+		Long: `Require a password to open the project's preview URLs, so unreleased apps
+are not publicly reachable or crawlable. Production is not affected.
+
+With --username, previews use HTTP basic auth; otherwise visitors get a
+password page. Use --password-stdin to keep the password out of shell history,
+and --off to remove the protection. status shows whether previews are
+protected.`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	cmd.Flags().StringVar(&o.username, "username", "", "Username for HTTP basic auth (default: password page)")
+	cmd.Flags().StringVar(&o.password, "password", "", "Password required to open previews")
+	cmd.Flags().BoolVar(&o.passwordStdin, "password-stdin", false, "Read the password from stdin")
+	cmd.Flags().BoolVar(&o.off, "off", false, "Remove the preview protection")
+	return cmd
+}
+
+func (o *protectOptions) run(cmd *cobra.Command, args []string) error {
+	password := o.password
+	if o.passwordStdin {
+		if password != "" {
+			return newCLIError("invalid_argument", "--password and --password-stdin cannot be combined", ExitGeneral, nil)
+		}
+		line, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		password = strings.TrimRight(line, "\r\n")
+	}
+	switch {
+	case o.off && (password != "" || o.passwordStdin || o.username != ""):
+		return newCLIError("invalid_argument", "--off cannot be combined with --password or --username", ExitGeneral, nil)
+	case !o.off && password == "":
+		return newCLIError("missing_argument", "--password, --password-stdin or --off is required", ExitGeneral, nil)
+	}
+
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	projectID, _ := stateProjectID(o.projectID)
+	if projectID == "" {
+		return newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityPreviewProtection) {
+		return newCLIError("unsupported_server", "this RobotX server does not support preview protection", ExitAPI, nil)
+	}
+
+	resp := protectResponse{ProjectID: projectID}
+	if o.off {
+		if err := c.DisablePreviewProtection(projectID); err != nil {
+			return newCLIError("api_error", "failed to remove preview protection", ExitAPI, err)
+		}
+		o.logf("🔓 Previews of %s no longer require a password\n", projectID)
+	} else {
+		protection, err := c.SetPreviewProtection(projectID, o.username, password)
+		if err != nil {
+			return newCLIError("api_error", "failed to set preview protection", ExitAPI, err)
+		}
+		resp.Protected, resp.Username = true, protection.Username
+		if resp.Username != "" {
+			o.logf("🔒 Previews of %s now require basic auth as %s\n", projectID, resp.Username)
+		} else {
+			o.logf("🔒 Previews of %s now require a password\n", projectID)
+		}
+	}
+
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// formatPreviewProtection describes a project's preview protection for
+// status.
+func formatPreviewProtection(p *client.PreviewProtection) string {
+	switch {
+	case p == nil:
+		return "-"
+	case !p.Enabled:
+		return "off"
+	case p.Username != "":
+		return "basic auth (" + p.Username + ")"
+	}
+	return "password"
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+type pruneOptions struct {
+	*app
+	projectID string
+	keep      int
+	olderThan string
+	dryRun    bool
+}
+
+type pruneResponse struct {
+	ProjectID string        `json:"project_id"`
+	DryRun    bool          `json:"dry_run"`
+	Kept      int           `json:"kept"`
+	Deleted   []prunedBuild `json:"deleted"`
+	Failed    []prunedBuild `json:"failed,omitempty"`
+	Truncated bool          `json:"truncated,omitempty"`
+}
+
+type prunedBuild struct {
+	BuildID    string    `json:"build_id"`
+	VersionSeq int64     `json:"version_seq,omitempty"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	Error      string    `json:"error,omitempty"`
+}
+
+func newPruneCmd(a *app) *cobra.Command {
+	o := &pruneOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete old builds of a project",
+		Long: `Delete old builds and their artifacts to control storage costs.
+
+The newest --keep builds are always kept, as are pinned builds (see pin), the
+published build, the preview build, the previously published build recorded
+in .robotx/state.json and builds that have not finished. With --older-than
+only builds older than that age are deleted, e.g. 30d, 2w or 12h.
+
+Use --dry-run to list what would be deleted. Deleting asks for confirmation
+unless --yes is given.`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	cmd.Flags().IntVar(&o.keep, "keep", 10, "Number of newest builds to keep")
+	cmd.Flags().StringVar(&o.olderThan, "older-than", "", "Only delete builds older than this age (e.g. 30d, 2w, 12h)")
+	cmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "List the builds that would be deleted without deleting them")
+	return cmd
+}
+
+func (o *pruneOptions) run(cmd *cobra.Command, args []string) error {
+	if o.keep < 0 {
+		return newCLIError("invalid_argument", "--keep must not be negative", ExitGeneral, nil)
+	}
+	var maxAge time.Duration
+	if o.olderThan != "" {
+		age, err := parseAge(o.olderThan)
+		if err != nil {
+			return newCLIError("invalid_argument", fmt.Sprintf("invalid --older-than: %v", err), ExitGeneral, nil)
+		}
+		maxAge = age
+	}
+
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	projectID, st := stateProjectID(o.projectID)
+	if projectID == "" {
+		return newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	if !o.dryRun && !serverCapabilities(c, baseURL).Supports(client.CapabilityDeleteBuilds) {
+		return newCLIError("unsupported_server", "this RobotX server does not support deleting builds", ExitAPI, nil)
+	}
+	project, err := c.GetProject(projectID)
+	if err != nil {
+		return newCLIError("api_error", "failed to get project", ExitAPI, err)
+	}
+	builds, truncated, err := listBuildsSince(c, projectID, time.Time{})
+	if err != nil {
+		return newCLIError("api_error", "failed to list builds", ExitAPI, err)
+	}
+
+	protected := protectedBuilds(project, st)
+	var cutoff time.Time
+	if maxAge > 0 {
+		cutoff = time.Now().Add(-maxAge)
+	}
+	resp := pruneResponse{ProjectID: projectID, DryRun: o.dryRun, Deleted: []prunedBuild{}, Truncated: truncated}
+	var candidates []prunedBuild
+	for i, b := range sortBuildsNewestFirst(builds) {
+		status := normalizedBuildStatus(b.Status)
+		if i < o.keep || b.Pinned || protected[b.BuildID] || (status != "success" && status != "failed") ||
+			(!cutoff.IsZero() && !b.CreatedAt.Before(cutoff)) {
+			resp.Kept++
+			continue
+		}
+		candidates = append(candidates, prunedBuild{BuildID: b.BuildID, VersionSeq: b.VersionSeq, Status: b.Status, CreatedAt: b.CreatedAt})
+	}
+
+	if len(candidates) == 0 {
+		o.logf("✅ Nothing to prune; keeping %d build(s)\n", resp.Kept)
+		return o.emitPrune(cmd, resp)
+	}
+	if !o.isJSONOutput() {
+		w := tabwriter.NewWriter(o.out(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "BUILD_ID\tSEQ\tSTATUS\tCREATED_AT")
+		for _, b := range candidates {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", b.BuildID, formatBuildVersionSeq(b.VersionSeq), b.Status, formatBuildTime(b.CreatedAt))
+		}
+		_ = w.Flush()
+	}
+	if o.dryRun {
+		resp.Deleted = candidates
+		o.logf("🧹 Would delete %d build(s) and keep %d (dry run)\n", len(candidates), resp.Kept)
+		return o.emitPrune(cmd, resp)
+	}
+
+	if err := o.confirm(fmt.Sprintf("Delete %d build(s) of %s", len(candidates), projectID)); err != nil {
+		return err
+	}
+	for _, b := range candidates {
+		if err := c.DeleteBuild(projectID, b.BuildID); err != nil {
+			b.Error = err.Error()
+			resp.Failed = append(resp.Failed, b)
+			o.logf("❌ Failed to delete %s: %v\n", b.BuildID, err)
+			continue
+		}
+		resp.Deleted = append(resp.Deleted, b)
+	}
+	o.logf("🧹 Deleted %d build(s), kept %d\n", len(resp.Deleted), resp.Kept)
+	if len(resp.Failed) > 0 {
+		cliErr := newCLIError("prune_failed", fmt.Sprintf("failed to delete %d of %d build(s)", len(resp.Failed), len(candidates)), ExitAPI, nil)
+		cliErr.Details = resp
+		return cliErr
+	}
+	return o.emitPrune(cmd, resp)
+}
+
+func (o *pruneOptions) emitPrune(cmd *cobra.Command, resp pruneResponse) error {
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// protectedBuilds returns the builds prune must keep regardless of age: the
+// published and preview builds and the recorded previous publish, which
+// rollback returns to.
+func protectedBuilds(project *client.Project, st *deployState) map[string]bool {
+	protected := map[string]bool{}
+	if refs := project.RuntimeRefs; refs != nil {
+		if refs.Publish != nil {
+			protected[refs.Publish.BuildID] = true
+		}
+		if refs.Preview != nil {
+			protected[refs.Preview.BuildID] = true
+		}
+	}
+	if st != nil {
+		for _, p := range []*statePublish{st.LastPublish, st.PreviousPublish} {
+			if p != nil {
+				protected[p.BuildID] = true
+			}
+		}
+	}
+	delete(protected, "")
+	return protected
+}
+
+// parseAge parses a duration that may also use d (days) and w (weeks), such
+// as 30d or 2w.
+func parseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(value, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count <= 0 {
+				return 0, fmt.Errorf("expected a positive number before %q: %s", suffix, value)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive: %s", value)
+	}
+	return d, nil
+}
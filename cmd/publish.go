@@ -1,80 +1,387 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/haibingtown/robotx_cli/pkg/client"
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
-var publishCmd = &cobra.Command{
-	Use:   "publish",
-	Short: "Publish a build to production",
-	Long:  `Publish a specific build to the production environment.`,
-	RunE:  runPublish,
+type publishOptions struct {
+	*app
+	projectID string
+	buildID   string
+	stage     bool
+	commit    bool
+	abort     bool
+	purge     bool
+	// requireApproval creates a publish request instead of publishing.
+	requireApproval bool
+	slackWebhook    string
+	waitApproval    bool
+	approvalTimeout int
 }
 
-var (
-	publishProjectID string
-	publishBuildID   string
-)
-
 type publishResponse struct {
 	ProjectID     string `json:"project_id"`
-	BuildID       string `json:"build_id"`
+	BuildID       string `json:"build_id,omitempty"`
 	ProductionURL string `json:"production_url,omitempty"`
+	// Stage is "staged", "committed" or "aborted" for blue/green publishes.
+	Stage      string `json:"stage,omitempty"`
+	StagingURL string `json:"staging_url,omitempty"`
+	// CachePurged reports that --purge invalidated the CDN cache.
+	CachePurged bool `json:"cache_purged,omitempty"`
+	// EnvChanges lists the production env changes synced from robotx.yaml.
+	EnvChanges []pipeline.EnvVarChange `json:"env_changes,omitempty"`
+	// Approval is the publish request created with --require-approval.
+	Approval *client.PublishApproval `json:"approval,omitempty"`
+	Timings  *commandTimings         `json:"timings,omitempty"`
 }
 
-func init() {
-	rootCmd.AddCommand(publishCmd)
+func newPublishCmd(a *app) *cobra.Command {
+	o := &publishOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "publish",
+		Short: "Publish a build to production",
+		Long: `Publish a specific build to the production environment.
+
+Inside a deployed project directory, --project-id and --build-id default to the
+project and last build recorded in .robotx/state.json.
 
-	publishCmd.Flags().StringVarP(&publishProjectID, "project-id", "p", "", "Project ID (required)")
-	publishCmd.Flags().StringVarP(&publishBuildID, "build-id", "b", "", "Build ID (required)")
-	publishCmd.MarkFlagRequired("project-id")
-	publishCmd.MarkFlagRequired("build-id")
+Blue/green publishing splits the switch into steps: --stage deploys the build
+to a staging slot with its own URL, leaving production unchanged;
+--commit atomically switches production to the staged build; --abort
+discards it. status shows the staged build.
+
+--purge invalidates the CDN cache of the whole site once production serves
+the new build (see cache purge); a failed purge only warns.
+
+--require-approval leaves production unchanged and creates a publish request
+instead, which a second person or a later pipeline stage approves with
+"robotx approvals approve <id>"; the server publishes the build then.
+--slack-webhook posts the request to a Slack incoming webhook, and
+--wait-approval keeps publish running until the request is approved or
+rejected, failing with approval_rejected or approval_timeout otherwise.`,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	cmd.Flags().StringVarP(&o.buildID, "build-id", "b", "", "Build ID (default: last build from .robotx/state.json)")
+	cmd.Flags().BoolVar(&o.stage, "stage", false, "Deploy the build to the staging slot instead of production")
+	cmd.Flags().BoolVar(&o.commit, "commit", false, "Switch production to the staged build")
+	cmd.Flags().BoolVar(&o.abort, "abort", false, "Discard the staged build")
+	cmd.Flags().BoolVar(&o.purge, "purge", false, "Purge the CDN cache after publishing to production")
+	cmd.Flags().BoolVar(&o.requireApproval, "require-approval", false, "Create a publish request that must be approved instead of publishing")
+	cmd.Flags().StringVar(&o.slackWebhook, "slack-webhook", "", "Slack incoming webhook URL to post publish requests to")
+	cmd.Flags().BoolVar(&o.waitApproval, "wait-approval", false, "Wait until the publish request is approved or rejected")
+	cmd.Flags().IntVar(&o.approvalTimeout, "approval-timeout", 3600, "Seconds to wait for a decision with --wait-approval")
+	return cmd
 }
 
-func runPublish(cmd *cobra.Command, args []string) error {
-	baseURL := viper.GetString("base_url")
-	apiKey := viper.GetString("api_key")
+func (o *publishOptions) run(cmd *cobra.Command, args []string) error {
+	started := time.Now()
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
 
 	if baseURL == "" {
-		return newCLIError("missing_base_url", "base URL is required", 1, nil)
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
 	}
 	if apiKey == "" {
-		return newCLIError("missing_api_key", "API key is required", 1, nil)
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+
+	modes := 0
+	for _, set := range []bool{o.stage, o.commit, o.abort} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return newCLIError("invalid_argument", "only one of --stage, --commit and --abort can be given", ExitGeneral, nil)
+	}
+	if o.purge && (o.stage || o.abort || (o.requireApproval && !o.waitApproval)) {
+		return newCLIError("invalid_argument", "--purge only applies to publishes that change production", ExitGeneral, nil)
+	}
+	if o.requireApproval && modes > 0 {
+		return newCLIError("invalid_argument", "--require-approval cannot be combined with --stage, --commit or --abort", ExitGeneral, nil)
+	}
+	if !o.requireApproval {
+		for _, name := range []string{"slack-webhook", "wait-approval", "approval-timeout"} {
+			if cmd.Flags().Changed(name) {
+				return newCLIError("invalid_argument", fmt.Sprintf("--%s only applies with --require-approval", name), ExitGeneral, nil)
+			}
+		}
+	}
+	if o.waitApproval && o.approvalTimeout <= 0 {
+		return newCLIError("invalid_argument", "--approval-timeout must be positive", ExitGeneral, nil)
+	}
+
+	projectID, st := stateProjectID(o.projectID)
+	c := o.newAPIClient(baseURL, apiKey)
+	if modes > 0 && !serverCapabilities(c, baseURL).Supports(client.CapabilityStagedPublish) {
+		return newCLIError("unsupported_server", "this RobotX server does not support staged publishing", ExitAPI, nil)
+	}
+	if o.commit || o.abort {
+		if projectID == "" {
+			return newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+		}
+		if o.commit {
+			return o.runCommit(cmd, c, baseURL, projectID, st, started)
+		}
+		return o.runAbort(cmd, c, projectID, started)
 	}
 
-	c := client.NewClient(baseURL, apiKey)
+	buildID := o.buildID
+	if buildID == "" {
+		buildID = st.lastBuildID()
+	}
+	if projectID == "" || buildID == "" {
+		return newCLIError("missing_argument", "--project-id and --build-id are required outside a deployed project directory", ExitGeneral, nil)
+	}
+	if o.stage {
+		return o.runStage(cmd, c, projectID, buildID, started)
+	}
+	if o.requireApproval {
+		return o.runRequestApproval(cmd, c, baseURL, projectID, buildID, st, started)
+	}
 
-	logf("🚀 Publishing build %s to production...\n", publishBuildID)
-	publicPath, err := c.PublishBuild(publishProjectID, publishBuildID)
+	envChanges, err := o.syncPublishEnv(c, baseURL, projectID, st)
+	if err != nil {
+		return err
+	}
+	publishStarted := time.Now()
+	prodURL, err := o.publishBuild(c, baseURL, projectID, buildID, st)
 	if err != nil {
-		return newCLIError("publish_failed", "failed to publish", 4, err)
+		return err
+	}
+	o.recordHistory(historyEntry{
+		Command:       cmd.Name(),
+		ProjectID:     projectID,
+		BuildID:       buildID,
+		ProductionURL: prodURL,
+		Args:          []string{cmd.Name(), "--project-id=" + projectID, "--build-id=" + buildID},
+	})
+	cachePurged := o.purge && o.purgeAfterPublish(c, baseURL, projectID)
+
+	if err := o.emitSuccess(cmd.Name(), publishResponse{
+		ProjectID:     projectID,
+		BuildID:       buildID,
+		ProductionURL: prodURL,
+		CachePurged:   cachePurged,
+		EnvChanges:    envChanges,
+		Timings: &commandTimings{
+			PublishMS: time.Since(publishStarted).Milliseconds(),
+			TotalMS:   time.Since(started).Milliseconds(),
+		},
+	}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
 	}
 
-	logf("✅ Published successfully!\n")
+	return nil
+}
+
+// runStage deploys the build to the staging slot.
+func (o *publishOptions) runStage(cmd *cobra.Command, c *client.Client, projectID, buildID string, started time.Time) error {
+	o.logf("🟦 Staging build %s...\n", buildID)
+	staged, err := c.StagePublish(projectID, buildID)
+	if err != nil {
+		return newCLIError("publish_failed", "failed to stage build", ExitPublish, err)
+	}
+	o.logf("✅ Build staged; production is unchanged\n")
+	if staged.URL != "" {
+		o.logf("🌐 Staging URL: %s\n", verbatim(staged.URL))
+	}
+	o.logf("👉 Run 'robotx publish --commit' to switch production, or 'robotx publish --abort' to discard\n")
+	o.recordHistory(historyEntry{
+		Command:   cmd.Name(),
+		ProjectID: projectID,
+		BuildID:   buildID,
+		Args:      []string{cmd.Name(), "--stage", "--project-id=" + projectID, "--build-id=" + buildID},
+	})
+
+	return o.emitPublish(cmd, publishResponse{
+		ProjectID:  projectID,
+		BuildID:    staged.BuildID,
+		Stage:      "staged",
+		StagingURL: staged.URL,
+		Timings:    &commandTimings{TotalMS: time.Since(started).Milliseconds()},
+	})
+}
+
+// runCommit switches production to the staged build.
+func (o *publishOptions) runCommit(cmd *cobra.Command, c *client.Client, baseURL, projectID string, st *deployState, started time.Time) error {
+	envChanges, err := o.syncPublishEnv(c, baseURL, projectID, st)
+	if err != nil {
+		return err
+	}
+	o.logf("🚀 Switching production to the staged build...\n")
+	publishStarted := time.Now()
+	buildID, publicPath, err := c.CommitStagedPublish(projectID)
+	if err != nil {
+		return newCLIError("publish_failed", "failed to commit the staged build", ExitPublish, err)
+	}
+	if buildID == "" {
+		if project, err := c.GetProject(projectID); err == nil && project.RuntimeRefs != nil && project.RuntimeRefs.Publish != nil {
+			buildID = project.RuntimeRefs.Publish.BuildID
+		}
+	}
+	prodURL := o.finishPublish(c, baseURL, projectID, buildID, publicPath, st)
+	o.recordHistory(historyEntry{
+		Command:       cmd.Name(),
+		ProjectID:     projectID,
+		BuildID:       buildID,
+		ProductionURL: prodURL,
+		Args:          []string{cmd.Name(), "--commit", "--project-id=" + projectID},
+	})
+	cachePurged := o.purge && o.purgeAfterPublish(c, baseURL, projectID)
+
+	return o.emitPublish(cmd, publishResponse{
+		ProjectID:     projectID,
+		BuildID:       buildID,
+		ProductionURL: prodURL,
+		Stage:         "committed",
+		CachePurged:   cachePurged,
+		EnvChanges:    envChanges,
+		Timings: &commandTimings{
+			PublishMS: time.Since(publishStarted).Milliseconds(),
+			TotalMS:   time.Since(started).Milliseconds(),
+		},
+	})
+}
+
+// runRequestApproval creates a request to publish the build, leaving
+// production unchanged until it is approved.
+func (o *publishOptions) runRequestApproval(cmd *cobra.Command, c *client.Client, baseURL, projectID, buildID string, st *deployState, started time.Time) error {
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityPublishApprovals) {
+		return newCLIError("unsupported_server", "this RobotX server does not support publish approvals", ExitAPI, nil)
+	}
+	approval, err := c.RequestPublishApproval(projectID, buildID)
+	if err != nil {
+		if errors.Is(err, client.ErrUnsupported) {
+			return newCLIError("unsupported_server", "this RobotX server does not support publish approvals", ExitAPI, err)
+		}
+		return newCLIError("api_error", "failed to create the publish request", ExitAPI, err)
+	}
+	o.logf("⏳ Publish request %s created for build %s; production is unchanged until it is approved\n", approval.ApprovalID, buildID)
+	o.logf("👉 Run 'robotx approvals approve %s' to publish, or 'robotx approvals reject %s'\n", approval.ApprovalID, approval.ApprovalID)
+	o.recordHistory(historyEntry{
+		Command:   cmd.Name(),
+		ProjectID: projectID,
+		BuildID:   buildID,
+		Args:      []string{cmd.Name(), "--require-approval", "--project-id=" + projectID, "--build-id=" + buildID},
+	})
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if webhook := strings.TrimSpace(o.slackWebhook); webhook != "" {
+		o.notifySlack(ctx, c, baseURL, webhook, approval)
+	}
+	if !o.waitApproval {
+		return o.emitPublish(cmd, publishResponse{
+			ProjectID: projectID,
+			BuildID:   buildID,
+			Approval:  approval,
+			Timings:   &commandTimings{TotalMS: time.Since(started).Milliseconds()},
+		})
+	}
+
+	timeout := time.Duration(o.approvalTimeout) * time.Second
+	o.logf("⏳ Waiting up to %s for publish request %s to be decided...\n", timeout, approval.ApprovalID)
+	approval, err = waitForApproval(ctx, c, approval, timeout)
+	if err != nil {
+		return newCLIError("cancelled", "stopped waiting for the publish request; it stays pending", ExitCancelled, err)
+	}
+	switch approval.Status {
+	case client.ApprovalApproved:
+		o.logf("✅ Publish request %s approved by %s\n", approval.ApprovalID, valueOrDash(approval.DecidedBy))
+	case client.ApprovalRejected:
+		cliErr := newCLIError("approval_rejected", fmt.Sprintf("publish request %s was rejected by %s", approval.ApprovalID, valueOrDash(approval.DecidedBy)), ExitPublish, nil)
+		cliErr.Details = approval
+		return cliErr
+	default:
+		cliErr := newCLIError("approval_timeout", fmt.Sprintf("publish request %s is still %s after %s; it can still be approved with 'robotx approvals approve %s'", approval.ApprovalID, approval.Status, timeout, approval.ApprovalID), ExitPublish, nil)
+		cliErr.Details = approval
+		return cliErr
+	}
+	prodURL := o.finishPublish(c, baseURL, projectID, buildID, approval.ProductionURL, st)
+	cachePurged := o.purge && o.purgeAfterPublish(c, baseURL, projectID)
+	return o.emitPublish(cmd, publishResponse{
+		ProjectID:     projectID,
+		BuildID:       buildID,
+		ProductionURL: prodURL,
+		CachePurged:   cachePurged,
+		Approval:      approval,
+		Timings:       &commandTimings{TotalMS: time.Since(started).Milliseconds()},
+	})
+}
+
+// syncPublishEnv syncs the production env declared in the workspace
+// robotx.yaml when the project published is the workspace's own, i.e. the
+// one recorded in its state or its project_id.
+func (a *app) syncPublishEnv(c *client.Client, baseURL, projectID string, st *deployState) ([]pipeline.EnvVarChange, error) {
+	dir := findWorkspaceDir(".")
+	if dir == "" || (st == nil && projectID != a.workspaceProjectID) {
+		return nil, nil
+	}
+	return a.syncProductionEnv(c, baseURL, dir, projectID)
+}
+
+// runAbort discards the staged build.
+func (o *publishOptions) runAbort(cmd *cobra.Command, c *client.Client, projectID string, started time.Time) error {
+	if err := c.AbortStagedPublish(projectID); err != nil {
+		return newCLIError("publish_failed", "failed to discard the staged build", ExitPublish, err)
+	}
+	o.logf("🗑️  Staged build discarded; production is unchanged\n")
+	return o.emitPublish(cmd, publishResponse{
+		ProjectID: projectID,
+		Stage:     "aborted",
+		Timings:   &commandTimings{TotalMS: time.Since(started).Milliseconds()},
+	})
+}
+
+func (o *publishOptions) emitPublish(cmd *cobra.Command, resp publishResponse) error {
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// publishBuild publishes buildID and returns the production URL. When st is
+// the state of the published project, the publish is recorded in it.
+func (a *app) publishBuild(c *client.Client, baseURL, projectID, buildID string, st *deployState) (string, error) {
+	a.logf("🚀 Publishing build %s to production...\n", buildID)
+	publicPath, err := c.PublishBuild(projectID, buildID)
+	if err != nil {
+		return "", newCLIError("publish_failed", "failed to publish", ExitPublish, err)
+	}
+	return a.finishPublish(c, baseURL, projectID, buildID, publicPath, st), nil
+}
+
+// finishPublish reports a successful publish of buildID, records it in st and
+// returns the production URL.
+func (a *app) finishPublish(c *client.Client, baseURL, projectID, buildID, publicPath string, st *deployState) string {
+	a.logf("✅ Published successfully!\n")
 	prodURL := strings.TrimSpace(publicPath)
 	if prodURL == "" {
-		if project, err := c.GetProject(publishProjectID); err == nil {
+		if project, err := c.GetProject(projectID); err == nil {
 			prodURL = resolvePublishURL(baseURL, project)
 		}
 	}
 	if prodURL == "" {
-		prodURL = fmt.Sprintf("%s/%s", strings.TrimSuffix(baseURL, "/"), publishProjectID)
+		prodURL = fmt.Sprintf("%s/%s", strings.TrimSuffix(baseURL, "/"), projectID)
 	}
-	logf("🌐 Production URL: %s\n", prodURL)
+	a.logf("🌐 Production URL: %s\n", verbatim(prodURL))
 
-	if err := emitSuccess(cmd.Name(), publishResponse{
-		ProjectID:     publishProjectID,
-		BuildID:       publishBuildID,
-		ProductionURL: prodURL,
-	}); err != nil {
-		return newCLIError("output_error", "failed to render JSON output", 1, err)
+	if st != nil && buildID != "" {
+		st.recordPublish(buildID, prodURL)
+		st.save(a)
 	}
-
-	return nil
+	return prodURL
 }
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/haibingtown/robotx_cli/pkg/qrcode"
+)
+
+// qrPNGScale is the size in pixels of one module in QR PNGs.
+const qrPNGScale = 8
+
+// qrPNG returns a QR code of url as a base64 PNG for JSON output, or "" in
+// text output or when url cannot be encoded.
+func (a *app) qrPNG(url string) string {
+	if !a.isJSONOutput() || url == "" {
+		return ""
+	}
+	code, err := qrcode.Encode(url)
+	if err != nil {
+		return ""
+	}
+	png, err := code.PNG(qrPNGScale)
+	if err != nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(png)
+}
+
+// printQR prints a QR code of url for scanning from a phone. It prints
+// nothing in JSON output or accessible mode.
+func (a *app) printQR(url string) {
+	if a.isJSONOutput() || a.isAccessible() || url == "" {
+		return
+	}
+	code, err := qrcode.Encode(url)
+	if err != nil {
+		a.logf("⚠️  Cannot render a QR code: %v\n", err)
+		return
+	}
+	fmt.Fprint(a.out(), code.Terminal())
+}
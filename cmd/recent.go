@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+type recentOptions struct {
+	*app
+	limit int
+	rerun int
+}
+
+type recentResponse struct {
+	Entries []recentEntry `json:"entries"`
+}
+
+type recentEntry struct {
+	Index int `json:"index"`
+	historyEntry
+}
+
+func newRecentCmd(a *app) *cobra.Command {
+	o := &recentOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "recent",
+		Short: "List recent deploys and publishes",
+		Long: `List deploys, publishes and rollbacks recorded in the history file of the data
+directory (usually ~/.local/share/robotx/history), newest first. Use --rerun <n> to repeat entry n with the same arguments.`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+
+	cmd.Flags().IntVar(&o.limit, "limit", 20, "Maximum number of entries to list")
+	cmd.Flags().IntVar(&o.rerun, "rerun", 0, "Repeat the entry with this index")
+	return cmd
+}
+
+func (o *recentOptions) run(cmd *cobra.Command, args []string) error {
+	history := loadHistory()
+	entries := make([]recentEntry, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		entries = append(entries, recentEntry{Index: len(entries) + 1, historyEntry: history[i]})
+	}
+
+	if o.rerun != 0 {
+		if o.rerun < 0 || o.rerun > len(entries) {
+			return newCLIError("invalid_argument", fmt.Sprintf("no history entry %d (have %d)", o.rerun, len(entries)), ExitGeneral, nil)
+		}
+		return o.rerunEntry(cmd, entries[o.rerun-1].historyEntry)
+	}
+
+	if o.limit > 0 && len(entries) > o.limit {
+		entries = entries[:o.limit]
+	}
+	if err := o.emitSuccess(cmd.Name(), recentResponse{Entries: entries}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if o.isJSONOutput() {
+		return nil
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(o.out(), "No recent deploys or publishes")
+		return nil
+	}
+	w := tabwriter.NewWriter(o.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tTIME\tCOMMAND\tPROJECT\tBUILD\tURL")
+	for _, e := range entries {
+		project := e.ProjectName
+		if project == "" {
+			project = e.ProjectID
+		}
+		url := e.ProductionURL
+		if url == "" {
+			url = e.PreviewURL
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			e.Index,
+			e.Time.Local().Format("2006-01-02 15:04:05"),
+			e.Command,
+			valueOrDash(project),
+			valueOrDash(e.BuildID),
+			valueOrDash(url),
+		)
+	}
+	w.Flush()
+	return nil
+}
+
+// rerunEntry runs the recorded command in a fresh command tree, passing on the
+// global flags given to this invocation.
+func (o *recentOptions) rerunEntry(cmd *cobra.Command, e historyEntry) error {
+	if len(e.Args) == 0 {
+		return newCLIError("invalid_argument", "history entry has no command to rerun", ExitGeneral, nil)
+	}
+	var args []string
+	inherited := cmd.InheritedFlags()
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if inherited.Lookup(f.Name) != nil {
+			args = appendFlagArgs(args, f)
+		}
+	})
+	args = append(args, e.Args...)
+	o.logf("🔁 Rerunning: robotx %s\n", strings.Join(e.Args, " "))
+
+	rerun := newApp()
+	rerun.root.SetIn(cmd.InOrStdin())
+	rerun.root.SetOut(o.out())
+	rerun.root.SetErr(o.errOut())
+	rerun.root.SetArgs(args)
+	return rerun.root.ExecuteContext(cmd.Context())
+}
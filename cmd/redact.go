@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/haibingtown/robotx_cli/pkg/redact"
+)
+
+// secretEnvVars hold secrets that are never read into the config.
+var secretEnvVars = []string{"ROBOTX_PASSPHRASE", "VAULT_TOKEN"}
+
+// secrets returns the credential values of this invocation, from flags,
+// environment, config, login and Vault, so they can be redacted from
+// everything the CLI prints.
+func (a *app) secrets() []string {
+	var values []string
+	add := func(value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	for _, key := range []string{"api_key", "service_token"} {
+		add(a.v.GetString(key))
+	}
+	if a.secretSettings == nil {
+		a.secretSettings = []flagSetting{}
+		for _, setting := range collectFlagSettings(a.root) {
+			if isSecretConfigKey(setting.Key) {
+				a.secretSettings = append(a.secretSettings, setting)
+			}
+		}
+	}
+	for _, setting := range a.secretSettings {
+		add(setting.Flag.Value.String())
+		add(a.v.GetString(setting.Key))
+	}
+	if saved, ok := a.v.Get(credentialsConfigKey).(map[string]interface{}); ok {
+		for _, key := range saved {
+			if key != nil {
+				add(fmt.Sprint(key))
+			}
+		}
+	}
+	for _, name := range secretEnvVars {
+		add(os.Getenv(name))
+	}
+	return values
+}
+
+// redact scrubs the credentials of this invocation and generic secrets such
+// as bearer tokens and signed URL parameters from s.
+func (a *app) redact(s string) string {
+	return redact.String(s, a.secrets()...)
+}
+
+// verbatim marks a log argument printed without redaction: a URL the user
+// asked for, such as a share link or a preview URL, whose token is the
+// point of printing it.
+type verbatim string
+
+// redactArgs scrubs the string, error and Stringer arguments of a log line,
+// except verbatim ones. Format strings are constants and are left alone.
+func (a *app) redactArgs(args []interface{}) []interface{} {
+	var secrets []string
+	scrubbed := make([]interface{}, len(args))
+	for i, arg := range args {
+		var s string
+		switch v := arg.(type) {
+		case verbatim:
+			scrubbed[i] = string(v)
+			continue
+		case string:
+			s = v
+		case error, fmt.Stringer:
+			// Sprint copes with nil pointers behind the interface.
+			s = fmt.Sprint(v)
+		default:
+			scrubbed[i] = arg
+			continue
+		}
+		if secrets == nil {
+			secrets = a.secrets()
+		}
+		scrubbed[i] = redact.String(s, secrets...)
+	}
+	return scrubbed
+}
+
+// redactDetails scrubs the details of an error by redacting their JSON
+// encoding, so secrets nested in any field are caught. Details without
+// secrets are returned as they are.
+func redactDetails(details interface{}, secrets []string) interface{} {
+	if details == nil {
+		return nil
+	}
+	var raw bytes.Buffer
+	enc := json.NewEncoder(&raw)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(details); err != nil {
+		return details
+	}
+	scrubbedRaw := redact.Bytes(raw.Bytes(), secrets...)
+	if bytes.Equal(scrubbedRaw, raw.Bytes()) {
+		return details
+	}
+	var scrubbed interface{}
+	if err := json.Unmarshal(scrubbedRaw, &scrubbed); err != nil {
+		return details
+	}
+	return scrubbed
+}
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+// releaseDerivedFlags are the deploy flags release sets itself; they are
+// hidden and refused.
+var releaseDerivedFlags = []string{"version-label", "version-scheme", "source-ref", "publish", "wait", "from-stdin", "archive-format", "target", "all-targets"}
+
+type releaseOptions struct {
+	*deployOptions
+	tag string
+}
+
+type releaseResponse struct {
+	Tag       string `json:"tag"`
+	CommitSHA string `json:"commit_sha"`
+	// ReleaseNotes is the message of the annotated tag, empty for a
+	// lightweight tag.
+	ReleaseNotes  string `json:"release_notes,omitempty"`
+	NotesAttached bool   `json:"notes_attached"`
+	*deployResponse
+}
+
+func newReleaseCmd(a *app) *cobra.Command {
+	o := &releaseOptions{deployOptions: &deployOptions{app: a}}
+	cmd := &cobra.Command{
+		Use:   "release [project-path]",
+		Short: "Deploy and publish the git tag at HEAD",
+		Long: `Release the project at a git tag in one step: release refuses to run unless
+HEAD is tagged and the project directory has no uncommitted changes, then
+deploys with the tag as the version label and "tag:<tag>@<sha>" as the
+source ref, waits for the build and publishes it. The message of an
+annotated tag is attached to the build as its release notes.
+
+Files under .robotx/ do not count as changes. When HEAD has several tags,
+choose one with --tag. The other deploy flags apply as for deploy, and
+default to the deploy settings of the config file and robotx.yaml.`,
+		Example: `  git tag -a v1.4.0 -m "Checkout redesign" && robotx release
+  robotx release --tag v1.4.0 --name acme-site`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: o.run,
+	}
+	o.addFlags(cmd)
+	for _, name := range releaseDerivedFlags {
+		_ = cmd.Flags().MarkHidden(name)
+	}
+	cmd.Flags().StringVar(&o.tag, "tag", "", "Tag to release when HEAD has several (default: the tag at HEAD)")
+	return cmd
+}
+
+func (o *releaseOptions) run(cmd *cobra.Command, args []string) error {
+	started := time.Now()
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for _, name := range releaseDerivedFlags {
+		if cmd.Flags().Changed(name) {
+			return newCLIError("invalid_argument", fmt.Sprintf("--%s cannot be used with release, which takes the version from the tag, waits for the build and publishes it", name), ExitGeneral, nil)
+		}
+	}
+	absPath, err := deployPath(args)
+	if err != nil {
+		return err
+	}
+	tag, sha, err := o.releaseTag(absPath)
+	if err != nil {
+		return err
+	}
+	if err := checkCleanTree(absPath); err != nil {
+		return err
+	}
+	notes, err := gitOutput(absPath, "for-each-ref", "--format=%(objecttype)%00%(contents:subject)%00%(contents:body)", "refs/tags/"+tag)
+	if err != nil {
+		return newCLIError("git_error", "failed to read tag "+tag, ExitGeneral, err)
+	}
+	if kind, message, _ := strings.Cut(notes, "\x00"); kind == "tag" {
+		subject, body, _ := strings.Cut(message, "\x00")
+		notes = strings.TrimSpace(subject + "\n\n" + strings.TrimSpace(body))
+	} else {
+		notes = ""
+	}
+
+	// Settings inherited from deploy may name a label, targets and the
+	// like; the release decides them.
+	o.versionLabel = tag
+	o.sourceRef = fmt.Sprintf("tag:%s@%s", tag, sha)
+	o.publish = true
+	o.wait = true
+	o.fromStdin = false
+	o.targets = nil
+	o.allTargets = false
+	o.logf("🏷️  Releasing %s (%s)\n", tag, shortSHA(sha))
+
+	c, baseURL, err := o.deployClient(cmd)
+	if err != nil {
+		return err
+	}
+	deployed, err := o.deployProject(ctx, cmd, c, baseURL, absPath, started, nil)
+	if err != nil {
+		return err
+	}
+	// Deploy only reuses a build of unchanged source when it carries the
+	// label (a deduplicated upload was keyed on it); never attach the notes
+	// of the tag to an untagged build.
+	if deployed.Reused && !deployed.Deduplicated && deployed.VersionLabel != tag {
+		return newCLIError("release_not_labeled", fmt.Sprintf("reused build %s is labeled %q, not %s; release again with --force to build the tag", deployed.BuildID, deployed.VersionLabel, tag), ExitGeneral, nil)
+	}
+
+	resp := releaseResponse{Tag: tag, CommitSHA: sha, ReleaseNotes: notes, deployResponse: deployed}
+	if notes != "" && deployed.BuildID != "" {
+		resp.NotesAttached = o.attachReleaseNotes(c, baseURL, deployed, tag, notes)
+	}
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if o.qr {
+		o.printQR(deployed.qrURL())
+	}
+	return nil
+}
+
+// releaseTag returns the tag to release, which must point at HEAD, and the
+// commit of HEAD.
+func (o *releaseOptions) releaseTag(dir string) (string, string, error) {
+	sha, err := gitOutput(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", "", newCLIError("not_on_tag", fmt.Sprintf("%s is not a git checkout with commits; release runs on a git tag", dir), ExitGeneral, err)
+	}
+	out, err := gitOutput(dir, "tag", "--points-at", "HEAD")
+	if err != nil {
+		return "", "", newCLIError("git_error", "failed to list the tags at HEAD", ExitGeneral, err)
+	}
+	tags := strings.Fields(out)
+	want := strings.TrimSpace(o.tag)
+	switch {
+	case want != "":
+		for _, tag := range tags {
+			if tag == want {
+				return tag, sha, nil
+			}
+		}
+		return "", "", newCLIError("not_on_tag", fmt.Sprintf("tag %s does not point at HEAD (%s)", want, shortSHA(sha)), ExitGeneral, nil)
+	case len(tags) == 0:
+		return "", "", newCLIError("not_on_tag", fmt.Sprintf("HEAD (%s) is not tagged; tag it first, e.g. git tag -a v1.0.0 -m \"Release notes\"", shortSHA(sha)), ExitGeneral, nil)
+	case len(tags) > 1:
+		return "", "", newCLIError("invalid_argument", fmt.Sprintf("HEAD has several tags (%s); choose one with --tag", strings.Join(tags, ", ")), ExitGeneral, nil)
+	}
+	return tags[0], sha, nil
+}
+
+// checkCleanTree refuses uncommitted changes in dir, which would be
+// deployed under a tag that does not contain them.
+func checkCleanTree(dir string) error {
+	out, err := gitOutput(dir, "status", "--porcelain", "--", ".", ":(exclude)"+deployStateDir)
+	if err != nil {
+		return newCLIError("git_error", "failed to check the working tree", ExitGeneral, err)
+	}
+	if out == "" {
+		return nil
+	}
+	var files []string
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) > 3 {
+			files = append(files, line[3:])
+		}
+	}
+	cliErr := newCLIError("dirty_tree", fmt.Sprintf("%s has %d uncommitted change(s); commit or stash them before releasing", dir, len(files)), ExitGeneral, nil)
+	cliErr.Details = map[string]interface{}{"files": files}
+	return cliErr
+}
+
+// attachReleaseNotes attaches notes to the released build and reports
+// whether it did. The release is already published, so failures are only
+// warned about.
+func (o *releaseOptions) attachReleaseNotes(c *client.Client, baseURL string, deployed *deployResponse, tag, notes string) bool {
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityReleaseNotes) {
+		o.logf("⚠️  This RobotX server does not store release notes; they are only included in the output\n")
+		return false
+	}
+	if err := c.SetReleaseNotes(deployed.ProjectID, deployed.BuildID, notes); err != nil {
+		if errors.Is(err, client.ErrUnsupported) {
+			o.logf("⚠️  This RobotX server does not store release notes; they are only included in the output\n")
+		} else {
+			o.logf("⚠️  Failed to attach the release notes: %v\n", err)
+		}
+		return false
+	}
+	o.logf("📝 Attached the release notes of %s to build %s\n", tag, deployed.BuildID)
+	return true
+}
+
+// gitOutput runs git in dir and returns its output without the final
+// newline.
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
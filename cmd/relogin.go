@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bufio"
+	"net/http"
+	"strings"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+// shouldOfferRelogin reports whether a failed command is worth retrying
+// after an inline login: the server rejected the saved API key, and a
+// terminal is available to run the login flow.
+func (a *app) shouldOfferRelogin(cmd *cobra.Command, err error) bool {
+	if cmd == nil || cmd.Name() == "login" || a.output == nil {
+		return false
+	}
+	if !client.IsStatus(err, http.StatusUnauthorized) || a.isJSONOutput() || a.isNonInteractive() {
+		return false
+	}
+	// Logging in only replaces the key saved in the config file.
+	return !a.apiKeyOverridden() && strings.ToLower(strings.TrimSpace(a.authMode)) != authModeService
+}
+
+// relogin asks whether to log in again and runs the login flow for the
+// current server and config file. It reports whether the login succeeded.
+func (a *app) relogin() (bool, error) {
+	a.noticef("🔑 Your session expired. Log in now? [Y/n]: ")
+	answer, _ := bufio.NewReader(a.root.InOrStdin()).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "y", "yes":
+	default:
+		return false, nil
+	}
+
+	args := []string{"login"}
+	if a.cfgFile != "" {
+		args = append(args, "--config", a.cfgFile)
+	}
+	if base := strings.TrimSpace(a.v.GetString("base_url")); base != "" {
+		args = append(args, "--base-url", base)
+	}
+	login := newApp()
+	login.root.SetArgs(args)
+	login.root.SetIn(a.root.InOrStdin())
+	login.root.SetOut(a.root.OutOrStdout())
+	login.root.SetErr(a.root.ErrOrStderr())
+	if err := login.root.Execute(); err != nil {
+		return false, err
+	}
+	a.logf("🔁 Retrying the command...\n")
+	return true, nil
+}
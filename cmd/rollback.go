@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+type rollbackOptions struct {
+	*app
+	projectID string
+	buildID   string
+}
+
+type rollbackResponse struct {
+	ProjectID     string          `json:"project_id"`
+	BuildID       string          `json:"build_id"`
+	FromBuildID   string          `json:"from_build_id,omitempty"`
+	ProductionURL string          `json:"production_url,omitempty"`
+	Timings       *commandTimings `json:"timings,omitempty"`
+}
+
+func newRollbackCmd(a *app) *cobra.Command {
+	o := &rollbackOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Publish the previously published build again",
+		Long: `Roll production back to the build published before the current one.
+
+The target is the previous publish recorded in .robotx/state.json, or else the
+newest successful build older than the one currently published. Inside a
+deployed project directory --project-id defaults to the recorded project.`,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	cmd.Flags().StringVarP(&o.buildID, "build-id", "b", "", "Build ID to roll back to (default: previously published build)")
+	return cmd
+}
+
+func (o *rollbackOptions) run(cmd *cobra.Command, args []string) error {
+	started := time.Now()
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+
+	projectID, st := stateProjectID(o.projectID)
+	if projectID == "" {
+		return newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	current := ""
+	if project, err := c.GetProject(projectID); err == nil && project.RuntimeRefs != nil && project.RuntimeRefs.Publish != nil {
+		current = project.RuntimeRefs.Publish.BuildID
+	}
+	if current == "" && st != nil && st.LastPublish != nil {
+		current = st.LastPublish.BuildID
+	}
+
+	target := o.buildID
+	if target == "" && st != nil && st.PreviousPublish != nil && st.PreviousPublish.BuildID != current {
+		target = st.PreviousPublish.BuildID
+	}
+	if target == "" {
+		builds, err := c.ListBuildsForProject(projectID, 50)
+		if err != nil {
+			return newCLIError("api_error", "failed to list builds", ExitAPI, err)
+		}
+		target = previousSuccessfulBuild(builds, current)
+	}
+	if target == "" {
+		return newCLIError("no_rollback_target", "no earlier successful build to roll back to", ExitGeneral, nil)
+	}
+	if target == current {
+		return newCLIError("no_rollback_target", "build "+target+" is already published", ExitGeneral, nil)
+	}
+
+	if err := o.confirm(fmt.Sprintf("Roll back production of %s from %s to build %s", projectID, valueOrDash(current), target)); err != nil {
+		return err
+	}
+	o.logf("⏪ Rolling back from %s to %s\n", valueOrDash(current), target)
+	publishStarted := time.Now()
+	prodURL, err := o.publishBuild(c, baseURL, projectID, target, st)
+	if err != nil {
+		return err
+	}
+	o.recordHistory(historyEntry{
+		Command:       cmd.Name(),
+		ProjectID:     projectID,
+		BuildID:       target,
+		ProductionURL: prodURL,
+		Args:          []string{cmd.Name(), "--project-id=" + projectID, "--build-id=" + target},
+	})
+
+	if err := o.emitSuccess(cmd.Name(), rollbackResponse{
+		ProjectID:     projectID,
+		BuildID:       target,
+		FromBuildID:   current,
+		ProductionURL: prodURL,
+		Timings: &commandTimings{
+			PublishMS: time.Since(publishStarted).Milliseconds(),
+			TotalMS:   time.Since(started).Milliseconds(),
+		},
+	}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// previousSuccessfulBuild returns the newest successful build older than
+// current, or the newest successful build other than current when current is
+// unknown to the list.
+func previousSuccessfulBuild(builds []*client.Build, current string) string {
+	seenCurrent := current == ""
+	for _, b := range sortBuildsNewestFirst(builds) {
+		if b.BuildID == current {
+			seenCurrent = true
+			continue
+		}
+		if seenCurrent && b.Status == "success" {
+			return b.BuildID
+		}
+	}
+	if current != "" && !seenCurrent {
+		return previousSuccessfulBuild(builds, "")
+	}
+	return ""
+}
+
+// sortBuildsNewestFirst returns a copy of builds ordered by version, newest
+// first, falling back to creation time.
+func sortBuildsNewestFirst(builds []*client.Build) []*client.Build {
+	sorted := append([]*client.Build(nil), builds...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].VersionSeq != sorted[j].VersionSeq {
+			return sorted[i].VersionSeq > sorted[j].VersionSeq
+		}
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+	return sorted
+}
@@ -3,98 +3,294 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
+	"github.com/haibingtown/robotx_cli/pkg/telemetry"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-var (
-	cfgFile      string
-	baseURL      string
-	apiKey       string
-	outputFormat string
-	outputJSON   bool
-)
-
 var version = "dev"
 
-var rootCmd = &cobra.Command{
-	Use:   "robotx",
-	Short: "RobotX CLI - Deploy AI applications to RobotX platform",
-	Long: `RobotX CLI is a command-line tool for deploying AI applications to the RobotX platform.
-It provides a simple interface for AI agents to deploy and manage project versions.`,
-	SilenceUsage:  true,
-	SilenceErrors: true,
-	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		return normalizeOutputConfig()
-	},
-}
+// app holds the state shared by the commands of one root command: global
+// flag values and a private viper instance. Nothing is stored in package
+// variables, so several root commands can run concurrently in one process.
+type app struct {
+	root *cobra.Command
+	v    *viper.Viper
 
-func Execute() error {
-	return rootCmd.Execute()
-}
+	cfgFile            string
+	baseURL            string
+	apiKey             string
+	outputFormat       string
+	outputJSON         bool
+	lang               string
+	verbose            bool
+	fallbackURLs       []string
+	profileName        string
+	nonInteractive     bool
+	accessible         bool
+	assumeYes          bool
+	otelEndpoint       string
+	cacheEnabled       bool
+	cacheTTL           time.Duration
+	noCache            bool
+	authMode           string
+	httpDump           bool
+	httpDumpPath       string
+	vaultPath          string
+	strictDeprecations bool
 
-func init() {
-	cobra.OnInitialize(initConfig)
+	// vaultAPIKey is set when api_key was read from Vault, which takes
+	// precedence over the keys saved in the config file.
+	vaultAPIKey bool
+	// secretSettings are the flag settings holding secrets, collected on
+	// first use by secrets.
+	secretSettings []flagSetting
 
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.robotx.yaml)")
-	rootCmd.PersistentFlags().StringVar(&baseURL, "base-url", "", "RobotX server base URL")
-	rootCmd.PersistentFlags().StringVar(&apiKey, "api-key", "", "RobotX API key")
-	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", "text", "Output format (text|json)")
-	rootCmd.PersistentFlags().BoolVar(&outputJSON, "json", false, "Shortcut for --output json")
+	// workspaceProjectID is the project_id of the robotx.yaml found from
+	// the working directory.
+	workspaceProjectID string
 
-	viper.BindPFlag("base_url", rootCmd.PersistentFlags().Lookup("base-url"))
-	viper.BindPFlag("api_key", rootCmd.PersistentFlags().Lookup("api-key"))
+	output   *outputController
+	tracer   *telemetry.Tracer
+	span     *telemetry.Span
+	stepSpan *telemetry.Span
+	metrics  *deployMetrics
+	progress *stepProgress
+	// deployEvents, when set, returns an emitter that also receives the
+	// events of each deploy pipeline, given its steps. MCP and the daemon
+	// use it to follow deploys they run in-process.
+	deployEvents func([]pipeline.Step) pipeline.Emitter
+}
 
-	rootCmd.Version = version
-	rootCmd.SetVersionTemplate("{{.Name}} {{.Version}}\n")
+// NewRootCommand builds a fresh, independent robotx command tree. Use
+// SetArgs, SetOut, SetErr, and ExecuteContext on the result to run commands
+// programmatically.
+func NewRootCommand() *cobra.Command {
+	return newApp().root
 }
 
-func initConfig() {
-	if cfgFile != "" {
-		viper.SetConfigFile(cfgFile)
-	} else {
-		defaultConfigPath, err := resolveDefaultConfigPath()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting home directory: %v\n", err)
-			os.Exit(1)
-		}
-		viper.SetConfigFile(defaultConfigPath)
+func newApp() *app {
+	a := &app{v: viper.New()}
+	root := &cobra.Command{
+		Use:   "robotx",
+		Short: "RobotX CLI - Deploy AI applications to RobotX platform",
+		Long: `RobotX CLI is a command-line tool for deploying AI applications to the RobotX platform.
+It provides a simple interface for AI agents to deploy and manage project versions.
+
+` + exitCodesHelp(),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := a.initConfig(); err != nil {
+				return err
+			}
+			a.migrateConfigOnFirstRun(cmd)
+			if err := a.applyWorkspaceConfig(); err != nil {
+				return err
+			}
+			if err := a.applyProfile(); err != nil {
+				return err
+			}
+			applyFlagAliases(cmd)
+			if err := a.applyFlagSettings(cmd); err != nil {
+				return err
+			}
+			if err := a.resolveOutput(); err != nil {
+				return err
+			}
+			if err := a.resolveLanguage(); err != nil {
+				return err
+			}
+			if err := a.checkDeprecations(cmd); err != nil {
+				return err
+			}
+			if err := a.applyVault(cmd); err != nil {
+				return err
+			}
+			if err := a.normalizeBaseURL(cmd); err != nil {
+				return err
+			}
+			a.startHTTPDump(cmd)
+			a.applyScopedCredentials()
+			if err := a.decryptAPIKey(cmd); err != nil {
+				return err
+			}
+			if err := a.applyAPIKeyCommand(cmd); err != nil {
+				return err
+			}
+			a.discoverBaseURL(cmd)
+			if err := a.applyAuthMode(cmd); err != nil {
+				return err
+			}
+			a.startTelemetry(cmd)
+			return validateRequiredFlags(cmd)
+		},
 	}
-	viper.SetConfigType("yaml")
+	a.root = root
 
-	viper.SetEnvPrefix("ROBOTX")
-	viper.AutomaticEnv()
+	root.PersistentFlags().StringVar(&a.cfgFile, "config", "", "config file (default $XDG_CONFIG_HOME/robotx/config.yaml, or ~/.robotx.yaml if it exists)")
+	root.PersistentFlags().StringVar(&a.baseURL, "base-url", "", "RobotX server base URL")
+	root.PersistentFlags().StringVar(&a.apiKey, "api-key", "", "RobotX API key")
+	root.PersistentFlags().StringVar(&a.authMode, "auth-mode", authModeKey, "How to authenticate: key (api_key) or service (exchange ROBOTX_SERVICE_TOKEN for short-lived credentials)")
+	root.PersistentFlags().StringVar(&a.outputFormat, "output", "text", "Output format (text|json)")
+	root.PersistentFlags().BoolVar(&a.outputJSON, "json", false, "Shortcut for --output json")
+	root.PersistentFlags().StringVar(&a.lang, "lang", "", "Language of user-facing messages: en or zh-CN (or set ROBOTX_LANG); error codes and JSON fields are not translated")
+	root.PersistentFlags().BoolVar(&a.verbose, "verbose", false, "Print diagnostic details such as the endpoint serving each request")
+	root.PersistentFlags().BoolVar(&a.nonInteractive, "non-interactive", false, "Never prompt or open a browser; implied by ROBOTX_AGENT=1 or a non-TTY stdout")
+	root.PersistentFlags().BoolVar(&a.accessible, "accessible", false, "Screen-reader friendly output: text prefixes such as [OK] instead of emoji, no boxes or QR codes (or set ROBOTX_ACCESSIBLE=1)")
+	root.PersistentFlags().BoolVarP(&a.assumeYes, "yes", "y", false, "Confirm destructive actions without prompting; required for them in non-interactive mode (or set ROBOTX_YES=1)")
+	root.PersistentFlags().StringVar(&a.profileName, "profile", "", "Config profile to use (profiles.<name> in the config file)")
+	root.PersistentFlags().BoolVar(&a.httpDump, "debug-http-dump", false, "Write the full HTTP requests and responses of this run, credentials redacted, to a file in the data directory")
+	root.PersistentFlags().StringVar(&a.otelEndpoint, "otel-endpoint", "", "Export OpenTelemetry traces to this OTLP/HTTP endpoint (default: $OTEL_EXPORTER_OTLP_ENDPOINT)")
+	root.PersistentFlags().BoolVar(&a.cacheEnabled, "cache", false, "Cache responses of read-only commands (projects, versions, status) in the data directory")
+	root.PersistentFlags().DurationVar(&a.cacheTTL, "cache-ttl", 15*time.Second, "How long cached responses are used before they are revalidated")
+	root.PersistentFlags().BoolVar(&a.noCache, "no-cache", false, "Bypass the response cache for this command")
+	root.PersistentFlags().StringVar(&a.vaultPath, "vault-path", "", "Read base_url, api_key and service_token from this HashiCorp Vault secret (e.g. secret/robotx), using VAULT_ADDR and VAULT_TOKEN")
+	root.PersistentFlags().BoolVar(&a.strictDeprecations, "strict-deprecations", false, "Fail instead of warning when a deprecated command or flag is used (or set ROBOTX_STRICT_DEPRECATIONS=1)")
+	root.PersistentFlags().StringSliceVar(&a.fallbackURLs, "fallback-base-url", nil, "Fallback base URL used for read requests when the primary is unavailable (repeatable)")
 
-	if err := viper.ReadInConfig(); err == nil && !isJSONOutput() {
-		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
-	}
+	a.v.BindPFlag("base_url", root.PersistentFlags().Lookup("base-url"))
+	a.v.BindPFlag("api_key", root.PersistentFlags().Lookup("api-key"))
+	a.v.BindPFlag("fallback_base_urls", root.PersistentFlags().Lookup("fallback-base-url"))
+	a.v.BindPFlag("profile", root.PersistentFlags().Lookup("profile"))
+	a.v.BindPFlag("lang", root.PersistentFlags().Lookup("lang"))
+
+	root.Version = version
+	root.SetVersionTemplate("{{.Name}} {{.Version}}\n")
+
+	root.AddCommand(
+		newLoginCmd(a),
+		newDeployCmd(a),
+		newRoutesCmd(a),
+		newJobsCmd(a),
+		newFlagsCmd(a),
+		newProjectsCmd(a),
+		newBulkCmd(a),
+		newVersionsCmd(a),
+		newStatusCmd(a),
+		newWaitCmd(a),
+		newOpenCmd(a),
+		newShareCmd(a),
+		newProtectCmd(a),
+		newMaintenanceCmd(a),
+		newPublishCmd(a),
+		newRollbackCmd(a),
+		newPruneCmd(a),
+		newPinCmd(a),
+		newUnpinCmd(a),
+		newVerifyDriftCmd(a),
+		newRecentCmd(a),
+		newStatsCmd(a),
+		newAnalyticsCmd(a),
+		newLogsCmd(a),
+		newConfigCmd(a),
+		newEnvVarsCmd(a),
+		newPingCmd(a),
+		newMCPCmd(a),
+		newDaemonCmd(a),
+		newPluginCmd(a),
+		newExplainExitCmd(a),
+		newExamplesCmd(a),
+		newCICmd(a),
+		newSchemaCmd(a),
+		newFilesCmd(a),
+		newCacheCmd(a),
+		newSearchLogsCmd(a),
+		newEventsCmd(a),
+		newPreviewArchiveCmd(a),
+		newVerifyCmd(a),
+		newUnlockCmd(a),
+		newEnvCmd(a),
+		newReleaseCmd(a),
+		newApprovalsCmd(a),
+		newLicensesCmd(a),
+	)
+	registerDeprecations(root)
+	return a
 }
 
-func resolveDefaultConfigPath() (string, error) {
-	home, err := os.UserHomeDir()
+// Execute runs the CLI with os.Args, dispatching unknown commands to plugins.
+func Execute() error {
+	a := newApp()
+	args, err := a.registerPluginCommand(os.Args[1:])
 	if err != nil {
-		return "", err
+		return newCLIError("invalid_argument", err.Error(), ExitGeneral, err)
 	}
-	path := filepath.Join(home, ".robotx.yaml")
-	if stat, err := os.Stat(path); err == nil && stat.IsDir() {
-		return "", fmt.Errorf("config path is a directory: %s", path)
+	if err := a.unknownCommandError(args); err != nil {
+		output := a.outputMode()
+		output.json = output.json || argsRequestJSON(args)
+		output.secrets = a.secrets()
+		if lang, ok := normalizeLang(os.Getenv("ROBOTX_LANG")); ok {
+			output.catalog = newMessageCatalog(lang)
+		}
+		return &outputError{err: err, output: output}
 	}
-	return path, nil
+	a.root.SetArgs(args)
+	cmd, err := a.root.ExecuteC()
+	if err != nil && a.shouldOfferRelogin(cmd, err) {
+		retry, loginErr := a.relogin()
+		switch {
+		case loginErr != nil:
+			err = loginErr
+		case retry:
+			a = newApp()
+			if args, err = a.registerPluginCommand(os.Args[1:]); err == nil {
+				a.root.SetArgs(args)
+				_, err = a.root.ExecuteC()
+			}
+		}
+	}
+	if err != nil {
+		output := a.outputMode()
+		if a.output == nil && argsRequestJSON(args) {
+			// Flag parsing failed before the output mode was resolved.
+			output.json = true
+		}
+		output.secrets = a.secrets()
+		return &outputError{err: err, output: output}
+	}
+	return nil
 }
 
-func normalizeOutputConfig() error {
-	if outputJSON {
-		outputFormat = "json"
+func (a *app) initConfig() error {
+	if a.cfgFile == "" {
+		a.cfgFile = strings.TrimSpace(os.Getenv("ROBOTX_CONFIG"))
 	}
-	outputFormat = strings.ToLower(strings.TrimSpace(outputFormat))
-	if outputFormat == "" {
-		outputFormat = "text"
+	if a.cfgFile != "" {
+		a.v.SetConfigFile(a.cfgFile)
+	} else {
+		defaultConfigPath, err := resolveDefaultConfigPath()
+		if err != nil {
+			return newCLIError("config_error", "failed to resolve config path", ExitGeneral, err)
+		}
+		a.v.SetConfigFile(defaultConfigPath)
 	}
-	if outputFormat != "text" && outputFormat != "json" {
-		return newCLIError("invalid_output_format", "invalid --output value (expected text or json)", 1, nil)
+	a.v.SetConfigType("yaml")
+
+	a.v.SetEnvPrefix("ROBOTX")
+	a.v.AutomaticEnv()
+
+	if err := a.v.ReadInConfig(); err == nil && !a.isJSONOutput() {
+		fmt.Fprintln(a.errOut(), "Using config file:", a.v.ConfigFileUsed())
 	}
 	return nil
 }
+
+// applyProfile merges profiles.<name> over the top-level config values.
+// Flags and environment variables still take precedence.
+func (a *app) applyProfile() error {
+	name := strings.TrimSpace(a.v.GetString("profile"))
+	if name == "" {
+		return nil
+	}
+	key := "profiles." + name
+	if !a.v.IsSet(key) {
+		return newCLIError("profile_not_found", fmt.Sprintf("profile not found in config: %s", name), ExitGeneral, nil)
+	}
+	return a.v.MergeConfigMap(a.v.GetStringMap(key))
+}
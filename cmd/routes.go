@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+type routesValidateResponse struct {
+	File      string        `json:"file"`
+	Valid     bool          `json:"valid"`
+	Headers   int           `json:"headers"`
+	Redirects int           `json:"redirects"`
+	Rewrites  int           `json:"rewrites"`
+	Issues    []routesIssue `json:"issues"`
+}
+
+func newRoutesCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "routes",
+		Short: "Work with the project's headers, redirects and rewrites",
+		Long: `A project can define custom response headers, redirects and rewrites in
+` + routesFileName + ` at its root. deploy validates the file and uploads the
+rules with the commit; the runtime applies them to the deployed build.
+
+  headers:
+    - source: /assets/*
+      headers:
+        Cache-Control: public, max-age=31536000, immutable
+  redirects:
+    - source: /old-blog/*
+      destination: /blog
+      status: 301          # 301, 302, 303, 307 or 308 (default)
+  rewrites:
+    - source: /app/*
+      destination: /index.html
+
+Sources start with /; * matches the rest of the path and :name one segment.`,
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "validate [project-path]",
+		Short: "Check " + routesFileName + " for mistakes before deploying",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  a.runRoutesValidate,
+	})
+	return cmd
+}
+
+func (a *app) runRoutesValidate(cmd *cobra.Command, args []string) error {
+	projectPath := "."
+	if len(args) > 0 {
+		projectPath = args[0]
+	}
+	path := filepath.Join(projectPath, routesFileName)
+	cfg, issues, err := loadRoutesConfig(projectPath)
+	if err != nil {
+		return newCLIError("invalid_routes", "failed to read "+path, ExitGeneral, err)
+	}
+	if cfg == nil && issues == nil {
+		return newCLIError("not_found", "no "+routesFileName+" in "+projectPath, ExitNotFound, nil)
+	}
+
+	resp := routesValidateResponse{File: path, Valid: len(issues) == 0, Issues: issues}
+	if cfg != nil {
+		resp.Headers, resp.Redirects, resp.Rewrites = len(cfg.Headers), len(cfg.Redirects), len(cfg.Rewrites)
+	}
+	if len(issues) > 0 {
+		for _, issue := range issues {
+			a.logf("❌ %s\n", issue)
+		}
+		cliErr := newCLIError("invalid_routes", fmt.Sprintf("%s has %d problem(s)", path, len(issues)), ExitGeneral, nil)
+		cliErr.Details = resp
+		return cliErr
+	}
+
+	a.logf("✅ %s is valid: %s\n", path, cfg.ruleCounts())
+	resp.Issues = []routesIssue{}
+	if err := a.emitSuccess("routes "+cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// loadDeployRoutes validates the project's routes file and returns the rules
+// to upload with the commit, or nil when the project has none.
+func (o *deployOptions) loadDeployRoutes(projectPath string, c *client.Client, baseURL string) ([]byte, error) {
+	cfg, issues, err := loadRoutesConfig(projectPath)
+	if err != nil {
+		return nil, newCLIError("invalid_routes", "failed to read "+routesFileName, ExitGeneral, err)
+	}
+	if len(issues) > 0 {
+		for _, issue := range issues {
+			o.logf("❌ %s\n", issue)
+		}
+		cliErr := newCLIError("invalid_routes", fmt.Sprintf("%s has %d problem(s); run 'robotx routes validate' for details", routesFileName, len(issues)), ExitGeneral, nil)
+		cliErr.Details = routesValidateResponse{File: filepath.Join(projectPath, routesFileName), Issues: issues}
+		return nil, cliErr
+	}
+	if cfg == nil {
+		return nil, nil
+	}
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityRoutes) {
+		o.warnf("This RobotX server does not apply %s; the rules are ignored", routesFileName)
+		return nil, nil
+	}
+	payload, err := cfg.payload()
+	if err != nil {
+		return nil, newCLIError("invalid_routes", "failed to encode "+routesFileName, ExitGeneral, err)
+	}
+	o.logf("🧭 Routes: %s\n", cfg.ruleCounts())
+	return payload, nil
+}
@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// routesFileName is the project file holding custom headers, redirects and
+// rewrites. It is uploaded with each commit and applied by the runtime.
+const routesFileName = "robotx.routes.yaml"
+
+// maxRoutesRules bounds the rules of each kind the runtime accepts.
+const maxRoutesRules = 200
+
+// redirectStatuses are the redirect status codes the runtime supports;
+// defaultRedirectStatus is used when a rule sets none.
+var redirectStatuses = map[int]bool{301: true, 302: true, 303: true, 307: true, 308: true}
+
+const defaultRedirectStatus = 308
+
+// reservedHeaders are managed by the runtime and cannot be set by rules.
+var reservedHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Host":              true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+}
+
+type routesConfig struct {
+	Headers   []headerRule   `yaml:"headers" json:"headers,omitempty"`
+	Redirects []redirectRule `yaml:"redirects" json:"redirects,omitempty"`
+	Rewrites  []rewriteRule  `yaml:"rewrites" json:"rewrites,omitempty"`
+}
+
+type headerRule struct {
+	Source  string            `yaml:"source" json:"source"`
+	Headers map[string]string `yaml:"headers" json:"headers"`
+	line    int
+	path    string
+}
+
+type redirectRule struct {
+	Source      string `yaml:"source" json:"source"`
+	Destination string `yaml:"destination" json:"destination"`
+	Status      int    `yaml:"status" json:"status"`
+	line        int
+	path        string
+}
+
+type rewriteRule struct {
+	Source      string `yaml:"source" json:"source"`
+	Destination string `yaml:"destination" json:"destination"`
+	line        int
+	path        string
+}
+
+// routesIssue is one problem found in the routes file.
+type routesIssue struct {
+	Line    int    `json:"line,omitempty"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// loadRoutesConfig reads the routes file of the project in projectPath. It
+// returns nil without error when the project has none.
+func loadRoutesConfig(projectPath string) (*routesConfig, []routesIssue, error) {
+	raw, err := os.ReadFile(filepath.Join(projectPath, routesFileName))
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg, issues := parseRoutesConfig(raw)
+	return cfg, issues, nil
+}
+
+// parseRoutesConfig parses and validates a routes file. Problems, including
+// YAML syntax errors, are returned as issues.
+func parseRoutesConfig(raw []byte) (*routesConfig, []routesIssue) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, []routesIssue{{Path: routesFileName, Message: err.Error()}}
+	}
+	cfg := &routesConfig{}
+	if len(doc.Content) == 0 {
+		return cfg, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, []routesIssue{{Line: root.Line, Path: routesFileName, Message: "expected a mapping with headers, redirects and rewrites"}}
+	}
+
+	issues := unknownKeys(root, "", "headers", "redirects", "rewrites")
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+		if key != "headers" && key != "redirects" && key != "rewrites" {
+			continue
+		}
+		if value.Kind != yaml.SequenceNode {
+			if value.Tag != "!!null" {
+				issues = append(issues, routesIssue{Line: value.Line, Path: key, Message: "expected a list of rules"})
+			}
+			continue
+		}
+		if len(value.Content) > maxRoutesRules {
+			issues = append(issues, routesIssue{Line: value.Line, Path: key, Message: fmt.Sprintf("at most %d rules are allowed", maxRoutesRules)})
+		}
+		for j, node := range value.Content {
+			path := fmt.Sprintf("%s[%d]", key, j)
+			switch key {
+			case "headers":
+				issues = append(issues, unknownKeys(node, path, "source", "headers")...)
+				rule := headerRule{line: node.Line, path: path}
+				if err := node.Decode(&rule); err != nil {
+					issues = append(issues, routesIssue{Line: node.Line, Path: path, Message: yamlErrorMessage(err)})
+					continue
+				}
+				cfg.Headers = append(cfg.Headers, rule)
+			case "redirects":
+				issues = append(issues, unknownKeys(node, path, "source", "destination", "status")...)
+				rule := redirectRule{line: node.Line, path: path}
+				if err := node.Decode(&rule); err != nil {
+					issues = append(issues, routesIssue{Line: node.Line, Path: path, Message: yamlErrorMessage(err)})
+					continue
+				}
+				cfg.Redirects = append(cfg.Redirects, rule)
+			case "rewrites":
+				issues = append(issues, unknownKeys(node, path, "source", "destination")...)
+				rule := rewriteRule{line: node.Line, path: path}
+				if err := node.Decode(&rule); err != nil {
+					issues = append(issues, routesIssue{Line: node.Line, Path: path, Message: yamlErrorMessage(err)})
+					continue
+				}
+				cfg.Rewrites = append(cfg.Rewrites, rule)
+			}
+		}
+	}
+	return cfg, append(issues, cfg.validate()...)
+}
+
+// unknownKeys reports the keys of a mapping node that are not allowed.
+func unknownKeys(node *yaml.Node, path string, allowed ...string) []routesIssue {
+	if node.Kind != yaml.MappingNode {
+		return []routesIssue{{Line: node.Line, Path: path, Message: "expected a mapping"}}
+	}
+	var issues []routesIssue
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		known := false
+		for _, a := range allowed {
+			if key.Value == a {
+				known = true
+			}
+		}
+		if !known {
+			issues = append(issues, routesIssue{
+				Line:    key.Line,
+				Path:    strings.TrimPrefix(path+"."+key.Value, "."),
+				Message: fmt.Sprintf("unknown key (expected %s)", strings.Join(allowed, ", ")),
+			})
+		}
+	}
+	return issues
+}
+
+func yamlErrorMessage(err error) string {
+	if typeErr, ok := err.(*yaml.TypeError); ok && len(typeErr.Errors) > 0 {
+		return strings.Join(typeErr.Errors, "; ")
+	}
+	return err.Error()
+}
+
+// validate checks the rules and fills in defaults.
+func (c *routesConfig) validate() []routesIssue {
+	var issues []routesIssue
+	add := func(line int, path, format string, args ...interface{}) {
+		issues = append(issues, routesIssue{Line: line, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, rule := range c.Headers {
+		path := rule.path
+		if msg := checkRouteSource(rule.Source); msg != "" {
+			add(rule.line, path+".source", "%s", msg)
+		}
+		if len(rule.Headers) == 0 {
+			add(rule.line, path+".headers", "at least one header is required")
+		}
+		names := make([]string, 0, len(rule.Headers))
+		for name := range rule.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			value := rule.Headers[name]
+			switch {
+			case !validHeaderName(name):
+				add(rule.line, path+".headers", "invalid header name %q", name)
+			case reservedHeaders[http.CanonicalHeaderKey(name)]:
+				add(rule.line, path+".headers", "header %s is managed by the runtime", http.CanonicalHeaderKey(name))
+			case strings.ContainsAny(value, "\r\n"):
+				add(rule.line, path+".headers", "value of %s must not contain line breaks", name)
+			}
+		}
+	}
+
+	seen := map[string]string{}
+	for i := range c.Redirects {
+		rule := &c.Redirects[i]
+		path := rule.path
+		if msg := checkRouteSource(rule.Source); msg != "" {
+			add(rule.line, path+".source", "%s", msg)
+		}
+		switch {
+		case rule.Destination == "":
+			add(rule.line, path+".destination", "is required")
+		case !strings.HasPrefix(rule.Destination, "/") && !strings.HasPrefix(rule.Destination, "http://") && !strings.HasPrefix(rule.Destination, "https://"):
+			add(rule.line, path+".destination", "must be a path starting with / or an http(s) URL")
+		case rule.Destination == rule.Source:
+			add(rule.line, path+".destination", "redirects to itself")
+		}
+		if rule.Status == 0 {
+			rule.Status = defaultRedirectStatus
+		} else if !redirectStatuses[rule.Status] {
+			add(rule.line, path+".status", "must be one of 301, 302, 303, 307, 308")
+		}
+		if prev, ok := seen[rule.Source]; ok && rule.Source != "" {
+			add(rule.line, path+".source", "duplicates %s; only the first match applies", prev)
+		}
+		seen[rule.Source] = path
+	}
+
+	for _, rule := range c.Rewrites {
+		path := rule.path
+		if msg := checkRouteSource(rule.Source); msg != "" {
+			add(rule.line, path+".source", "%s", msg)
+		}
+		if !strings.HasPrefix(rule.Destination, "/") {
+			add(rule.line, path+".destination", "must be a path starting with /; rewrites cannot proxy to other hosts")
+		}
+		if prev, ok := seen[rule.Source]; ok && rule.Source != "" {
+			add(rule.line, path+".source", "duplicates %s; only the first match applies", prev)
+		}
+		seen[rule.Source] = path
+	}
+	return issues
+}
+
+// checkRouteSource validates a source pattern: a path starting with /, where
+// * matches the rest of the path and :name matches one segment.
+func checkRouteSource(source string) string {
+	switch {
+	case source == "":
+		return "is required"
+	case !strings.HasPrefix(source, "/"):
+		return "must start with /"
+	case strings.ContainsAny(source, " \t?#"):
+		return "must not contain whitespace, a query or a fragment"
+	case strings.Contains(source, "*") && !strings.HasSuffix(source, "*"):
+		return "* is only allowed at the end"
+	}
+	return ""
+}
+
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r > 127 || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// payload returns the rules as JSON for upload, with defaults filled in.
+func (c *routesConfig) payload() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+// ruleCounts summarizes the config for log lines.
+func (c *routesConfig) ruleCounts() string {
+	return fmt.Sprintf("%d header rule(s), %d redirect(s), %d rewrite(s)", len(c.Headers), len(c.Redirects), len(c.Rewrites))
+}
+
+func (i routesIssue) String() string {
+	if i.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", i.Line, i.Path, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", i.Path, i.Message)
+}
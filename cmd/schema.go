@@ -0,0 +1,354 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+)
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// errorSchemaName is the schema name of the error envelope every command
+// writes on failure.
+const errorSchemaName = "error"
+
+// outputTypes lists the types each command emits as the data of its JSON
+// success envelope, keyed like the envelope's command field. Commands with
+// several output shapes list each of them.
+var outputTypes = map[string][]interface{}{
+	"analytics":         {analyticsResponse{}},
+	"approvals approve": {approvalResponse{}},
+	"approvals list":    {approvalsListResponse{}},
+	"approvals reject":  {approvalResponse{}},
+	"bulk":              {bulkResponse{}},
+	"cache purge":       {cachePurgeResponse{}},
+	"ci init":           {ciInitResponse{}},
+	"config decrypt":    {configEncryptResponse{}},
+	"config encrypt":    {configEncryptResponse{}},
+	"config get":        {configValueResponse{}},
+	"config migrate":    {configMigrateResponse{}},
+	"config set":        {configValueResponse{}},
+	"config unset":      {configValueResponse{}},
+	"config validate":   {configValidateResponse{}},
+	"config view":       {configViewResponse{}},
+	"deploy":            {deployResponse{}, deployTargetsResponse{}},
+	"env-vars":          {envVarsResponse{}},
+	"env diff":          {envDiffResponse{}},
+	"events":            {eventsResponse{}},
+	"examples":          {examplesListResponse{}, exampleResponse{}},
+	"explain-exit":      {explainExitResponse{}},
+	"files delete":      {filesResponse{}},
+	"files get":         {filesResponse{}},
+	"files put":         {filesResponse{}},
+	"flags list":        {featureFlagsResponse{}},
+	"flags remove":      {featureFlagsResponse{}},
+	"flags set":         {featureFlagsResponse{}},
+	"jobs add":          {client.Job{}},
+	"jobs list":         {jobsListResponse{}},
+	"jobs remove":       {jobRemoveResponse{}},
+	"licenses":          {licensesResponse{}},
+	"login":             {loginResponse{}},
+	"logs":              {logsResponse{}, multiLogsResponse{}},
+	"maintenance off":   {maintenanceResponse{}},
+	"maintenance on":    {maintenanceResponse{}},
+	"open":              {openResponse{}},
+	"pin":               {pinResponse{}},
+	"ping":              {pingResponse{}},
+	"plugin list":       {pluginListResponse{}},
+	"projects":          {projectsResponse{}},
+	"projects clone":    {projectCloneResponse{}},
+	"protect":           {protectResponse{}},
+	"preview-archive":   {previewArchiveResponse{}},
+	"prune":             {pruneResponse{}},
+	"publish":           {publishResponse{}},
+	"recent":            {recentResponse{}},
+	"release":           {releaseResponse{}},
+	"rollback":          {rollbackResponse{}},
+	"routes validate":   {routesValidateResponse{}},
+	"schema":            {schemaListResponse{}, schemaResponse{}},
+	"search-logs":       {searchLogsResponse{}},
+	"share":             {shareResponse{}},
+	"stats":             {statsResponse{}},
+	"status":            {statusResponse{}},
+	"unlock":            {unlockResponse{}},
+	"unpin":             {pinResponse{}},
+	"verify":            {verifyResponse{}},
+	"verify-drift":      {verifyDriftResponse{}},
+	"versions":          {versionsResponse{}},
+	"wait":              {waitResponse{}},
+}
+
+type schemaListResponse struct {
+	Schemas []string `json:"schemas"`
+}
+
+type schemaResponse struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema"`
+}
+
+type schemaOptions struct {
+	*app
+}
+
+func newSchemaCmd(a *app) *cobra.Command {
+	o := &schemaOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "schema [command]",
+		Short: "Print the JSON Schema of a command's JSON output",
+		Long: `Print the JSON Schema (draft 2020-12) of the envelope a command writes with
+--output json, generated from the CLI's own response types, so agents and
+scripts can validate and type the output. "robotx schema error" describes
+the error envelope shared by all commands. Without a command, the commands
+with a schema are listed.
+
+Subcommands are named like the envelope's command field, e.g. "config get".`,
+		Example: `  robotx schema
+  robotx schema deploy > deploy.schema.json
+  robotx schema "config get"
+  robotx schema error`,
+		Args: cobra.ArbitraryArgs,
+		ValidArgsFunction: func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return schemaNames(), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: o.run,
+	}
+	return cmd
+}
+
+func (o *schemaOptions) run(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		names := schemaNames()
+		if err := o.emitSuccess(cmd.Name(), schemaListResponse{Schemas: names}); err != nil {
+			return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+		}
+		if !o.isJSONOutput() {
+			w := tabwriter.NewWriter(o.out(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "COMMAND")
+			for _, name := range names {
+				fmt.Fprintln(w, name)
+			}
+			w.Flush()
+		}
+		return nil
+	}
+
+	name := strings.Join(args, " ")
+	schema, ok := commandSchema(name)
+	if !ok {
+		return newCLIError("invalid_argument", fmt.Sprintf("no schema for %q (run 'robotx schema' for the list)", name), ExitGeneral, nil)
+	}
+	if err := o.emitSuccess(cmd.Name(), schemaResponse{Name: name, Schema: schema}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if !o.isJSONOutput() {
+		raw, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return newCLIError("output_error", "failed to render schema", ExitGeneral, err)
+		}
+		fmt.Fprintln(o.out(), string(raw))
+	}
+	return nil
+}
+
+// schemaNames returns the commands with a schema and "error", sorted.
+func schemaNames() []string {
+	names := []string{errorSchemaName}
+	for name, types := range outputTypes {
+		if len(types) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// commandSchema returns the JSON Schema of the envelope written by the
+// command name, or of the error envelope for "error".
+func commandSchema(name string) (map[string]interface{}, bool) {
+	g := newSchemaGenerator()
+	var schema map[string]interface{}
+	if name == errorSchemaName {
+		schema = g.structSchema(reflect.TypeOf(errorEnvelope{}))
+		schema["properties"].(map[string]interface{})["success"] = map[string]interface{}{"const": false}
+		schema["title"] = "robotx error output"
+	} else {
+		types := outputTypes[name]
+		if len(types) == 0 {
+			return nil, false
+		}
+		var data map[string]interface{}
+		if len(types) == 1 {
+			data = g.schemaFor(reflect.TypeOf(types[0]))
+		} else {
+			var shapes []interface{}
+			for _, t := range types {
+				shapes = append(shapes, g.schemaFor(reflect.TypeOf(t)))
+			}
+			data = map[string]interface{}{"anyOf": shapes}
+		}
+		schema = map[string]interface{}{
+			"title": "robotx " + name + " output",
+			"type":  "object",
+			"properties": map[string]interface{}{
+				"success": map[string]interface{}{"const": true},
+				"command": map[string]interface{}{"const": name},
+				"data":    data,
+			},
+			"required": []string{"success", "command"},
+		}
+	}
+	schema["$schema"] = jsonSchemaDialect
+	if len(g.defs) > 0 {
+		schema["$defs"] = g.defs
+	}
+	return schema, true
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	durationType  = reflect.TypeOf(time.Duration(0))
+	rawJSONType   = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaGenerator derives JSON Schemas from Go types the way encoding/json
+// encodes them. Named struct types become $defs referenced by name, which
+// also covers recursive types.
+type schemaGenerator struct {
+	defs  map[string]interface{}
+	names map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{defs: map[string]interface{}{}, names: map[reflect.Type]string{}}
+}
+
+func (g *schemaGenerator) schemaFor(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == durationType:
+		return map[string]interface{}{"type": "integer", "description": "nanoseconds"}
+	case t == rawJSONType:
+		return map[string]interface{}{}
+	case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		// Custom encodings are not described.
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + g.define(t)}
+	}
+	// Interfaces hold any JSON value.
+	return map[string]interface{}{}
+}
+
+// define adds the named struct type t to the $defs and returns its name.
+func (g *schemaGenerator) define(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := g.defs[name]; taken {
+		name = t.String()
+	}
+	g.names[t] = name
+	g.defs[name] = nil
+	g.defs[name] = g.structSchema(t)
+	return name
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	g.addFields(t, properties, &required)
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addFields adds the JSON properties of the fields of struct type t,
+// flattening untagged embedded structs like encoding/json does.
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			g.addFields(ft, properties, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		var schema map[string]interface{}
+		if strings.Contains(","+opts+",", ",string,") {
+			schema = map[string]interface{}{"type": "string"}
+		} else {
+			schema = g.schemaFor(f.Type)
+		}
+		if strings.Contains(","+opts+",", ",omitempty,") {
+			properties[name] = schema
+			continue
+		}
+		// Fields without omitempty are always written, as null when nil.
+		switch f.Type.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+			if len(schema) > 0 && f.Type != rawJSONType {
+				schema = map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+			}
+		}
+		properties[name] = schema
+		*required = append(*required, name)
+	}
+}
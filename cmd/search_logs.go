@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+// searchLogsMaxLine is the longest log line searched; longer lines are cut.
+const searchLogsMaxLine = 1 << 20
+
+type searchLogsOptions struct {
+	*app
+	projectID  string
+	query      string
+	since      string
+	regex      bool
+	ignoreCase bool
+	context    int
+	maxMatches int
+}
+
+type searchLogsResponse struct {
+	ProjectID string    `json:"project_id"`
+	Query     string    `json:"query"`
+	Since     time.Time `json:"since"`
+	// Source is "server" when the server searched the logs it keeps, or
+	// "local" for the build logs kept in the project directory.
+	Source string `json:"source"`
+	// Searched is the number of local build logs searched.
+	Searched int `json:"searched_builds,omitempty"`
+	// MissingLogs are builds in the window without a local log.
+	MissingLogs []string                  `json:"missing_logs,omitempty"`
+	Builds      []*client.BuildLogMatches `json:"builds"`
+}
+
+func newSearchLogsCmd(a *app) *cobra.Command {
+	o := &searchLogsOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "search-logs",
+		Short: "Search the build logs of a project",
+		Long: `Search the build logs of a project's recent builds for a text and print the
+matching lines with context, grouped by build, to find when an error first
+appeared or which versions hit it.
+
+The query is plain text unless --regex is given. Servers that keep build
+logs search them; otherwise the local logs of the builds in the window are
+searched, which needs the project directory that ran the deploys (logs of
+the last 20 builds are kept).`,
+		Example: `  robotx search-logs --query "TypeError"
+  robotx search-logs -p proj_123 -q "cannot find module '.*'" --regex --since 30d
+  robotx search-logs -q warning -i -C 0 --max-matches 5`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	cmd.Flags().StringVarP(&o.query, "query", "q", "", "Text to search for")
+	cmd.Flags().StringVar(&o.since, "since", "7d", "Only search builds created within this window (e.g. 24h, 7d, 2w)")
+	cmd.Flags().BoolVar(&o.regex, "regex", false, "Treat --query as a regular expression")
+	cmd.Flags().BoolVarP(&o.ignoreCase, "ignore-case", "i", false, "Match case-insensitively")
+	cmd.Flags().IntVarP(&o.context, "context", "C", 2, "Lines of context to show around each match")
+	cmd.Flags().IntVar(&o.maxMatches, "max-matches", 20, "Most matches to show per build (0 for all)")
+	markFlagsRequired(cmd, "query")
+	return cmd
+}
+
+func (o *searchLogsOptions) run(cmd *cobra.Command, args []string) error {
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	if o.context < 0 || o.maxMatches < 0 {
+		return newCLIError("invalid_argument", "--context and --max-matches cannot be negative", ExitGeneral, nil)
+	}
+	window, err := parseAge(o.since)
+	if err != nil {
+		return newCLIError("invalid_argument", fmt.Sprintf("invalid --since: %v", err), ExitGeneral, nil)
+	}
+	pattern, err := o.pattern()
+	if err != nil {
+		return err
+	}
+	projectID, st := stateProjectID(o.projectID)
+	if projectID == "" {
+		return newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	search := client.LogSearch{
+		Query:      o.query,
+		Regex:      o.regex,
+		IgnoreCase: o.ignoreCase,
+		Since:      time.Now().Add(-window),
+		Context:    o.context,
+		MaxMatches: o.maxMatches,
+	}
+	resp := searchLogsResponse{ProjectID: projectID, Query: o.query, Since: search.Since}
+	c := o.newAPIClient(baseURL, apiKey)
+	if serverCapabilities(c, baseURL).Supports(client.CapabilityLogSearch) {
+		resp.Source = "server"
+		resp.Builds, err = c.SearchBuildLogs(projectID, search)
+		if err != nil {
+			return newCLIError("api_error", "failed to search build logs", ExitAPI, err)
+		}
+	} else {
+		if st == nil {
+			return newCLIError("not_found", "this RobotX server does not search build logs and no local build logs were found; run this command inside the project directory that ran the deploys", ExitNotFound, nil)
+		}
+		resp.Source = "local"
+		if err := o.searchLocal(c, st, search, pattern, &resp); err != nil {
+			return err
+		}
+	}
+	if resp.Builds == nil {
+		resp.Builds = []*client.BuildLogMatches{}
+	}
+
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if !o.isJSONOutput() {
+		o.printMatches(resp)
+	}
+	return nil
+}
+
+// pattern compiles the query into the matcher used for local logs.
+func (o *searchLogsOptions) pattern() (*regexp.Regexp, error) {
+	expr := o.query
+	if !o.regex {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if o.ignoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, newCLIError("invalid_argument", fmt.Sprintf("invalid --query pattern: %v", err), ExitGeneral, nil)
+	}
+	return re, nil
+}
+
+// searchLocal searches the local logs of the project's builds created since
+// search.Since, newest first.
+func (o *searchLogsOptions) searchLocal(c *client.Client, st *deployState, search client.LogSearch, pattern *regexp.Regexp, resp *searchLogsResponse) error {
+	builds, err := c.ListBuildsForProject(resp.ProjectID, maxBuildLogs)
+	if err != nil {
+		return newCLIError("api_error", "failed to list project builds", ExitAPI, err)
+	}
+	for _, b := range builds {
+		if b.CreatedAt.Before(search.Since) {
+			continue
+		}
+		file, err := os.Open(st.buildLogPath(b.BuildID))
+		if err != nil {
+			resp.MissingLogs = append(resp.MissingLogs, b.BuildID)
+			continue
+		}
+		matches, truncated, err := searchLog(file, pattern, search.Context, search.MaxMatches)
+		file.Close()
+		if err != nil {
+			return newCLIError("read_failed", "failed to read build log", ExitGeneral, err)
+		}
+		resp.Searched++
+		if len(matches) == 0 {
+			continue
+		}
+		createdAt := b.CreatedAt
+		resp.Builds = append(resp.Builds, &client.BuildLogMatches{
+			BuildID:    b.BuildID,
+			VersionSeq: b.VersionSeq,
+			Status:     b.Status,
+			CreatedAt:  &createdAt,
+			Matches:    matches,
+			Truncated:  truncated,
+		})
+	}
+	return nil
+}
+
+// searchLog returns the lines of r matching pattern with up to context lines
+// around each. Context lines are not repeated between nearby matches. At
+// most maxMatches matches are returned when positive, with truncated set
+// if there were more.
+func searchLog(r io.Reader, pattern *regexp.Regexp, context, maxMatches int) (matches []client.LogMatch, truncated bool, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), searchLogsMaxLine)
+	var before []string
+	after := 0
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if pattern.MatchString(line) {
+			if maxMatches > 0 && len(matches) == maxMatches {
+				return matches, true, nil
+			}
+			matches = append(matches, client.LogMatch{Line: n, Text: line, Before: before})
+			before, after = nil, context
+			continue
+		}
+		if after > 0 {
+			last := &matches[len(matches)-1]
+			last.After = append(last.After, line)
+			after--
+			continue
+		}
+		if context > 0 {
+			before = append(before, line)
+			if len(before) > context {
+				before = before[1:]
+			}
+		}
+	}
+	return matches, false, scanner.Err()
+}
+
+// printMatches prints the matches grep-style under a header per build:
+// "N:" marks matching lines, "N-" context lines and "--" gaps.
+func (o *searchLogsOptions) printMatches(resp searchLogsResponse) {
+	w := o.out()
+	total := 0
+	for i, b := range resp.Builds {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		created := "-"
+		if b.CreatedAt != nil {
+			created = b.CreatedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "==> %s (#%s, %s, %s) <==\n", b.BuildID, formatBuildVersionSeq(b.VersionSeq), valueOrDash(b.Status), created)
+		next := 0
+		for _, m := range b.Matches {
+			first := m.Line - len(m.Before)
+			if next > 0 && first > next {
+				fmt.Fprintln(w, "--")
+			}
+			for j, line := range m.Before {
+				fmt.Fprintf(w, "%d-%s\n", first+j, line)
+			}
+			fmt.Fprintf(w, "%d:%s\n", m.Line, m.Text)
+			for j, line := range m.After {
+				fmt.Fprintf(w, "%d-%s\n", m.Line+1+j, line)
+			}
+			next = m.Line + len(m.After) + 1
+		}
+		if b.Truncated {
+			fmt.Fprintf(w, "... more matches not shown (raise --max-matches)\n")
+		}
+		total += len(b.Matches)
+	}
+
+	if resp.Source == "local" {
+		o.logf("🔎 %d matches in %d of %d builds since %s\n", total, len(resp.Builds), resp.Searched, resp.Since.Local().Format("2006-01-02 15:04"))
+		if len(resp.MissingLogs) > 0 {
+			o.logf("ℹ️  %d builds in the window have no local log: %s\n", len(resp.MissingLogs), strings.Join(resp.MissingLogs, ", "))
+		}
+	} else {
+		o.logf("🔎 %d matches in %d builds since %s\n", total, len(resp.Builds), resp.Since.Local().Format("2006-01-02 15:04"))
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	authModeKey     = "key"
+	authModeService = "service"
+)
+
+// applyAuthMode exchanges the service-account token for short-lived
+// credentials in service mode. The credentials replace api_key for this
+// process only; nothing is written to the config file.
+func (a *app) applyAuthMode(cmd *cobra.Command) error {
+	mode := strings.ToLower(strings.TrimSpace(a.authMode))
+	switch mode {
+	case "", authModeKey:
+		return nil
+	case authModeService:
+	default:
+		return newCLIError("invalid_argument", fmt.Sprintf("invalid --auth-mode %q (expected %s or %s)", a.authMode, authModeKey, authModeService), ExitGeneral, nil)
+	}
+	if cmd.Name() == "login" {
+		return newCLIError("invalid_argument", "login saves user credentials and cannot be used with --auth-mode service", ExitGeneral, nil)
+	}
+	if isOfflineCommand(cmd) {
+		return nil
+	}
+
+	token := strings.TrimSpace(a.v.GetString("service_token"))
+	if token == "" {
+		return newCLIError("missing_service_token", "--auth-mode service requires ROBOTX_SERVICE_TOKEN", ExitAuth, nil)
+	}
+	baseURL := strings.TrimSpace(a.v.GetString("base_url"))
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+
+	c := client.NewClient(baseURL, token)
+	a.dumpHTTP(c)
+	if a.verbose {
+		c.SetObserver(a.observeRequest)
+	}
+	creds, err := c.ExchangeServiceToken()
+	if err != nil {
+		if client.IsNotFound(err) {
+			return newCLIError("unsupported_server", "this RobotX server does not support service-account tokens", ExitAPI, err)
+		}
+		return newCLIError("token_exchange_failed", "failed to exchange the service token for credentials", ExitAuth, err)
+	}
+	a.v.Set("api_key", creds.AccessToken)
+	if a.verbose {
+		if creds.ExpiresAt.IsZero() {
+			a.logf("🔑 Using short-lived service credentials\n")
+		} else {
+			a.logf("🔑 Using short-lived service credentials valid until %s\n", creds.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+// maxShareExpiry bounds how long a share link may stay valid.
+const maxShareExpiry = 30 * 24 * time.Hour
+
+type shareOptions struct {
+	*app
+	projectID string
+	buildID   string
+	expires   string
+	qr        bool
+}
+
+type shareResponse struct {
+	ProjectID string    `json:"project_id"`
+	BuildID   string    `json:"build_id"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+	QRPNG     string    `json:"qr_png,omitempty"`
+}
+
+func newShareCmd(a *app) *cobra.Command {
+	o := &shareOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "share",
+		Short: "Create a time-limited preview link for a build",
+		Long: `Create a signed link to a build's preview that expires after --expires, so
+stakeholders can review private builds without a RobotX account.
+
+The link grants read access to the preview until it expires; anyone who has
+it can open it. --expires accepts durations such as 72h, 7d or 2w, up to 30d.
+Use --qr to show a QR code of the link.
+
+Inside a deployed project directory, --project-id and --build-id default to the
+project and last build recorded in .robotx/state.json.`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	cmd.Flags().StringVarP(&o.buildID, "build-id", "b", "", "Build ID (default: last build from .robotx/state.json)")
+	cmd.Flags().StringVar(&o.expires, "expires", "72h", "How long the link stays valid (e.g. 72h, 7d)")
+	cmd.Flags().BoolVar(&o.qr, "qr", false, "Show a QR code of the link (base64 PNG qr_png in JSON)")
+	return cmd
+}
+
+func (o *shareOptions) run(cmd *cobra.Command, args []string) error {
+	expiresIn, err := parseAge(o.expires)
+	if err != nil {
+		return newCLIError("invalid_argument", fmt.Sprintf("invalid --expires: %v", err), ExitGeneral, nil)
+	}
+	if expiresIn > maxShareExpiry {
+		return newCLIError("invalid_argument", "--expires must not exceed 30d", ExitGeneral, nil)
+	}
+
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	projectID, st := stateProjectID(o.projectID)
+	buildID := o.buildID
+	if buildID == "" {
+		buildID = st.lastBuildID()
+	}
+	if projectID == "" || buildID == "" {
+		return newCLIError("missing_argument", "--project-id and --build-id are required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityShareLinks) {
+		return newCLIError("unsupported_server", "this RobotX server does not support share links", ExitAPI, nil)
+	}
+	link, err := c.CreateShareLink(projectID, buildID, expiresIn)
+	if err != nil {
+		return newCLIError("api_error", "failed to create share link", ExitAPI, err)
+	}
+
+	resp := shareResponse{ProjectID: projectID, BuildID: link.BuildID, URL: link.URL, ExpiresAt: link.ExpiresAt}
+	if resp.ExpiresAt.IsZero() {
+		resp.ExpiresAt = time.Now().Add(expiresIn).UTC().Truncate(time.Second)
+	}
+	if o.qr {
+		resp.QRPNG = o.qrPNG(resp.URL)
+	}
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if o.isJSONOutput() {
+		return nil
+	}
+	fmt.Fprint(o.out(), o.formatLog("🔗 %s\n", verbatim(resp.URL)))
+	fmt.Fprintf(o.out(), "Expires: %s\n", resp.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+	if o.qr {
+		o.printQR(resp.URL)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+)
+
+// slackMessage is the payload of a Slack incoming webhook: Text is shown in
+// notifications, Blocks in the channel.
+type slackMessage struct {
+	Text   string                   `json:"text"`
+	Blocks []map[string]interface{} `json:"blocks,omitempty"`
+}
+
+// approvalSlackMessage is the card announcing a publish request. Incoming
+// webhooks cannot receive button clicks, so the card links to the request
+// and shows the command that approves it.
+func approvalSlackMessage(approval *client.PublishApproval, projectName, previewURL string) slackMessage {
+	project := firstNonEmpty(projectName, approval.ProjectID)
+	text := fmt.Sprintf("Publish request %s: build %s of %s is waiting for approval", approval.ApprovalID, approval.BuildID, project)
+
+	fields := []map[string]interface{}{
+		slackField("Project", project),
+		slackField("Build", approval.BuildID),
+	}
+	if approval.RequestedBy != "" {
+		fields = append(fields, slackField("Requested by", approval.RequestedBy))
+	}
+	blocks := []map[string]interface{}{
+		{"type": "header", "text": map[string]interface{}{"type": "plain_text", "text": "Production publish awaiting approval"}},
+		{"type": "section", "fields": fields},
+		{"type": "section", "text": map[string]interface{}{
+			"type": "mrkdwn",
+			"text": fmt.Sprintf("Approve with `robotx approvals approve %s` or reject with `robotx approvals reject %s`.", approval.ApprovalID, approval.ApprovalID),
+		}},
+	}
+	var buttons []map[string]interface{}
+	if approval.URL != "" {
+		buttons = append(buttons, slackButton("Review request", approval.URL, "primary"))
+	}
+	if previewURL != "" {
+		buttons = append(buttons, slackButton("Open preview", previewURL, ""))
+	}
+	if len(buttons) > 0 {
+		blocks = append(blocks, map[string]interface{}{"type": "actions", "elements": buttons})
+	}
+	return slackMessage{Text: text, Blocks: blocks}
+}
+
+func slackField(name, value string) map[string]interface{} {
+	return map[string]interface{}{"type": "mrkdwn", "text": fmt.Sprintf("*%s*\n%s", name, value)}
+}
+
+func slackButton(label, url, style string) map[string]interface{} {
+	button := map[string]interface{}{
+		"type": "button",
+		"text": map[string]interface{}{"type": "plain_text", "text": label},
+		"url":  url,
+	}
+	if style != "" {
+		button["style"] = style
+	}
+	return button
+}
+
+// postSlackMessage sends msg to a Slack incoming webhook.
+func postSlackMessage(ctx context.Context, webhook string, msg slackMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("slack webhook returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
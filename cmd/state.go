@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+)
+
+// deployStateDir and deployStateFile locate the per-project state written
+// after deploys: <project>/.robotx/state.json.
+const (
+	deployStateDir  = ".robotx"
+	deployStateFile = "state.json"
+)
+
+// deployState remembers the last deploy of a project directory so status,
+// publish and rollback work without flags from inside it.
+type deployState struct {
+	ProjectID       string        `json:"project_id"`
+	ProjectName     string        `json:"project_name,omitempty"`
+	SourceDigest    string        `json:"source_digest,omitempty"`
+	LastBuild       *stateBuild   `json:"last_build,omitempty"`
+	LastPublish     *statePublish `json:"last_publish,omitempty"`
+	PreviousPublish *statePublish `json:"previous_publish,omitempty"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+
+	path string
+}
+
+type stateBuild struct {
+	BuildID      string `json:"build_id"`
+	CommitID     string `json:"commit_id,omitempty"`
+	Status       string `json:"status,omitempty"`
+	VersionSeq   int64  `json:"version_seq,omitempty"`
+	VersionLabel string `json:"version_label,omitempty"`
+}
+
+type statePublish struct {
+	BuildID       string    `json:"build_id"`
+	ProductionURL string    `json:"production_url,omitempty"`
+	PublishedAt   time.Time `json:"published_at"`
+}
+
+// deployStatePath returns the state file of the project in dir.
+func deployStatePath(dir string) string {
+	return filepath.Join(dir, deployStateDir, deployStateFile)
+}
+
+// loadDeployState reads the state of the project in dir. A missing or
+// unreadable file yields an empty state that saves to dir.
+func loadDeployState(dir string) *deployState {
+	st := &deployState{path: deployStatePath(dir)}
+	if raw, err := os.ReadFile(st.path); err == nil {
+		_ = json.Unmarshal(raw, st)
+	}
+	return st
+}
+
+// findDeployState looks for a state file in the working directory and its
+// parents, so commands also work from a project's subdirectories. It returns
+// nil when there is none.
+func findDeployState() *deployState {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	dataDir, _ := robotxDataDir()
+	for {
+		path := deployStatePath(dir)
+		if filepath.Dir(path) != dataDir && fileExists(path) {
+			if st := loadDeployState(dir); st.ProjectID != "" {
+				return st
+			}
+			return nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+	}
+}
+
+// stateProjectID returns projectID, or the project recorded in the working
+// directory's state when it is empty.
+func stateProjectID(projectID string) (string, *deployState) {
+	st := findDeployState()
+	if projectID == "" && st != nil {
+		projectID = st.ProjectID
+	}
+	if st != nil && st.ProjectID != projectID {
+		st = nil
+	}
+	return projectID, st
+}
+
+func (st *deployState) lastBuildID() string {
+	if st == nil || st.LastBuild == nil {
+		return ""
+	}
+	return st.LastBuild.BuildID
+}
+
+func (st *deployState) recordBuild(build *client.Build) {
+	if build == nil || build.BuildID == "" {
+		return
+	}
+	st.LastBuild = &stateBuild{
+		BuildID:      build.BuildID,
+		CommitID:     build.CommitID,
+		Status:       build.Status,
+		VersionSeq:   build.VersionSeq,
+		VersionLabel: build.VersionLabel,
+	}
+}
+
+func (st *deployState) recordPublish(buildID, productionURL string) {
+	if st.LastPublish != nil && st.LastPublish.BuildID != buildID {
+		st.PreviousPublish = st.LastPublish
+	}
+	st.LastPublish = &statePublish{
+		BuildID:       buildID,
+		ProductionURL: productionURL,
+		PublishedAt:   time.Now().UTC(),
+	}
+}
+
+// save writes the state file. Failing to save never fails the command that
+// produced the state; the error is only logged.
+func (st *deployState) save(a *app) {
+	st.UpdatedAt = time.Now().UTC()
+	raw, err := json.MarshalIndent(st, "", "  ")
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(st.path), 0o755); err == nil {
+			err = os.WriteFile(st.path, append(raw, '\n'), 0o644)
+		}
+	}
+	if err != nil {
+		a.logf("⚠️  Could not save deploy state to %s: %v\n", st.path, err)
+	}
+}
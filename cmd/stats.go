@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+// statsMaxPages bounds how many pages of builds stats reads for one window.
+const statsMaxPages = 10
+
+type statsOptions struct {
+	*app
+	projectID string
+	days      int
+}
+
+type statsResponse struct {
+	ProjectID   string    `json:"project_id"`
+	WindowDays  int       `json:"window_days"`
+	Since       time.Time `json:"since"`
+	Builds      int       `json:"builds"`
+	Succeeded   int       `json:"succeeded"`
+	Failed      int       `json:"failed"`
+	Other       int       `json:"other"`
+	SuccessRate float64   `json:"success_rate"`
+	// AvgBuildSeconds averages finished builds, from creation to finish.
+	AvgBuildSeconds float64    `json:"avg_build_seconds"`
+	BuildsPerDay    float64    `json:"builds_per_day"`
+	LastBuildAt     *time.Time `json:"last_build_at,omitempty"`
+	Daily           []statsDay `json:"daily"`
+	// Truncated is true when the window holds more builds than stats reads.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type statsDay struct {
+	Date      string `json:"date"`
+	Builds    int    `json:"builds"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+}
+
+func newStatsCmd(a *app) *cobra.Command {
+	o := &statsOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Summarize a project's builds over a time window",
+		Long: `Summarize a project's builds over the last --days days: build count, success
+rate, average build duration, builds per day and a per-day breakdown.
+
+Statistics are computed from the project's build list. Inside a deployed
+project directory the project ID defaults to the one in .robotx/state.json.`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID")
+	cmd.Flags().IntVar(&o.days, "days", 30, "Size of the window in days")
+	return cmd
+}
+
+func (o *statsOptions) run(cmd *cobra.Command, args []string) error {
+	projectID, _ := stateProjectID(o.projectID)
+	if projectID == "" {
+		return newCLIError("missing_argument", "--project-id is required", ExitGeneral, nil)
+	}
+	if o.days <= 0 {
+		return newCLIError("invalid_argument", "--days must be positive", ExitGeneral, nil)
+	}
+
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	now := time.Now()
+	since := now.AddDate(0, 0, -o.days)
+	o.logf("📊 Computing build stats for project %s over the last %d days\n", projectID, o.days)
+	builds, truncated, err := listBuildsSince(c, projectID, since)
+	if err != nil {
+		return newCLIError("api_error", "failed to list project builds", ExitAPI, err)
+	}
+
+	resp := buildStats(builds, since, o.days)
+	resp.ProjectID = projectID
+	resp.Truncated = truncated
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if o.isJSONOutput() {
+		return nil
+	}
+
+	out := o.out()
+	fmt.Fprintf(out, "Builds:          %d (%d succeeded, %d failed, %d other)\n", resp.Builds, resp.Succeeded, resp.Failed, resp.Other)
+	fmt.Fprintf(out, "Success rate:    %.1f%%\n", resp.SuccessRate*100)
+	fmt.Fprintf(out, "Avg build time:  %s\n", formatStatsSeconds(resp.AvgBuildSeconds))
+	fmt.Fprintf(out, "Builds per day:  %.2f\n", resp.BuildsPerDay)
+	fmt.Fprintf(out, "Last build:      %s\n", formatBuildTimePtr(resp.LastBuildAt))
+	if resp.Truncated {
+		fmt.Fprint(out, o.formatLog("⚠️  Only the most recent %d builds were counted.\n", resp.Builds))
+	}
+	if len(resp.Daily) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tBUILDS\tSUCCEEDED\tFAILED")
+	for _, day := range resp.Daily {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", day.Date, day.Builds, day.Succeeded, day.Failed)
+	}
+	_ = w.Flush()
+	return nil
+}
+
+// listBuildsSince pages through a project's builds, newest first, until one
+// was created before since. It reports whether it stopped at statsMaxPages.
+func listBuildsSince(c *client.Client, projectID string, since time.Time) ([]*client.Build, bool, error) {
+	var builds []*client.Build
+	cursor := ""
+	for page := 0; page < statsMaxPages; page++ {
+		result, err := c.ListBuildsPage(projectID, 100, cursor)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, b := range result.Builds {
+			if b.CreatedAt.Before(since) {
+				return builds, false, nil
+			}
+			builds = append(builds, b)
+		}
+		if !result.HasMore || result.NextCursor == "" {
+			return builds, false, nil
+		}
+		cursor = result.NextCursor
+	}
+	return builds, true, nil
+}
+
+func buildStats(builds []*client.Build, since time.Time, days int) statsResponse {
+	resp := statsResponse{WindowDays: days, Since: since.UTC(), Daily: []statsDay{}}
+	daily := map[string]*statsDay{}
+	var totalSeconds float64
+	var finished int
+	var last time.Time
+	for _, b := range builds {
+		resp.Builds++
+		date := b.CreatedAt.Local().Format("2006-01-02")
+		day := daily[date]
+		if day == nil {
+			day = &statsDay{Date: date}
+			daily[date] = day
+		}
+		day.Builds++
+		switch normalizedBuildStatus(b.Status) {
+		case "success":
+			resp.Succeeded++
+			day.Succeeded++
+		case "failed":
+			resp.Failed++
+			day.Failed++
+		default:
+			resp.Other++
+		}
+		if b.FinishedAt != nil && !b.CreatedAt.IsZero() && b.FinishedAt.After(b.CreatedAt) {
+			totalSeconds += b.FinishedAt.Sub(b.CreatedAt).Seconds()
+			finished++
+		}
+		if b.CreatedAt.After(last) {
+			last = b.CreatedAt
+		}
+	}
+
+	if done := resp.Succeeded + resp.Failed; done > 0 {
+		resp.SuccessRate = float64(resp.Succeeded) / float64(done)
+	}
+	if finished > 0 {
+		resp.AvgBuildSeconds = totalSeconds / float64(finished)
+	}
+	resp.BuildsPerDay = float64(resp.Builds) / float64(days)
+	if !last.IsZero() {
+		resp.LastBuildAt = &last
+	}
+	for _, day := range daily {
+		resp.Daily = append(resp.Daily, *day)
+	}
+	sort.Slice(resp.Daily, func(i, j int) bool { return resp.Daily[i].Date > resp.Daily[j].Date })
+	return resp
+}
+
+// normalizedBuildStatus folds the unsuccessful final statuses (cancelled,
+// timed out) into "failed", for counting builds as succeeded or failed.
+func normalizedBuildStatus(status string) string {
+	switch status = client.NormalizeBuildStatus(status); status {
+	case client.BuildCancelled, client.BuildTimedOut:
+		return client.BuildFailed
+	}
+	return status
+}
+
+func formatStatsSeconds(seconds float64) string {
+	if seconds <= 0 {
+		return "-"
+	}
+	return (time.Duration(seconds * float64(time.Second))).Round(time.Second).String()
+}
@@ -2,82 +2,155 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"text/tabwriter"
 
 	"github.com/haibingtown/robotx_cli/pkg/client"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
-var statusCmd = &cobra.Command{
-	Use:   "status",
-	Short: "Get project or build status",
-	Long:  `Get the status of a project or specific build.`,
-	RunE:  runStatus,
+type statusOptions struct {
+	*app
+	projectID string
+	buildID   string
+	showLogs  bool
 }
 
-var (
-	statusProjectID string
-	statusBuildID   string
-	showLogs        bool
-)
-
 type statusResponse struct {
 	Project *client.Project `json:"project,omitempty"`
 	Build   *client.Build   `json:"build,omitempty"`
 	URLs    *statusURLs     `json:"urls,omitempty"`
+	// PendingApprovals lists the publish requests awaiting approval.
+	PendingApprovals []*client.PublishApproval `json:"pending_approvals,omitempty"`
 }
 
 type statusURLs struct {
 	PreviewURL    string `json:"preview_url,omitempty"`
 	ProductionURL string `json:"production_url,omitempty"`
+	// StagingURL is set while a build is staged with publish --stage.
+	StagingURL string `json:"staging_url,omitempty"`
 }
 
-func init() {
-	rootCmd.AddCommand(statusCmd)
+func newStatusCmd(a *app) *cobra.Command {
+	o := &statusOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Get project or build status",
+		Long: `Get the status of a project or specific build.
 
-	statusCmd.Flags().StringVarP(&statusProjectID, "project-id", "p", "", "Project ID")
-	statusCmd.Flags().StringVarP(&statusBuildID, "build-id", "b", "", "Build ID (optional)")
-	statusCmd.Flags().BoolVarP(&showLogs, "logs", "l", false, "Deprecated: build logs are no longer available")
+Without flags inside a deployed project directory, shows the project and last
+build recorded in .robotx/state.json.`,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID")
+	cmd.Flags().StringVarP(&o.buildID, "build-id", "b", "", "Build ID (optional)")
+	cmd.Flags().BoolVarP(&o.showLogs, "logs", "l", false, "Deprecated: build logs are no longer available")
+	return cmd
 }
 
-func runStatus(cmd *cobra.Command, args []string) error {
-	if statusProjectID == "" && statusBuildID == "" {
-		return newCLIError("missing_argument", "at least one of --project-id or --build-id is required", 1, nil)
+func (o *statusOptions) run(cmd *cobra.Command, args []string) error {
+	projectID, buildID := o.projectID, o.buildID
+	if projectID == "" && buildID == "" {
+		var st *deployState
+		projectID, st = stateProjectID("")
+		buildID = st.lastBuildID()
+	}
+	if projectID == "" && buildID == "" {
+		return newCLIError("missing_argument", "at least one of --project-id or --build-id is required", ExitGeneral, nil)
 	}
-	if showLogs {
-		return newCLIError("unsupported_feature", "build logs are unavailable because RobotX no longer runs remote builds", 1, nil)
+	if o.showLogs {
+		return newCLIError("unsupported_feature", "build logs are unavailable because RobotX no longer runs remote builds", ExitGeneral, nil)
 	}
 
-	baseURL := viper.GetString("base_url")
-	apiKey := viper.GetString("api_key")
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
 
 	if baseURL == "" {
-		return newCLIError("missing_base_url", "base URL is required", 1, nil)
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
 	}
 	if apiKey == "" {
-		return newCLIError("missing_api_key", "API key is required", 1, nil)
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+
+	c := o.newCachedAPIClient(baseURL, apiKey)
+	resp, err := o.fetchStatus(c, baseURL, projectID, buildID)
+	if err != nil {
+		return err
+	}
+
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	if o.isJSONOutput() {
+		return nil
 	}
 
-	c := client.NewClient(baseURL, apiKey)
-	resp := statusResponse{}
+	w := tabwriter.NewWriter(o.out(), 0, 0, 2, ' ', 0)
+	if resp.Project != nil {
+		fmt.Fprint(w, o.formatLog("\n📋 Project Information:\n"))
+		fmt.Fprintf(w, "ID:\t%s\n", resp.Project.ProjectID)
+		fmt.Fprintf(w, "Name:\t%s\n", resp.Project.Name)
+		fmt.Fprintf(w, "Visibility:\t%s\n", resp.Project.Visibility)
+		fmt.Fprintf(w, "Preview Protection:\t%s\n", formatPreviewProtection(resp.Project.PreviewProtection))
+		fmt.Fprintf(w, "Maintenance:\t%s\n", formatMaintenance(resp.Project.Maintenance))
+		fmt.Fprintf(w, "Created:\t%s\n", resp.Project.CreatedAt.Format("2006-01-02 15:04:05"))
+		fmt.Fprintf(w, "Updated:\t%s\n", resp.Project.UpdatedAt.Format("2006-01-02 15:04:05"))
+		if refs := resp.Project.RuntimeRefs; refs != nil && refs.Staging != nil {
+			fmt.Fprintf(w, "Staged Build:\t%s (#%s)\n", refs.Staging.BuildID, formatBuildVersionSeq(refs.Staging.VersionSeq))
+		}
+	}
+	if resp.Build != nil {
+		fmt.Fprint(w, o.formatLog("\n📋 Build Information:\n"))
+		fmt.Fprintf(w, "ID:\t%s\n", resp.Build.BuildID)
+		fmt.Fprintf(w, "Status:\t%s\n", resp.Build.StatusText())
+		fmt.Fprintf(w, "Pinned:\t%s\n", formatPinned(resp.Build.Pinned))
+		fmt.Fprintf(w, "Version Seq:\t%s\n", formatBuildVersionSeq(resp.Build.VersionSeq))
+		fmt.Fprintf(w, "Version Label:\t%s\n", valueOrDash(resp.Build.VersionLabel))
+		fmt.Fprintf(w, "Source Ref:\t%s\n", valueOrDash(resp.Build.SourceRef))
+		fmt.Fprintf(w, "Commit:\t%s\n", resp.Build.CommitID)
+		fmt.Fprintf(w, "Created:\t%s\n", resp.Build.CreatedAt.Format("2006-01-02 15:04:05"))
+		if resp.Build.FinishedAt != nil {
+			fmt.Fprintf(w, "Finished:\t%s\n", resp.Build.FinishedAt.Format("2006-01-02 15:04:05"))
+		}
+	}
+	w.Flush()
+	if resp.URLs != nil {
+		fmt.Fprint(o.out(), o.formatLog("\n🌐 URLs:\n"))
+		fmt.Fprintf(o.out(), "Preview: %s\n", resp.URLs.PreviewURL)
+		fmt.Fprintf(o.out(), "Production: %s\n", resp.URLs.ProductionURL)
+		if resp.URLs.StagingURL != "" {
+			fmt.Fprintf(o.out(), "Staging: %s\n", resp.URLs.StagingURL)
+		}
+	}
+	if len(resp.PendingApprovals) > 0 {
+		fmt.Fprint(o.out(), o.formatLog("\n⏳ Pending Approvals:\n"))
+		for _, approval := range resp.PendingApprovals {
+			fmt.Fprintf(o.out(), "%s: build %s, requested by %s at %s\n", approval.ApprovalID, approval.BuildID, valueOrDash(approval.RequestedBy), formatBuildTimePtr(approval.CreatedAt))
+		}
+	}
 
-	if statusProjectID != "" {
-		logf("📦 Fetching project information...\n")
-		project, err := c.GetProject(statusProjectID)
+	return nil
+}
+
+// fetchStatus loads the project and/or build and derives their URLs.
+func (a *app) fetchStatus(c *client.Client, baseURL, projectID, buildID string) (*statusResponse, error) {
+	resp := &statusResponse{}
+
+	if projectID != "" {
+		a.logf("📦 Fetching project information...\n")
+		project, err := c.GetProject(projectID)
 		if err != nil {
-			return newCLIError("api_error", "failed to get project", 2, err)
+			return nil, newCLIError("api_error", "failed to get project", ExitAPI, err)
 		}
 		resp.Project = project
 	}
 
-	if statusBuildID != "" {
-		logf("\n🔨 Fetching build information...\n")
-		build, err := c.GetBuild(statusProjectID, statusBuildID)
+	if buildID != "" {
+		a.logf("\n🔨 Fetching build information...\n")
+		build, err := c.GetBuild(projectID, buildID)
 		if err != nil {
-			return newCLIError("api_error", "failed to get build", 2, err)
+			return nil, newCLIError("api_error", "failed to get build", ExitAPI, err)
 		}
 		resp.Build = build
 
@@ -89,7 +162,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	urlProjectID := statusProjectID
+	urlProjectID := projectID
 	if urlProjectID == "" {
 		if resp.Project != nil {
 			urlProjectID = resp.Project.ProjectID
@@ -102,50 +175,23 @@ func runStatus(cmd *cobra.Command, args []string) error {
 			PreviewURL:    projectPreviewURL(resp.Project, baseURL),
 			ProductionURL: resolvePublishURL(baseURL, resp.Project),
 		}
+		if refs := resp.Project.RuntimeRefs; refs != nil && refs.Staging != nil {
+			resp.URLs.StagingURL = refs.Staging.URL
+		}
+		// Pending approvals are extra information; failing to list them
+		// does not fail the status.
+		if serverCapabilities(c, baseURL).Supports(client.CapabilityPublishApprovals) {
+			if approvals, err := c.ListApprovals(resp.Project.ProjectID, client.ApprovalPending); err == nil {
+				resp.PendingApprovals = approvals
+			}
+		}
 	} else if urlProjectID != "" {
 		resp.URLs = &statusURLs{
 			PreviewURL:    fmt.Sprintf("%s/preview/%s", baseURL, urlProjectID),
 			ProductionURL: fmt.Sprintf("%s/%s", baseURL, urlProjectID),
 		}
 	}
-
-	if err := emitSuccess(cmd.Name(), resp); err != nil {
-		return newCLIError("output_error", "failed to render JSON output", 1, err)
-	}
-	if isJSONOutput() {
-		return nil
-	}
-
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	if resp.Project != nil {
-		fmt.Fprintf(w, "\n📋 Project Information:\n")
-		fmt.Fprintf(w, "ID:\t%s\n", resp.Project.ProjectID)
-		fmt.Fprintf(w, "Name:\t%s\n", resp.Project.Name)
-		fmt.Fprintf(w, "Visibility:\t%s\n", resp.Project.Visibility)
-		fmt.Fprintf(w, "Created:\t%s\n", resp.Project.CreatedAt.Format("2006-01-02 15:04:05"))
-		fmt.Fprintf(w, "Updated:\t%s\n", resp.Project.UpdatedAt.Format("2006-01-02 15:04:05"))
-	}
-	if resp.Build != nil {
-		fmt.Fprintf(w, "\n📋 Build Information:\n")
-		fmt.Fprintf(w, "ID:\t%s\n", resp.Build.BuildID)
-		fmt.Fprintf(w, "Status:\t%s\n", resp.Build.Status)
-		fmt.Fprintf(w, "Version Seq:\t%s\n", formatBuildVersionSeq(resp.Build.VersionSeq))
-		fmt.Fprintf(w, "Version Label:\t%s\n", valueOrDash(resp.Build.VersionLabel))
-		fmt.Fprintf(w, "Source Ref:\t%s\n", valueOrDash(resp.Build.SourceRef))
-		fmt.Fprintf(w, "Commit:\t%s\n", resp.Build.CommitID)
-		fmt.Fprintf(w, "Created:\t%s\n", resp.Build.CreatedAt.Format("2006-01-02 15:04:05"))
-		if resp.Build.FinishedAt != nil {
-			fmt.Fprintf(w, "Finished:\t%s\n", resp.Build.FinishedAt.Format("2006-01-02 15:04:05"))
-		}
-	}
-	w.Flush()
-	if resp.URLs != nil {
-		fmt.Printf("\n🌐 URLs:\n")
-		fmt.Printf("Preview: %s\n", resp.URLs.PreviewURL)
-		fmt.Printf("Production: %s\n", resp.URLs.ProductionURL)
-	}
-
-	return nil
+	return resp, nil
 }
 
 func formatBuildVersionSeq(seq int64) string {
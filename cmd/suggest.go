@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// commandSuggestions maps command names familiar from other CLIs to the
+// robotx command line that does the same.
+var commandSuggestions = map[string]string{
+	"push":    "deploy",
+	"up":      "deploy",
+	"ship":    "deploy",
+	"list":    "projects",
+	"ls":      "projects",
+	"apps":    "projects",
+	"tail":    "logs -f",
+	"log":     "logs",
+	"info":    "status",
+	"show":    "status",
+	"promote": "publish",
+	"revert":  "rollback",
+	"health":  "ping",
+	"whoami":  "ping",
+	"signin":  "login",
+	"auth":    "login",
+}
+
+// cobraReservedCommands are the hidden commands cobra handles itself, such
+// as the requests of shell completion scripts.
+var cobraReservedCommands = map[string]bool{
+	cobra.ShellCompRequestCmd:       true,
+	cobra.ShellCompNoDescRequestCmd: true,
+}
+
+type unknownCommandDetails struct {
+	Command     string   `json:"command"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// unknownCommandError reports an unknown top-level command in args, with
+// the commands the user probably meant: the equivalent of a command known
+// from other CLIs and the commands whose names are close to it. It returns
+// nil when args name a command, a plugin included.
+func (a *app) unknownCommandError(args []string) error {
+	root := a.root
+	// Cobra adds help and completion lazily in Execute; add them now so Find
+	// sees them.
+	root.InitDefaultHelpCmd()
+	root.InitDefaultCompletionCmd()
+	if cmd, _, err := root.Find(args); err == nil || cmd != root {
+		return nil
+	}
+	i := firstPositionalArg(root.PersistentFlags(), args)
+	if i < 0 {
+		return nil
+	}
+	name := args[i]
+	if cobraReservedCommands[name] {
+		return nil
+	}
+
+	var suggestions []string
+	seen := map[string]bool{}
+	add := func(line string) {
+		if !seen[line] {
+			seen[line] = true
+			suggestions = append(suggestions, "robotx "+line)
+		}
+	}
+	if line, ok := commandSuggestions[strings.ToLower(name)]; ok {
+		add(line)
+	}
+	for _, s := range root.SuggestionsFor(name) {
+		add(s)
+	}
+
+	message := fmt.Sprintf("unknown command %q (run 'robotx --help' for the list of commands)", name)
+	switch len(suggestions) {
+	case 0:
+	case 1:
+		message = fmt.Sprintf("unknown command %q; did you mean '%s'?", name, suggestions[0])
+	default:
+		message = fmt.Sprintf("unknown command %q; did you mean one of: %s?", name, strings.Join(suggestions, ", "))
+	}
+	cliErr := newCLIError("unknown_command", message, ExitGeneral, nil)
+	cliErr.Details = unknownCommandDetails{Command: name, Suggestions: suggestions}
+	return cliErr
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
+	"github.com/haibingtown/robotx_cli/pkg/telemetry"
+
+	"github.com/spf13/cobra"
+)
+
+// otlpEndpoint returns the OTLP/HTTP endpoint from --otel-endpoint or the
+// standard OpenTelemetry environment variables.
+func (a *app) otlpEndpoint() string {
+	for _, endpoint := range []string{
+		a.otelEndpoint,
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"),
+		os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+	} {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			return endpoint
+		}
+	}
+	return ""
+}
+
+// startTelemetry opens the command span when an OTLP endpoint is configured
+// and wraps the command so its spans are exported when it returns.
+func (a *app) startTelemetry(cmd *cobra.Command) {
+	endpoint := a.otlpEndpoint()
+	if endpoint == "" || cmd.RunE == nil || a.tracer != nil {
+		return
+	}
+	service := strings.TrimSpace(os.Getenv("OTEL_SERVICE_NAME"))
+	if service == "" {
+		service = "robotx-cli"
+	}
+	a.tracer = telemetry.New(endpoint, service, version, telemetry.ParseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")))
+	a.span = a.tracer.Start(cmd.CommandPath(), nil)
+	a.span.SetAttribute("robotx.command", cmd.CommandPath())
+
+	run := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		err := run(cmd, args)
+		a.finishTelemetry(err)
+		return err
+	}
+}
+
+// finishTelemetry ends the command span and exports the trace. Export
+// failures are reported but never fail the command.
+func (a *app) finishTelemetry(err error) {
+	if err != nil {
+		_, _, _, exitCode := classifyError(err)
+		a.span.SetAttribute("robotx.exit_code", int(exitCode))
+	}
+	a.span.End(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.tracer.Flush(ctx); err != nil {
+		a.logf("⚠️  Could not export traces: %v\n", err)
+	}
+}
+
+// traceEmitter turns pipeline steps into spans under the command span. It
+// returns nil when tracing is off.
+func (a *app) traceEmitter() pipeline.Emitter {
+	if a.tracer == nil {
+		return nil
+	}
+	return pipeline.EmitterFunc(func(e pipeline.Event) {
+		switch e.Type {
+		case pipeline.EventStepStarted:
+			a.stepSpan = a.tracer.StartAt("deploy."+e.Step, a.span, telemetry.KindInternal, e.Time)
+			a.stepSpan.SetAttribute("robotx.step", e.Step)
+		case pipeline.EventStepFinished, pipeline.EventStepFailed:
+			a.stepSpan.EndAt(e.Time, e.Err)
+			a.stepSpan = nil
+		case pipeline.EventStepSkipped:
+			span := a.tracer.StartAt("deploy."+e.Step, a.span, telemetry.KindInternal, e.Time)
+			span.SetAttribute("robotx.step", e.Step)
+			span.SetAttribute("robotx.skipped", true)
+			span.EndAt(e.Time, nil)
+		}
+	})
+}
+
+// traceRequest records an API call as a client span under the running step,
+// or the command span outside a deploy.
+func (a *app) traceRequest(info client.RequestInfo) {
+	parent := a.stepSpan
+	if parent == nil {
+		parent = a.span
+	}
+	end := time.Now()
+	span := a.tracer.StartAt("HTTP "+info.Method, parent, telemetry.KindClient, end.Add(-info.Duration))
+	span.SetAttribute("http.request.method", info.Method)
+	span.SetAttribute("url.full", a.redact(info.URL))
+	if u, err := url.Parse(info.Endpoint); err == nil && u.Host != "" {
+		span.SetAttribute("server.address", u.Hostname())
+	}
+	if info.Status > 0 {
+		span.SetAttribute("http.response.status_code", info.Status)
+	}
+	if info.Failover {
+		span.SetAttribute("robotx.failover", true)
+	}
+	var err error
+	if info.Err != nil {
+		err = errors.New(a.redact(info.Err.Error()))
+	}
+	span.EndAt(end, err)
+}
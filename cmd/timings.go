@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
+)
+
+// commandTimings reports where a command spent its time, in milliseconds, so
+// deploy performance can be tracked across runs. Stages that did not run are
+// omitted.
+type commandTimings struct {
+	PackageMS   int64 `json:"package_ms,omitempty"`
+	UploadMS    int64 `json:"upload_ms,omitempty"`
+	BuildMS     int64 `json:"build_ms,omitempty"`
+	BuildWaitMS int64 `json:"build_wait_ms,omitempty"`
+	PublishMS   int64 `json:"publish_ms,omitempty"`
+	TotalMS     int64 `json:"total_ms"`
+}
+
+// deployTimings groups the pipeline's step durations into stages.
+func deployTimings(d *pipeline.Deploy, total time.Duration) *commandTimings {
+	steps := d.StepDurations
+	return &commandTimings{
+		PackageMS:   (steps[pipeline.StepPackageSource] + steps[pipeline.StepPackageArtifacts] + steps[pipeline.StepCheckBudgets] + steps[pipeline.StepPackageFunctions]).Milliseconds(),
+		UploadMS:    (steps[pipeline.StepUploadSource] + steps[pipeline.StepUploadArtifacts] + steps[pipeline.StepUploadFunctions]).Milliseconds(),
+		BuildMS:     (steps[pipeline.StepBuild] + steps[pipeline.StepBuildFunctions]).Milliseconds(),
+		BuildWaitMS: steps[pipeline.StepWait].Milliseconds(),
+		PublishMS:   steps[pipeline.StepPublish].Milliseconds(),
+		TotalMS:     total.Milliseconds(),
+	}
+}
+
+// String renders the non-empty stages for verbose text output.
+func (t *commandTimings) String() string {
+	stages := []struct {
+		name string
+		ms   int64
+	}{
+		{"package", t.PackageMS},
+		{"upload", t.UploadMS},
+		{"build", t.BuildMS},
+		{"build wait", t.BuildWaitMS},
+		{"publish", t.PublishMS},
+		{"total", t.TotalMS},
+	}
+	var parts []string
+	for _, s := range stages {
+		if s.ms > 0 || s.name == "total" {
+			parts = append(parts, fmt.Sprintf("%s %s", s.name, time.Duration(s.ms)*time.Millisecond))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
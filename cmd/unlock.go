@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+type unlockOptions struct {
+	*app
+	projectID string
+}
+
+type unlockResponse struct {
+	ProjectID string `json:"project_id"`
+	// Released is false when the project was not locked.
+	Released bool               `json:"released"`
+	Lock     *client.DeployLock `json:"lock,omitempty"`
+}
+
+func newUnlockCmd(a *app) *cobra.Command {
+	o := &unlockOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "unlock",
+		Short: "Release a project's deploy lock left by a dead deploy",
+		Long: `Deploys take a lock on the project so that concurrent deploys, e.g. from two
+CI jobs, run one after the other. A deploy that is killed without releasing
+the lock holds it until it expires; unlock releases it at once, whoever
+holds it. Only use it when the holder is no longer running.
+
+Inside a deployed project directory --project-id defaults to the recorded
+project.`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	return cmd
+}
+
+func (o *unlockOptions) run(cmd *cobra.Command, args []string) error {
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	projectID, _ := stateProjectID(o.projectID)
+	if projectID == "" {
+		return newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	if !serverCapabilities(c, baseURL).Supports(client.CapabilityDeployLocks) {
+		return newCLIError("unsupported_server", "this RobotX server does not lock deploys", ExitAPI, nil)
+	}
+	lock, err := c.GetDeployLock(projectID)
+	if err != nil {
+		return newCLIError("api_error", "failed to get the deploy lock", ExitAPI, err)
+	}
+	resp := unlockResponse{ProjectID: projectID, Lock: lock}
+	if lock == nil {
+		o.logf("🔓 %s is not locked\n", projectID)
+	} else {
+		if err := o.confirm(fmt.Sprintf("Release the deploy lock of %s held by %s", projectID, valueOrDash(lock.Holder))); err != nil {
+			return err
+		}
+		if err := c.ReleaseDeployLock(projectID, ""); err != nil {
+			return newCLIError("api_error", "failed to release the deploy lock", ExitAPI, err)
+		}
+		resp.Released = true
+		o.logf("🔓 Released the deploy lock of %s\n", projectID)
+	}
+
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// deployLockHolder describes this deploy to others waiting for the lock:
+// the CI job running it, if any, and the machine and process.
+func deployLockHolder() string {
+	host, _ := os.Hostname()
+	holder := fmt.Sprintf("robotx on %s (pid %d)", firstNonEmpty(host, "unknown host"), os.Getpid())
+	for _, job := range []struct{ name, env string }{
+		{"GitHub Actions run", "GITHUB_RUN_ID"},
+		{"GitLab job", "CI_JOB_ID"},
+		{"CircleCI build", "CIRCLE_BUILD_NUM"},
+	} {
+		if id := strings.TrimSpace(os.Getenv(job.env)); id != "" {
+			return fmt.Sprintf("%s %s, %s", job.name, id, holder)
+		}
+	}
+	return holder
+}
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const defaultVaultAddr = "https://127.0.0.1:8200"
+
+// vaultFields are the secret fields --vault-path reads, with the
+// environment variable that takes precedence over each.
+var vaultFields = []struct{ key, env string }{
+	{"base_url", "ROBOTX_BASE_URL"},
+	{"api_key", "ROBOTX_API_KEY"},
+	{"service_token", "ROBOTX_SERVICE_TOKEN"},
+}
+
+// applyVault reads base_url, api_key and service_token from the HashiCorp
+// Vault secret at --vault-path, authenticating with VAULT_TOKEN the way the
+// vault CLI does. The values replace the config file's for this process
+// only; flags and ROBOTX_* variables still win. Offline commands skip it.
+func (a *app) applyVault(cmd *cobra.Command) error {
+	path := strings.Trim(strings.TrimSpace(a.vaultPath), "/")
+	if path == "" || isOfflineCommand(cmd) {
+		return nil
+	}
+	secret, err := readVaultSecret(cmd.Context(), path)
+	if err != nil {
+		var cliErr *cliError
+		if errors.As(err, &cliErr) {
+			return err
+		}
+		return newCLIError("vault_failed", fmt.Sprintf("failed to read Vault secret %s", path), ExitAuth, err)
+	}
+
+	var applied []string
+	for _, field := range vaultFields {
+		value, ok := secret[field.key].(string)
+		if !ok || strings.TrimSpace(value) == "" {
+			continue
+		}
+		if _, set := os.LookupEnv(field.env); set {
+			continue
+		}
+		if flag := a.root.PersistentFlags().Lookup(strings.ReplaceAll(field.key, "_", "-")); flag != nil && flag.Changed {
+			continue
+		}
+		a.v.Set(field.key, strings.TrimSpace(value))
+		if field.key == "api_key" {
+			a.vaultAPIKey = true
+		}
+		applied = append(applied, field.key)
+	}
+	if len(applied) == 0 {
+		if !hasAnyVaultField(secret) {
+			return newCLIError("vault_failed", fmt.Sprintf("Vault secret %s has none of the fields base_url, api_key or service_token", path), ExitAuth, nil)
+		}
+		return nil
+	}
+	if a.verbose {
+		a.logf("🔐 Using %s from Vault secret %s\n", strings.Join(applied, ", "), path)
+	}
+	return nil
+}
+
+func hasAnyVaultField(secret map[string]interface{}) bool {
+	for _, field := range vaultFields {
+		if _, ok := secret[field.key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// readVaultSecret returns the fields of the secret at path. Like the vault
+// CLI, it asks Vault for the KV version of the mount holding path and reads
+// a version 2 secret from its data path (secret/robotx from
+// secret/data/robotx). Tokens that may not ask have the path tried as a
+// version 2 secret and then as a version 1 secret.
+func readVaultSecret(ctx context.Context, path string) (map[string]interface{}, error) {
+	token, err := vaultToken()
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := vaultHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	addr := strings.TrimRight(firstNonEmpty(strings.TrimSpace(os.Getenv("VAULT_ADDR")), defaultVaultAddr), "/")
+
+	var denied error
+	for _, candidate := range vaultSecretPaths(ctx, httpClient, addr, token, path) {
+		data, found, err := getVaultSecret(ctx, httpClient, addr, token, candidate)
+		// Policies usually grant only the path of the engine's version,
+		// so Vault denies the other one rather than reporting it missing.
+		var statusErr *vaultStatusError
+		if errors.As(err, &statusErr) && statusErr.status == http.StatusForbidden {
+			if denied == nil {
+				denied = err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+		// KV version 2 nests the fields under data next to metadata.
+		if inner, ok := data["data"].(map[string]interface{}); ok {
+			if _, v2 := data["metadata"]; v2 {
+				return inner, nil
+			}
+		}
+		return data, nil
+	}
+	if denied != nil {
+		return nil, denied
+	}
+	return nil, fmt.Errorf("no secret at %s on %s", path, addr)
+}
+
+// vaultSecretPaths returns the API paths to read the secret at path from:
+// the one for the KV version of its mount when Vault reports it, otherwise
+// the version 2 and the version 1 path.
+func vaultSecretPaths(ctx context.Context, httpClient *http.Client, addr, token, path string) []string {
+	mount, _, err := getVaultSecret(ctx, httpClient, addr, token, "sys/internal/ui/mounts/"+path)
+	if err == nil && mount != nil {
+		mountPath, _ := mount["path"].(string)
+		options, _ := mount["options"].(map[string]interface{})
+		if rest, ok := strings.CutPrefix(path, mountPath); ok && mountPath != "" {
+			if mount["type"] == "kv" && options["version"] == "2" && !strings.HasPrefix(rest, "data/") {
+				return []string{mountPath + "data/" + rest}
+			}
+			return []string{path}
+		}
+	}
+	if mount, rest, ok := strings.Cut(path, "/"); ok && !strings.HasPrefix(rest, "data/") {
+		return []string{mount + "/data/" + rest, path}
+	}
+	return []string{path}
+}
+
+// vaultStatusError is a Vault response other than found or not found.
+type vaultStatusError struct {
+	status  int
+	message string
+}
+
+func (e *vaultStatusError) Error() string {
+	return e.message
+}
+
+func getVaultSecret(ctx context.Context, httpClient *http.Client, addr, token, path string) (map[string]interface{}, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr+"/v1/"+path, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := strings.TrimSpace(os.Getenv("VAULT_NAMESPACE")); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, false, nil
+	default:
+		var body struct {
+			Errors []string `json:"errors"`
+		}
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		message := "vault returned " + resp.Status
+		if json.Unmarshal(raw, &body) == nil && len(body.Errors) > 0 {
+			message += ": " + strings.Join(body.Errors, "; ")
+		}
+		return nil, false, &vaultStatusError{status: resp.StatusCode, message: message}
+	}
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return nil, false, fmt.Errorf("invalid Vault response: %w", err)
+	}
+	return secret.Data, secret.Data != nil, nil
+}
+
+// vaultToken returns VAULT_TOKEN, or the token vault login saved in
+// ~/.vault-token.
+func vaultToken() (string, error) {
+	if token := strings.TrimSpace(os.Getenv("VAULT_TOKEN")); token != "" {
+		return token, nil
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if raw, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+			if token := strings.TrimSpace(string(raw)); token != "" {
+				return token, nil
+			}
+		}
+	}
+	return "", newCLIError("missing_vault_token", "--vault-path requires VAULT_TOKEN (or a token saved by vault login)", ExitAuth, nil)
+}
+
+// vaultHTTPClient trusts the CA bundle in VAULT_CACERT in addition to the
+// system roots.
+func vaultHTTPClient() (*http.Client, error) {
+	caFile := strings.TrimSpace(os.Getenv("VAULT_CACERT"))
+	if caFile == "" {
+		return http.DefaultClient, nil
+	}
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read VAULT_CACERT: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates in VAULT_CACERT %s", caFile)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+type verifyOptions struct {
+	*app
+	projectID  string
+	buildID    string
+	dir        string
+	failOnDiff bool
+}
+
+type verifyResponse struct {
+	ProjectID string `json:"project_id"`
+	BuildID   string `json:"build_id"`
+	Dir       string `json:"dir"`
+	// Identical is true when the directory holds exactly the files of the
+	// deployed artifact with the same content.
+	Identical bool `json:"identical"`
+	// Unchanged is the number of files identical on both sides. In Files,
+	// added files are only local and removed files only deployed.
+	Unchanged int        `json:"unchanged"`
+	Files     *driftDiff `json:"files"`
+}
+
+func newVerifyCmd(a *app) *cobra.Command {
+	o := &verifyOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check that a local build output matches the deployed artifact",
+		Long: `Hash the files of a local build output directory and compare them with the
+artifact manifest of the build published to production, to confirm that
+what is live is what was built. Files only in the directory are reported as
+added, files only in the artifact as removed, and files whose content
+differs as changed.
+
+--build-id compares with another build instead. With --fail-on-diff the
+command exits with code 8 unless the files are identical, for use in CI.
+The server must provide artifact manifests.`,
+		Example: `  robotx verify --dir dist/
+  robotx verify -p proj_123 --dir build --build-id build_456 --fail-on-diff`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	cmd.Flags().StringVarP(&o.buildID, "build-id", "b", "", "Build to compare with (default: the build published to production)")
+	cmd.Flags().StringVar(&o.dir, "dir", "", "Local build output directory, like dist/")
+	cmd.Flags().BoolVar(&o.failOnDiff, "fail-on-diff", false, "Exit with code 8 when the files differ")
+	markFlagsRequired(cmd, "dir")
+	return cmd
+}
+
+func (o *verifyOptions) run(cmd *cobra.Command, args []string) error {
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	if info, err := os.Stat(o.dir); err != nil || !info.IsDir() {
+		return newCLIError("invalid_argument", fmt.Sprintf("%s is not a directory", o.dir), ExitGeneral, err)
+	}
+	projectID, _ := stateProjectID(o.projectID)
+	if projectID == "" {
+		return newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	buildID := strings.TrimSpace(o.buildID)
+	if buildID == "" {
+		project, err := c.GetProject(projectID)
+		if err != nil {
+			if client.IsNotFound(err) {
+				return newCLIError("not_found", fmt.Sprintf("project %s not found", projectID), ExitNotFound, err)
+			}
+			return newCLIError("api_error", "failed to get project", ExitAPI, err)
+		}
+		if refs := project.RuntimeRefs; refs != nil && refs.Publish != nil {
+			buildID = refs.Publish.BuildID
+		}
+		if buildID == "" {
+			return newCLIError("not_published", "nothing is published yet; pass --build-id to compare with a build", ExitGeneral, nil)
+		}
+	}
+
+	o.logf("🔍 Hashing files in %s...\n", o.dir)
+	local, err := hashDirectory(o.dir)
+	if err != nil {
+		return newCLIError("read_failed", "failed to read "+o.dir, ExitGeneral, err)
+	}
+	deployed, err := c.GetBuildManifest(buildID)
+	if err != nil {
+		if client.IsNotFound(err) {
+			return newCLIError("unsupported_server", "this RobotX server does not provide artifact manifests", ExitAPI, err)
+		}
+		return newCLIError("api_error", "failed to get build manifest", ExitAPI, err)
+	}
+	for i := range deployed.Files {
+		deployed.Files[i].Path = strings.TrimPrefix(deployed.Files[i].Path, "/")
+	}
+
+	diff := diffManifests(deployed, local)
+	resp := verifyResponse{
+		ProjectID: projectID,
+		BuildID:   buildID,
+		Dir:       o.dir,
+		Identical: len(diff.Added)+len(diff.Removed)+len(diff.Modified) == 0,
+		Unchanged: len(local.Files) - len(diff.Added) - len(diff.Modified),
+		Files:     diff,
+	}
+	o.printVerify(resp)
+	if o.failOnDiff && !resp.Identical {
+		cliErr := newCLIError("verify_mismatch", fmt.Sprintf("%s differs from build %s", o.dir, buildID), ExitDrift, nil)
+		cliErr.Details = resp
+		return cliErr
+	}
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// hashDirectory returns a manifest of the files under dir, with paths
+// relative to it and slash-separated like artifact manifests.
+func hashDirectory(dir string) (*client.BuildManifest, error) {
+	manifest := &client.BuildManifest{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() || info.Name() == ".DS_Store" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, client.ManifestFile{Path: filepath.ToSlash(rel), SHA256: sum, Size: info.Size()})
+		return nil
+	})
+	return manifest, err
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (o *verifyOptions) printVerify(resp verifyResponse) {
+	if o.isJSONOutput() {
+		return
+	}
+	for _, group := range []struct {
+		mark  string
+		paths []string
+	}{{"+", resp.Files.Added}, {"-", resp.Files.Removed}, {"~", resp.Files.Modified}} {
+		for _, p := range group.paths {
+			fmt.Fprintf(o.out(), "%s %s\n", group.mark, p)
+		}
+	}
+	if resp.Identical {
+		fmt.Fprint(o.out(), o.formatLog("✅ %s matches build %s (%d files)\n", resp.Dir, resp.BuildID, resp.Unchanged))
+		return
+	}
+	fmt.Fprint(o.out(), o.formatLog("⚠️  %s differs from build %s: %d added, %d removed, %d changed, %d unchanged\n",
+		resp.Dir, resp.BuildID, len(resp.Files.Added), len(resp.Files.Removed), len(resp.Files.Modified), resp.Unchanged))
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+// Drift states reported by verify-drift.
+const (
+	driftIdentical   = "identical"
+	driftBehind      = "behind"
+	driftAhead       = "ahead"
+	driftUnpublished = "unpublished"
+)
+
+type verifyDriftOptions struct {
+	*app
+	projectID   string
+	failOnDrift bool
+}
+
+type verifyDriftResponse struct {
+	ProjectID  string      `json:"project_id"`
+	State      string      `json:"state"`
+	Production *driftBuild `json:"production,omitempty"`
+	Latest     *driftBuild `json:"latest,omitempty"`
+	// DigestCompared is false when the server has no artifact manifests and
+	// the builds were compared by ID and version only.
+	DigestCompared bool       `json:"digest_compared"`
+	Files          *driftDiff `json:"files,omitempty"`
+}
+
+type driftBuild struct {
+	BuildID    string `json:"build_id"`
+	VersionSeq int64  `json:"version_seq,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+}
+
+type driftDiff struct {
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+func newVerifyDriftCmd(a *app) *cobra.Command {
+	o := &verifyDriftOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "verify-drift",
+		Short: "Check whether production serves the latest successful build",
+		Long: `Compare the published artifact with the latest successful build and report
+whether production is identical, behind or ahead.
+
+When the server provides artifact manifests the builds are compared by content
+digest, so a rebuild of the same source counts as identical, and the changed
+files are listed. Otherwise they are compared by build ID and version.
+
+With --fail-on-drift the command exits with code 8 unless production is
+identical, for use in CI.`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	cmd.Flags().BoolVar(&o.failOnDrift, "fail-on-drift", false, "Exit with code 8 when production is not identical")
+	return cmd
+}
+
+func (o *verifyDriftOptions) run(cmd *cobra.Command, args []string) error {
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	projectID, _ := stateProjectID(o.projectID)
+	if projectID == "" {
+		return newCLIError("missing_argument", "--project-id is required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	project, err := c.GetProject(projectID)
+	if err != nil {
+		return newCLIError("api_error", "failed to get project", ExitAPI, err)
+	}
+	builds, err := c.ListBuildsForProject(projectID, 50)
+	if err != nil {
+		return newCLIError("api_error", "failed to list builds", ExitAPI, err)
+	}
+
+	resp := verifyDriftResponse{ProjectID: projectID}
+	if refs := project.RuntimeRefs; refs != nil && refs.Publish != nil && refs.Publish.BuildID != "" {
+		resp.Production = &driftBuild{BuildID: refs.Publish.BuildID, VersionSeq: refs.Publish.VersionSeq}
+	}
+	for _, b := range sortBuildsNewestFirst(builds) {
+		if normalizedBuildStatus(b.Status) == "success" {
+			resp.Latest = &driftBuild{BuildID: b.BuildID, VersionSeq: b.VersionSeq}
+			break
+		}
+	}
+	if resp.Latest == nil {
+		return newCLIError("no_successful_build", "project has no successful build to compare with", ExitGeneral, nil)
+	}
+
+	if err := o.compareDrift(c, &resp); err != nil {
+		return err
+	}
+	o.printDrift(resp)
+	if o.failOnDrift && resp.State != driftIdentical {
+		cliErr := newCLIError("drift_detected", fmt.Sprintf("production is %s", resp.State), ExitDrift, nil)
+		cliErr.Details = resp
+		return cliErr
+	}
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// compareDrift sets resp.State, comparing artifact manifests when the
+// server has them.
+func (o *verifyDriftOptions) compareDrift(c *client.Client, resp *verifyDriftResponse) error {
+	prod, latest := resp.Production, resp.Latest
+	switch {
+	case prod == nil:
+		resp.State = driftUnpublished
+		return nil
+	case prod.BuildID == latest.BuildID:
+		resp.State = driftIdentical
+		return nil
+	}
+
+	prodManifest, err := c.GetBuildManifest(prod.BuildID)
+	if err == nil {
+		var latestManifest *client.BuildManifest
+		latestManifest, err = c.GetBuildManifest(latest.BuildID)
+		if err == nil {
+			resp.DigestCompared = true
+			prod.Digest, latest.Digest = prodManifest.Digest, latestManifest.Digest
+			if prod.Digest != "" && prod.Digest == latest.Digest {
+				resp.State = driftIdentical
+				return nil
+			}
+			if len(prodManifest.Files) > 0 || len(latestManifest.Files) > 0 {
+				resp.Files = diffManifests(prodManifest, latestManifest)
+			}
+		}
+	}
+	if err != nil && !client.IsNotFound(err) {
+		return newCLIError("api_error", "failed to get build manifest", ExitAPI, err)
+	}
+
+	if prod.VersionSeq > latest.VersionSeq {
+		resp.State = driftAhead
+	} else {
+		resp.State = driftBehind
+	}
+	return nil
+}
+
+// diffManifests lists the files that differ from the production manifest to
+// the latest one.
+func diffManifests(prod, latest *client.BuildManifest) *driftDiff {
+	diff := &driftDiff{Added: []string{}, Removed: []string{}, Modified: []string{}}
+	prodFiles := map[string]string{}
+	for _, f := range prod.Files {
+		prodFiles[f.Path] = f.SHA256
+	}
+	for _, f := range latest.Files {
+		sum, ok := prodFiles[f.Path]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, f.Path)
+		case sum != f.SHA256:
+			diff.Modified = append(diff.Modified, f.Path)
+		}
+		delete(prodFiles, f.Path)
+	}
+	for path := range prodFiles {
+		diff.Removed = append(diff.Removed, path)
+	}
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+func (o *verifyDriftOptions) printDrift(resp verifyDriftResponse) {
+	if o.isJSONOutput() {
+		return
+	}
+	describe := func(b *driftBuild) string {
+		if b == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%s (#%s)", b.BuildID, formatBuildVersionSeq(b.VersionSeq))
+	}
+	fmt.Fprintf(o.out(), "%-25s%s\n", "Production:", describe(resp.Production))
+	fmt.Fprintf(o.out(), "%-25s%s\n", "Latest successful build:", describe(resp.Latest))
+	switch resp.State {
+	case driftIdentical:
+		fmt.Fprint(o.out(), o.formatLog("✅ Production is up to date\n"))
+	case driftBehind:
+		fmt.Fprint(o.out(), o.formatLog("⚠️  Production is behind the latest successful build\n"))
+	case driftAhead:
+		fmt.Fprint(o.out(), o.formatLog("⚠️  Production is ahead of the latest successful build\n"))
+	case driftUnpublished:
+		fmt.Fprint(o.out(), o.formatLog("⚠️  Nothing is published yet\n"))
+	}
+	if f := resp.Files; f != nil {
+		fmt.Fprintf(o.out(), "Files: %d added, %d removed, %d modified\n", len(f.Added), len(f.Removed), len(f.Modified))
+	}
+}
@@ -2,94 +2,108 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"text/tabwriter"
 	"time"
 
 	"github.com/haibingtown/robotx_cli/pkg/client"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
-var versionsCmd = &cobra.Command{
-	Use:     "versions",
-	Aliases: []string{"builds"},
-	Short:   "List recent build versions for a project",
-	Long:    `List recent build versions for a project, useful for multi-version management and selecting a build to publish.`,
-	RunE:    runVersions,
+type versionsOptions struct {
+	*app
+	projectID string
+	limit     int
+	cursor    string
 }
 
-var (
-	versionsProjectID string
-	versionsLimit     int
-)
-
 type versionsResponse struct {
-	ProjectID string          `json:"project_id"`
-	Limit     int             `json:"limit"`
-	Builds    []*client.Build `json:"builds"`
+	ProjectID  string          `json:"project_id"`
+	Limit      int             `json:"limit"`
+	Builds     []*client.Build `json:"builds"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	HasMore    bool            `json:"has_more"`
 }
 
-func init() {
-	rootCmd.AddCommand(versionsCmd)
-	versionsCmd.Flags().StringVarP(&versionsProjectID, "project-id", "p", "", "Project ID (required)")
-	versionsCmd.Flags().IntVar(&versionsLimit, "limit", 20, "Number of recent versions to list (max 100 on server)")
-	versionsCmd.MarkFlagRequired("project-id")
+func newVersionsCmd(a *app) *cobra.Command {
+	o := &versionsOptions{app: a}
+	cmd := &cobra.Command{
+		Use:     "versions",
+		Aliases: []string{"builds"},
+		Short:   "List recent build versions for a project",
+		Long: `List recent build versions for a project, useful for multi-version management and selecting a build to publish.
+
+Versions are listed newest first, one page at a time. When more remain, the
+output includes a cursor; pass it to --cursor to get the next page.`,
+		RunE: o.run,
+	}
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (required)")
+	cmd.Flags().IntVar(&o.limit, "limit", 20, "Number of recent versions to list (max 100 on server)")
+	cmd.Flags().StringVar(&o.cursor, "cursor", "", "Cursor from a previous page's next_cursor")
+	markFlagsRequired(cmd, "project-id")
+	return cmd
 }
 
-func runVersions(cmd *cobra.Command, args []string) error {
-	baseURL := viper.GetString("base_url")
-	apiKey := viper.GetString("api_key")
+func (o *versionsOptions) run(cmd *cobra.Command, args []string) error {
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
 
 	if baseURL == "" {
-		return newCLIError("missing_base_url", "base URL is required", 1, nil)
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
 	}
 	if apiKey == "" {
-		return newCLIError("missing_api_key", "API key is required", 1, nil)
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
 	}
 
-	c := client.NewClient(baseURL, apiKey)
-	logf("📋 Listing recent versions for project: %s\n", versionsProjectID)
-	builds, err := c.ListBuildsForProject(versionsProjectID, versionsLimit)
+	c := o.newCachedAPIClient(baseURL, apiKey)
+	o.logf("📋 Listing recent versions for project: %s\n", o.projectID)
+	page, err := c.ListBuildsPage(o.projectID, o.limit, o.cursor)
 	if err != nil {
-		return newCLIError("api_error", "failed to list project versions", 2, err)
+		return newCLIError("api_error", "failed to list project versions", ExitAPI, err)
 	}
+	builds := page.Builds
 
 	resp := versionsResponse{
-		ProjectID: versionsProjectID,
-		Limit:     versionsLimit,
-		Builds:    builds,
+		ProjectID:  o.projectID,
+		Limit:      o.limit,
+		Builds:     builds,
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
 	}
-	if err := emitSuccess(cmd.Name(), resp); err != nil {
-		return newCLIError("output_error", "failed to render JSON output", 1, err)
+	if err := o.emitSuccess(cmd.Name(), resp); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
 	}
-	if isJSONOutput() {
+	if o.isJSONOutput() {
 		return nil
 	}
 
 	if len(builds) == 0 {
-		fmt.Fprintln(os.Stdout, "No build versions found.")
+		fmt.Fprintln(o.out(), "No build versions found.")
 		return nil
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "BUILD_ID\tSEQ\tLABEL\tSOURCE_REF\tSTATUS\tCOMMIT_ID\tCREATED_AT\tFINISHED_AT")
+	w := tabwriter.NewWriter(o.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BUILD_ID\tSEQ\tLABEL\tSOURCE_REF\tSTATUS\tPINNED\tCOMMIT_ID\tCREATED_AT\tFINISHED_AT\tPREVIEW")
 	for _, b := range builds {
 		fmt.Fprintf(
 			w,
-			"%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			"%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
 			b.BuildID,
 			formatBuildVersionSeq(b.VersionSeq),
 			valueOrDash(b.VersionLabel),
 			valueOrDash(b.SourceRef),
-			b.Status,
+			b.StatusText(),
+			formatPinned(b.Pinned),
 			b.CommitID,
 			formatBuildTime(b.CreatedAt),
 			formatBuildTimePtr(b.FinishedAt),
+			valueOrDash(buildPreviewURL(baseURL, b)),
 		)
 	}
 	_ = w.Flush()
 
+	if page.NextCursor != "" {
+		fmt.Fprintf(o.out(), "\nMore versions: robotx versions -p %s --limit %d --cursor %s\n", o.projectID, o.limit, page.NextCursor)
+	}
 	return nil
 }
 
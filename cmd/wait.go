@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+	"github.com/haibingtown/robotx_cli/pkg/pipeline"
+
+	"github.com/spf13/cobra"
+)
+
+type waitOptions struct {
+	*app
+	projectID    string
+	buildID      string
+	timeout      int
+	pollInterval int
+	heartbeat    int
+	publish      bool
+}
+
+type waitResponse struct {
+	ProjectID     string `json:"project_id"`
+	BuildID       string `json:"build_id"`
+	BuildStatus   string `json:"build_status"`
+	RawStatus     string `json:"build_raw_status,omitempty"`
+	VersionSeq    int64  `json:"version_seq,omitempty"`
+	PreviewURL    string `json:"preview_url,omitempty"`
+	ProductionURL string `json:"production_url,omitempty"`
+	Published     bool   `json:"published"`
+}
+
+// waitResume is attached to errors of interrupted or timed-out waits.
+type waitResume struct {
+	ProjectID     string `json:"project_id"`
+	BuildID       string `json:"build_id"`
+	ResumeCommand string `json:"resume_command"`
+}
+
+func newWaitCmd(a *app) *cobra.Command {
+	o := &waitOptions{app: a}
+	cmd := &cobra.Command{
+		Use:   "wait",
+		Short: "Wait for a build to finish",
+		Long: `Wait for an existing build to finish, optionally publishing it.
+
+When deploy is interrupted or times out while waiting, it prints the wait
+command that continues from the same build, so CI retries can keep waiting
+instead of rebuilding.
+
+Inside a deployed project directory, --project-id and --build-id default to the
+project and last build recorded in .robotx/state.json.`,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+
+	cmd.Flags().StringVarP(&o.projectID, "project-id", "p", "", "Project ID (default: from .robotx/state.json)")
+	cmd.Flags().StringVarP(&o.buildID, "build-id", "b", "", "Build ID (default: last build from .robotx/state.json)")
+	cmd.Flags().IntVar(&o.timeout, "timeout", 600, "Build timeout in seconds")
+	cmd.Flags().IntVar(&o.pollInterval, "poll-interval", 5, "Seconds between build status checks")
+	cmd.Flags().IntVar(&o.heartbeat, "heartbeat", 30, "Seconds between progress lines while the status is unchanged")
+	cmd.Flags().BoolVar(&o.publish, "publish", false, "Publish the build to production when it succeeds")
+	return cmd
+}
+
+func (o *waitOptions) run(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	baseURL := o.v.GetString("base_url")
+	apiKey := o.v.GetString("api_key")
+	if baseURL == "" {
+		return newCLIError("missing_base_url", "base URL is required", ExitGeneral, nil)
+	}
+	if apiKey == "" {
+		return newCLIError("missing_api_key", "API key is required", ExitAuth, nil)
+	}
+	projectID, st := stateProjectID(o.projectID)
+	buildID := o.buildID
+	if buildID == "" {
+		buildID = st.lastBuildID()
+	}
+	if projectID == "" || buildID == "" {
+		return newCLIError("missing_argument", "--project-id and --build-id are required outside a deployed project directory", ExitGeneral, nil)
+	}
+
+	c := o.newAPIClient(baseURL, apiKey)
+	project, err := c.GetProject(projectID)
+	if err != nil {
+		if client.IsNotFound(err) {
+			return newCLIError("not_found", fmt.Sprintf("project %s not found", projectID), ExitNotFound, err)
+		}
+		return newCLIError("api_error", "failed to get project", ExitAPI, err)
+	}
+	build, err := c.GetBuild(projectID, buildID)
+	if err != nil {
+		if client.IsNotFound(err) {
+			return newCLIError("not_found", fmt.Sprintf("build %s not found", buildID), ExitNotFound, err)
+		}
+		return newCLIError("api_error", "failed to get build", ExitAPI, err)
+	}
+
+	steps := []pipeline.Step{pipeline.WaitForBuild{
+		Timeout:   time.Duration(o.timeout) * time.Second,
+		Interval:  time.Duration(o.pollInterval) * time.Second,
+		Heartbeat: time.Duration(o.heartbeat) * time.Second,
+	}}
+	if o.publish {
+		steps = append(steps, pipeline.Publish{})
+	}
+	d := &pipeline.Deploy{
+		Client:  c,
+		URLs:    projectURLs{baseURL: baseURL},
+		Project: project,
+		Build:   build,
+	}
+	o.logf("⏳ Waiting for build %s of %s\n", buildID, projectID)
+	if err := pipeline.New(pipeline.Emitters{o.deployEventLogger(steps), o.traceEmitter()}, steps...).Run(ctx, d); err != nil {
+		cliErr := deployStepError(ctx, err)
+		if ctx.Err() != nil {
+			cliErr = newCLIError("cancelled", "wait cancelled", ExitCancelled, err)
+		}
+		return o.withWaitResume(cliErr, err, d, o.publish, o.timeout)
+	}
+
+	build = d.Build
+	previewURL := d.PreviewURL
+	if previewURL == "" && build.Status == "success" {
+		previewURL = firstNonEmpty(buildPreviewURL(baseURL, build), projectPreviewURL(project, baseURL))
+	}
+	if st != nil {
+		st.recordBuild(build)
+		if d.ProductionURL != "" {
+			st.recordPublish(build.BuildID, d.ProductionURL)
+		}
+		st.save(o.app)
+	}
+	if d.ProductionURL != "" {
+		o.recordHistory(historyEntry{
+			Command:       cmd.Name(),
+			ProjectID:     projectID,
+			BuildID:       build.BuildID,
+			PreviewURL:    previewURL,
+			ProductionURL: d.ProductionURL,
+			Args:          []string{cmd.Name(), "--project-id=" + projectID, "--build-id=" + buildID, "--publish"},
+		})
+	}
+
+	if err := o.emitSuccess(cmd.Name(), waitResponse{
+		ProjectID:     projectID,
+		BuildID:       build.BuildID,
+		BuildStatus:   build.Status,
+		RawStatus:     build.RawStatus,
+		VersionSeq:    build.VersionSeq,
+		PreviewURL:    previewURL,
+		ProductionURL: d.ProductionURL,
+		Published:     d.ProductionURL != "",
+	}); err != nil {
+		return newCLIError("output_error", "failed to render JSON output", ExitGeneral, err)
+	}
+	return nil
+}
+
+// withWaitResume adds the command that resumes waiting to errors of waits
+// that stopped before the build finished (interrupts, timeouts and failed
+// status checks) and prints it. Failed builds and other steps are returned
+// unchanged.
+func (a *app) withWaitResume(err, pipelineErr error, d *pipeline.Deploy, publish bool, timeout int) error {
+	var stepErr *pipeline.StepError
+	var buildFailed *pipeline.BuildFailedError
+	if !errors.As(pipelineErr, &stepErr) || stepErr.Step != pipeline.StepWait || errors.As(pipelineErr, &buildFailed) {
+		return err
+	}
+	if d.Project == nil || d.Build == nil || d.Build.BuildID == "" {
+		return err
+	}
+	var cliErr *cliError
+	if !errors.As(err, &cliErr) || cliErr.Details != nil {
+		return err
+	}
+	resume := waitResume{
+		ProjectID:     d.Project.ProjectID,
+		BuildID:       d.Build.BuildID,
+		ResumeCommand: resumeWaitCommand(d.Project.ProjectID, d.Build.BuildID, publish, timeout),
+	}
+	cliErr.Details = resume
+	a.logf("⏸️  Build %s keeps running on the server. Resume waiting with:\n   %s\n", resume.BuildID, resume.ResumeCommand)
+	return cliErr
+}
+
+// resumeWaitCommand returns the wait command continuing from a build.
+func resumeWaitCommand(projectID, buildID string, publish bool, timeout int) string {
+	parts := []string{"robotx", "wait", "--project-id", projectID, "--build-id", buildID}
+	if timeout > 0 {
+		parts = append(parts, "--timeout", fmt.Sprint(timeout))
+	}
+	if publish {
+		parts = append(parts, "--publish")
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// workspaceOnlyKeys are robotx.yaml keys that are not CLI settings.
+var workspaceOnlyKeys = map[string]bool{
+	"budgets":    true,
+	"env":        true,
+	"project_id": true,
+	"targets":    true,
+	"version":    true,
+}
+
+// workspaceSecretKeys are refused in robotx.yaml, which is usually committed.
+var workspaceSecretKeys = map[string]bool{
+	"api_key":            true,
+	"service_token":      true,
+	credentialsConfigKey: true,
+	"profiles":           true,
+}
+
+// findWorkspaceDir returns the nearest directory from start upward that
+// holds robotx.yaml. The search stops at the git root, like .git discovery,
+// and returns "" when there is no workspace.
+func findWorkspaceDir(start string) string {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return ""
+	}
+	for {
+		if stat, err := os.Stat(filepath.Join(dir, projectConfigFileName)); err == nil && !stat.IsDir() {
+			return dir
+		}
+		if fileExists(filepath.Join(dir, ".git")) {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// applyWorkspaceConfig merges the settings of the workspace robotx.yaml over
+// the user config file. Profiles, environment variables and flags still take
+// precedence. project_id becomes the default of every --project-id flag.
+func (a *app) applyWorkspaceConfig() error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	dir := findWorkspaceDir(cwd)
+	if dir == "" {
+		return nil
+	}
+	path := filepath.Join(dir, projectConfigFileName)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return newCLIError("invalid_project_config", "failed to read "+path, ExitGeneral, err)
+	}
+	cfg := map[string]interface{}{}
+	if err := decodeProjectYAML(raw, dir, &cfg); err != nil {
+		return err
+	}
+
+	var refused []string
+	settings := map[string]interface{}{}
+	for key, value := range cfg {
+		switch {
+		case workspaceSecretKeys[key]:
+			refused = append(refused, key)
+		case !workspaceOnlyKeys[key]:
+			settings[key] = value
+		}
+	}
+	if len(refused) > 0 {
+		sort.Strings(refused)
+		return newCLIError("invalid_project_config", fmt.Sprintf("%s must not contain %s; keep credentials and profiles in the user config file", path, strings.Join(refused, ", ")), ExitGeneral, nil)
+	}
+	if projectID, ok := cfg["project_id"].(string); ok {
+		a.workspaceProjectID = strings.TrimSpace(projectID)
+	}
+	if !a.isJSONOutput() {
+		fmt.Fprintln(a.errOut(), "Using workspace config:", path)
+	}
+	return a.v.MergeConfigMap(settings)
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIVersion is the version of the RobotX API this client speaks. It is
+// sent with every request so the server can keep serving old CLIs the
+// responses they understand, and tell them when they must upgrade.
+const APIVersion = "1"
+
+const (
+	// APIVersionHeader carries APIVersion on requests.
+	APIVersionHeader = "X-RobotX-CLI-API-Version"
+	// minAPIVersionHeader is the oldest API version the server still
+	// serves, sent with unsupported-version errors.
+	minAPIVersionHeader = "X-RobotX-API-Min-Version"
+)
+
+// unsupportedVersionCodes are the error codes of responses that refuse the
+// client's API version, in addition to 426 Upgrade Required.
+var unsupportedVersionCodes = map[string]bool{
+	"unsupported_api_version": true,
+	"api_version_unsupported": true,
+	"client_upgrade_required": true,
+}
+
+// APIDeprecation describes a server notice that the client's API version is
+// deprecated, from the Deprecation and Sunset response headers.
+type APIDeprecation struct {
+	// Sunset is when the version stops being served, zero when unknown.
+	Sunset time.Time
+	// Link is the documentation linked with rel="deprecation", if any.
+	Link string
+}
+
+// SetDeprecationHandler registers a callback invoked the first time a
+// response marks the client's API version as deprecated.
+func (c *Client) SetDeprecationHandler(fn func(APIDeprecation)) {
+	c.versionTransport().onDeprecation = fn
+}
+
+// IsAPIVersionUnsupported reports whether err is the server refusing the
+// client's API version.
+func IsAPIVersionUnsupported(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusUpgradeRequired || unsupportedVersionCodes[strings.ToLower(apiErr.Code)]
+}
+
+// CheckAPIVersion returns an APIError when resp refuses the client's API
+// version, for requests made outside the client such as the login flows.
+func CheckAPIVersion(resp *http.Response, body []byte) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	apiErr := &APIError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body)), MinAPIVersion: resp.Header.Get(minAPIVersionHeader)}
+	fillAPIError(apiErr, body)
+	if !IsAPIVersionUnsupported(apiErr) {
+		return nil
+	}
+	return apiErr
+}
+
+// apiVersionTransport adds the API version header to the API requests of
+// the client and watches their responses for deprecation notices. Pages
+// fetched with FetchURL, which carry no credentials, are left alone.
+type apiVersionTransport struct {
+	next          http.RoundTripper
+	onDeprecation func(APIDeprecation)
+	once          sync.Once
+}
+
+func (t *apiVersionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") == "" {
+		return t.next.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set(APIVersionHeader, APIVersion)
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || t.onDeprecation == nil {
+		return resp, err
+	}
+	if d, ok := parseDeprecation(resp.Header); ok {
+		t.once.Do(func() { t.onDeprecation(d) })
+	}
+	return resp, nil
+}
+
+// versionTransport returns the client's outermost transport, which every
+// request goes through.
+func (c *Client) versionTransport() *apiVersionTransport {
+	if t, ok := c.httpClient.Transport.(*apiVersionTransport); ok {
+		return t
+	}
+	next := c.httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	t := &apiVersionTransport{next: next}
+	c.httpClient.Transport = t
+	return t
+}
+
+// parseDeprecation reads the Deprecation header, either a date (RFC 9745,
+// "@<unix seconds>") or the older "true", with the Sunset date and the
+// deprecation link when present.
+func parseDeprecation(h http.Header) (APIDeprecation, bool) {
+	value := strings.TrimSpace(h.Get("Deprecation"))
+	if value == "" || strings.EqualFold(value, "false") {
+		return APIDeprecation{}, false
+	}
+	var d APIDeprecation
+	if sunset, err := http.ParseTime(strings.TrimSpace(h.Get("Sunset"))); err == nil {
+		d.Sunset = sunset
+	}
+	for _, link := range h.Values("Link") {
+		for _, part := range strings.Split(link, ",") {
+			target, params, ok := strings.Cut(part, ";")
+			if ok && strings.Contains(strings.ReplaceAll(params, " ", ""), `rel="deprecation"`) {
+				d.Link = strings.Trim(strings.TrimSpace(target), "<>")
+			}
+		}
+	}
+	return d, true
+}
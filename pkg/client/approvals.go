@@ -0,0 +1,129 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Approval states of a PublishApproval.
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+)
+
+// PublishApproval is a request to publish a build to production that waits
+// for someone to approve it. The server publishes the build when it is
+// approved.
+type PublishApproval struct {
+	ApprovalID  string     `json:"approval_id"`
+	ProjectID   string     `json:"project_id"`
+	BuildID     string     `json:"build_id"`
+	Status      string     `json:"status"`
+	RequestedBy string     `json:"requested_by,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	// DecidedBy, DecidedAt and Comment are set once the request is approved
+	// or rejected; Comment holds the approval comment or rejection reason.
+	DecidedBy string     `json:"decided_by,omitempty"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+	Comment   string     `json:"comment,omitempty"`
+	// ProductionURL is set once the approved build is published.
+	ProductionURL string `json:"production_url,omitempty"`
+	// URL is the web page where the request can be reviewed and decided.
+	URL string `json:"url,omitempty"`
+}
+
+// RequestPublishApproval creates a pending request to publish a build.
+// Servers without approvals return an error matching IsNotFound.
+func (c *Client) RequestPublishApproval(projectID, buildID string) (*PublishApproval, error) {
+	body, err := json.Marshal(map[string]string{"build_id": buildID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return c.approvalRequest("POST", fmt.Sprintf("/api/projects/%s/approvals", projectID), body)
+}
+
+// ListApprovals lists the publish requests of a project, newest first, only
+// those in status when it is not empty.
+func (c *Client) ListApprovals(projectID, status string) ([]*PublishApproval, error) {
+	path := fmt.Sprintf("/api/projects/%s/approvals", projectID)
+	if status != "" {
+		path += "?" + url.Values{"status": {status}}.Encode()
+	}
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("%w: approvals", ErrUnsupported)
+	default:
+		return nil, c.parseError(resp)
+	}
+
+	var result struct {
+		Approvals []*PublishApproval `json:"approvals"`
+	}
+	if err := decodeResponse(resp.Body, &result); err != nil {
+		return nil, err
+	}
+	return result.Approvals, nil
+}
+
+// GetApproval returns a publish request.
+func (c *Client) GetApproval(approvalID string) (*PublishApproval, error) {
+	return c.approvalRequest("GET", "/api/approvals/"+url.PathEscape(approvalID), nil)
+}
+
+// ApprovePublish approves a pending publish request, which publishes its
+// build, and returns the decided request.
+func (c *Client) ApprovePublish(approvalID, comment string) (*PublishApproval, error) {
+	body, err := json.Marshal(map[string]string{"comment": comment})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return c.approvalRequest("POST", fmt.Sprintf("/api/approvals/%s/approve", url.PathEscape(approvalID)), body)
+}
+
+// RejectPublish rejects a pending publish request.
+func (c *Client) RejectPublish(approvalID, reason string) (*PublishApproval, error) {
+	body, err := json.Marshal(map[string]string{"comment": reason})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return c.approvalRequest("POST", fmt.Sprintf("/api/approvals/%s/reject", url.PathEscape(approvalID)), body)
+}
+
+func (c *Client) approvalRequest(method, path string, body []byte) (*PublishApproval, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	resp, err := c.doRequest(method, path, reader)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("%w: approvals", ErrUnsupported)
+	default:
+		return nil, c.parseError(resp)
+	}
+
+	var approval PublishApproval
+	if err := decodeResponse(resp.Body, &approval); err != nil {
+		return nil, err
+	}
+	return &approval, nil
+}
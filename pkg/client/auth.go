@@ -0,0 +1,53 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ServiceCredentials are short-lived credentials issued for a service token.
+type ServiceCredentials struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at,omitempty"`
+	// ExpiresIn is the lifetime in seconds, for servers that send it instead
+	// of expires_at.
+	ExpiresIn int `json:"expires_in,omitempty"`
+}
+
+// ExchangeServiceToken trades the client's key, a service-account token, for
+// short-lived API credentials.
+func (c *Client) ExchangeServiceToken() (*ServiceCredentials, error) {
+	body, err := json.Marshal(map[string]string{"grant_type": "service_token"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	resp, err := c.doRequest("POST", "/api/auth/token/exchange", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: service token exchange", ErrUnsupported)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, c.parseError(resp)
+	}
+
+	var creds ServiceCredentials
+	if err := decodeResponse(resp.Body, &creds); err != nil {
+		return nil, err
+	}
+	creds.AccessToken = strings.TrimSpace(creds.AccessToken)
+	if creds.AccessToken == "" {
+		return nil, fmt.Errorf("token exchange response missing access_token")
+	}
+	if creds.ExpiresAt.IsZero() && creds.ExpiresIn > 0 {
+		creds.ExpiresAt = time.Now().Add(time.Duration(creds.ExpiresIn) * time.Second)
+	}
+	return &creds, nil
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Build event types. Servers may send others, which are kept with their
+// message and data.
+const (
+	BuildEventStepStarted   = "step_started"
+	BuildEventStepFinished  = "step_finished"
+	BuildEventCacheHit      = "cache_hit"
+	BuildEventCacheMiss     = "cache_miss"
+	BuildEventArtifact      = "artifact"
+	BuildEventBuildFinished = "build_finished"
+)
+
+// BuildEvent is a structured event emitted by the server while it runs a
+// build, such as a step starting or the size of the produced artifact.
+type BuildEvent struct {
+	// Seq orders the events of a build; it is passed back to fetch the
+	// events after it.
+	Seq  int64     `json:"seq"`
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	// Step is the build step the event belongs to, e.g. install or build.
+	Step string `json:"step,omitempty"`
+	// Status is the outcome of a finished step or build.
+	Status     string `json:"status,omitempty"`
+	DurationMS int64  `json:"duration_ms,omitempty"`
+	CacheKey   string `json:"cache_key,omitempty"`
+	// Name and SizeBytes describe an artifact event.
+	Name      string `json:"name,omitempty"`
+	SizeBytes int64  `json:"size_bytes,omitempty"`
+	Message   string `json:"message,omitempty"`
+	// Data holds any further fields of the event as sent by the server.
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// Final reports whether e is the last event of its build.
+func (e *BuildEvent) Final() bool {
+	return e.Type == BuildEventBuildFinished
+}
+
+// ListBuildEvents returns the events of a build with a sequence number
+// greater than after, oldest first. Servers without the endpoint return an
+// error matching IsNotFound.
+func (c *Client) ListBuildEvents(projectID, buildID string, after int64) ([]*BuildEvent, error) {
+	path := fmt.Sprintf("/api/projects/%s/builds/%s/events", projectID, buildID)
+	if after > 0 {
+		path = fmt.Sprintf("%s?after=%d", path, after)
+	}
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("%w: build events", ErrUnsupported)
+	default:
+		return nil, c.parseError(resp)
+	}
+
+	var result struct {
+		Events []*BuildEvent `json:"events"`
+	}
+	if err := decodeResponse(resp.Body, &result); err != nil {
+		return nil, err
+	}
+	return result.Events, nil
+}
@@ -0,0 +1,32 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// SetBuildMetadata stores value as the metadata entry key of a build,
+// replacing any it had. A 404 means the build does not exist; servers without
+// build metadata return ErrUnsupported for 405.
+func (c *Client) SetBuildMetadata(projectID, buildID, key string, value interface{}) error {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	resp, err := c.doRequest("PUT", fmt.Sprintf("/api/projects/%s/builds/%s/metadata/%s", projectID, buildID, url.PathEscape(key)), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusMethodNotAllowed:
+		return fmt.Errorf("%w: build metadata", ErrUnsupported)
+	}
+	return c.parseError(resp)
+}
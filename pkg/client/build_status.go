@@ -0,0 +1,97 @@
+package client
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Normalized build statuses. Build.Status always holds one of these; the
+// status reported by the server is kept in Build.RawStatus when it differs.
+const (
+	BuildQueued    = "queued"
+	BuildRunning   = "running"
+	BuildSuccess   = "success"
+	BuildFailed    = "failed"
+	BuildCancelled = "cancelled"
+	BuildTimedOut  = "timed_out"
+	BuildSkipped   = "skipped"
+	BuildUnknown   = "unknown"
+)
+
+// buildStatusAliases maps the statuses servers report to normalized ones.
+var buildStatusAliases = map[string]string{
+	"queued":      BuildQueued,
+	"pending":     BuildQueued,
+	"waiting":     BuildQueued,
+	"created":     BuildQueued,
+	"scheduled":   BuildQueued,
+	"running":     BuildRunning,
+	"building":    BuildRunning,
+	"in_progress": BuildRunning,
+	"started":     BuildRunning,
+	"processing":  BuildRunning,
+	"success":     BuildSuccess,
+	"succeeded":   BuildSuccess,
+	"successful":  BuildSuccess,
+	"completed":   BuildSuccess,
+	"failed":      BuildFailed,
+	"failure":     BuildFailed,
+	"error":       BuildFailed,
+	"errored":     BuildFailed,
+	"cancelled":   BuildCancelled,
+	"canceled":    BuildCancelled,
+	"aborted":     BuildCancelled,
+	"timed_out":   BuildTimedOut,
+	"timeout":     BuildTimedOut,
+	"timedout":    BuildTimedOut,
+	"skipped":     BuildSkipped,
+	"unknown":     BuildUnknown,
+}
+
+// NormalizeBuildStatus maps a server build status to one of the Build*
+// constants; unrecognized statuses become BuildUnknown.
+func NormalizeBuildStatus(status string) string {
+	key := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(status)), "-", "_")
+	if normalized, ok := buildStatusAliases[key]; ok {
+		return normalized
+	}
+	return BuildUnknown
+}
+
+// BuildStatusTerminal reports whether a normalized status is final. Unknown
+// statuses are not, so waiting continues until the status is recognized or
+// the wait times out.
+func BuildStatusTerminal(status string) bool {
+	switch status {
+	case BuildSuccess, BuildFailed, BuildCancelled, BuildTimedOut, BuildSkipped:
+		return true
+	}
+	return false
+}
+
+// StatusText is the normalized status followed by the server's own status
+// when it differs, e.g. "cancelled (aborted)".
+func (b *Build) StatusText() string {
+	if b.RawStatus != "" {
+		return b.Status + " (" + b.RawStatus + ")"
+	}
+	return b.Status
+}
+
+// UnmarshalJSON normalizes the status of a decoded build.
+func (b *Build) UnmarshalJSON(data []byte) error {
+	type plain Build
+	if err := json.Unmarshal(data, (*plain)(b)); err != nil {
+		return err
+	}
+	if b.Status == "" {
+		return nil
+	}
+	if normalized := NormalizeBuildStatus(b.Status); normalized != b.Status {
+		if b.RawStatus == "" {
+			b.RawStatus = b.Status
+		}
+		b.Status = normalized
+	}
+	return nil
+}
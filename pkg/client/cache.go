@@ -0,0 +1,206 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ResponseCache keeps successful GET responses on disk so read-only commands
+// run repeatedly, e.g. by a polling agent, do not hit the API every time.
+// Entries younger than the TTL are served without a request; older ones are
+// revalidated with If-None-Match when the server sent an ETag.
+type ResponseCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// NewResponseCache returns a cache storing entries under dir.
+func NewResponseCache(dir string, ttl time.Duration) *ResponseCache {
+	return &ResponseCache{dir: dir, ttl: ttl}
+}
+
+type cacheEntry struct {
+	StoredAt    time.Time `json:"stored_at"`
+	ETag        string    `json:"etag,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	Body        []byte    `json:"body"`
+}
+
+// Clear removes the cached responses of a server.
+func (rc *ResponseCache) Clear(baseURL string) error {
+	return os.RemoveAll(rc.scopeDir(baseURL))
+}
+
+// scopeDir groups the entries of one server so writes can clear them.
+func (rc *ResponseCache) scopeDir(baseURL string) string {
+	sum := sha256.Sum256([]byte(strings.TrimRight(baseURL, "/")))
+	return filepath.Join(rc.dir, hex.EncodeToString(sum[:8]))
+}
+
+// entryPath keys entries by API key as well, so accounts never share data.
+func (rc *ResponseCache) entryPath(baseURL, apiKey, path string) string {
+	sum := sha256.Sum256([]byte(apiKey + "\n" + path))
+	return filepath.Join(rc.scopeDir(baseURL), hex.EncodeToString(sum[:16])+".json")
+}
+
+func (rc *ResponseCache) load(file string) *cacheEntry {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// store writes an entry atomically; failures only cost a future cache miss.
+func (rc *ResponseCache) store(file string, entry *cacheEntry) {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".entry-*")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.Write(raw)
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil || os.Rename(tmp.Name(), file) != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	header := http.Header{}
+	if e.ContentType != "" {
+		header.Set("Content-Type", e.ContentType)
+	}
+	if e.ETag != "" {
+		header.Set("ETag", e.ETag)
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// SetResponseCache attaches a response cache. With reads the client serves GET
+// requests from it; either way it clears the server's entries after requests
+// that change data, so cached readers in later runs do not see stale state.
+func (c *Client) SetResponseCache(cache *ResponseCache, reads bool) {
+	c.cache = cache
+	c.cacheReads = reads
+}
+
+// invalidateCache drops cached responses after a write.
+func (c *Client) invalidateCache() {
+	if c.cache != nil {
+		_ = c.cache.Clear(c.baseURL)
+	}
+}
+
+func (c *Client) doCachedGet(path string) (*http.Response, error) {
+	file := c.cache.entryPath(c.baseURL, c.apiKey, path)
+	entry := c.cache.load(file)
+	if entry != nil && time.Since(entry.StoredAt) < c.cache.ttl {
+		resp := entry.response(nil)
+		c.observe(RequestInfo{Method: http.MethodGet, URL: c.baseURL + path, Endpoint: c.baseURL, Status: resp.StatusCode, Cached: true})
+		return resp, nil
+	}
+
+	header := http.Header{}
+	if entry != nil && entry.ETag != "" {
+		header.Set("If-None-Match", entry.ETag)
+	}
+	resp, err := c.send(http.MethodGet, path, nil, header)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotModified && entry != nil:
+		resp.Body.Close()
+		entry.StoredAt = time.Now()
+		c.cache.store(file, entry)
+		return entry.response(resp.Request), nil
+	case resp.StatusCode == http.StatusOK && !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"):
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		c.cache.store(file, &cacheEntry{
+			StoredAt:    time.Now(),
+			ETag:        resp.Header.Get("ETag"),
+			ContentType: resp.Header.Get("Content-Type"),
+			Body:        body,
+		})
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return resp, nil
+}
+
+// doConditionalGet is doRequest for GETs that status watchers repeat: it
+// remembers each response's ETag and, when the server answers If-None-Match
+// with 304, returns the remembered body.
+func (c *Client) doConditionalGet(path string) (*http.Response, error) {
+	if c.cache != nil && c.cacheReads {
+		return c.doCachedGet(path)
+	}
+
+	c.etagMu.Lock()
+	entry := c.etags[path]
+	c.etagMu.Unlock()
+	header := http.Header{}
+	if entry != nil {
+		header.Set("If-None-Match", entry.ETag)
+	}
+	resp, err := c.send(http.MethodGet, path, nil, header)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotModified && entry != nil:
+		resp.Body.Close()
+		return entry.response(resp.Request), nil
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		c.etagMu.Lock()
+		if c.etags == nil {
+			c.etags = map[string]*cacheEntry{}
+		}
+		c.etags[path] = &cacheEntry{
+			StoredAt:    time.Now(),
+			ETag:        resp.Header.Get("ETag"),
+			ContentType: resp.Header.Get("Content-Type"),
+			Body:        body,
+		}
+		c.etagMu.Unlock()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return resp, nil
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Well-known capability names advertised by RobotX servers.
+const (
+	CapabilityLocalBuildArtifacts = "local_build_artifacts"
+	CapabilitySSELogs             = "sse_logs"
+	CapabilityChannels            = "channels"
+	CapabilitySourceDigest        = "source_digest"
+	CapabilityDeleteBuilds        = "delete_builds"
+	CapabilityPinBuilds           = "pin_builds"
+	CapabilityStagedPublish       = "staged_publish"
+	CapabilityShareLinks          = "share_links"
+	CapabilityPreviewProtection   = "preview_protection"
+	CapabilityRoutes              = "routes"
+	CapabilityFunctions           = "functions"
+	CapabilityScheduledJobs       = "scheduled_jobs"
+	CapabilityFeatureFlags        = "feature_flags"
+	CapabilityAnalytics           = "analytics"
+	CapabilityMaintenanceMode     = "maintenance_mode"
+	CapabilityProjectEnv          = "project_env"
+	CapabilityCopyBuilds          = "copy_builds"
+	CapabilityArtifactFiles       = "artifact_files"
+	CapabilityCachePurge          = "cache_purge"
+	CapabilityLogSearch           = "log_search"
+	CapabilityBuildEvents         = "build_events"
+	CapabilityDeployLocks         = "deploy_locks"
+	CapabilityChannelEnv          = "channel_env"
+	CapabilityReleaseNotes        = "release_notes"
+	CapabilityPublishApprovals    = "publish_approvals"
+	CapabilityBuildMetadata       = "build_metadata"
+)
+
+// Capabilities lists optional features supported by a server.
+type Capabilities struct {
+	APIVersion string   `json:"api_version,omitempty"`
+	Features   []string `json:"features"`
+	// Known is false when the server does not advertise capabilities at all;
+	// callers should then assume features exist and handle 404s instead.
+	Known bool `json:"known"`
+}
+
+// Supports reports whether feature is available. Unknown capability sets
+// report true so older servers keep working through endpoint fallbacks.
+func (c *Capabilities) Supports(feature string) bool {
+	if c == nil || !c.Known {
+		return true
+	}
+	for _, f := range c.Features {
+		if strings.EqualFold(f, feature) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	capabilitiesMu    sync.Mutex
+	capabilitiesCache = map[string]*Capabilities{}
+)
+
+// GetCapabilities returns the server's advertised capabilities, cached per base URL.
+func (c *Client) GetCapabilities() (*Capabilities, error) {
+	key := strings.TrimRight(c.baseURL, "/")
+	capabilitiesMu.Lock()
+	cached, ok := capabilitiesCache[key]
+	capabilitiesMu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	caps, err := c.fetchCapabilities()
+	if err != nil {
+		return nil, err
+	}
+	capabilitiesMu.Lock()
+	capabilitiesCache[key] = caps
+	capabilitiesMu.Unlock()
+	return caps, nil
+}
+
+func (c *Client) fetchCapabilities() (*Capabilities, error) {
+	resp, err := c.doRequest("GET", "/api/capabilities", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// Older servers only expose feature flags through the health endpoint.
+		health, err := c.Health()
+		if err != nil || len(health.Features) == 0 {
+			return &Capabilities{Known: false}, nil
+		}
+		return &Capabilities{Features: health.Features, Known: true}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	rawBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(unwrapData(rawBody), &payload); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	caps := &Capabilities{Known: true}
+	if v, ok := payload["api_version"].(string); ok {
+		caps.APIVersion = strings.TrimSpace(v)
+	}
+	for _, key := range []string{"features", "capabilities"} {
+		if raw, ok := payload[key]; ok {
+			caps.Features = featureNames(raw)
+			break
+		}
+	}
+	return caps, nil
+}
+
+// SetCapabilities seeds the capability cache, e.g. from a persisted copy.
+func SetCapabilities(baseURL string, caps *Capabilities) {
+	if caps == nil {
+		return
+	}
+	capabilitiesMu.Lock()
+	capabilitiesCache[strings.TrimRight(baseURL, "/")] = caps
+	capabilitiesMu.Unlock()
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Env channels: the runtime environment of production and of preview
+// builds.
+const (
+	EnvChannelProduction = "production"
+	EnvChannelPreview    = "preview"
+)
+
+// GetChannelEnv returns the runtime environment variables of a channel of a
+// project. Servers without channel environments return an error matching
+// IsNotFound.
+func (c *Client) GetChannelEnv(projectID, channel string) (map[string]string, error) {
+	resp, err := c.doRequest("GET", fmt.Sprintf("/api/projects/%s/env?%s", projectID, url.Values{"channel": {channel}}.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("%w: channel env", ErrUnsupported)
+	default:
+		return nil, c.parseError(resp)
+	}
+
+	var result struct {
+		Env map[string]string `json:"env"`
+	}
+	if err := decodeResponse(resp.Body, &result); err != nil {
+		return nil, err
+	}
+	if result.Env == nil {
+		result.Env = map[string]string{}
+	}
+	return result.Env, nil
+}
+
+// SetChannelEnv creates or updates runtime environment variables of a
+// channel of a project; variables not in env are kept.
+func (c *Client) SetChannelEnv(projectID, channel string, env map[string]string) error {
+	body, err := json.Marshal(map[string]interface{}{"env": env})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	resp, err := c.doRequest("PATCH", fmt.Sprintf("/api/projects/%s/env?%s", projectID, url.Values{"channel": {channel}}.Encode()), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return fmt.Errorf("%w: channel env", ErrUnsupported)
+	default:
+		return c.parseError(resp)
+	}
+}
+
+// DeleteChannelEnv removes a runtime environment variable of a channel of a
+// project. Removing a variable that is not set succeeds.
+func (c *Client) DeleteChannelEnv(projectID, channel, key string) error {
+	resp, err := c.doRequest("DELETE", fmt.Sprintf("/api/projects/%s/env/%s?%s", projectID, url.PathEscape(key), url.Values{"channel": {channel}}.Encode()), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	case http.StatusMethodNotAllowed:
+		return fmt.Errorf("%w: channel env", ErrUnsupported)
+	}
+	return c.parseError(resp)
+}
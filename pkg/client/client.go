@@ -2,31 +2,85 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Client struct {
-	baseURL    string
-	apiKey     string
-	httpClient *http.Client
+	baseURL      string
+	fallbackURLs []string
+	preferred    atomic.Int32
+	apiKey       string
+	httpClient   *http.Client
+	observer     func(RequestInfo)
+	progress     func(sent, total int64)
+	cache        *ResponseCache
+	cacheReads   bool
+	etagMu       sync.Mutex
+	etags        map[string]*cacheEntry
+}
+
+// RequestInfo describes a single HTTP exchange performed by the client.
+type RequestInfo struct {
+	Method   string
+	URL      string
+	Endpoint string
+	Status   int
+	Duration time.Duration
+	Err      error
+	// Failover is true when the request went to a fallback endpoint rather
+	// than the base URL.
+	Failover bool
+	// Cached is true when the response came from the response cache.
+	Cached bool
 }
 
 func NewClient(baseURL, apiKey string) *Client {
-	return &Client{
+	c := &Client{
 		baseURL: baseURL,
 		apiKey:  apiKey,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 	}
+	c.versionTransport()
+	return c
+}
+
+// SetFallbackBaseURLs configures secondary endpoints used when the primary
+// base URL is unreachable. Only read (GET) requests fail over.
+func (c *Client) SetFallbackBaseURLs(urls []string) {
+	c.fallbackURLs = nil
+	for _, u := range urls {
+		u = strings.TrimRight(strings.TrimSpace(u), "/")
+		if u == "" || u == strings.TrimRight(c.baseURL, "/") {
+			continue
+		}
+		c.fallbackURLs = append(c.fallbackURLs, u)
+	}
+	c.preferred.Store(0)
+}
+
+// SetUploadProgress registers a callback invoked as upload bodies are sent.
+func (c *Client) SetUploadProgress(fn func(sent, total int64)) {
+	c.progress = fn
+}
+
+// SetObserver registers a callback invoked after every HTTP request.
+func (c *Client) SetObserver(fn func(RequestInfo)) {
+	c.observer = fn
 }
 
 // Project represents a RobotX project
@@ -37,8 +91,26 @@ type Project struct {
 	PreviewURL  string              `json:"preview_url,omitempty"`
 	PublishURL  string              `json:"publish_url,omitempty"`
 	RuntimeRefs *ProjectRuntimeRefs `json:"runtime_refs,omitempty"`
-	CreatedAt   time.Time           `json:"created_at"`
-	UpdatedAt   time.Time           `json:"updated_at"`
+	// PreviewProtection is nil when the server does not report it.
+	PreviewProtection *PreviewProtection `json:"preview_protection,omitempty"`
+	// Maintenance is nil when the server does not report it.
+	Maintenance *MaintenanceMode `json:"maintenance,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// MaintenanceMode describes the maintenance page served on a project's
+// production URL instead of the published build.
+type MaintenanceMode struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message,omitempty"`
+}
+
+// PreviewProtection describes the password that guards a project's preview
+// URLs. With a username it is HTTP basic auth, otherwise a password page.
+type PreviewProtection struct {
+	Enabled  bool   `json:"enabled"`
+	Username string `json:"username,omitempty"`
 }
 
 type RuntimeRefVersion struct {
@@ -56,6 +128,8 @@ type RuntimeRefVersion struct {
 type ProjectRuntimeRefs struct {
 	Preview *RuntimeRefVersion `json:"preview,omitempty"`
 	Publish *RuntimeRefVersion `json:"publish,omitempty"`
+	// Staging is the build staged for a blue/green publish, if any.
+	Staging *RuntimeRefVersion `json:"staging,omitempty"`
 }
 
 // BuildPlan describes detected build instructions from server-side scanning.
@@ -81,9 +155,24 @@ type ScannerResult struct {
 type SourceCommit struct {
 	CommitID      string         `json:"commit_id"`
 	ProjectID     string         `json:"project_id"`
+	Digest        string         `json:"digest,omitempty"`
 	ScannerResult *ScannerResult `json:"scanner_result,omitempty"`
+	// Deduplicated is set when the server answered a repeated upload with
+	// the commit and build created by an earlier request with the same
+	// idempotency key.
+	Deduplicated bool `json:"deduplicated,omitempty"`
 }
 
+const (
+	// IdempotencyKeyHeader carries a key identifying an upload, so the
+	// server answers a retried or concurrent repeat with the commit and
+	// build it already created instead of creating new ones.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader marks a response replayed for a repeated
+	// idempotency key.
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
 type BuildVersionInput struct {
 	VersionLabel string `json:"version_label,omitempty"`
 	SourceRef    string `json:"source_ref,omitempty"`
@@ -91,16 +180,20 @@ type BuildVersionInput struct {
 
 // Build represents a build task
 type Build struct {
-	BuildID           string     `json:"build_id"`
-	ProjectID         string     `json:"project_id"`
-	CommitID          string     `json:"commit_id"`
-	VersionSeq        int64      `json:"version_seq,omitempty"`
-	VersionLabel      string     `json:"version_label,omitempty"`
-	SourceRef         string     `json:"source_ref,omitempty"`
-	Status            string     `json:"status"`
+	BuildID      string `json:"build_id"`
+	ProjectID    string `json:"project_id"`
+	CommitID     string `json:"commit_id"`
+	VersionSeq   int64  `json:"version_seq,omitempty"`
+	VersionLabel string `json:"version_label,omitempty"`
+	SourceRef    string `json:"source_ref,omitempty"`
+	Status       string `json:"status"`
+	// RawStatus is the status reported by the server when it differs from
+	// the normalized Status, e.g. "canceled" or an unrecognized value.
+	RawStatus         string     `json:"raw_status,omitempty"`
 	RuntimeArtifactID string     `json:"runtime_artifact_id,omitempty"`
 	ErrorMsg          string     `json:"error_msg,omitempty"`
 	PreviewPath       string     `json:"preview_path,omitempty"`
+	Pinned            bool       `json:"pinned,omitempty"`
 	CreatedAt         time.Time  `json:"created_at"`
 	FinishedAt        *time.Time `json:"finished_at,omitempty"`
 }
@@ -129,16 +222,69 @@ func (c *Client) CreateProject(req CreateProjectRequest) (*Project, error) {
 	}
 
 	var project Project
-	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := decodeResponse(resp.Body, &project); err != nil {
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+// UpdateProjectRequest changes project settings; empty fields are left
+// unchanged.
+type UpdateProjectRequest struct {
+	Name       string `json:"name,omitempty"`
+	Visibility string `json:"visibility,omitempty"`
+}
+
+// UpdateProject renames a project or changes its visibility.
+func (c *Client) UpdateProject(projectID string, req UpdateProjectRequest) (*Project, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
+	resp, err := c.doRequest("PATCH", fmt.Sprintf("/api/projects/%s", projectID), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		return &Project{ProjectID: projectID, Name: req.Name, Visibility: req.Visibility}, nil
+	case http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("%w: update project", ErrUnsupported)
+	default:
+		return nil, c.parseError(resp)
+	}
+
+	var project Project
+	if err := decodeResponse(resp.Body, &project); err != nil {
+		return nil, err
+	}
 	return &project, nil
 }
 
+// DeleteProject deletes a project with its builds.
+func (c *Client) DeleteProject(projectID string) error {
+	resp, err := c.doRequest("DELETE", fmt.Sprintf("/api/projects/%s", projectID), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusMethodNotAllowed:
+		return fmt.Errorf("%w: delete project", ErrUnsupported)
+	}
+	return c.parseError(resp)
+}
+
 // GetProject retrieves project information
 func (c *Client) GetProject(projectID string) (*Project, error) {
-	resp, err := c.doRequest("GET", fmt.Sprintf("/api/projects/%s", projectID), nil)
+	resp, err := c.doConditionalGet(fmt.Sprintf("/api/projects/%s", projectID))
 	if err != nil {
 		return nil, err
 	}
@@ -149,8 +295,8 @@ func (c *Client) GetProject(projectID string) (*Project, error) {
 	}
 
 	var project Project
-	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := decodeResponse(resp.Body, &project); err != nil {
+		return nil, err
 	}
 
 	return &project, nil
@@ -162,7 +308,7 @@ func (c *Client) ListProjects(limit int) ([]*Project, error) {
 	if limit > 0 {
 		path = fmt.Sprintf("%s?limit=%d", path, limit)
 	}
-	resp, err := c.doRequest("GET", path, nil)
+	resp, err := c.doConditionalGet(path)
 	if err != nil {
 		return nil, err
 	}
@@ -235,8 +381,12 @@ func extractProjectsFromJSON(raw []byte) ([]*Project, bool, error) {
 	return nil, false, nil
 }
 
-// UploadSource uploads source code and creates a commit/build.
-func (c *Client) UploadSource(projectID, sourcePath string, version *BuildVersionInput) (*SourceCommit, *Build, error) {
+// UploadSource uploads source code and creates a commit/build. digest, when
+// set, is recorded on the commit so later deploys of identical source can
+// reuse its build. idempotencyKey, when set, is sent as the Idempotency-Key
+// header; the returned commit is marked Deduplicated when the server
+// answered with the commit of an earlier upload with the same key.
+func (c *Client) UploadSource(projectID, sourcePath, digest, idempotencyKey string, version *BuildVersionInput, routes []byte) (*SourceCommit, *Build, error) {
 	// Create multipart form
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
@@ -256,32 +406,99 @@ func (c *Client) UploadSource(projectID, sourcePath string, version *BuildVersio
 	if _, err := io.Copy(part, file); err != nil {
 		return nil, nil, fmt.Errorf("failed to copy file: %w", err)
 	}
-	if version != nil {
-		if versionLabel := strings.TrimSpace(version.VersionLabel); versionLabel != "" {
-			if err := writer.WriteField("version_label", versionLabel); err != nil {
-				return nil, nil, fmt.Errorf("failed to write version_label: %w", err)
-			}
-		}
-		if sourceRef := strings.TrimSpace(version.SourceRef); sourceRef != "" {
-			if err := writer.WriteField("source_ref", sourceRef); err != nil {
-				return nil, nil, fmt.Errorf("failed to write source_ref: %w", err)
-			}
+	if digest != "" {
+		if err := writer.WriteField("digest", digest); err != nil {
+			return nil, nil, fmt.Errorf("failed to write digest: %w", err)
 		}
 	}
+	if err := writeCommitFields(writer, version, routes); err != nil {
+		return nil, nil, err
+	}
 
 	if err := writer.Close(); err != nil {
 		return nil, nil, fmt.Errorf("failed to close writer: %w", err)
 	}
 
 	// Create request
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/projects/%s/commits", c.baseURL, projectID), body)
+	req, err := c.newUploadRequest(fmt.Sprintf("%s/api/projects/%s/commits", c.baseURL, projectID), body)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	if idempotencyKey != "" {
+		req.Header.Set(IdempotencyKeyHeader, idempotencyKey)
+	}
+	return c.sendCommit(projectID, req)
+}
+
+// UploadSourceStream uploads a source archive read from r, e.g. stdin, without
+// buffering it: the multipart body is streamed as r is read. format is the
+// archive format ("zip" or "tar.gz"). No digest is sent, so the server
+// cannot deduplicate the commit.
+func (c *Client) UploadSourceStream(projectID string, r io.Reader, format string, version *BuildVersionInput, routes []byte) (*SourceCommit, *Build, error) {
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeSourceStream(writer, r, format, version, routes))
+	}()
+
+	req, err := c.newStreamUploadRequest(fmt.Sprintf("%s/api/projects/%s/commits", c.baseURL, projectID), pr)
+	if err != nil {
+		pr.Close()
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	return c.sendCommit(projectID, req)
+}
+
+// writeSourceStream writes the commit fields before the archive, so the
+// server can read them without buffering the file.
+func writeSourceStream(writer *multipart.Writer, r io.Reader, format string, version *BuildVersionInput, routes []byte) error {
+	if err := writer.WriteField("archive_format", format); err != nil {
+		return fmt.Errorf("failed to write archive_format: %w", err)
+	}
+	if err := writeCommitFields(writer, version, routes); err != nil {
+		return err
+	}
+	part, err := writer.CreateFormFile("file", "source."+format)
+	if err != nil {
+		return fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return fmt.Errorf("failed to read source archive: %w", err)
+	}
+	return writer.Close()
+}
+
+// writeCommitFields writes the optional version and routes fields of a
+// source commit.
+func writeCommitFields(writer *multipart.Writer, version *BuildVersionInput, routes []byte) error {
+	if version != nil {
+		if versionLabel := strings.TrimSpace(version.VersionLabel); versionLabel != "" {
+			if err := writer.WriteField("version_label", versionLabel); err != nil {
+				return fmt.Errorf("failed to write version_label: %w", err)
+			}
+		}
+		if sourceRef := strings.TrimSpace(version.SourceRef); sourceRef != "" {
+			if err := writer.WriteField("source_ref", sourceRef); err != nil {
+				return fmt.Errorf("failed to write source_ref: %w", err)
+			}
+		}
+	}
+	if len(routes) > 0 {
+		if err := writer.WriteField("routes", string(routes)); err != nil {
+			return fmt.Errorf("failed to write routes: %w", err)
+		}
+	}
+	return nil
+}
 
+// sendCommit sends a source upload request and decodes the created commit
+// and build.
+func (c *Client) sendCommit(projectID string, req *http.Request) (*SourceCommit, *Build, error) {
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to upload source: %w", err)
@@ -305,62 +522,63 @@ func (c *Client) UploadSource(projectID, sourcePath string, version *BuildVersio
 		Commit   *SourceCommit `json:"commit"`
 		Build    *Build        `json:"build"`
 		CommitID string        `json:"commit_id"`
+		// Some APIs return a top-level build_id without build object.
+		BuildID string `json:"build_id"`
 	}
-	if len(rawBody) > 0 {
-		if err := json.Unmarshal(rawBody, &result); err != nil {
-			// Try common wrapped payload structure: {"data": {...}}
-			var wrapped struct {
-				Data struct {
-					Commit   *SourceCommit `json:"commit"`
-					Build    *Build        `json:"build"`
-					CommitID string        `json:"commit_id"`
-				} `json:"data"`
-			}
-			if err2 := json.Unmarshal(rawBody, &wrapped); err2 == nil {
-				result.Commit = wrapped.Data.Commit
-				result.Build = wrapped.Data.Build
-				result.CommitID = wrapped.Data.CommitID
-			} else {
-				return nil, nil, fmt.Errorf("failed to decode response: %w", err)
-			}
+	if len(bytes.TrimSpace(rawBody)) > 0 {
+		if err := json.Unmarshal(unwrapData(rawBody), &result); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode response: %w", err)
 		}
 	}
 
 	if result.Commit == nil && result.CommitID != "" {
 		result.Commit = &SourceCommit{CommitID: result.CommitID, ProjectID: projectID}
 	}
+	if buildID := strings.TrimSpace(result.BuildID); result.Build == nil && buildID != "" {
+		result.Build = &Build{BuildID: buildID, ProjectID: projectID}
+	}
+	if result.Commit != nil && strings.EqualFold(resp.Header.Get(idempotentReplayedHeader), "true") {
+		result.Commit.Deduplicated = true
+	}
 
-	// Some APIs return a top-level build_id without build object.
-	if result.Build == nil && len(rawBody) > 0 {
-		var fallback struct {
-			BuildID string `json:"build_id"`
-			Data    struct {
-				BuildID string `json:"build_id"`
-			} `json:"data"`
-		}
-		if err := json.Unmarshal(rawBody, &fallback); err == nil {
-			buildID := strings.TrimSpace(fallback.BuildID)
-			if buildID == "" {
-				buildID = strings.TrimSpace(fallback.Data.BuildID)
-			}
-			if buildID != "" {
-				result.Build = &Build{BuildID: buildID, ProjectID: projectID}
-			}
-		}
+	return result.Commit, result.Build, nil
+}
+
+// HeadCommit returns the latest source commit of a project together with its
+// build. Servers without the endpoint return an error matching IsNotFound.
+func (c *Client) HeadCommit(projectID string) (*SourceCommit, *Build, error) {
+	resp, err := c.doRequest("GET", fmt.Sprintf("/api/projects/%s/commits/head", projectID), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil, fmt.Errorf("%w: commits/head", ErrUnsupported)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, c.parseError(resp)
 	}
 
+	var result struct {
+		Commit *SourceCommit `json:"commit"`
+		Build  *Build        `json:"build"`
+	}
+	if err := decodeResponse(resp.Body, &result); err != nil {
+		return nil, nil, err
+	}
 	return result.Commit, result.Build, nil
 }
 
 // GetBuild retrieves build information.
 func (c *Client) GetBuild(projectID, buildID string) (*Build, error) {
-	resp, err := c.doRequest("GET", fmt.Sprintf("/api/builds/%s", buildID), nil)
+	resp, err := c.doConditionalGet(fmt.Sprintf("/api/builds/%s", buildID))
 	if err != nil {
 		return nil, err
 	}
 	if resp.StatusCode == http.StatusNotFound && projectID != "" {
 		resp.Body.Close()
-		resp, err = c.doRequest("GET", fmt.Sprintf("/api/projects/%s/builds/%s", projectID, buildID), nil)
+		resp, err = c.doConditionalGet(fmt.Sprintf("/api/projects/%s/builds/%s", projectID, buildID))
 		if err != nil {
 			return nil, err
 		}
@@ -372,8 +590,8 @@ func (c *Client) GetBuild(projectID, buildID string) (*Build, error) {
 	}
 
 	var build Build
-	if err := json.NewDecoder(resp.Body).Decode(&build); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := decodeResponse(resp.Body, &build); err != nil {
+		return nil, err
 	}
 
 	return &build, nil
@@ -385,7 +603,7 @@ func (c *Client) ListBuildsForProject(projectID string, limit int) ([]*Build, er
 	if limit > 0 {
 		path = fmt.Sprintf("%s?limit=%d", path, limit)
 	}
-	resp, err := c.doRequest("GET", path, nil)
+	resp, err := c.doConditionalGet(path)
 	if err != nil {
 		return nil, err
 	}
@@ -396,12 +614,176 @@ func (c *Client) ListBuildsForProject(projectID string, limit int) ([]*Build, er
 	}
 
 	var builds []*Build
-	if err := json.NewDecoder(resp.Body).Decode(&builds); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := decodeResponse(resp.Body, &builds); err != nil {
+		return nil, err
 	}
 	return builds, nil
 }
 
+// BuildPage is one page of a project's builds, newest first.
+type BuildPage struct {
+	Builds     []*Build `json:"builds"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+	HasMore    bool     `json:"has_more"`
+}
+
+// offsetCursorPrefix marks cursors made by the client for servers that return
+// a plain build list without cursors.
+const offsetCursorPrefix = "offset:"
+
+// ListBuildsPage lists up to limit builds of a project after cursor, which is
+// empty for the first page. Servers that paginate return their own cursors;
+// for servers that return a plain list the client pages through it by offset,
+// which is limited by the server's maximum list size.
+func (c *Client) ListBuildsPage(projectID string, limit int, cursor string) (*BuildPage, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	offset := 0
+	query := url.Values{}
+	if strings.HasPrefix(cursor, offsetCursorPrefix) {
+		n, err := strconv.Atoi(strings.TrimPrefix(cursor, offsetCursorPrefix))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid cursor: %s", cursor)
+		}
+		offset = n
+	} else if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	// One extra build tells whether a plain list has more.
+	query.Set("limit", strconv.Itoa(offset+limit+1))
+
+	resp, err := c.doRequest("GET", fmt.Sprintf("/api/projects/%s/builds?%s", projectID, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	raw = unwrapData(raw)
+	var page BuildPage
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &page); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		if len(page.Builds) > limit {
+			page.Builds = page.Builds[:limit]
+			page.HasMore = true
+		}
+		page.HasMore = page.HasMore || page.NextCursor != ""
+		return &page, nil
+	}
+
+	var builds []*Build
+	if err := json.Unmarshal(raw, &builds); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if offset > len(builds) {
+		offset = len(builds)
+	}
+	builds = builds[offset:]
+	if len(builds) > limit {
+		page.HasMore = true
+		page.NextCursor = offsetCursorPrefix + strconv.Itoa(offset+limit)
+		builds = builds[:limit]
+	}
+	page.Builds = builds
+	return &page, nil
+}
+
+// BuildManifest lists the files of a build's artifact with their digests.
+type BuildManifest struct {
+	BuildID string         `json:"build_id"`
+	Digest  string         `json:"digest"`
+	Files   []ManifestFile `json:"files,omitempty"`
+}
+
+// ManifestFile is one file of a build artifact.
+type ManifestFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size,omitempty"`
+}
+
+// GetBuildManifest returns the artifact manifest of a build. Servers without
+// the endpoint return an error matching IsNotFound.
+func (c *Client) GetBuildManifest(buildID string) (*BuildManifest, error) {
+	resp, err := c.doRequest("GET", fmt.Sprintf("/api/builds/%s/manifest", buildID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: build manifest", ErrUnsupported)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var manifest BuildManifest
+	if err := decodeResponse(resp.Body, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// DeleteBuild deletes a build and its artifacts. Servers without the
+// endpoint return an error matching IsNotFound.
+func (c *Client) DeleteBuild(projectID, buildID string) error {
+	resp, err := c.doRequest("DELETE", fmt.Sprintf("/api/projects/%s/builds/%s", projectID, buildID), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusMethodNotAllowed:
+		return fmt.Errorf("%w: delete build", ErrUnsupported)
+	}
+	return c.parseError(resp)
+}
+
+// SetBuildPinned pins or unpins a build. Pinned builds are protected from
+// deletion on the server. Servers without the endpoint return an error
+// matching IsNotFound.
+func (c *Client) SetBuildPinned(projectID, buildID string, pinned bool) (*Build, error) {
+	method := "PUT"
+	if !pinned {
+		method = "DELETE"
+	}
+	resp, err := c.doRequest(method, fmt.Sprintf("/api/projects/%s/builds/%s/pin", projectID, buildID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		return &Build{BuildID: buildID, ProjectID: projectID, Pinned: pinned}, nil
+	case http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("%w: pin build", ErrUnsupported)
+	default:
+		return nil, c.parseError(resp)
+	}
+
+	var build Build
+	if err := decodeResponse(resp.Body, &build); err != nil || build.BuildID == "" {
+		return &Build{BuildID: buildID, ProjectID: projectID, Pinned: pinned}, nil
+	}
+	return &build, nil
+}
+
 // PublishBuild publishes a build to production
 func (c *Client) PublishBuild(projectID, buildID string) (string, error) {
 	body, err := json.Marshal(map[string]string{
@@ -427,7 +809,7 @@ func (c *Client) PublishBuild(projectID, buildID string) (string, error) {
 			URL string `json:"url"`
 		} `json:"publish,omitempty"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err == nil {
+	if err := decodeResponse(resp.Body, &result); err == nil {
 		publicPath := strings.TrimSpace(result.PublicPath)
 		if publicPath != "" {
 			return publicPath, nil
@@ -440,6 +822,101 @@ func (c *Client) PublishBuild(projectID, buildID string) (string, error) {
 	return "", nil
 }
 
+// StagedPublish is a build deployed to a project's staging slot, waiting to
+// be switched to production.
+type StagedPublish struct {
+	BuildID  string    `json:"build_id"`
+	URL      string    `json:"url,omitempty"`
+	StagedAt time.Time `json:"staged_at,omitempty"`
+}
+
+// StagePublish deploys a build to the project's staging slot without
+// changing production. Servers without the endpoint return an error matching
+// IsNotFound.
+func (c *Client) StagePublish(projectID, buildID string) (*StagedPublish, error) {
+	body, err := json.Marshal(map[string]string{"build_id": buildID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	resp, err := c.doRequest("POST", fmt.Sprintf("/api/projects/%s/publish/stage", projectID), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: publish/stage", ErrUnsupported)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, c.parseError(resp)
+	}
+
+	var result struct {
+		StagedPublish
+		Staging *StagedPublish `json:"staging"`
+	}
+	if err := decodeResponse(resp.Body, &result); err != nil {
+		return nil, err
+	}
+	staged := &result.StagedPublish
+	if result.Staging != nil {
+		staged = result.Staging
+	}
+	if staged.BuildID == "" {
+		staged.BuildID = buildID
+	}
+	return staged, nil
+}
+
+// CommitStagedPublish atomically switches production to the staged build and
+// returns its build ID and public path.
+func (c *Client) CommitStagedPublish(projectID string) (string, string, error) {
+	resp, err := c.doRequest("POST", fmt.Sprintf("/api/projects/%s/publish/commit", projectID), nil)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", c.parseError(resp)
+	}
+
+	var result struct {
+		BuildID    string `json:"build_id"`
+		PublicPath string `json:"public_path"`
+		Publish    *struct {
+			BuildID string `json:"build_id"`
+			URL     string `json:"url"`
+		} `json:"publish,omitempty"`
+	}
+	if err := decodeResponse(resp.Body, &result); err != nil {
+		return "", "", err
+	}
+	if result.Publish != nil {
+		if result.BuildID == "" {
+			result.BuildID = result.Publish.BuildID
+		}
+		if result.PublicPath == "" {
+			result.PublicPath = result.Publish.URL
+		}
+	}
+	return result.BuildID, strings.TrimSpace(result.PublicPath), nil
+}
+
+// AbortStagedPublish discards the staged build; production is unchanged.
+func (c *Client) AbortStagedPublish(projectID string) error {
+	resp, err := c.doRequest("DELETE", fmt.Sprintf("/api/projects/%s/publish/stage", projectID), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return c.parseError(resp)
+	}
+	return nil
+}
+
 // UploadBuildArtifacts uploads a zip of build outputs for a given build.
 func (c *Client) UploadBuildArtifacts(buildID, zipPath string) (*Build, error) {
 	body := &bytes.Buffer{}
@@ -462,7 +939,7 @@ func (c *Client) UploadBuildArtifacts(buildID, zipPath string) (*Build, error) {
 		return nil, fmt.Errorf("failed to close writer: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/builds/%s/artifacts", c.baseURL, buildID), body)
+	req, err := c.newUploadRequest(fmt.Sprintf("%s/api/builds/%s/artifacts", c.baseURL, buildID), body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -480,33 +957,192 @@ func (c *Client) UploadBuildArtifacts(buildID, zipPath string) (*Build, error) {
 	}
 
 	var build Build
-	if err := json.NewDecoder(resp.Body).Decode(&build); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := decodeResponse(resp.Body, &build); err != nil {
+		return nil, err
 	}
 	return &build, nil
 }
 
-func (c *Client) doRequest(method, path string, body io.Reader) (*http.Response, error) {
-	req, err := http.NewRequest(method, c.baseURL+path, body)
+// newUploadRequest builds a POST request whose body reports progress to the
+// registered upload callback.
+func (c *Client) newUploadRequest(url string, body *bytes.Buffer) (*http.Request, error) {
+	// Uploads create commits and builds, so cached listings are stale.
+	c.invalidateCache()
+	if c.progress == nil {
+		return http.NewRequest("POST", url, body)
+	}
+	total := int64(body.Len())
+	req, err := http.NewRequest("POST", url, &progressReader{r: body, total: total, fn: c.progress})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
+	req.ContentLength = total
+	return req, nil
+}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
+// newStreamUploadRequest is newUploadRequest for a body of unknown length,
+// sent chunked. Progress reports the bytes sent with a zero total.
+func (c *Client) newStreamUploadRequest(url string, body io.Reader) (*http.Request, error) {
+	c.invalidateCache()
+	if c.progress == nil {
+		return http.NewRequest("POST", url, body)
 	}
+	return http.NewRequest("POST", url, &progressReader{r: body, fn: c.progress})
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+type progressReader struct {
+	r     io.Reader
+	sent  int64
+	total int64
+	fn    func(sent, total int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.fn(p.sent, p.total)
 	}
+	return n, err
+}
 
-	return resp, nil
+func (c *Client) doRequest(method, path string, body io.Reader) (*http.Response, error) {
+	if c.cache == nil {
+		return c.send(method, path, body, nil)
+	}
+	if method == http.MethodGet {
+		if c.cacheReads {
+			return c.doCachedGet(path)
+		}
+		return c.send(method, path, body, nil)
+	}
+	resp, err := c.send(method, path, body, nil)
+	if err == nil && resp.StatusCode < http.StatusBadRequest {
+		c.invalidateCache()
+	}
+	return resp, err
 }
 
-func (c *Client) parseError(resp *http.Response) error {
+// send performs a request, trying fallback endpoints for reads.
+func (c *Client) send(method, path string, body io.Reader, header http.Header) (*http.Response, error) {
+	if method != http.MethodGet || len(c.fallbackURLs) == 0 {
+		return c.doRequestTo(c.baseURL, method, path, body, header, false)
+	}
+
+	endpoints := c.endpoints()
+	var lastErr error
+	preferred := int(c.preferred.Load())
+	for i := 0; i < len(endpoints); i++ {
+		idx := (preferred + i) % len(endpoints)
+		resp, err := c.doRequestTo(endpoints[idx], method, path, nil, header, idx != 0)
+		if err == nil && !isFailoverStatus(resp.StatusCode) {
+			c.preferred.Store(int32(idx))
+			return resp, nil
+		}
+		if err == nil {
+			if i == len(endpoints)-1 {
+				return resp, nil
+			}
+			resp.Body.Close()
+			lastErr = fmt.Errorf("endpoint %s returned status %d", endpoints[idx], resp.StatusCode)
+			continue
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (c *Client) endpoints() []string {
+	return append([]string{c.baseURL}, c.fallbackURLs...)
+}
+
+func isFailoverStatus(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+func (c *Client) doRequestTo(endpoint, method, path string, body io.Reader, header http.Header, failover bool) (*http.Response, error) {
+	req, err := http.NewRequest(method, endpoint+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	info := RequestInfo{
+		Method:   method,
+		URL:      req.URL.String(),
+		Endpoint: endpoint,
+		Duration: time.Since(start),
+		Err:      err,
+		Failover: failover,
+	}
+	if resp != nil {
+		info.Status = resp.StatusCode
+	}
+	c.observe(info)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	return resp, nil
+}
+
+// FetchURL GETs an absolute URL such as a deployed page. The API key is only
+// sent to the configured API hosts, which serve owner-only previews.
+func (c *Client) FetchURL(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for _, endpoint := range c.endpoints() {
+		if u, err := url.Parse(endpoint); err == nil && strings.EqualFold(u.Host, req.URL.Host) {
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+			break
+		}
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	info := RequestInfo{
+		Method:   http.MethodGet,
+		URL:      req.URL.String(),
+		Endpoint: req.URL.Scheme + "://" + req.URL.Host,
+		Duration: time.Since(start),
+		Err:      err,
+	}
+	if resp != nil {
+		info.Status = resp.StatusCode
+	}
+	c.observe(info)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	return resp, nil
+}
+
+func (c *Client) observe(info RequestInfo) {
+	if c.observer != nil {
+		c.observer(info)
+	}
+}
+
+func (c *Client) parseError(resp *http.Response) error {
 	body, _ := io.ReadAll(resp.Body)
+	apiErr := &APIError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body)), MinAPIVersion: resp.Header.Get(minAPIVersionHeader)}
+	fillAPIError(apiErr, body)
+	return apiErr
+}
+
+// fillAPIError sets the code and message of apiErr from a JSON error body.
+func fillAPIError(apiErr *APIError, body []byte) {
 	var errResp struct {
 		Error   interface{} `json:"error"`
 		Message string      `json:"message"`
@@ -533,18 +1169,575 @@ func (c *Client) parseError(resp *http.Response) error {
 				}
 			}
 		}
+		apiErr.Code = strings.TrimSpace(errResp.Code)
+		apiErr.Message = msg
+	}
+}
 
-		if msg != "" {
-			if strings.TrimSpace(errResp.Code) != "" {
-				return fmt.Errorf("API error (status %d, code %s): %s", resp.StatusCode, strings.TrimSpace(errResp.Code), msg)
-			}
-			return fmt.Errorf("API error (status %d): %s", resp.StatusCode, msg)
-		}
+// ShareLink is a signed, time-limited link to a build's preview that works
+// without a RobotX account.
+type ShareLink struct {
+	BuildID   string    `json:"build_id"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateShareLink creates a share link to a build's preview that expires
+// after expiresIn. Servers without the endpoint return an error matching
+// IsNotFound.
+func (c *Client) CreateShareLink(projectID, buildID string, expiresIn time.Duration) (*ShareLink, error) {
+	body, err := json.Marshal(map[string]int64{"expires_in_seconds": int64(expiresIn.Seconds())})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	resp, err := c.doRequest("POST", fmt.Sprintf("/api/projects/%s/builds/%s/share", projectID, buildID), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("%w: build share links", ErrUnsupported)
+	default:
+		return nil, c.parseError(resp)
+	}
+
+	var link ShareLink
+	if err := decodeResponse(resp.Body, &link); err != nil {
+		return nil, err
+	}
+	if link.URL == "" {
+		return nil, fmt.Errorf("share response has no url")
+	}
+	if link.BuildID == "" {
+		link.BuildID = buildID
+	}
+	return &link, nil
+}
+
+// SetPreviewProtection requires password, and username if not empty, to open
+// the project's preview URLs.
+func (c *Client) SetPreviewProtection(projectID, username, password string) (*PreviewProtection, error) {
+	body, err := json.Marshal(map[string]string{"username": username, "password": password})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	resp, err := c.doRequest("PUT", fmt.Sprintf("/api/projects/%s/preview-protection", projectID), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		return &PreviewProtection{Enabled: true, Username: username}, nil
+	case http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("%w: preview protection", ErrUnsupported)
+	default:
+		return nil, c.parseError(resp)
+	}
+
+	var protection PreviewProtection
+	if err := decodeResponse(resp.Body, &protection); err != nil {
+		return &PreviewProtection{Enabled: true, Username: username}, nil
+	}
+	protection.Enabled = true
+	if protection.Username == "" {
+		protection.Username = username
+	}
+	return &protection, nil
+}
+
+// DisablePreviewProtection makes the project's preview URLs open without a
+// password again.
+func (c *Client) DisablePreviewProtection(projectID string) error {
+	resp, err := c.doRequest("DELETE", fmt.Sprintf("/api/projects/%s/preview-protection", projectID), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusMethodNotAllowed:
+		return fmt.Errorf("%w: preview protection", ErrUnsupported)
+	default:
+		return c.parseError(resp)
+	}
+}
+
+// EnableMaintenance serves a maintenance page, with message if not empty, on
+// the project's production URL. The published build stays published.
+func (c *Client) EnableMaintenance(projectID, message string) (*MaintenanceMode, error) {
+	body, err := json.Marshal(map[string]string{"message": message})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	resp, err := c.doRequest("PUT", fmt.Sprintf("/api/projects/%s/maintenance", projectID), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		return &MaintenanceMode{Enabled: true, Message: message}, nil
+	case http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("%w: maintenance mode", ErrUnsupported)
+	default:
+		return nil, c.parseError(resp)
+	}
+
+	var mode MaintenanceMode
+	if err := decodeResponse(resp.Body, &mode); err != nil {
+		return &MaintenanceMode{Enabled: true, Message: message}, nil
+	}
+	mode.Enabled = true
+	if mode.Message == "" {
+		mode.Message = message
+	}
+	return &mode, nil
+}
+
+// DisableMaintenance serves the published build on the project's production
+// URL again.
+func (c *Client) DisableMaintenance(projectID string) error {
+	resp, err := c.doRequest("DELETE", fmt.Sprintf("/api/projects/%s/maintenance", projectID), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusMethodNotAllowed:
+		return fmt.Errorf("%w: maintenance mode", ErrUnsupported)
+	default:
+		return c.parseError(resp)
+	}
+}
+
+// FunctionEndpoint is a serverless function deployed with a build.
+type FunctionEndpoint struct {
+	Name    string `json:"name"`
+	Route   string `json:"route,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Runtime string `json:"runtime,omitempty"`
+}
+
+// UploadFunctions attaches the zipped functions directory to a build and
+// returns the function endpoints the server deployed from it.
+func (c *Client) UploadFunctions(buildID, zipPath string) ([]*FunctionEndpoint, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	file, err := os.Open(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open functions archive: %w", err)
+	}
+	defer file.Close()
+
+	part, err := writer.CreateFormFile("file", filepath.Base(zipPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, fmt.Errorf("failed to copy file: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close writer: %w", err)
+	}
+
+	req, err := c.newUploadRequest(fmt.Sprintf("%s/api/builds/%s/functions", c.baseURL, buildID), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload functions: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("%w: build functions", ErrUnsupported)
+	default:
+		return nil, c.parseError(resp)
+	}
+
+	var result struct {
+		Functions []*FunctionEndpoint `json:"functions"`
+	}
+	if err := decodeResponse(resp.Body, &result); err != nil {
+		return nil, err
+	}
+	return result.Functions, nil
+}
+
+// Job is a scheduled invocation of a path of a deployed project.
+type Job struct {
+	JobID      string     `json:"job_id"`
+	ProjectID  string     `json:"project_id,omitempty"`
+	Name       string     `json:"name,omitempty"`
+	Schedule   string     `json:"schedule"`
+	Method     string     `json:"method,omitempty"`
+	Path       string     `json:"path"`
+	LastStatus string     `json:"last_status,omitempty"`
+	LastRunAt  *time.Time `json:"last_run_at,omitempty"`
+	NextRunAt  *time.Time `json:"next_run_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at,omitempty"`
+}
+
+// CreateJobRequest registers a scheduled job.
+type CreateJobRequest struct {
+	Name     string `json:"name,omitempty"`
+	Schedule string `json:"schedule"`
+	Method   string `json:"method,omitempty"`
+	Path     string `json:"path"`
+}
+
+// ListJobs lists a project's scheduled jobs. Servers without scheduled jobs
+// return an error matching IsNotFound.
+func (c *Client) ListJobs(projectID string) ([]*Job, error) {
+	resp, err := c.doRequest("GET", fmt.Sprintf("/api/projects/%s/jobs", projectID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: project jobs", ErrUnsupported)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	raw = unwrapData(raw)
+	var jobs []*Job
+	if err := json.Unmarshal(raw, &jobs); err == nil {
+		return jobs, nil
+	}
+	var wrapped struct {
+		Jobs []*Job `json:"jobs"`
+	}
+	if err := json.Unmarshal(raw, &wrapped); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return wrapped.Jobs, nil
+}
+
+// CreateJob registers a scheduled job for a project.
+func (c *Client) CreateJob(projectID string, req CreateJobRequest) (*Job, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	resp, err := c.doRequest("POST", fmt.Sprintf("/api/projects/%s/jobs", projectID), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	trimmedBody := strings.TrimSpace(string(body))
-	if trimmedBody == "" {
-		return fmt.Errorf("API error: status %d", resp.StatusCode)
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("%w: project jobs", ErrUnsupported)
+	default:
+		return nil, c.parseError(resp)
+	}
+
+	var job Job
+	if err := decodeResponse(resp.Body, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// DeleteJob removes a scheduled job.
+func (c *Client) DeleteJob(projectID, jobID string) error {
+	resp, err := c.doRequest("DELETE", fmt.Sprintf("/api/projects/%s/jobs/%s", projectID, jobID), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return c.parseError(resp)
+	}
+	return nil
+}
+
+// ListFlags returns a project's feature flags. Values are strings, numbers or
+// booleans as stored by the server.
+func (c *Client) ListFlags(projectID string) (map[string]interface{}, error) {
+	resp, err := c.doRequest("GET", fmt.Sprintf("/api/projects/%s/flags", projectID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: project flags", ErrUnsupported)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+	return decodeFlags(resp.Body)
+}
+
+// SetFlags creates or updates feature flags of a project, leaving other flags
+// unchanged, and returns all flags after the update.
+func (c *Client) SetFlags(projectID string, flags map[string]interface{}) (map[string]interface{}, error) {
+	body, err := json.Marshal(map[string]interface{}{"flags": flags})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	resp, err := c.doRequest("PATCH", fmt.Sprintf("/api/projects/%s/flags", projectID), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		return nil, nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("%w: project flags", ErrUnsupported)
+	default:
+		return nil, c.parseError(resp)
+	}
+	return decodeFlags(resp.Body)
+}
+
+// DeleteFlag removes a feature flag of a project.
+func (c *Client) DeleteFlag(projectID, key string) error {
+	resp, err := c.doRequest("DELETE", fmt.Sprintf("/api/projects/%s/flags/%s", projectID, url.PathEscape(key)), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return c.parseError(resp)
+	}
+	return nil
+}
+
+// decodeFlags accepts {"flags": {...}}, {"data": {"flags": {...}}} or a bare
+// object of flags.
+func decodeFlags(r io.Reader) (map[string]interface{}, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	raw = unwrapData(raw)
+	var wrapped struct {
+		Flags map[string]interface{} `json:"flags"`
+	}
+	if err := json.Unmarshal(raw, &wrapped); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if wrapped.Flags != nil {
+		return wrapped.Flags, nil
+	}
+	var flags map[string]interface{}
+	if err := json.Unmarshal(raw, &flags); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return flags, nil
+}
+
+// Analytics summarizes the production traffic of a project over a window.
+type Analytics struct {
+	ProjectID      string          `json:"project_id,omitempty"`
+	Since          time.Time       `json:"since"`
+	Until          time.Time       `json:"until"`
+	Requests       int64           `json:"requests"`
+	BandwidthBytes int64           `json:"bandwidth_bytes"`
+	TopPaths       []AnalyticsPath `json:"top_paths"`
+	// StatusCodes counts responses by HTTP status code, e.g. "200" or "404".
+	StatusCodes map[string]int64 `json:"status_codes"`
+}
+
+// AnalyticsPath is the traffic of one request path.
+type AnalyticsPath struct {
+	Path           string `json:"path"`
+	Requests       int64  `json:"requests"`
+	BandwidthBytes int64  `json:"bandwidth_bytes,omitempty"`
+}
+
+// GetAnalytics returns the traffic of a project since the given time.
+func (c *Client) GetAnalytics(projectID string, since time.Time) (*Analytics, error) {
+	query := url.Values{"since": {since.UTC().Format(time.RFC3339)}}
+	resp, err := c.doRequest("GET", fmt.Sprintf("/api/projects/%s/analytics?%s", projectID, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: project analytics", ErrUnsupported)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var analytics Analytics
+	if err := decodeResponse(resp.Body, &analytics); err != nil {
+		return nil, err
+	}
+	return &analytics, nil
+}
+
+// GetProjectEnv returns the runtime environment variables of a project.
+func (c *Client) GetProjectEnv(projectID string) (map[string]string, error) {
+	resp, err := c.doRequest("GET", fmt.Sprintf("/api/projects/%s/env", projectID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: project env", ErrUnsupported)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var result struct {
+		Env map[string]string `json:"env"`
+	}
+	if err := decodeResponse(resp.Body, &result); err != nil {
+		return nil, err
+	}
+	return result.Env, nil
+}
+
+// SetProjectEnv creates or updates runtime environment variables of a
+// project; variables not in env are kept.
+func (c *Client) SetProjectEnv(projectID string, env map[string]string) error {
+	body, err := json.Marshal(map[string]interface{}{"env": env})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	resp, err := c.doRequest("PATCH", fmt.Sprintf("/api/projects/%s/env", projectID), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return fmt.Errorf("%w: project env", ErrUnsupported)
+	default:
+		return c.parseError(resp)
+	}
+}
+
+// DeleteProjectEnv removes a runtime environment variable of a project.
+// Removing a variable that is not set succeeds.
+func (c *Client) DeleteProjectEnv(projectID, key string) error {
+	resp, err := c.doRequest("DELETE", fmt.Sprintf("/api/projects/%s/env/%s", projectID, url.PathEscape(key)), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	case http.StatusMethodNotAllowed:
+		return fmt.Errorf("%w: project env", ErrUnsupported)
+	}
+	return c.parseError(resp)
+}
+
+// CopyBuild creates a build of projectID from the artifacts of a successful
+// build of another project, without building again.
+func (c *Client) CopyBuild(projectID, sourceBuildID string) (*Build, error) {
+	body, err := json.Marshal(map[string]string{"build_id": sourceBuildID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	resp, err := c.doRequest("POST", fmt.Sprintf("/api/projects/%s/builds/copy", projectID), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("%w: copy builds", ErrUnsupported)
+	default:
+		return nil, c.parseError(resp)
+	}
+
+	var build Build
+	if err := decodeResponse(resp.Body, &build); err != nil {
+		return nil, err
+	}
+	return &build, nil
+}
+
+// CachePurge is a CDN cache invalidation of a project's production URL.
+type CachePurge struct {
+	PurgeID string `json:"purge_id,omitempty"`
+	// Paths are the purged paths; empty means the whole site.
+	Paths  []string `json:"paths,omitempty"`
+	Status string   `json:"status,omitempty"`
+}
+
+// PurgeCache invalidates the CDN cache of the given paths of a project's
+// production URL, or of the whole site when paths is empty. Servers without
+// the endpoint return an error matching IsNotFound.
+func (c *Client) PurgeCache(projectID string, paths []string) (*CachePurge, error) {
+	if paths == nil {
+		paths = []string{}
+	}
+	body, err := json.Marshal(map[string][]string{"paths": paths})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	resp, err := c.doRequest("POST", fmt.Sprintf("/api/projects/%s/cache/purge", projectID), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
+	case http.StatusNoContent:
+		return &CachePurge{Paths: paths}, nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("%w: cache purge", ErrUnsupported)
+	default:
+		return nil, c.parseError(resp)
+	}
+
+	var purge CachePurge
+	if err := decodeResponse(resp.Body, &purge); err != nil {
+		return nil, err
+	}
+	if purge.Paths == nil {
+		purge.Paths = paths
 	}
-	return fmt.Errorf("API error: status %d, body: %s", resp.StatusCode, trimmedBody)
+	return &purge, nil
 }
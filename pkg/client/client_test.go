@@ -0,0 +1,288 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// payloadShapes are the two ways servers return a payload: bare, and in a
+// {"data": ...} envelope.
+var payloadShapes = []struct {
+	name string
+	wrap func(payload string) string
+}{
+	{"bare", func(payload string) string { return payload }},
+	{"wrapped", func(payload string) string { return `{"success":true,"code":0,"message":"ok","data":` + payload + `}` }},
+}
+
+// newTestClient returns a client of a server answering path with status
+// and body, and every other path with 404.
+func newTestClient(t *testing.T, path string, status int, body string) *Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL, "test-key")
+}
+
+func TestCreateProject(t *testing.T) {
+	for _, shape := range payloadShapes {
+		t.Run(shape.name, func(t *testing.T) {
+			c := newTestClient(t, "/api/projects", http.StatusCreated, shape.wrap(`{"project_id":"proj_1","name":"demo"}`))
+			project, err := c.CreateProject(CreateProjectRequest{Name: "demo"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if project.ProjectID != "proj_1" || project.Name != "demo" {
+				t.Errorf("got project %+v", project)
+			}
+		})
+	}
+}
+
+func TestGetProject(t *testing.T) {
+	for _, shape := range payloadShapes {
+		t.Run(shape.name, func(t *testing.T) {
+			c := newTestClient(t, "/api/projects/proj_1", http.StatusOK, shape.wrap(`{"project_id":"proj_1","name":"demo","visibility":"private"}`))
+			project, err := c.GetProject("proj_1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if project.ProjectID != "proj_1" || project.Visibility != "private" {
+				t.Errorf("got project %+v", project)
+			}
+		})
+	}
+}
+
+func TestListProjects(t *testing.T) {
+	for _, shape := range payloadShapes {
+		t.Run(shape.name, func(t *testing.T) {
+			c := newTestClient(t, "/api/projects", http.StatusOK, shape.wrap(`[{"project_id":"proj_1"},{"project_id":"proj_2"}]`))
+			projects, err := c.ListProjects(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(projects) != 2 || projects[1].ProjectID != "proj_2" {
+				t.Errorf("got projects %+v", projects)
+			}
+		})
+	}
+}
+
+func TestUploadSource(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "source.zip")
+	if err := os.WriteFile(source, []byte("zip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, shape := range payloadShapes {
+		t.Run(shape.name, func(t *testing.T) {
+			c := newTestClient(t, "/api/projects/proj_1/commits", http.StatusCreated, shape.wrap(`{"commit":{"commit_id":"c1","project_id":"proj_1"},"build":{"build_id":"b1","project_id":"proj_1","status":"queued"}}`))
+			commit, build, err := c.UploadSource("proj_1", source, "", "", nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if commit == nil || commit.CommitID != "c1" {
+				t.Errorf("got commit %+v", commit)
+			}
+			if build == nil || build.BuildID != "b1" || build.Status != "queued" {
+				t.Errorf("got build %+v", build)
+			}
+		})
+	}
+}
+
+func TestUploadSourceIDsOnly(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "source.zip")
+	if err := os.WriteFile(source, []byte("zip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, shape := range payloadShapes {
+		t.Run(shape.name, func(t *testing.T) {
+			c := newTestClient(t, "/api/projects/proj_1/commits", http.StatusAccepted, shape.wrap(`{"commit_id":"c1","build_id":"b1"}`))
+			commit, build, err := c.UploadSource("proj_1", source, "", "", nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if commit == nil || commit.CommitID != "c1" || commit.ProjectID != "proj_1" {
+				t.Errorf("got commit %+v", commit)
+			}
+			if build == nil || build.BuildID != "b1" || build.ProjectID != "proj_1" {
+				t.Errorf("got build %+v", build)
+			}
+		})
+	}
+}
+
+func TestHeadCommit(t *testing.T) {
+	for _, shape := range payloadShapes {
+		t.Run(shape.name, func(t *testing.T) {
+			c := newTestClient(t, "/api/projects/proj_1/commits/head", http.StatusOK, shape.wrap(`{"commit":{"commit_id":"c1","digest":"sha256:ab"},"build":{"build_id":"b1","status":"success"}}`))
+			commit, build, err := c.HeadCommit("proj_1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if commit == nil || commit.Digest != "sha256:ab" {
+				t.Errorf("got commit %+v", commit)
+			}
+			if build == nil || build.BuildID != "b1" {
+				t.Errorf("got build %+v", build)
+			}
+		})
+	}
+}
+
+func TestGetBuild(t *testing.T) {
+	paths := []string{"/api/builds/b1", "/api/projects/proj_1/builds/b1"}
+	for _, path := range paths {
+		for _, shape := range payloadShapes {
+			t.Run(path+"/"+shape.name, func(t *testing.T) {
+				c := newTestClient(t, path, http.StatusOK, shape.wrap(`{"build_id":"b1","project_id":"proj_1","status":"success","version_label":"v1.0.0"}`))
+				build, err := c.GetBuild("proj_1", "b1")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if build.BuildID != "b1" || build.Status != "success" || build.VersionLabel != "v1.0.0" {
+					t.Errorf("got build %+v", build)
+				}
+			})
+		}
+	}
+}
+
+func TestListBuildsForProject(t *testing.T) {
+	for _, shape := range payloadShapes {
+		t.Run(shape.name, func(t *testing.T) {
+			c := newTestClient(t, "/api/projects/proj_1/builds", http.StatusOK, shape.wrap(`[{"build_id":"b2"},{"build_id":"b1"}]`))
+			builds, err := c.ListBuildsForProject("proj_1", 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(builds) != 2 || builds[0].BuildID != "b2" {
+				t.Errorf("got builds %+v", builds)
+			}
+		})
+	}
+}
+
+func TestGetBuildManifest(t *testing.T) {
+	for _, shape := range payloadShapes {
+		t.Run(shape.name, func(t *testing.T) {
+			c := newTestClient(t, "/api/builds/b1/manifest", http.StatusOK, shape.wrap(`{"build_id":"b1","digest":"sha256:ab","files":[{"path":"index.html"}]}`))
+			manifest, err := c.GetBuildManifest("b1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if manifest.Digest != "sha256:ab" || len(manifest.Files) != 1 {
+				t.Errorf("got manifest %+v", manifest)
+			}
+		})
+	}
+}
+
+func TestPublishBuild(t *testing.T) {
+	for _, shape := range payloadShapes {
+		t.Run(shape.name, func(t *testing.T) {
+			c := newTestClient(t, "/api/projects/proj_1/publish", http.StatusOK, shape.wrap(`{"public_path":"https://demo.example.com"}`))
+			url, err := c.PublishBuild("proj_1", "b1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if url != "https://demo.example.com" {
+				t.Errorf("got url %q", url)
+			}
+		})
+	}
+}
+
+func TestHealth(t *testing.T) {
+	for _, shape := range payloadShapes {
+		t.Run(shape.name, func(t *testing.T) {
+			c := newTestClient(t, "/api/health", http.StatusOK, shape.wrap(`{"status":"ok","version":"1.2.0"}`))
+			health, err := c.Health()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if health.Status != "ok" || health.Version != "1.2.0" {
+				t.Errorf("got health %+v", health)
+			}
+		})
+	}
+}
+
+func TestWhoAmI(t *testing.T) {
+	for _, shape := range payloadShapes {
+		t.Run(shape.name, func(t *testing.T) {
+			c := newTestClient(t, "/api/auth/whoami", http.StatusOK, shape.wrap(`{"user":{"user_id":"u1","email":"dev@example.com"}}`))
+			identity, err := c.WhoAmI()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if identity.UserID != "u1" || identity.Email != "dev@example.com" {
+				t.Errorf("got identity %+v", identity)
+			}
+		})
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	for _, shape := range payloadShapes {
+		t.Run(shape.name, func(t *testing.T) {
+			c := newTestClient(t, "/api/discovery", http.StatusOK, shape.wrap(`{"base_url":"https://eu.example.com/","region":"eu"}`))
+			discovery, err := c.Discover()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if discovery.BaseURL != "https://eu.example.com" || discovery.Region != "eu" {
+				t.Errorf("got discovery %+v", discovery)
+			}
+		})
+	}
+}
+
+func TestFailoverFlag(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(primary.Close)
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	t.Cleanup(fallback.Close)
+
+	c := NewClient(primary.URL, "test-key")
+	c.SetFallbackBaseURLs([]string{fallback.URL})
+	var requests []RequestInfo
+	c.SetObserver(func(info RequestInfo) { requests = append(requests, info) })
+
+	// The second call starts at the fallback, which answered the first.
+	for i := 0; i < 2; i++ {
+		if _, err := c.Health(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []struct {
+		endpoint string
+		failover bool
+	}{
+		{primary.URL, false},
+		{fallback.URL, true},
+		{fallback.URL, true},
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("got %d requests, want %d", len(requests), len(want))
+	}
+	for i, w := range want {
+		if requests[i].Endpoint != w.endpoint || requests[i].Failover != w.failover {
+			t.Errorf("request %d went to %s with failover %v, want %s with %v", i, requests[i].Endpoint, requests[i].Failover, w.endpoint, w.failover)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// decodeResponse decodes a JSON response body into v. Servers return
+// payloads either bare or wrapped in a {"data": ...} envelope; both decode
+// the same.
+func decodeResponse(r io.Reader, v interface{}) error {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if err := json.Unmarshal(unwrapData(raw), v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// envelopeKeys are the members a {"data": ...} envelope may carry next to
+// data. An object with any other member is a resource that happens to have
+// a data field, and is decoded as it is.
+var envelopeKeys = map[string]bool{
+	"data":       true,
+	"success":    true,
+	"code":       true,
+	"message":    true,
+	"msg":        true,
+	"error":      true,
+	"request_id": true,
+}
+
+// unwrapData returns the payload of a {"data": ...} envelope, or raw itself
+// when it is not one. An object is an envelope when its "data" member holds
+// an object or an array, it has a success, code or message member, and it
+// has no members besides envelopeKeys.
+func unwrapData(raw []byte) []byte {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return raw
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &members); err != nil {
+		return raw
+	}
+	for key := range members {
+		if !envelopeKeys[key] {
+			return raw
+		}
+	}
+	_, success := members["success"]
+	_, code := members["code"]
+	_, message := members["message"]
+	if !success && !code && !message {
+		return raw
+	}
+	if data := bytes.TrimSpace(members["data"]); len(data) > 0 && (data[0] == '{' || data[0] == '[') {
+		return data
+	}
+	return raw
+}
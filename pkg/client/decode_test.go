@@ -0,0 +1,28 @@
+package client
+
+import "testing"
+
+func TestUnwrapData(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"bare object", `{"build_id":"b1"}`, `{"build_id":"b1"}`},
+		{"bare array", `[{"build_id":"b1"}]`, `[{"build_id":"b1"}]`},
+		{"success envelope", `{"success":true,"data":{"build_id":"b1"}}`, `{"build_id":"b1"}`},
+		{"code envelope", `{"code":0,"message":"ok","data":[{"build_id":"b1"}]}`, `[{"build_id":"b1"}]`},
+		{"data without envelope fields", `{"data":{"build_id":"b1"}}`, `{"data":{"build_id":"b1"}}`},
+		{"resource with data field", `{"seq":1,"type":"log","message":"x","data":{"line":"ok"}}`, `{"seq":1,"type":"log","message":"x","data":{"line":"ok"}}`},
+		{"scalar data", `{"success":true,"data":"ok"}`, `{"success":true,"data":"ok"}`},
+		{"not json", `ok`, `ok`},
+		{"empty", ``, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(unwrapData([]byte(tt.raw))); got != tt.want {
+				t.Errorf("unwrapData(%s) = %s, want %s", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Discovery tells which RobotX server an API key belongs to.
+type Discovery struct {
+	BaseURL string `json:"base_url"`
+	Region  string `json:"region,omitempty"`
+}
+
+// Discover asks the discovery endpoint of the client's server which server
+// serves the client's API key.
+func (c *Client) Discover() (*Discovery, error) {
+	resp, err := c.doRequest("GET", "/api/discovery", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: discovery", ErrUnsupported)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var d Discovery
+	if err := decodeResponse(resp.Body, &d); err != nil {
+		return nil, err
+	}
+	d.BaseURL = strings.TrimRight(strings.TrimSpace(d.BaseURL), "/")
+	if d.BaseURL == "" {
+		return nil, fmt.Errorf("discovery response missing base_url")
+	}
+	return &d, nil
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/redact"
+)
+
+// SetHTTPDump appends every request and response of the client, bodies
+// included, to the file at path, so the raw server responses can be
+// inspected when decoding them goes wrong. Credential headers, token fields,
+// signed URLs and the API key are redacted. Upload bodies and event streams are not
+// captured.
+func (c *Client) SetHTTPDump(path string) {
+	// Dump below the API version transport so the header is captured.
+	t := c.versionTransport()
+	t.next = &dumpTransport{next: t.next, path: path, secret: c.apiKey}
+}
+
+// dumpTransport writes each exchange to the dump file once it completes.
+// The file is opened per exchange so it needs no closing.
+type dumpTransport struct {
+	next   http.RoundTripper
+	path   string
+	secret string
+	mu     sync.Mutex
+}
+
+func (t *dumpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "### %s\n", time.Now().Format(time.RFC3339Nano))
+	upload := strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/")
+	if dump, err := httputil.DumpRequestOut(req, !upload); err == nil {
+		buf.Write(dump)
+	} else {
+		fmt.Fprintf(&buf, "%s %s\n[request not captured: %v]\n", req.Method, req.URL, err)
+	}
+	if upload {
+		buf.WriteString("[multipart upload body omitted]")
+	}
+	buf.WriteString("\n\n")
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		fmt.Fprintf(&buf, "--- error after %s: %v\n\n", time.Since(start).Round(time.Millisecond), err)
+		t.write(buf.Bytes())
+		return resp, err
+	}
+	fmt.Fprintf(&buf, "--- response after %s\n", time.Since(start).Round(time.Millisecond))
+	stream := strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+	if dump, err := httputil.DumpResponse(resp, !stream); err == nil {
+		buf.Write(dump)
+	} else {
+		fmt.Fprintf(&buf, "[response not captured: %v]", err)
+	}
+	if stream {
+		buf.WriteString("[event stream body omitted]")
+	}
+	buf.WriteString("\n\n")
+	t.write(buf.Bytes())
+	return resp, nil
+}
+
+// write appends a redacted exchange to the dump file. Failures are ignored:
+// the dump must never break the request it describes.
+func (t *dumpTransport) write(dump []byte) {
+	dump = redact.Bytes(dump, t.secret)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f, err := os.OpenFile(t.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.Write(dump)
+}
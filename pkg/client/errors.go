@@ -0,0 +1,50 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrUnsupported is returned when the server does not implement an endpoint.
+var ErrUnsupported = errors.New("endpoint not supported by server")
+
+// APIError is returned for non-success HTTP responses from the RobotX API.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Body       string
+	// MinAPIVersion is the oldest API version the server serves, when it
+	// refuses the client's.
+	MinAPIVersion string
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		if e.Code != "" {
+			return fmt.Sprintf("API error (status %d, code %s): %s", e.StatusCode, e.Code, e.Message)
+		}
+		return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Message)
+	}
+	if e.Body == "" {
+		return fmt.Sprintf("API error: status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("API error: status %d, body: %s", e.StatusCode, e.Body)
+}
+
+// IsStatus reports whether err is an APIError with the given HTTP status.
+func IsStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// IsUnauthorized reports whether err indicates missing or invalid credentials.
+func IsUnauthorized(err error) bool {
+	return IsStatus(err, http.StatusUnauthorized) || IsStatus(err, http.StatusForbidden)
+}
+
+// IsNotFound reports whether err is a 404 API error or an unsupported endpoint.
+func IsNotFound(err error) bool {
+	return IsStatus(err, http.StatusNotFound) || errors.Is(err, ErrUnsupported)
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"time"
+)
+
+// File targets: the published build or the latest preview build of a
+// project.
+const (
+	FileTargetProduction = "production"
+	FileTargetPreview    = "preview"
+)
+
+// FileRef names a single file of a deployed artifact: a path of the build
+// published to production, of the latest preview, or of a given build.
+type FileRef struct {
+	Path    string
+	Target  string
+	BuildID string
+}
+
+// values encodes ref as the path field with the build ID or, without one,
+// the target.
+func (r FileRef) values() url.Values {
+	q := url.Values{"path": {r.Path}}
+	if r.BuildID != "" {
+		q.Set("build_id", r.BuildID)
+	} else if r.Target != "" {
+		q.Set("target", r.Target)
+	}
+	return q
+}
+
+// ArtifactFile describes a file of a deployed artifact.
+type ArtifactFile struct {
+	Path        string     `json:"path"`
+	Size        int64      `json:"size"`
+	SHA256      string     `json:"sha256,omitempty"`
+	ContentType string     `json:"content_type,omitempty"`
+	BuildID     string     `json:"build_id,omitempty"`
+	URL         string     `json:"url,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// PutArtifactFile uploads the local file at localPath as ref.Path of the
+// deployed artifact, replacing the file if it exists, without a rebuild.
+// Servers without the endpoint return an error matching IsNotFound.
+func (c *Client) PutArtifactFile(projectID string, ref FileRef, localPath string) (*ArtifactFile, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	fields := ref.values()
+	for _, key := range []string{"path", "target", "build_id"} {
+		if value := fields.Get(key); value != "" {
+			if err := writer.WriteField(key, value); err != nil {
+				return nil, fmt.Errorf("failed to write %s: %w", key, err)
+			}
+		}
+	}
+	part, err := writer.CreateFormFile("file", path.Base(ref.Path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, fmt.Errorf("failed to copy file: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close writer: %w", err)
+	}
+
+	req, err := c.newUploadRequest(fmt.Sprintf("%s/api/projects/%s/files", c.baseURL, projectID), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("%w: artifact files", ErrUnsupported)
+	default:
+		return nil, c.parseError(resp)
+	}
+
+	var uploaded ArtifactFile
+	if err := decodeResponse(resp.Body, &uploaded); err != nil {
+		return nil, err
+	}
+	if uploaded.Path == "" {
+		uploaded.Path = ref.Path
+	}
+	return &uploaded, nil
+}
+
+// GetArtifactFile writes the content of a file of the deployed artifact to
+// w and returns its description from the response headers. A missing file
+// returns an error matching IsNotFound.
+func (c *Client) GetArtifactFile(projectID string, ref FileRef, w io.Writer) (*ArtifactFile, error) {
+	resp, err := c.send(http.MethodGet, fmt.Sprintf("/api/projects/%s/files?%s", projectID, ref.values().Encode()), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return &ArtifactFile{
+		Path:        ref.Path,
+		Size:        n,
+		ContentType: resp.Header.Get("Content-Type"),
+		BuildID:     resp.Header.Get("X-RobotX-Build-ID"),
+	}, nil
+}
+
+// DeleteArtifactFile removes a file of the deployed artifact. A missing
+// file returns an error matching IsNotFound.
+func (c *Client) DeleteArtifactFile(projectID string, ref FileRef) error {
+	resp, err := c.doRequest("DELETE", fmt.Sprintf("/api/projects/%s/files?%s", projectID, ref.values().Encode()), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return c.parseError(resp)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// HealthStatus describes the server health endpoint response.
+type HealthStatus struct {
+	Status   string   `json:"status,omitempty"`
+	Version  string   `json:"version,omitempty"`
+	Features []string `json:"features,omitempty"`
+}
+
+// Identity describes the account that owns the current credentials.
+type Identity struct {
+	UserID   string `json:"user_id,omitempty"`
+	Username string `json:"username,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Org      string `json:"org,omitempty"`
+}
+
+// Health calls the server health endpoint.
+func (c *Client) Health() (*HealthStatus, error) {
+	var lastErr error
+	for _, path := range []string{"/api/health", "/healthz", "/health"} {
+		resp, err := c.doRequest("GET", path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("%w: %s", ErrUnsupported, path)
+			continue
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, c.parseError(resp)
+		}
+		rawBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		return decodeHealthStatus(rawBody), nil
+	}
+	return nil, lastErr
+}
+
+func decodeHealthStatus(raw []byte) *HealthStatus {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(unwrapData(raw), &payload); err != nil {
+		// Plain-text health endpoints such as "ok".
+		return &HealthStatus{Status: strings.TrimSpace(string(raw))}
+	}
+
+	out := &HealthStatus{}
+	if v, ok := payload["status"].(string); ok {
+		out.Status = strings.TrimSpace(v)
+	}
+	for _, key := range []string{"version", "server_version"} {
+		if v, ok := payload[key].(string); ok && strings.TrimSpace(v) != "" {
+			out.Version = strings.TrimSpace(v)
+			break
+		}
+	}
+	for _, key := range []string{"features", "feature_flags"} {
+		if raw, ok := payload[key]; ok {
+			out.Features = featureNames(raw)
+			break
+		}
+	}
+	return out
+}
+
+// featureNames accepts either a list of names or a map of name -> enabled.
+func featureNames(raw interface{}) []string {
+	var names []string
+	switch v := raw.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				names = append(names, strings.TrimSpace(s))
+			}
+		}
+	case map[string]interface{}:
+		for name, enabled := range v {
+			if b, ok := enabled.(bool); ok && !b {
+				continue
+			}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WhoAmI returns the identity associated with the API key.
+func (c *Client) WhoAmI() (*Identity, error) {
+	for _, path := range []string{"/api/auth/whoami", "/api/me"} {
+		resp, err := c.doRequest("GET", path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			continue
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, c.parseError(resp)
+		}
+		rawBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		rawBody = unwrapData(rawBody)
+		var wrapped struct {
+			User *Identity `json:"user"`
+		}
+		if err := json.Unmarshal(rawBody, &wrapped); err == nil && wrapped.User != nil {
+			return wrapped.User, nil
+		}
+		var identity Identity
+		if err := json.Unmarshal(rawBody, &identity); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		return &identity, nil
+	}
+	return nil, fmt.Errorf("%w: whoami", ErrUnsupported)
+}
+
+// VerifyAuth checks that the API key is accepted by the server. It uses the
+// whoami endpoint when available and falls back to a minimal project listing.
+func (c *Client) VerifyAuth() (*Identity, error) {
+	identity, err := c.WhoAmI()
+	if err == nil {
+		return identity, nil
+	}
+	if !IsNotFound(err) {
+		return nil, err
+	}
+	if _, err := c.ListProjects(1); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DeployLock is an advisory lease on deploying a project, held by one
+// deploy at a time so concurrent deploys of the same project do not race.
+// It expires unless renewed.
+type DeployLock struct {
+	LockID    string `json:"lock_id"`
+	ProjectID string `json:"project_id,omitempty"`
+	// Holder describes who holds the lock, e.g. a CI job or a machine.
+	Holder     string     `json:"holder,omitempty"`
+	AcquiredAt *time.Time `json:"acquired_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// DeployLockedError is returned when another deploy holds the project's
+// lock. Lock describes that holder when the server reports it.
+type DeployLockedError struct {
+	Lock *DeployLock
+	Err  *APIError
+}
+
+func (e *DeployLockedError) Error() string {
+	if e.Lock != nil && e.Lock.Holder != "" {
+		return fmt.Sprintf("project is locked by %s", e.Lock.Holder)
+	}
+	return "project is locked by another deploy"
+}
+
+func (e *DeployLockedError) Unwrap() error {
+	return e.Err
+}
+
+// AcquireDeployLock takes the deploy lock of a project for ttl. It returns
+// a *DeployLockedError while another deploy holds it. Servers without the
+// endpoint return an error matching IsNotFound.
+func (c *Client) AcquireDeployLock(projectID, holder string, ttl time.Duration) (*DeployLock, error) {
+	body, err := json.Marshal(map[string]interface{}{"holder": holder, "ttl_seconds": int(ttl.Seconds())})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return c.lockRequest("POST", fmt.Sprintf("/api/projects/%s/lock", projectID), body)
+}
+
+// RenewDeployLock extends a held deploy lock by ttl from now.
+func (c *Client) RenewDeployLock(projectID, lockID string, ttl time.Duration) (*DeployLock, error) {
+	body, err := json.Marshal(map[string]interface{}{"ttl_seconds": int(ttl.Seconds())})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return c.lockRequest("PUT", fmt.Sprintf("/api/projects/%s/lock/%s", projectID, url.PathEscape(lockID)), body)
+}
+
+func (c *Client) lockRequest(method, path string, body []byte) (*DeployLock, error) {
+	resp, err := c.doRequest(method, path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusConflict, http.StatusLocked:
+		return nil, lockedError(resp)
+	case http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("%w: deploy locks", ErrUnsupported)
+	default:
+		return nil, c.parseError(resp)
+	}
+
+	var lock DeployLock
+	if err := decodeResponse(resp.Body, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockedError reads the holder of the lock from a conflict response.
+func lockedError(resp *http.Response) error {
+	raw, _ := io.ReadAll(resp.Body)
+	apiErr := &APIError{StatusCode: resp.StatusCode, Body: string(bytes.TrimSpace(raw))}
+	fillAPIError(apiErr, raw)
+	var held struct {
+		Lock *DeployLock `json:"lock"`
+	}
+	if err := json.Unmarshal(unwrapData(raw), &held); err != nil || held.Lock == nil {
+		_ = json.Unmarshal(raw, &held)
+	}
+	return &DeployLockedError{Lock: held.Lock, Err: apiErr}
+}
+
+// GetDeployLock returns the current deploy lock of a project, or nil when
+// it is not locked.
+func (c *Client) GetDeployLock(projectID string) (*DeployLock, error) {
+	resp, err := c.doRequest("GET", fmt.Sprintf("/api/projects/%s/lock", projectID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent, http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, c.parseError(resp)
+	}
+	var lock DeployLock
+	if err := decodeResponse(resp.Body, &lock); err != nil {
+		return nil, err
+	}
+	if lock.LockID == "" {
+		return nil, nil
+	}
+	return &lock, nil
+}
+
+// ReleaseDeployLock releases a project's deploy lock. With an empty lockID
+// the lock is removed whoever holds it, to recover from a deploy that died
+// without releasing it.
+func (c *Client) ReleaseDeployLock(projectID, lockID string) error {
+	path := fmt.Sprintf("/api/projects/%s/lock", projectID)
+	if lockID != "" {
+		path += "/" + url.PathEscape(lockID)
+	}
+	resp, err := c.doRequest("DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return c.parseError(resp)
+}
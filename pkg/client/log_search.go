@@ -0,0 +1,87 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// LogSearch selects the build log lines matched by SearchBuildLogs.
+type LogSearch struct {
+	// Query is matched as plain text, or as a regular expression with
+	// Regex.
+	Query      string
+	Regex      bool
+	IgnoreCase bool
+	// Since limits the search to builds created after it, when not zero.
+	Since time.Time
+	// Context is the number of lines returned before and after each match.
+	Context int
+	// MaxMatches caps the matches returned per build, when positive.
+	MaxMatches int
+}
+
+// LogMatch is a matching line of a build log with its context.
+type LogMatch struct {
+	// Line is the 1-based line number in the log.
+	Line   int      `json:"line"`
+	Text   string   `json:"text"`
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+// BuildLogMatches are the matches in the log of one build.
+type BuildLogMatches struct {
+	BuildID    string     `json:"build_id"`
+	VersionSeq int64      `json:"version_seq,omitempty"`
+	Status     string     `json:"status,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	Matches    []LogMatch `json:"matches"`
+	// Truncated is set when the build had more matches than MaxMatches.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// SearchBuildLogs searches the build logs the server keeps for a project and
+// returns the builds with matches, newest first. Servers without the
+// endpoint return an error matching IsNotFound.
+func (c *Client) SearchBuildLogs(projectID string, search LogSearch) ([]*BuildLogMatches, error) {
+	query := url.Values{"q": {search.Query}}
+	if search.Regex {
+		query.Set("regex", "true")
+	}
+	if search.IgnoreCase {
+		query.Set("ignore_case", "true")
+	}
+	if !search.Since.IsZero() {
+		query.Set("since", search.Since.UTC().Format(time.RFC3339))
+	}
+	if search.Context > 0 {
+		query.Set("context", strconv.Itoa(search.Context))
+	}
+	if search.MaxMatches > 0 {
+		query.Set("max_matches", strconv.Itoa(search.MaxMatches))
+	}
+	resp, err := c.doRequest("GET", fmt.Sprintf("/api/projects/%s/logs/search?%s", projectID, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("%w: log search", ErrUnsupported)
+	default:
+		return nil, c.parseError(resp)
+	}
+
+	var result struct {
+		Builds []*BuildLogMatches `json:"builds"`
+	}
+	if err := decodeResponse(resp.Body, &result); err != nil {
+		return nil, err
+	}
+	return result.Builds, nil
+}
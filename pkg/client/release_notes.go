@@ -0,0 +1,31 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// SetReleaseNotes attaches release notes to a build, replacing any it had.
+// A 404 means the build does not exist; servers without release notes return
+// ErrUnsupported for 405.
+func (c *Client) SetReleaseNotes(projectID, buildID, notes string) error {
+	body, err := json.Marshal(map[string]string{"notes": notes})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	resp, err := c.doRequest("PUT", fmt.Sprintf("/api/projects/%s/builds/%s/release-notes", projectID, buildID), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusMethodNotAllowed:
+		return fmt.Errorf("%w: release notes", ErrUnsupported)
+	}
+	return c.parseError(resp)
+}
@@ -0,0 +1,369 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const sessionHeader = "Mcp-Session-Id"
+
+// HTTPHandler serves MCP over HTTP. It implements the streamable HTTP
+// transport at /mcp and the legacy HTTP+SSE transport at /sse (event stream)
+// and /message (client messages). When token is non-empty every request must
+// carry "Authorization: Bearer <token>".
+func (s *Server) HTTPHandler(token string) http.Handler {
+	h := &httpTransport{server: s, token: token, sessions: map[string]*httpSession{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mcp", h.auth(h.serveStreamable))
+	mux.HandleFunc("/sse", h.auth(h.serveSSE))
+	mux.HandleFunc("/message", h.auth(h.serveMessage))
+	return mux
+}
+
+type httpTransport struct {
+	server *Server
+	token  string
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+}
+
+type httpSession struct {
+	id       string
+	inflight *inflightRequests
+	// events carries messages for legacy SSE sessions; nil otherwise.
+	events chan []byte
+	done   chan struct{}
+}
+
+func (h *httpTransport) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.token != "" {
+			got := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+			if subtle.ConstantTimeCompare([]byte(got), []byte(h.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="robotx-mcp"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+func (h *httpTransport) newSession(legacy bool) *httpSession {
+	sess := &httpSession{id: newSessionID(), inflight: newInflightRequests(), done: make(chan struct{})}
+	if legacy {
+		sess.events = make(chan []byte, 64)
+	}
+	h.mu.Lock()
+	h.sessions[sess.id] = sess
+	h.mu.Unlock()
+	return sess
+}
+
+func (h *httpTransport) session(id string) *httpSession {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.sessions[id]
+}
+
+func (h *httpTransport) closeSession(id string) {
+	h.mu.Lock()
+	sess, ok := h.sessions[id]
+	delete(h.sessions, id)
+	h.mu.Unlock()
+	if ok {
+		close(sess.done)
+	}
+}
+
+// serveStreamable implements the streamable HTTP transport: each POST carries
+// one message or a batch, and responses are returned either as JSON or, when
+// the client accepts it, as an SSE stream that also carries progress
+// notifications.
+func (h *httpTransport) serveStreamable(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+	case http.MethodDelete:
+		h.closeSession(r.Header.Get(sessionHeader))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	reqs, batch, err := readRequests(r.Body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, parseErrorResponse())
+		return
+	}
+
+	var sess *httpSession
+	if id := r.Header.Get(sessionHeader); id != "" {
+		if sess = h.session(id); sess == nil {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+	} else if hasMethod(reqs, "initialize") {
+		sess = h.newSession(false)
+	}
+	if sess != nil {
+		w.Header().Set(sessionHeader, sess.id)
+	}
+
+	var calls []request
+	for _, req := range reqs {
+		if len(req.ID) == 0 {
+			if sess != nil {
+				h.server.handleNotification(req, sess.inflight)
+			}
+			continue
+		}
+		calls = append(calls, req)
+	}
+	if len(calls) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		responses := h.handleCalls(r.Context(), sess, calls, nil)
+		switch {
+		case len(responses) == 0:
+			// Every call was cancelled, e.g. because the client went away.
+			w.WriteHeader(http.StatusAccepted)
+		case batch:
+			writeJSON(w, http.StatusOK, responses)
+		default:
+			writeJSON(w, http.StatusOK, responses[0])
+		}
+		return
+	}
+
+	stream, ok := newSSEWriter(w)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	notify := func(method string, params interface{}) error {
+		return stream.send(notification{JSONRPC: "2.0", Method: method, Params: params})
+	}
+	for _, resp := range h.handleCalls(r.Context(), sess, calls, notify) {
+		_ = stream.send(resp)
+	}
+}
+
+// handleCalls runs requests concurrently and returns their responses in order.
+// Requests cancelled by the client produce no response.
+func (h *httpTransport) handleCalls(ctx context.Context, sess *httpSession, calls []request, notify Notifier) []interface{} {
+	results := make([]interface{}, len(calls))
+	var wg sync.WaitGroup
+	for i, req := range calls {
+		reqCtx, cancel := context.WithCancel(ctx)
+		key := ""
+		if sess != nil {
+			key = sess.inflight.add(req.ID, cancel)
+		}
+		wg.Add(1)
+		go func(i int, req request) {
+			defer wg.Done()
+			defer cancel()
+			if sess != nil {
+				defer sess.inflight.done(key)
+			}
+			resp := h.server.handle(reqCtx, req, notify)
+			if reqCtx.Err() == nil {
+				results[i] = resp
+			}
+		}(i, req)
+	}
+	wg.Wait()
+
+	out := results[:0]
+	for _, resp := range results {
+		if resp != nil {
+			out = append(out, resp)
+		}
+	}
+	return out
+}
+
+// serveSSE opens a legacy HTTP+SSE session. The first event tells the client
+// where to POST its messages; responses are delivered on this stream.
+func (h *httpTransport) serveSSE(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	stream, ok := newSSEWriter(w)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	sess := h.newSession(true)
+	defer h.closeSession(sess.id)
+
+	if err := stream.event("endpoint", []byte("/message?sessionId="+sess.id)); err != nil {
+		return
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case raw := <-sess.events:
+			if err := stream.event("message", raw); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *httpTransport) serveMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess := h.session(r.URL.Query().Get("sessionId"))
+	if sess == nil || sess.events == nil {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+	reqs, _, err := readRequests(r.Body)
+	if err != nil {
+		http.Error(w, "invalid JSON-RPC message", http.StatusBadRequest)
+		return
+	}
+
+	send := func(v interface{}) error {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		select {
+		case sess.events <- raw:
+			return nil
+		case <-sess.done:
+			return fmt.Errorf("session closed")
+		}
+	}
+	notify := func(method string, params interface{}) error {
+		return send(notification{JSONRPC: "2.0", Method: method, Params: params})
+	}
+	for _, req := range reqs {
+		if len(req.ID) == 0 {
+			h.server.handleNotification(req, sess.inflight)
+			continue
+		}
+		// The session outlives this POST, so tie the call to the session
+		// rather than to the request context.
+		ctx, cancel := context.WithCancel(context.Background())
+		key := sess.inflight.add(req.ID, cancel)
+		go func(req request) {
+			defer sess.inflight.done(key)
+			go func() {
+				select {
+				case <-sess.done:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+			resp := h.server.handle(ctx, req, notify)
+			if ctx.Err() == nil {
+				_ = send(resp)
+			}
+		}(req)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// readRequests decodes a single JSON-RPC message or a batch.
+func readRequests(body io.Reader) ([]request, bool, error) {
+	raw, err := io.ReadAll(io.LimitReader(body, 16*1024*1024))
+	if err != nil {
+		return nil, false, err
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var reqs []request
+		if err := json.Unmarshal(raw, &reqs); err != nil || len(reqs) == 0 {
+			return nil, true, fmt.Errorf("invalid batch")
+		}
+		return reqs, true, nil
+	}
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return nil, false, err
+	}
+	return []request{req}, false, nil
+}
+
+func hasMethod(reqs []request, method string) bool {
+	for _, req := range reqs {
+		if req.Method == method {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type sseWriter struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func newSSEWriter(w http.ResponseWriter) (*sseWriter, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, false
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return &sseWriter{w: w, flusher: flusher}, true
+}
+
+func (s *sseWriter) send(v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.event("message", raw)
+}
+
+func (s *sseWriter) event(name string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+func newSessionID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
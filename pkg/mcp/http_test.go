@@ -0,0 +1,74 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newBlockingServer returns a server with a "wait" tool that runs until its
+// call is cancelled, and a channel receiving a value when the tool starts.
+func newBlockingServer() (*Server, chan struct{}) {
+	started := make(chan struct{}, 1)
+	s := NewServer("test", "0.0.0")
+	s.AddTool(Tool{Name: "wait", InputSchema: map[string]interface{}{"type": "object"}}, func(ctx context.Context, args json.RawMessage) (*ToolResult, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	return s, started
+}
+
+func TestStreamableCancelledRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"single", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"wait"}}`},
+		{"batch", `[{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"wait"}}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, started := newBlockingServer()
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				<-started
+				cancel()
+			}()
+			req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(tt.body)).WithContext(ctx)
+			rec := httptest.NewRecorder()
+			s.HTTPHandler("").ServeHTTP(rec, req)
+			if rec.Code != http.StatusAccepted {
+				t.Errorf("got status %d, want %d", rec.Code, http.StatusAccepted)
+			}
+			if body := strings.TrimSpace(rec.Body.String()); body != "" {
+				t.Errorf("got body %q, want none", body)
+			}
+		})
+	}
+}
+
+func TestStreamableCall(t *testing.T) {
+	s := NewServer("test", "0.0.0")
+	s.AddTool(Tool{Name: "echo", InputSchema: map[string]interface{}{"type": "object"}}, func(ctx context.Context, args json.RawMessage) (*ToolResult, error) {
+		return TextResult("ok", false), nil
+	})
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo"}}`
+	rec := httptest.NewRecorder()
+	s.HTTPHandler("").ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	var resp struct {
+		Result ToolResult `json:"result"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Result.Content) != 1 || resp.Result.Content[0].Text != "ok" {
+		t.Errorf("got result %+v", resp.Result)
+	}
+}
@@ -0,0 +1,38 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type progressKey struct{}
+
+type progressReporter struct {
+	token  json.RawMessage
+	notify Notifier
+}
+
+func withProgress(ctx context.Context, token json.RawMessage, notify Notifier) context.Context {
+	return context.WithValue(ctx, progressKey{}, &progressReporter{token: token, notify: notify})
+}
+
+// ReportProgress sends a notifications/progress message for the tool call
+// running in ctx. It is a no-op when the client did not request progress.
+// total may be 0 when unknown.
+func ReportProgress(ctx context.Context, progress, total float64, message string) {
+	reporter, ok := ctx.Value(progressKey{}).(*progressReporter)
+	if !ok || reporter == nil {
+		return
+	}
+	params := map[string]interface{}{
+		"progressToken": reporter.token,
+		"progress":      progress,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+	_ = reporter.notify("notifications/progress", params)
+}
@@ -0,0 +1,419 @@
+// Package mcp implements a minimal Model Context Protocol server that
+// exposes tools and resources over JSON-RPC 2.0.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Supported protocol revisions, newest first.
+var protocolVersions = []string{"2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeNotFound       = -32002
+)
+
+// Tool describes a callable tool.
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// ToolHandler executes a tool call with raw JSON arguments.
+type ToolHandler func(ctx context.Context, args json.RawMessage) (*ToolResult, error)
+
+// Content is a single item of tool output.
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// ToolResult is returned from tools/call.
+type ToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// TextResult builds a single text content tool result.
+func TextResult(text string, isError bool) *ToolResult {
+	return &ToolResult{Content: []Content{{Type: "text", Text: text}}, IsError: isError}
+}
+
+// Resource describes a readable resource.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceTemplate describes a parameterized resource URI.
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents is the body of a read resource.
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+// ResourceProvider lists and reads resources.
+type ResourceProvider interface {
+	ListResources(ctx context.Context) ([]Resource, error)
+	ResourceTemplates() []ResourceTemplate
+	ReadResource(ctx context.Context, uri string) ([]ResourceContents, error)
+}
+
+// Error is a JSON-RPC error that handlers may return to control the code.
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Server dispatches MCP requests to registered tools and resources.
+type Server struct {
+	name      string
+	version   string
+	tools     map[string]Tool
+	handlers  map[string]ToolHandler
+	resources ResourceProvider
+}
+
+// NewServer creates a server advertising the given implementation info.
+func NewServer(name, version string) *Server {
+	return &Server{
+		name:     name,
+		version:  version,
+		tools:    map[string]Tool{},
+		handlers: map[string]ToolHandler{},
+	}
+}
+
+// AddTool registers a tool.
+func (s *Server) AddTool(tool Tool, handler ToolHandler) {
+	s.tools[tool.Name] = tool
+	s.handlers[tool.Name] = handler
+}
+
+// SetResourceProvider registers the resource provider.
+func (s *Server) SetResourceProvider(p ResourceProvider) {
+	s.resources = p
+}
+
+// Notifier sends a JSON-RPC notification to the connected client.
+type Notifier func(method string, params interface{}) error
+
+// ServeStdio reads newline-delimited JSON-RPC messages from r and writes
+// responses to w until r is exhausted or ctx is cancelled. Requests are
+// handled concurrently so that long tool calls can report progress and be
+// cancelled with notifications/cancelled.
+func (s *Server) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancelAll := context.WithCancel(ctx)
+	defer cancelAll()
+
+	var mu sync.Mutex
+	write := func(v interface{}) error {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, err = w.Write(append(raw, '\n'))
+		return err
+	}
+	notify := func(method string, params interface{}) error {
+		return write(notification{JSONRPC: "2.0", Method: method, Params: params})
+	}
+
+	inflight := newInflightRequests()
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			if err := write(parseErrorResponse()); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(req.ID) == 0 {
+			s.handleNotification(req, inflight)
+			continue
+		}
+
+		reqCtx, cancel := context.WithCancel(ctx)
+		key := inflight.add(req.ID, cancel)
+		wg.Add(1)
+		go func(req request) {
+			defer wg.Done()
+			defer inflight.done(key)
+			resp := s.handle(reqCtx, req, notify)
+			if reqCtx.Err() != nil && ctx.Err() == nil {
+				// Cancelled by the client: no response is expected.
+				return
+			}
+			_ = write(resp)
+		}(req)
+	}
+	return scanner.Err()
+}
+
+// HandleMessage processes one JSON-RPC message and returns the response, or
+// nil for notifications. notify may be nil when the transport cannot push
+// notifications.
+func (s *Server) HandleMessage(ctx context.Context, raw []byte, notify Notifier) interface{} {
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return parseErrorResponse()
+	}
+	if len(req.ID) == 0 {
+		s.handleNotification(req, nil)
+		return nil
+	}
+	return s.handle(ctx, req, notify)
+}
+
+func parseErrorResponse() response {
+	return response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: "parse error"}}
+}
+
+func (s *Server) handleNotification(req request, inflight *inflightRequests) {
+	if req.Method != "notifications/cancelled" || inflight == nil {
+		return
+	}
+	var params struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err == nil {
+		inflight.cancel(params.RequestID)
+	}
+}
+
+func (s *Server) handle(ctx context.Context, req request, notify Notifier) interface{} {
+	result, err := s.dispatch(ctx, req, notify)
+	resp := response{JSONRPC: "2.0", ID: req.ID}
+	if err != nil {
+		rpcErr, ok := err.(*Error)
+		if !ok {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+	} else {
+		resp.Result = result
+	}
+	return resp
+}
+
+type inflightRequests struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func newInflightRequests() *inflightRequests {
+	return &inflightRequests{cancels: map[string]context.CancelFunc{}}
+}
+
+func (m *inflightRequests) add(id json.RawMessage, cancel context.CancelFunc) string {
+	key := string(bytes.TrimSpace(id))
+	m.mu.Lock()
+	m.cancels[key] = cancel
+	m.mu.Unlock()
+	return key
+}
+
+func (m *inflightRequests) done(key string) {
+	m.mu.Lock()
+	if cancel, ok := m.cancels[key]; ok {
+		cancel()
+		delete(m.cancels, key)
+	}
+	m.mu.Unlock()
+}
+
+func (m *inflightRequests) cancel(id json.RawMessage) {
+	key := string(bytes.TrimSpace(id))
+	m.mu.Lock()
+	cancel, ok := m.cancels[key]
+	m.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+func (s *Server) dispatch(ctx context.Context, req request, notify Notifier) (interface{}, error) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req.Params), nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": s.toolList()}, nil
+	case "tools/call":
+		return s.callTool(ctx, req.Params, notify)
+	case "resources/list":
+		if s.resources == nil {
+			return map[string]interface{}{"resources": []Resource{}}, nil
+		}
+		resources, err := s.resources.ListResources(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if resources == nil {
+			resources = []Resource{}
+		}
+		return map[string]interface{}{"resources": resources}, nil
+	case "resources/templates/list":
+		templates := []ResourceTemplate{}
+		if s.resources != nil {
+			templates = append(templates, s.resources.ResourceTemplates()...)
+		}
+		return map[string]interface{}{"resourceTemplates": templates}, nil
+	case "resources/read":
+		return s.readResource(ctx, req.Params)
+	default:
+		return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+	}
+}
+
+func (s *Server) initialize(params json.RawMessage) interface{} {
+	var in struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	_ = json.Unmarshal(params, &in)
+	version := protocolVersions[0]
+	for _, v := range protocolVersions {
+		if v == in.ProtocolVersion {
+			version = v
+			break
+		}
+	}
+
+	capabilities := map[string]interface{}{
+		"tools": map[string]interface{}{},
+	}
+	if s.resources != nil {
+		capabilities["resources"] = map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities":    capabilities,
+		"serverInfo": map[string]string{
+			"name":    s.name,
+			"version": s.version,
+		},
+	}
+}
+
+func (s *Server) toolList() []Tool {
+	names := make([]string, 0, len(s.tools))
+	for name := range s.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tools := make([]Tool, 0, len(names))
+	for _, name := range names {
+		tools = append(tools, s.tools[name])
+	}
+	return tools
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage, notify Notifier) (interface{}, error) {
+	var in struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+		Meta      struct {
+			ProgressToken json.RawMessage `json:"progressToken"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(params, &in); err != nil {
+		return nil, &Error{Code: CodeInvalidParams, Message: "invalid tools/call params"}
+	}
+	handler, ok := s.handlers[in.Name]
+	if !ok {
+		return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("unknown tool: %s", in.Name)}
+	}
+	if len(in.Arguments) == 0 {
+		in.Arguments = json.RawMessage("{}")
+	}
+	if len(in.Meta.ProgressToken) > 0 && notify != nil {
+		ctx = withProgress(ctx, in.Meta.ProgressToken, notify)
+	}
+	result, err := handler(ctx, in.Arguments)
+	if err != nil {
+		return TextResult(err.Error(), true), nil
+	}
+	return result, nil
+}
+
+func (s *Server) readResource(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var in struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(params, &in); err != nil || in.URI == "" {
+		return nil, &Error{Code: CodeInvalidParams, Message: "invalid resources/read params"}
+	}
+	if s.resources == nil {
+		return nil, &Error{Code: CodeNotFound, Message: fmt.Sprintf("resource not found: %s", in.URI)}
+	}
+	contents, err := s.resources.ReadResource(ctx, in.URI)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"contents": contents}, nil
+}
@@ -0,0 +1,369 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severities of audit findings, from least to most severe.
+const (
+	SeverityLow      = "low"
+	SeverityModerate = "moderate"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// Severities lists the severities of audit findings, from least to most
+// severe.
+var Severities = []string{SeverityLow, SeverityModerate, SeverityHigh, SeverityCritical}
+
+// Audit tools whose JSON output ParseAuditReport reads.
+const (
+	AuditToolNpm  = "npm"
+	AuditToolPnpm = "pnpm"
+	AuditToolOSV  = "osv-scanner"
+)
+
+// auditFindingsShown is how many findings are logged when the audit fails.
+const auditFindingsShown = 10
+
+// AuditFinding is one vulnerable dependency.
+type AuditFinding struct {
+	Package  string `json:"package"`
+	Version  string `json:"version,omitempty"`
+	Severity string `json:"severity"`
+	ID       string `json:"id,omitempty"`
+	Title    string `json:"title,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// AuditReport is the result of a dependency audit. Counts holds the number
+// of findings by severity.
+type AuditReport struct {
+	Tool     string         `json:"tool"`
+	Counts   map[string]int `json:"counts"`
+	Findings []AuditFinding `json:"findings"`
+}
+
+// Summary lists the non-zero counts, most severe first.
+func (r *AuditReport) Summary() string {
+	var parts []string
+	for i := len(Severities) - 1; i >= 0; i-- {
+		if n := r.Counts[Severities[i]]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, Severities[i]))
+		}
+	}
+	if len(parts) == 0 {
+		return "no vulnerabilities"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// VulnerabilitiesFoundError reports findings at or above the audit
+// threshold, most severe first.
+type VulnerabilitiesFoundError struct {
+	Tool      string         `json:"tool"`
+	Threshold string         `json:"threshold"`
+	Counts    map[string]int `json:"counts"`
+	Findings  []AuditFinding `json:"findings"`
+}
+
+func (e *VulnerabilitiesFoundError) Error() string {
+	return fmt.Sprintf("%s found %d vulnerable package(s) of %s severity or higher", e.Tool, len(e.Findings), e.Threshold)
+}
+
+// Auditor audits the dependencies of d.ProjectPath.
+type Auditor interface {
+	Audit(ctx context.Context, d *Deploy) (*AuditReport, error)
+}
+
+// Audit fails the deploy when the project's dependencies have known
+// vulnerabilities of Threshold severity or higher. It runs before anything
+// reaches the server.
+type Audit struct {
+	Auditor   Auditor
+	Threshold string
+}
+
+func (Audit) Name() string { return StepAudit }
+
+func (s Audit) Skip(d *Deploy) bool { return s.Auditor == nil }
+
+func (s Audit) Run(ctx context.Context, d *Deploy) error {
+	report, err := s.Auditor.Audit(ctx, d)
+	if err != nil {
+		return err
+	}
+	d.AuditReport = report
+	result := &VulnerabilitiesFoundError{Tool: report.Tool, Threshold: s.Threshold, Counts: report.Counts, Findings: []AuditFinding{}}
+	for _, f := range report.Findings {
+		if severityRank(f.Severity) >= severityRank(s.Threshold) {
+			result.Findings = append(result.Findings, f)
+		}
+	}
+	if len(report.Findings) == 0 {
+		d.Logf(LevelSuccess, "Dependency audit (%s): no vulnerabilities", report.Tool)
+		return nil
+	}
+	if len(result.Findings) == 0 {
+		d.Logf(LevelSuccess, "Dependency audit (%s): %s, none of %s severity or higher", report.Tool, report.Summary(), s.Threshold)
+		return nil
+	}
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		return severityRank(result.Findings[i].Severity) > severityRank(result.Findings[j].Severity)
+	})
+	d.Logf(LevelError, "Dependency audit (%s): %s", report.Tool, report.Summary())
+	for i, f := range result.Findings {
+		if i == auditFindingsShown {
+			d.Logf(LevelError, "... and %d more", len(result.Findings)-auditFindingsShown)
+			break
+		}
+		d.Logf(LevelError, "%s %s: %s", f.Severity, strings.TrimSpace(f.Package+" "+f.Version), firstNonEmpty(f.Title, f.ID))
+	}
+	return result
+}
+
+// ValidSeverity reports whether s is one of Severities.
+func ValidSeverity(s string) bool {
+	return severityRank(s) >= 0
+}
+
+func severityRank(s string) int {
+	for i, severity := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// normalizeSeverity maps the severity names of the audit tools onto
+// Severities; unknown names, like npm's "info", map to low.
+func normalizeSeverity(s string) string {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "critical":
+		return SeverityCritical
+	case "high":
+		return SeverityHigh
+	case "moderate", "medium":
+		return SeverityModerate
+	}
+	return SeverityLow
+}
+
+// cvssSeverity maps a CVSS base score onto Severities.
+func cvssSeverity(score float64) string {
+	switch {
+	case score >= 9:
+		return SeverityCritical
+	case score >= 7:
+		return SeverityHigh
+	case score >= 4:
+		return SeverityModerate
+	}
+	return SeverityLow
+}
+
+// ParseAuditReport reads the JSON output of npm audit, pnpm audit or
+// osv-scanner, telling them apart by their shape.
+func ParseAuditReport(data []byte) (*AuditReport, error) {
+	var probe struct {
+		Vulnerabilities json.RawMessage `json:"vulnerabilities"`
+		Advisories      json.RawMessage `json:"advisories"`
+		Results         json.RawMessage `json:"results"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("audit output is not JSON: %w", err)
+	}
+	var report *AuditReport
+	var err error
+	switch {
+	case probe.Vulnerabilities != nil:
+		report, err = parseNpmAudit(data)
+	case probe.Advisories != nil:
+		report, err = parseAdvisoriesAudit(data)
+	case probe.Results != nil:
+		report, err = parseOSVScanner(data)
+	default:
+		return nil, fmt.Errorf("unrecognized audit output: expected npm audit, pnpm audit or osv-scanner JSON")
+	}
+	if err != nil {
+		return nil, err
+	}
+	report.Counts = map[string]int{}
+	for _, severity := range Severities {
+		report.Counts[severity] = 0
+	}
+	for _, f := range report.Findings {
+		report.Counts[f.Severity]++
+	}
+	return report, nil
+}
+
+// parseNpmAudit reads the output of npm audit --json from npm 7 on, which
+// lists vulnerable packages with the advisories they are affected by.
+func parseNpmAudit(data []byte) (*AuditReport, error) {
+	var out struct {
+		Vulnerabilities map[string]struct {
+			Name     string            `json:"name"`
+			Severity string            `json:"severity"`
+			Range    string            `json:"range"`
+			Via      []json.RawMessage `json:"via"`
+		} `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse npm audit output: %w", err)
+	}
+	report := &AuditReport{Tool: AuditToolNpm, Findings: []AuditFinding{}}
+	names := make([]string, 0, len(out.Vulnerabilities))
+	for name := range out.Vulnerabilities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v := out.Vulnerabilities[name]
+		finding := AuditFinding{Package: firstNonEmpty(v.Name, name), Version: v.Range, Severity: normalizeSeverity(v.Severity)}
+		// via names either advisories or the vulnerable dependencies that
+		// make this package vulnerable.
+		for _, raw := range v.Via {
+			var advisory struct {
+				Source json.Number `json:"source"`
+				Title  string      `json:"title"`
+				URL    string      `json:"url"`
+			}
+			if json.Unmarshal(raw, &advisory) == nil && advisory.Title != "" {
+				finding.ID = firstNonEmpty(advisoryID(advisory.URL), advisory.Source.String())
+				finding.Title = advisory.Title
+				finding.URL = advisory.URL
+				break
+			}
+			var dependency string
+			if json.Unmarshal(raw, &dependency) == nil && finding.Title == "" {
+				finding.Title = "via " + dependency
+			}
+		}
+		report.Findings = append(report.Findings, finding)
+	}
+	return report, nil
+}
+
+// parseAdvisoriesAudit reads the advisories format of pnpm audit --json and
+// npm audit --json before npm 7.
+func parseAdvisoriesAudit(data []byte) (*AuditReport, error) {
+	var out struct {
+		Advisories map[string]struct {
+			ID               json.Number `json:"id"`
+			ModuleName       string      `json:"module_name"`
+			Severity         string      `json:"severity"`
+			Title            string      `json:"title"`
+			URL              string      `json:"url"`
+			GithubAdvisoryID string      `json:"github_advisory_id"`
+			Findings         []struct {
+				Version string `json:"version"`
+			} `json:"findings"`
+		} `json:"advisories"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse pnpm audit output: %w", err)
+	}
+	report := &AuditReport{Tool: AuditToolPnpm, Findings: []AuditFinding{}}
+	keys := make([]string, 0, len(out.Advisories))
+	for key := range out.Advisories {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		a := out.Advisories[key]
+		var versions []string
+		for _, f := range a.Findings {
+			if f.Version != "" {
+				versions = append(versions, f.Version)
+			}
+		}
+		report.Findings = append(report.Findings, AuditFinding{
+			Package:  a.ModuleName,
+			Version:  strings.Join(versions, ", "),
+			Severity: normalizeSeverity(a.Severity),
+			ID:       firstNonEmpty(a.GithubAdvisoryID, advisoryID(a.URL), a.ID.String()),
+			Title:    a.Title,
+			URL:      a.URL,
+		})
+	}
+	return report, nil
+}
+
+// parseOSVScanner reads the output of osv-scanner --format json. Aliases of
+// one vulnerability, e.g. a GHSA and a CVE, are grouped into one finding.
+func parseOSVScanner(data []byte) (*AuditReport, error) {
+	type vulnerability struct {
+		ID               string `json:"id"`
+		Summary          string `json:"summary"`
+		DatabaseSpecific struct {
+			Severity string `json:"severity"`
+		} `json:"database_specific"`
+	}
+	var out struct {
+		Results []struct {
+			Packages []struct {
+				Package struct {
+					Name    string `json:"name"`
+					Version string `json:"version"`
+				} `json:"package"`
+				Vulnerabilities []vulnerability `json:"vulnerabilities"`
+				Groups          []struct {
+					IDs         []string `json:"ids"`
+					MaxSeverity string   `json:"max_severity"`
+				} `json:"groups"`
+			} `json:"packages"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse osv-scanner output: %w", err)
+	}
+	report := &AuditReport{Tool: AuditToolOSV, Findings: []AuditFinding{}}
+	for _, result := range out.Results {
+		for _, pkg := range result.Packages {
+			vulns := map[string]vulnerability{}
+			for _, v := range pkg.Vulnerabilities {
+				vulns[v.ID] = v
+			}
+			finding := func(id string) AuditFinding {
+				return AuditFinding{Package: pkg.Package.Name, Version: pkg.Package.Version, ID: id, Title: vulns[id].Summary, URL: "https://osv.dev/" + id}
+			}
+			if len(pkg.Groups) == 0 {
+				for _, v := range pkg.Vulnerabilities {
+					f := finding(v.ID)
+					f.Severity = normalizeSeverity(v.DatabaseSpecific.Severity)
+					report.Findings = append(report.Findings, f)
+				}
+				continue
+			}
+			for _, group := range pkg.Groups {
+				if len(group.IDs) == 0 {
+					continue
+				}
+				f := finding(group.IDs[0])
+				if score, err := strconv.ParseFloat(group.MaxSeverity, 64); err == nil {
+					f.Severity = cvssSeverity(score)
+				} else {
+					f.Severity = normalizeSeverity(vulns[group.IDs[0]].DatabaseSpecific.Severity)
+				}
+				report.Findings = append(report.Findings, f)
+			}
+		}
+	}
+	return report, nil
+}
+
+// advisoryID returns the GHSA ID at the end of a GitHub advisory URL.
+func advisoryID(url string) string {
+	id := url[strings.LastIndex(url, "/")+1:]
+	if strings.HasPrefix(id, "GHSA-") {
+		return id
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+package pipeline
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+)
+
+// Budgets limits the size of the build output, in bytes. Zero disables a
+// limit.
+type Budgets struct {
+	Total   int64 `json:"total,omitempty"`
+	MaxJS   int64 `json:"max_js,omitempty"`
+	MaxCSS  int64 `json:"max_css,omitempty"`
+	MaxFile int64 `json:"max_file,omitempty"`
+}
+
+// IsZero reports whether no limit is set.
+func (b Budgets) IsZero() bool {
+	return b == Budgets{}
+}
+
+// BudgetViolation is one exceeded budget. Path is empty for the total.
+type BudgetViolation struct {
+	Budget string `json:"budget"`
+	Path   string `json:"path,omitempty"`
+	Size   int64  `json:"size"`
+	Limit  int64  `json:"limit"`
+}
+
+// BudgetFile is a file of the build output with its size.
+type BudgetFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// BudgetExceededError reports a build output over its size budgets, with the
+// largest files as a breakdown.
+type BudgetExceededError struct {
+	TotalSize    int64             `json:"total_size"`
+	Violations   []BudgetViolation `json:"violations"`
+	LargestFiles []BudgetFile      `json:"largest_files"`
+}
+
+func (e *BudgetExceededError) Error() string {
+	v := e.Violations[0]
+	msg := fmt.Sprintf("%s is %s, over the %s budget of %s", firstNonEmpty(v.Path, "build output"), FormatBytes(v.Size), v.Budget, FormatBytes(v.Limit))
+	if len(e.Violations) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e.Violations)-1)
+	}
+	return msg
+}
+
+// budgetLargestFiles is how many files the breakdown lists.
+const budgetLargestFiles = 10
+
+// CheckBudgets compares the build output with size budgets: the files of the
+// artifact archive for new builds, the artifact manifest for reused ones.
+type CheckBudgets struct {
+	Budgets Budgets
+}
+
+func (CheckBudgets) Name() string { return StepCheckBudgets }
+
+func (s CheckBudgets) Skip(d *Deploy) bool { return s.Budgets.IsZero() }
+
+func (s CheckBudgets) Run(ctx context.Context, d *Deploy) error {
+	var files []BudgetFile
+	if d.Reused {
+		manifest, err := d.Client.GetBuildManifest(d.Build.BuildID)
+		if client.IsNotFound(err) {
+			d.Logf(LevelWarn, "Size budgets not checked: the server has no artifact manifest for reused build %s", d.Build.BuildID)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(manifest.Files) == 0 {
+			d.Logf(LevelWarn, "Size budgets not checked: the artifact manifest of reused build %s lists no files", d.Build.BuildID)
+			return nil
+		}
+		for _, f := range manifest.Files {
+			files = append(files, BudgetFile{Path: f.Path, Size: f.Size})
+		}
+	} else {
+		var err error
+		if files, err = archiveFiles(d.ArtifactArchive); err != nil {
+			return err
+		}
+	}
+
+	result := s.Budgets.check(files)
+	d.Logf(LevelInfo, "Build output: %s in %d file(s)", FormatBytes(result.TotalSize), len(files))
+	if len(result.Violations) == 0 {
+		d.Logf(LevelSuccess, "Size budgets met")
+		return nil
+	}
+	for _, v := range result.Violations {
+		d.Logf(LevelError, "%s budget exceeded: %s is %s (limit %s)", v.Budget, firstNonEmpty(v.Path, "build output"), FormatBytes(v.Size), FormatBytes(v.Limit))
+	}
+	return result
+}
+
+func (b Budgets) check(files []BudgetFile) *BudgetExceededError {
+	result := &BudgetExceededError{Violations: []BudgetViolation{}}
+	for _, f := range files {
+		result.TotalSize += f.Size
+		ext := strings.ToLower(filepath.Ext(f.Path))
+		switch {
+		case b.MaxJS > 0 && (ext == ".js" || ext == ".mjs" || ext == ".cjs") && f.Size > b.MaxJS:
+			result.Violations = append(result.Violations, BudgetViolation{Budget: "max_js", Path: f.Path, Size: f.Size, Limit: b.MaxJS})
+		case b.MaxCSS > 0 && ext == ".css" && f.Size > b.MaxCSS:
+			result.Violations = append(result.Violations, BudgetViolation{Budget: "max_css", Path: f.Path, Size: f.Size, Limit: b.MaxCSS})
+		case b.MaxFile > 0 && f.Size > b.MaxFile:
+			result.Violations = append(result.Violations, BudgetViolation{Budget: "max_file", Path: f.Path, Size: f.Size, Limit: b.MaxFile})
+		}
+	}
+	if b.Total > 0 && result.TotalSize > b.Total {
+		result.Violations = append([]BudgetViolation{{Budget: "total", Size: result.TotalSize, Limit: b.Total}}, result.Violations...)
+	}
+
+	sorted := append([]BudgetFile(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Size > sorted[j].Size })
+	if len(sorted) > budgetLargestFiles {
+		sorted = sorted[:budgetLargestFiles]
+	}
+	result.LargestFiles = sorted
+	return result
+}
+
+// archiveFiles lists the files of a zip archive with their uncompressed
+// sizes, so budgets apply to what is uploaded rather than the whole output
+// directory.
+func archiveFiles(archive string) ([]BudgetFile, error) {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact archive: %w", err)
+	}
+	defer r.Close()
+	var files []BudgetFile
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		files = append(files, BudgetFile{Path: f.Name, Size: int64(f.UncompressedSize64)})
+	}
+	return files, nil
+}
+
+// FormatBytes renders a size with a binary unit, e.g. "1.5 MB".
+func FormatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.2f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.2f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,63 @@
+package pipeline
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+)
+
+// ArchiveDigest returns a digest of the files in a zip archive. It covers
+// entry names and contents only, so archives of identical source produce the
+// same digest regardless of timestamps or entry order.
+func ArchiveDigest(path string) (string, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	files := make([]*zip.File, 0, len(reader.File))
+	for _, f := range reader.File {
+		if !f.FileInfo().IsDir() {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	h := sha256.New()
+	for _, f := range files {
+		rc, err := f.Open()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", f.Name, f.UncompressedSize64)
+		_, err = io.Copy(h, rc)
+		rc.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// IdempotencyKey derives the idempotency key of a source upload from the
+// project, the source digest and the commit fields sent with it, so retries
+// of the same deploy share a key while a new version label or changed
+// routes get a commit of their own. The key identifies the source, not the
+// attempt: UploadSource leaves it out when the build for that source failed.
+func IdempotencyKey(projectID, digest string, version *client.BuildVersionInput, routes []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", projectID, digest)
+	if version != nil {
+		fmt.Fprintf(h, "%s\x00%s\x00", version.VersionLabel, version.SourceRef)
+	} else {
+		fmt.Fprint(h, "\x00\x00")
+	}
+	h.Write(routes)
+	return "robotx-source-" + hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"context"
+	"sort"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+)
+
+// Env change actions.
+const (
+	EnvAdd    = "add"
+	EnvChange = "change"
+	EnvRemove = "remove"
+)
+
+// EnvVarChange is a change needed to bring the environment of a channel in
+// line with the desired variables. Value is the desired value; the current
+// value, which may be a secret set elsewhere, is never reported.
+type EnvVarChange struct {
+	Key    string `json:"key"`
+	Action string `json:"action"`
+	Value  string `json:"value,omitempty"`
+}
+
+// DiffEnv returns the changes that turn current into desired, by key.
+// Variables missing from desired are removed.
+func DiffEnv(current, desired map[string]string) []EnvVarChange {
+	var changes []EnvVarChange
+	for key, value := range desired {
+		old, ok := current[key]
+		switch {
+		case !ok:
+			changes = append(changes, EnvVarChange{Key: key, Action: EnvAdd, Value: value})
+		case old != value:
+			changes = append(changes, EnvVarChange{Key: key, Action: EnvChange, Value: value})
+		}
+	}
+	for key := range current {
+		if _, ok := desired[key]; !ok {
+			changes = append(changes, EnvVarChange{Key: key, Action: EnvRemove})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// SyncChannelEnv makes the environment of a channel of a project exactly
+// env and returns the changes made.
+func SyncChannelEnv(c *client.Client, projectID, channel string, env map[string]string) ([]EnvVarChange, error) {
+	current, err := c.GetChannelEnv(projectID, channel)
+	if err != nil {
+		return nil, err
+	}
+	changes := DiffEnv(current, env)
+	set := map[string]string{}
+	for _, change := range changes {
+		if change.Action != EnvRemove {
+			set[change.Key] = change.Value
+		}
+	}
+	if len(set) > 0 {
+		if err := c.SetChannelEnv(projectID, channel, set); err != nil {
+			return nil, err
+		}
+	}
+	for _, change := range changes {
+		if change.Action == EnvRemove {
+			if err := c.DeleteChannelEnv(projectID, channel, change.Key); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return changes, nil
+}
+
+// CountEnvChanges counts the changes by action.
+func CountEnvChanges(changes []EnvVarChange) (added, changed, removed int) {
+	for _, change := range changes {
+		switch change.Action {
+		case EnvAdd:
+			added++
+		case EnvChange:
+			changed++
+		case EnvRemove:
+			removed++
+		}
+	}
+	return added, changed, removed
+}
+
+// SyncEnv makes the runtime environment of a channel match Env, the
+// variables the project declares for it: preview before the build is
+// created, production before publishing. Variables set on the server but
+// not in Env are removed.
+type SyncEnv struct {
+	Channel string
+	Env     map[string]string
+}
+
+func (s SyncEnv) Name() string {
+	if s.Channel == client.EnvChannelPreview {
+		return StepSyncPreviewEnv
+	}
+	return StepSyncProductionEnv
+}
+
+func (s SyncEnv) Run(ctx context.Context, d *Deploy) error {
+	changes, err := SyncChannelEnv(d.Client, d.Project.ProjectID, s.Channel, s.Env)
+	if err != nil {
+		return err
+	}
+	if d.EnvChanges == nil {
+		d.EnvChanges = map[string][]EnvVarChange{}
+	}
+	d.EnvChanges[s.Channel] = changes
+	if len(changes) == 0 {
+		d.Logf(LevelInfo, "The %s env is up to date (%d variables)", s.Channel, len(s.Env))
+		return nil
+	}
+	added, changed, removed := CountEnvChanges(changes)
+	d.Logf(LevelSuccess, "Synced %s env: %d added, %d changed, %d removed", s.Channel, added, changed, removed)
+	return nil
+}
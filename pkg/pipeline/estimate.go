@@ -0,0 +1,47 @@
+package pipeline
+
+import (
+	"sort"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+)
+
+// estimateSamples is how many recent builds of the project are consulted
+// for the expected build duration.
+const estimateSamples = 20
+
+// estimateBuildDuration returns the median duration of the successful builds
+// among builds, other than the build with ID exclude, or zero when none of
+// them has both timestamps.
+func estimateBuildDuration(builds []*client.Build, exclude string) time.Duration {
+	var durations []time.Duration
+	for _, b := range builds {
+		if b == nil || b.BuildID == exclude || b.Status != client.BuildSuccess {
+			continue
+		}
+		if b.FinishedAt == nil || b.CreatedAt.IsZero() || !b.FinishedAt.After(b.CreatedAt) {
+			continue
+		}
+		durations = append(durations, b.FinishedAt.Sub(b.CreatedAt))
+	}
+	if len(durations) == 0 {
+		return 0
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	mid := len(durations) / 2
+	if len(durations)%2 == 0 {
+		return (durations[mid-1] + durations[mid]) / 2
+	}
+	return durations[mid]
+}
+
+// buildEstimate looks up the expected duration of the deploy's build from
+// the project's previous builds. Failures only disable the estimate.
+func buildEstimate(d *Deploy) time.Duration {
+	builds, err := d.Client.ListBuildsForProject(d.Project.ProjectID, estimateSamples)
+	if err != nil {
+		return 0
+	}
+	return estimateBuildDuration(builds, d.Build.BuildID)
+}
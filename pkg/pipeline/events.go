@@ -0,0 +1,68 @@
+package pipeline
+
+import "time"
+
+// EventType identifies what an Event reports.
+type EventType string
+
+const (
+	EventStepStarted  EventType = "step_started"
+	EventStepFinished EventType = "step_finished"
+	EventStepFailed   EventType = "step_failed"
+	EventStepSkipped  EventType = "step_skipped"
+	EventLog          EventType = "log"
+	EventProgress     EventType = "progress"
+	EventURL          EventType = "url"
+)
+
+// Level classifies log events.
+type Level string
+
+const (
+	LevelInfo    Level = "info"
+	LevelSuccess Level = "success"
+	LevelWarn    Level = "warn"
+	LevelError   Level = "error"
+)
+
+// Event is emitted while a pipeline runs. Progress events carry the bytes
+// sent of an upload; URL events carry the URL in Message and its kind
+// ("preview" or "production") in Name. Build status logs carry the
+// estimated seconds until the build finishes when the project has earlier
+// successful builds to estimate from.
+type Event struct {
+	Type                      EventType `json:"type"`
+	Step                      string    `json:"step"`
+	Level                     Level     `json:"level,omitempty"`
+	Name                      string    `json:"name,omitempty"`
+	Message                   string    `json:"message,omitempty"`
+	Sent                      int64     `json:"sent,omitempty"`
+	Total                     int64     `json:"total,omitempty"`
+	EstimatedRemainingSeconds *int      `json:"estimated_remaining_seconds,omitempty"`
+	Err                       error     `json:"-"`
+	Time                      time.Time `json:"time"`
+}
+
+// Emitter receives pipeline events. Emit is called from the goroutine running
+// the pipeline.
+type Emitter interface {
+	Emit(Event)
+}
+
+// EmitterFunc adapts a function to the Emitter interface.
+type EmitterFunc func(Event)
+
+func (f EmitterFunc) Emit(e Event) {
+	f(e)
+}
+
+// Emitters fans events out to several emitters.
+type Emitters []Emitter
+
+func (m Emitters) Emit(e Event) {
+	for _, emitter := range m {
+		if emitter != nil {
+			emitter.Emit(e)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+)
+
+// Defaults of AcquireLock.
+const (
+	defaultLockTTL      = 5 * time.Minute
+	defaultLockInterval = 5 * time.Second
+)
+
+// maxLockRenewalFailures is how many renewals in a row may fail before the
+// lock is given up for lost. Renewals run every third of the TTL, so by
+// then the lock has expired on the server.
+const maxLockRenewalFailures = 3
+
+// ErrLockLost is the cause the pipeline is cancelled with when the deploy
+// lock can no longer be renewed, so another deploy may take the project.
+var ErrLockLost = errors.New("deploy lock lost")
+
+// AcquireLock takes the project's deploy lock so that concurrent deploys of
+// the same project run one after the other. While another deploy holds the
+// lock it retries for up to Timeout. The lock is renewed in the background
+// until the caller releases it with Deploy.ReleaseLock, and expires on the
+// server if the deploy dies without releasing it. Failed renewals are
+// logged as warnings; when they keep failing the deploy is cancelled with
+// ErrLockLost.
+type AcquireLock struct {
+	// Holder describes this deploy to others waiting for the lock.
+	Holder  string
+	Timeout time.Duration
+	// TTL is how long the lock lasts without renewal; Interval is how often
+	// a held lock is retried.
+	TTL      time.Duration
+	Interval time.Duration
+}
+
+func (AcquireLock) Name() string { return StepLock }
+
+func (s AcquireLock) Run(ctx context.Context, d *Deploy) error {
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = defaultLockTTL
+	}
+	interval := s.Interval
+	if interval <= 0 {
+		interval = defaultLockInterval
+	}
+	deadline := time.Now().Add(s.Timeout)
+	waiting := false
+	for {
+		lock, err := d.Client.AcquireDeployLock(d.Project.ProjectID, s.Holder, ttl)
+		if err == nil {
+			d.lock = startLockRenewal(d, lock, ttl)
+			d.Logf(LevelSuccess, "Deploy lock acquired")
+			return nil
+		}
+		var locked *client.DeployLockedError
+		if !errors.As(err, &locked) {
+			return err
+		}
+		if !time.Now().Before(deadline) {
+			if s.Timeout > 0 {
+				return fmt.Errorf("gave up after waiting %s: %w", s.Timeout, err)
+			}
+			return err
+		}
+		if !waiting {
+			d.Logf(LevelInfo, "%s; waiting up to %s for it to be released", locked.Error(), s.Timeout)
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(min(interval, time.Until(deadline))):
+		}
+	}
+}
+
+// heldLock is a deploy lock renewed in the background until released.
+type heldLock struct {
+	client    *client.Client
+	projectID string
+	lock      *client.DeployLock
+	stop      context.CancelFunc
+	done      sync.WaitGroup
+}
+
+func startLockRenewal(d *Deploy, lock *client.DeployLock, ttl time.Duration) *heldLock {
+	ctx, stop := context.WithCancel(context.Background())
+	h := &heldLock{client: d.Client, projectID: d.Project.ProjectID, lock: lock, stop: stop}
+	h.done.Add(1)
+	go func() {
+		defer h.done.Done()
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		failures := 0
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			_, err := h.client.RenewDeployLock(h.projectID, lock.LockID, ttl)
+			if err == nil {
+				failures = 0
+				continue
+			}
+			// A failed renewal is retried on the next tick; the lock only
+			// lapses if renewals keep failing for the whole TTL. The
+			// warning is emitted directly: Deploy.Warnings belongs to the
+			// steps.
+			failures++
+			d.emit(Event{Type: EventLog, Level: LevelWarn, Message: fmt.Sprintf("Deploy lock renewal failed (%d/%d): %v", failures, maxLockRenewalFailures, err)})
+			if failures >= maxLockRenewalFailures {
+				d.cancel(fmt.Errorf("%w: %d renewals in a row failed: %w", ErrLockLost, failures, err))
+				return
+			}
+		}
+	}()
+	return h
+}
+
+// ReleaseLock releases the deploy lock taken by AcquireLock, if any. It is
+// safe to call when no lock was taken.
+func (d *Deploy) ReleaseLock() error {
+	h := d.lock
+	if h == nil {
+		return nil
+	}
+	d.lock = nil
+	h.stop()
+	h.done.Wait()
+	return h.client.ReleaseDeployLock(h.projectID, h.lock.LockID)
+}
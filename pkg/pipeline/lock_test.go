@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+)
+
+// waitStep blocks until the deploy is cancelled.
+type waitStep struct{}
+
+func (waitStep) Name() string { return "wait" }
+
+func (waitStep) Run(ctx context.Context, d *Deploy) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(5 * time.Second):
+		return errors.New("deploy was not cancelled")
+	}
+}
+
+func TestLockRenewalFailuresCancelDeploy(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/projects/proj_1/lock", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"lock_id":"l1"}`))
+	})
+	mux.HandleFunc("PUT /api/projects/proj_1/lock/l1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"lock not found"}`))
+	})
+	mux.HandleFunc("DELETE /api/projects/proj_1/lock/l1", func(w http.ResponseWriter, r *http.Request) {})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var mu sync.Mutex
+	var warnings []string
+	emitter := EmitterFunc(func(e Event) {
+		if e.Type == EventLog && e.Level == LevelWarn {
+			mu.Lock()
+			warnings = append(warnings, e.Message)
+			mu.Unlock()
+		}
+	})
+	d := &Deploy{
+		Client:  client.NewClient(server.URL, "test-key"),
+		Project: &client.Project{ProjectID: "proj_1"},
+	}
+	err := New(emitter, AcquireLock{TTL: 30 * time.Millisecond}, waitStep{}).Run(context.Background(), d)
+	_ = d.ReleaseLock()
+
+	var stepErr *StepError
+	if !errors.As(err, &stepErr) || stepErr.Step != "wait" || !errors.Is(err, ErrLockLost) {
+		t.Fatalf("got error %v, want ErrLockLost in the wait step", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(warnings) != maxLockRenewalFailures {
+		t.Errorf("got %d renewal warnings %q, want %d", len(warnings), warnings, maxLockRenewalFailures)
+	}
+}
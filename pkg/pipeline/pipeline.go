@@ -0,0 +1,243 @@
+// Package pipeline runs a RobotX deploy as a sequence of steps: resolve the
+// project, package and upload the source, build locally, package and upload
+// the artifacts, wait for the build, and publish. Steps report what they do
+// through an Emitter so every front end (CLI, MCP, daemon) shows the same
+// progress.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+)
+
+// Step is one stage of a deploy.
+type Step interface {
+	Name() string
+	Run(ctx context.Context, d *Deploy) error
+}
+
+// Skipper is implemented by steps that are not needed for some deploys.
+type Skipper interface {
+	Skip(d *Deploy) bool
+}
+
+// URLResolver derives the user-facing URLs of a deployed project.
+type URLResolver interface {
+	PreviewURL(project *client.Project, build *client.Build) string
+	ProductionURL(project *client.Project) string
+}
+
+// Deploy is the state shared by the steps of one deploy: the inputs set by
+// the caller and the results filled in by the steps.
+type Deploy struct {
+	Client      *client.Client
+	URLs        URLResolver
+	ProjectPath string
+	ProjectName string
+	Visibility  string
+	Version     *client.BuildVersionInput
+	// Routes holds the project's headers, redirects and rewrites as JSON,
+	// uploaded with the commit for the runtime to apply.
+	Routes []byte
+	// FunctionsDir is the directory of serverless functions deployed with
+	// the build, relative to ProjectPath, or empty for static-only projects.
+	FunctionsDir string
+	// Force disables reuse of an existing build for unchanged source.
+	Force bool
+	// PreviousDigest and PreviousBuildID describe the last deploy of this
+	// directory, if known. ReuseBuild falls back to them when the server
+	// cannot report the project's latest commit.
+	PreviousDigest  string
+	PreviousBuildID string
+	// SourceStream, when set, is uploaded as the source archive instead of
+	// SourceArchive, in the SourceFormat archive format ("zip" or "tar.gz").
+	SourceStream io.Reader
+	SourceFormat string
+
+	Project          *client.Project
+	Commit           *client.SourceCommit
+	Build            *client.Build
+	SourceArchive    string
+	SourceDigest     string
+	ArtifactArchive  string
+	FunctionsArchive string
+	// Archive sizes in bytes, set by the packaging steps.
+	SourceArchiveSize    int64
+	ArtifactArchiveSize  int64
+	FunctionsArchiveSize int64
+	// Functions lists the function endpoints deployed with the build.
+	Functions []*client.FunctionEndpoint
+	// Reused is set when the source matched the project's latest commit and
+	// its successful build was reused instead of building again.
+	Reused bool
+	// Deduplicated is set when the server recognized the source upload as a
+	// repeat of an earlier one by its idempotency key and returned that
+	// upload's commit and build.
+	Deduplicated  bool
+	PreviewURL    string
+	ProductionURL string
+	// EnvChanges holds the changes SyncEnv made, by channel.
+	EnvChanges map[string][]EnvVarChange
+	// SmokeResults holds the checks run by SmokeTest.
+	SmokeResults []SmokeResult
+	// AuditReport holds the findings of Audit.
+	AuditReport *AuditReport
+	// StepDurations holds how long each step that ran took, by step name.
+	StepDurations map[string]time.Duration
+	// Warnings collects the messages logged at LevelWarn. With
+	// FailOnWarning the pipeline stops after the step that produced one.
+	Warnings      []string
+	FailOnWarning bool
+
+	// mu serializes events: the lock renewal emits from its own goroutine.
+	mu      sync.Mutex
+	emitter Emitter
+	step    string
+	// cancel stops the running pipeline with a cause, such as ErrLockLost.
+	cancel context.CancelCauseFunc
+	lock   *heldLock
+	// rebuild is set by ReuseBuild when the source matches a commit whose
+	// build failed. UploadSource then sends no idempotency key, which would
+	// only return that build again.
+	rebuild bool
+	// requestedVersion is Version as the caller set it, before
+	// GenerateVersionLabel filled in the label.
+	requestedVersion *client.BuildVersionInput
+}
+
+// Plan returns the build plan detected by the server, if any.
+func (d *Deploy) Plan() *client.BuildPlan {
+	if d.Commit == nil || d.Commit.ScannerResult == nil {
+		return nil
+	}
+	return d.Commit.ScannerResult.BuildPlan
+}
+
+// keyVersion returns the version fields the idempotency key is derived
+// from: those the caller asked for, so a retry with a generated label still
+// matches the first attempt.
+func (d *Deploy) keyVersion() *client.BuildVersionInput {
+	if d.requestedVersion != nil {
+		return d.requestedVersion
+	}
+	return d.Version
+}
+
+// Logf emits a log event for the running step.
+func (d *Deploy) Logf(level Level, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if level == LevelWarn {
+		d.Warnings = append(d.Warnings, message)
+	}
+	d.emit(Event{Type: EventLog, Level: level, Message: message})
+}
+
+// Progress emits an upload progress event for the running step.
+func (d *Deploy) Progress(sent, total int64) {
+	d.emit(Event{Type: EventProgress, Sent: sent, Total: total})
+}
+
+// URL emits a URL event for the running step.
+func (d *Deploy) URL(name, url string) {
+	if url != "" {
+		d.emit(Event{Type: EventURL, Name: name, Message: url})
+	}
+}
+
+func (d *Deploy) emit(e Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.emitter == nil {
+		return
+	}
+	if e.Step == "" {
+		e.Step = d.step
+	}
+	e.Time = time.Now()
+	d.emitter.Emit(e)
+}
+
+// StepError reports the step a deploy failed in.
+type StepError struct {
+	Step string
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Step, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// WarningsError stops a deploy with FailOnWarning set.
+type WarningsError struct {
+	Warnings []string
+}
+
+func (e *WarningsError) Error() string {
+	return fmt.Sprintf("%d warning(s) with warnings treated as errors: %s", len(e.Warnings), e.Warnings[0])
+}
+
+// Pipeline runs steps in order, stopping at the first error.
+type Pipeline struct {
+	Steps   []Step
+	Emitter Emitter
+}
+
+func (d *Deploy) setStep(name string) {
+	d.mu.Lock()
+	d.step = name
+	d.mu.Unlock()
+}
+
+// New returns a pipeline running steps and reporting to emitter.
+func New(emitter Emitter, steps ...Step) *Pipeline {
+	return &Pipeline{Steps: steps, Emitter: emitter}
+}
+
+// Run executes the steps against d. Errors are wrapped in *StepError.
+func (p *Pipeline) Run(ctx context.Context, d *Deploy) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	d.cancel = cancel
+	d.emitter = p.Emitter
+	if d.StepDurations == nil {
+		d.StepDurations = map[string]time.Duration{}
+	}
+	defer d.setStep("")
+	for _, step := range p.Steps {
+		d.setStep(step.Name())
+		if ctx.Err() != nil {
+			return &StepError{Step: d.step, Err: context.Cause(ctx)}
+		}
+		if skipper, ok := step.(Skipper); ok && skipper.Skip(d) {
+			d.emit(Event{Type: EventStepSkipped})
+			continue
+		}
+		d.emit(Event{Type: EventStepStarted})
+		start := time.Now()
+		err := step.Run(ctx, d)
+		d.StepDurations[d.step] += time.Since(start)
+		if err == nil && d.FailOnWarning && len(d.Warnings) > 0 {
+			err = &WarningsError{Warnings: d.Warnings}
+		}
+		// The step failed because the deploy lock was lost under it.
+		if cause := context.Cause(ctx); err != nil && errors.Is(cause, ErrLockLost) {
+			err = cause
+		}
+		if err != nil {
+			d.emit(Event{Type: EventStepFailed, Message: err.Error(), Err: err})
+			return &StepError{Step: d.step, Err: err}
+		}
+		d.emit(Event{Type: EventStepFinished})
+	}
+	return nil
+}
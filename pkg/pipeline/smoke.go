@@ -0,0 +1,151 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// smokeBodyLimit bounds how much of a response body is matched against a
+// pattern.
+const smokeBodyLimit = 1 << 20
+
+// SmokeCheck is one path requested on the preview URL. With Pattern the
+// response body must also match it.
+type SmokeCheck struct {
+	Path    string
+	Pattern *regexp.Regexp
+}
+
+// SmokeResult is the outcome of one SmokeCheck.
+type SmokeResult struct {
+	Path       string `json:"path"`
+	URL        string `json:"url"`
+	Status     int    `json:"status,omitempty"`
+	Pattern    string `json:"pattern,omitempty"`
+	OK         bool   `json:"ok"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// SmokeTestError reports a smoke test with failed checks.
+type SmokeTestError struct {
+	Results []SmokeResult
+}
+
+func (e *SmokeTestError) Error() string {
+	var failed []string
+	for _, r := range e.Results {
+		if !r.OK {
+			failed = append(failed, r.Path)
+		}
+	}
+	return fmt.Sprintf("%d of %d smoke test check(s) failed: %s", len(failed), len(e.Results), strings.Join(failed, ", "))
+}
+
+// SmokeTest requests paths on the preview URL of a successful build and
+// fails unless each answers 200, before the build is published.
+type SmokeTest struct {
+	Checks []SmokeCheck
+	// Timeout bounds each request; zero uses 30 seconds.
+	Timeout time.Duration
+}
+
+func (SmokeTest) Name() string { return StepSmokeTest }
+
+func (s SmokeTest) Skip(d *Deploy) bool { return len(s.Checks) == 0 }
+
+func (s SmokeTest) Run(ctx context.Context, d *Deploy) error {
+	if d.Build == nil || d.Build.Status != "success" {
+		return nil
+	}
+	if d.PreviewURL == "" {
+		return fmt.Errorf("no preview URL to smoke test")
+	}
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	d.Logf(LevelInfo, "Smoke testing %d path(s) on %s", len(s.Checks), d.PreviewURL)
+	results := make([]SmokeResult, 0, len(s.Checks))
+	failed := false
+	for _, check := range s.Checks {
+		result := s.check(ctx, d, check, timeout)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if result.OK {
+			d.Logf(LevelSuccess, "%s: %d (%dms)", result.Path, result.Status, result.DurationMS)
+		} else {
+			failed = true
+			d.Logf(LevelError, "%s: %s", result.Path, result.Error)
+		}
+		results = append(results, result)
+	}
+	d.SmokeResults = results
+	if failed {
+		return &SmokeTestError{Results: results}
+	}
+	return nil
+}
+
+func (s SmokeTest) check(ctx context.Context, d *Deploy, check SmokeCheck, timeout time.Duration) (result SmokeResult) {
+	result = SmokeResult{Path: check.Path, URL: smokeURL(d.PreviewURL, check.Path)}
+	if check.Pattern != nil {
+		result.Pattern = check.Pattern.String()
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() { result.DurationMS = time.Since(start).Milliseconds() }()
+	resp, err := d.Client.FetchURL(ctx, result.URL)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	result.Status = resp.StatusCode
+	if resp.StatusCode != http.StatusOK {
+		result.Error = fmt.Sprintf("expected status 200, got %d", resp.StatusCode)
+		return result
+	}
+	if check.Pattern != nil {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, smokeBodyLimit))
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to read response: %v", err)
+			return result
+		}
+		if !check.Pattern.Match(body) {
+			result.Error = fmt.Sprintf("response body does not match %q", result.Pattern)
+			return result
+		}
+	}
+	result.OK = true
+	return result
+}
+
+// smokeURL joins a path, which may carry a query, to the preview URL, keeping
+// the preview URL's own path prefix and query (e.g. an access token).
+func smokeURL(previewURL, path string) string {
+	base, err := url.Parse(previewURL)
+	if err != nil {
+		return strings.TrimRight(previewURL, "/") + "/" + strings.TrimLeft(path, "/")
+	}
+	path, query, _ := strings.Cut(path, "?")
+	base.Path = strings.TrimRight(base.Path, "/") + "/" + strings.TrimLeft(path, "/")
+	base.RawPath = ""
+	if query != "" {
+		if base.RawQuery != "" {
+			query = base.RawQuery + "&" + query
+		}
+		base.RawQuery = query
+	}
+	return base.String()
+}
@@ -0,0 +1,564 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+)
+
+// Step names, in the order the default deploy runs them.
+const (
+	StepAudit             = "audit"
+	StepResolveProject    = "resolve_project"
+	StepLock              = "lock"
+	StepPackageSource     = "package_source"
+	StepReuseBuild        = "reuse_build"
+	StepVersionLabel      = "version_label"
+	StepCheckVersionLabel = "check_version_label"
+	StepSyncPreviewEnv    = "sync_preview_env"
+	StepUploadSource      = "upload_source"
+	StepBuild             = "build"
+	StepBuildFunctions    = "build_functions"
+	StepPackageArtifacts  = "package_artifacts"
+	StepCheckBudgets      = "check_budgets"
+	StepPackageFunctions  = "package_functions"
+	StepUploadFunctions   = "upload_functions"
+	StepUploadArtifacts   = "upload_artifacts"
+	StepWait              = "wait"
+	StepSmokeTest         = "smoke_test"
+	StepSyncProductionEnv = "sync_production_env"
+	StepPublish           = "publish"
+)
+
+var (
+	// ErrNoBuild is returned when the server accepts the source upload but
+	// does not create a build to attach local artifacts to.
+	ErrNoBuild = errors.New("server did not return a build ID; local build upload is not supported by this server")
+	// ErrOutputDirMissing is returned when the local build left no output
+	// directory to package.
+	ErrOutputDirMissing = errors.New("output directory missing")
+	// ErrImplicitOutputDir is returned by PackageArtifacts with
+	// RequireOutputDir when neither a flag nor the build plan names the
+	// output directory.
+	ErrImplicitOutputDir = errors.New("no output directory configured and the build plan names none")
+	// ErrBuildTimeout is returned when the build is still running after the
+	// wait timeout; waiting can be resumed with the build ID.
+	ErrBuildTimeout = errors.New("build timeout")
+)
+
+// BuildFailedError reports a build that finished without success.
+type BuildFailedError struct {
+	Status string
+}
+
+func (e *BuildFailedError) Error() string {
+	return fmt.Sprintf("build failed with status: %s", e.Status)
+}
+
+// ArchiveKind tells a Packager what it is packaging.
+type ArchiveKind string
+
+const (
+	ArchiveSource    ArchiveKind = "source"
+	ArchiveArtifacts ArchiveKind = "artifacts"
+	ArchiveFunctions ArchiveKind = "functions"
+)
+
+// Packager turns a directory into a zip archive and returns its path. The
+// pipeline caller removes the archives when the deploy is done.
+type Packager interface {
+	Package(ctx context.Context, d *Deploy, kind ArchiveKind, root string) (string, error)
+}
+
+// Builder runs the local build in d.ProjectPath.
+type Builder interface {
+	Build(ctx context.Context, d *Deploy) error
+}
+
+// ResolveProject creates the project or reuses the one with the same name.
+type ResolveProject struct{}
+
+func (ResolveProject) Name() string { return StepResolveProject }
+
+func (ResolveProject) Run(ctx context.Context, d *Deploy) error {
+	d.Logf(LevelInfo, "Resolving project by name (create-or-update): %s", d.ProjectName)
+	project, err := d.Client.CreateProject(client.CreateProjectRequest{
+		Name:       d.ProjectName,
+		Visibility: d.Visibility,
+	})
+	if err != nil {
+		return err
+	}
+	d.Project = project
+	d.ProjectName = project.Name
+	d.Logf(LevelSuccess, "Project ready: %s", project.ProjectID)
+	return nil
+}
+
+// PackageSource packages the project directory.
+type PackageSource struct {
+	Packager Packager
+}
+
+func (PackageSource) Name() string { return StepPackageSource }
+
+func (s PackageSource) Run(ctx context.Context, d *Deploy) error {
+	d.Logf(LevelInfo, "Packaging source code from: %s", d.ProjectPath)
+	path, err := s.Packager.Package(ctx, d, ArchiveSource, d.ProjectPath)
+	if err != nil {
+		return err
+	}
+	d.SourceArchive = path
+	if stat, err := os.Stat(path); err == nil {
+		d.SourceArchiveSize = stat.Size()
+		d.Logf(LevelInfo, "Source archive size: %.2f MB", float64(stat.Size())/(1024.0*1024.0))
+	}
+	digest, err := ArchiveDigest(path)
+	if err != nil {
+		return fmt.Errorf("failed to compute source digest: %w", err)
+	}
+	d.SourceDigest = digest
+	d.Logf(LevelSuccess, "Source packaged: %s", path)
+	return nil
+}
+
+// ReuseBuild compares the source digest with the project's latest commit and,
+// when they match and that commit has a successful build carrying the
+// requested version label, if any, reuses the build so the upload, build and
+// wait steps are skipped. The check is best effort:
+// servers without the head-commit endpoint simply deploy as usual.
+type ReuseBuild struct {
+	// NewVersionLabel disables reuse for a deploy that GenerateVersionLabel
+	// labels: the existing build could not carry the new label.
+	NewVersionLabel bool
+}
+
+func (ReuseBuild) Name() string { return StepReuseBuild }
+
+func (ReuseBuild) Skip(d *Deploy) bool { return d.Force || d.SourceDigest == "" }
+
+func (s ReuseBuild) Run(ctx context.Context, d *Deploy) error {
+	head, build, err := d.Client.HeadCommit(d.Project.ProjectID)
+	if client.IsNotFound(err) {
+		head, build, err = previousCommit(d)
+	}
+	if err != nil {
+		d.Logf(LevelWarn, "Could not check latest commit, deploying anyway: %v", err)
+		return nil
+	}
+	if head == nil || head.Digest != d.SourceDigest {
+		return nil
+	}
+	if build == nil || build.BuildID == "" || build.Status != "success" {
+		d.rebuild = failedBuild(build)
+		d.Logf(LevelInfo, "Source unchanged but the latest build did not succeed; building again")
+		return nil
+	}
+	if s.NewVersionLabel {
+		d.Logf(LevelInfo, "Source unchanged but a new version label was requested; building again")
+		return nil
+	}
+	// A requested label must end up on the build: reuse only a build that
+	// already carries it, so CheckVersionLabel still sees the others.
+	if d.Version != nil && d.Version.VersionLabel != "" && strings.TrimSpace(build.VersionLabel) != d.Version.VersionLabel {
+		d.Logf(LevelInfo, "Source unchanged but build %s is not labeled %s; building again", build.BuildID, d.Version.VersionLabel)
+		return nil
+	}
+	d.Commit = head
+	d.Build = build
+	d.Reused = true
+	d.Logf(LevelSuccess, "Source unchanged since commit %s; reusing build %s (use --force to rebuild)", head.CommitID, build.BuildID)
+	if d.URLs != nil {
+		d.PreviewURL = d.URLs.PreviewURL(d.Project, d.Build)
+	}
+	d.URL("preview", d.PreviewURL)
+	return nil
+}
+
+// previousCommit rebuilds the latest commit from the caller's record of the
+// previous deploy, for servers without the head-commit endpoint.
+func previousCommit(d *Deploy) (*client.SourceCommit, *client.Build, error) {
+	if d.PreviousDigest == "" || d.PreviousBuildID == "" {
+		return nil, nil, nil
+	}
+	build, err := d.Client.GetBuild(d.Project.ProjectID, d.PreviousBuildID)
+	if err != nil {
+		if client.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	if build.ProjectID != "" && build.ProjectID != d.Project.ProjectID {
+		return nil, nil, nil
+	}
+	head := &client.SourceCommit{CommitID: build.CommitID, ProjectID: d.Project.ProjectID, Digest: d.PreviousDigest}
+	return head, build, nil
+}
+
+// skipWhenReused is embedded by steps that produce the build.
+type skipWhenReused struct{}
+
+func (skipWhenReused) Skip(d *Deploy) bool { return d.Reused }
+
+// UploadSource uploads the source archive, which creates the build. With
+// SourceStream set the stream is uploaded as it is read.
+type UploadSource struct {
+	skipWhenReused
+}
+
+func (UploadSource) Name() string { return StepUploadSource }
+
+func (UploadSource) Run(ctx context.Context, d *Deploy) error {
+	d.Client.SetUploadProgress(d.Progress)
+	defer d.Client.SetUploadProgress(nil)
+	var commit *client.SourceCommit
+	var build *client.Build
+	var err error
+	if d.SourceStream != nil {
+		d.Logf(LevelInfo, "Streaming %s source archive...", d.SourceFormat)
+		stream := &countingReader{r: d.SourceStream}
+		commit, build, err = d.Client.UploadSourceStream(d.Project.ProjectID, stream, d.SourceFormat, d.Version, d.Routes)
+		d.SourceArchiveSize = stream.n
+	} else {
+		d.Logf(LevelInfo, "Uploading source code...")
+		// --force asks for a new build, so it must not be answered with the
+		// one created by an earlier upload of the same source.
+		// Neither does a retry after a failed build.
+		var key string
+		if d.SourceDigest != "" && !d.Force && !d.rebuild {
+			key = IdempotencyKey(d.Project.ProjectID, d.SourceDigest, d.keyVersion(), d.Routes)
+		}
+		commit, build, err = d.Client.UploadSource(d.Project.ProjectID, d.SourceArchive, d.SourceDigest, key, d.Version, d.Routes)
+		// Servers without the head-commit endpoint only reveal the failed
+		// build when they replay the earlier upload.
+		if err == nil && key != "" && commit != nil && commit.Deduplicated && failedBuild(build) {
+			d.Logf(LevelInfo, "Build %s of the earlier upload did not succeed; uploading again", build.BuildID)
+			commit, build, err = d.Client.UploadSource(d.Project.ProjectID, d.SourceArchive, d.SourceDigest, "", d.Version, d.Routes)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	d.Commit = commit
+	d.Build = build
+	if commit != nil && commit.Deduplicated {
+		d.Deduplicated = true
+		d.Logf(LevelSuccess, "Source already uploaded by an earlier attempt: %s", commit.CommitID)
+	} else if commit != nil && commit.CommitID != "" {
+		d.Logf(LevelSuccess, "Source uploaded: %s", commit.CommitID)
+	}
+	if plan := d.Plan(); plan != nil {
+		for _, note := range plan.Notes {
+			d.Logf(LevelWarn, "Build plan: %s", note)
+		}
+	}
+	if build == nil || build.BuildID == "" {
+		return ErrNoBuild
+	}
+	if d.Deduplicated && build.Status == "success" {
+		// The earlier attempt finished the build; skip building it again.
+		d.Reused = true
+		d.Logf(LevelSuccess, "Build %s already succeeded; reusing it", build.BuildID)
+		if d.URLs != nil {
+			d.PreviewURL = d.URLs.PreviewURL(d.Project, d.Build)
+		}
+		d.URL("preview", d.PreviewURL)
+		return nil
+	}
+	d.Logf(LevelSuccess, "Build created: %s", build.BuildID)
+	return nil
+}
+
+// failedBuild reports whether build finished without succeeding.
+func failedBuild(build *client.Build) bool {
+	return build != nil && build.Status != client.BuildSuccess && client.BuildStatusTerminal(build.Status)
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// LocalBuild runs the build on this machine.
+type LocalBuild struct {
+	skipWhenReused
+	Builder Builder
+}
+
+func (LocalBuild) Name() string { return StepBuild }
+
+func (s LocalBuild) Run(ctx context.Context, d *Deploy) error {
+	return s.Builder.Build(ctx, d)
+}
+
+// PackageArtifacts packages the build output directory: OutputDir when set,
+// else the directory from the server's build plan, else "dist".
+type PackageArtifacts struct {
+	skipWhenReused
+	Packager  Packager
+	OutputDir string
+	// RequireOutputDir disables the "dist" default.
+	RequireOutputDir bool
+}
+
+func (PackageArtifacts) Name() string { return StepPackageArtifacts }
+
+func (s PackageArtifacts) Run(ctx context.Context, d *Deploy) error {
+	dir := strings.TrimSpace(s.OutputDir)
+	if plan := d.Plan(); dir == "" && plan != nil {
+		dir = strings.TrimSpace(plan.OutputDir)
+	}
+	if dir == "" {
+		if s.RequireOutputDir {
+			return ErrImplicitOutputDir
+		}
+		dir = "dist"
+	}
+	path := filepath.Join(d.ProjectPath, dir)
+	if stat, err := os.Stat(path); err != nil || !stat.IsDir() {
+		return fmt.Errorf("%w: %s", ErrOutputDirMissing, path)
+	}
+
+	d.Logf(LevelInfo, "Packaging build output from: %s", path)
+	archive, err := s.Packager.Package(ctx, d, ArchiveArtifacts, path)
+	if err != nil {
+		return err
+	}
+	d.ArtifactArchive = archive
+	if stat, err := os.Stat(archive); err == nil {
+		d.ArtifactArchiveSize = stat.Size()
+	}
+	d.Logf(LevelSuccess, "Build output packaged: %s", archive)
+	return nil
+}
+
+// skipWithoutFunctions is embedded by the function steps, which only run for
+// new builds of projects with a functions directory.
+type skipWithoutFunctions struct{}
+
+func (skipWithoutFunctions) Skip(d *Deploy) bool { return d.Reused || d.FunctionsDir == "" }
+
+// BuildFunctions runs the build steps of the functions directory.
+type BuildFunctions struct {
+	skipWithoutFunctions
+	Builder Builder
+}
+
+func (BuildFunctions) Name() string { return StepBuildFunctions }
+
+func (s BuildFunctions) Run(ctx context.Context, d *Deploy) error {
+	return s.Builder.Build(ctx, d)
+}
+
+// PackageFunctions packages the functions directory separately from the
+// static build output.
+type PackageFunctions struct {
+	skipWithoutFunctions
+	Packager Packager
+}
+
+func (PackageFunctions) Name() string { return StepPackageFunctions }
+
+func (s PackageFunctions) Run(ctx context.Context, d *Deploy) error {
+	path := filepath.Join(d.ProjectPath, d.FunctionsDir)
+	d.Logf(LevelInfo, "Packaging functions from: %s", path)
+	archive, err := s.Packager.Package(ctx, d, ArchiveFunctions, path)
+	if err != nil {
+		return err
+	}
+	d.FunctionsArchive = archive
+	if stat, err := os.Stat(archive); err == nil {
+		d.FunctionsArchiveSize = stat.Size()
+	}
+	d.Logf(LevelSuccess, "Functions packaged: %s", archive)
+	return nil
+}
+
+// UploadFunctions attaches the functions archive to the build. It runs
+// before the build output is uploaded, which may complete the build.
+type UploadFunctions struct {
+	skipWithoutFunctions
+}
+
+func (UploadFunctions) Name() string { return StepUploadFunctions }
+
+func (UploadFunctions) Run(ctx context.Context, d *Deploy) error {
+	d.Logf(LevelInfo, "Uploading functions...")
+	d.Client.SetUploadProgress(d.Progress)
+	defer d.Client.SetUploadProgress(nil)
+	functions, err := d.Client.UploadFunctions(d.Build.BuildID, d.FunctionsArchive)
+	if err != nil {
+		return err
+	}
+	d.Functions = functions
+	for _, fn := range functions {
+		d.Logf(LevelSuccess, "Function %s: %s", fn.Name, firstNonEmpty(fn.URL, fn.Route))
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// UploadArtifacts attaches the build output archive to the build.
+type UploadArtifacts struct {
+	skipWhenReused
+}
+
+func (UploadArtifacts) Name() string { return StepUploadArtifacts }
+
+func (UploadArtifacts) Run(ctx context.Context, d *Deploy) error {
+	d.Logf(LevelInfo, "Uploading build artifacts...")
+	d.Client.SetUploadProgress(d.Progress)
+	defer d.Client.SetUploadProgress(nil)
+	build, err := d.Client.UploadBuildArtifacts(d.Build.BuildID, d.ArtifactArchive)
+	if err != nil {
+		return err
+	}
+	if build != nil {
+		d.Build = build
+	}
+	d.Logf(LevelSuccess, "Build artifacts uploaded")
+	if d.Build.Status == "success" {
+		reportBuildSuccess(d)
+	}
+	return nil
+}
+
+// WaitForBuild polls the build until it finishes.
+type WaitForBuild struct {
+	skipWhenReused
+	Timeout  time.Duration
+	Interval time.Duration
+	// Heartbeat limits how often an unchanged status is logged; status
+	// changes are always logged. Zero logs every poll.
+	Heartbeat time.Duration
+}
+
+func (WaitForBuild) Name() string { return StepWait }
+
+func (s WaitForBuild) Run(ctx context.Context, d *Deploy) error {
+	if d.Build.Status == "success" {
+		return nil
+	}
+	d.Logf(LevelInfo, "Waiting for build to complete (timeout: %ds)...", int(s.Timeout.Seconds()))
+	estimate := buildEstimate(d)
+	if estimate > 0 {
+		d.Logf(LevelInfo, "Previous builds of this project took about %ds", int(estimate.Seconds()))
+	}
+	interval := s.Interval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	start := time.Now()
+	var lastStatus string
+	var lastLogged time.Time
+	for {
+		if time.Since(start) > s.Timeout {
+			return fmt.Errorf("%w after %d seconds", ErrBuildTimeout, int(s.Timeout.Seconds()))
+		}
+		build, err := d.Client.GetBuild(d.Project.ProjectID, d.Build.BuildID)
+		if err != nil {
+			return err
+		}
+		d.Build = build
+
+		switch {
+		case build.Status == client.BuildSuccess:
+			reportBuildSuccess(d)
+			return nil
+		case client.BuildStatusTerminal(build.Status):
+			d.Logf(LevelError, "Build failed with status: %s", build.StatusText())
+			return &BuildFailedError{Status: build.Status}
+		}
+		// Queued, running and unrecognized statuses keep waiting.
+		if status := build.StatusText(); status != lastStatus || time.Since(lastLogged) >= s.Heartbeat {
+			logBuildStatus(d, status, time.Since(start), estimate)
+			lastStatus, lastLogged = status, time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// logBuildStatus logs the status of a running build, with the time it is
+// expected to take still when earlier builds give an estimate. The estimate
+// counts from the creation of the build, which may predate the wait.
+func logBuildStatus(d *Deploy, status string, elapsed, estimate time.Duration) {
+	if estimate <= 0 {
+		d.Logf(LevelInfo, "Build status: %s (elapsed: %ds)", status, int(elapsed.Seconds()))
+		return
+	}
+	if !d.Build.CreatedAt.IsZero() {
+		if age := time.Since(d.Build.CreatedAt); age > elapsed {
+			elapsed = age
+		}
+	}
+	remaining := estimate - elapsed
+	if remaining < 0 {
+		remaining = 0
+	}
+	seconds := int(remaining.Seconds())
+	message := fmt.Sprintf("Build status: %s (elapsed: %ds, about %ds remaining)", status, int(elapsed.Seconds()), seconds)
+	if remaining == 0 {
+		message = fmt.Sprintf("Build status: %s (elapsed: %ds, longer than the usual %ds)", status, int(elapsed.Seconds()), int(estimate.Seconds()))
+	}
+	d.emit(Event{Type: EventLog, Level: LevelInfo, Message: message, EstimatedRemainingSeconds: &seconds})
+}
+
+func reportBuildSuccess(d *Deploy) {
+	d.Logf(LevelSuccess, "Local build completed successfully!")
+	if d.URLs != nil {
+		d.PreviewURL = d.URLs.PreviewURL(d.Project, d.Build)
+	}
+	d.URL("preview", d.PreviewURL)
+}
+
+// Publish promotes a successful build to production. Builds that have not
+// succeeded are left unpublished.
+type Publish struct{}
+
+func (Publish) Name() string { return StepPublish }
+
+func (Publish) Run(ctx context.Context, d *Deploy) error {
+	if d.Build == nil || d.Build.Status != "success" {
+		return nil
+	}
+	d.Logf(LevelInfo, "Publishing to production...")
+	publicPath, err := d.Client.PublishBuild(d.Project.ProjectID, d.Build.BuildID)
+	if err != nil {
+		return err
+	}
+	d.Logf(LevelSuccess, "Published successfully!")
+
+	d.ProductionURL = strings.TrimSpace(publicPath)
+	if d.ProductionURL == "" && d.URLs != nil {
+		d.ProductionURL = d.URLs.ProductionURL(d.Project)
+	}
+	d.URL("production", d.ProductionURL)
+	return nil
+}
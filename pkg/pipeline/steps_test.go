@@ -0,0 +1,91 @@
+package pipeline
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+)
+
+const testDigest = "sha256:abc"
+
+// retryServer is a server whose only commit of the test source, c1, has
+// the failed build b1. Uploads with an idempotency key replay c1; uploads
+// without one create commit c2 with build b2.
+type retryServer struct {
+	head bool
+	keys []string
+}
+
+func (s *retryServer) client(t *testing.T) *client.Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/projects/proj_1/commits/head", func(w http.ResponseWriter, r *http.Request) {
+		if !s.head {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"commit":{"commit_id":"c1","project_id":"proj_1","digest":"` + testDigest + `"},"build":{"build_id":"b1","status":"failed"}}`))
+	})
+	mux.HandleFunc("POST /api/projects/proj_1/commits", func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(client.IdempotencyKeyHeader)
+		s.keys = append(s.keys, key)
+		w.Header().Set("Content-Type", "application/json")
+		if key != "" {
+			w.Header().Set("Idempotent-Replayed", "true")
+			_, _ = w.Write([]byte(`{"commit":{"commit_id":"c1"},"build":{"build_id":"b1","status":"failed"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"commit":{"commit_id":"c2"},"build":{"build_id":"b2","status":"building"}}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return client.NewClient(server.URL, "test-key")
+}
+
+func TestRetryAfterFailedBuildCreatesNewBuild(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "source.zip")
+	if err := os.WriteFile(archive, []byte("zip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		head bool
+		keys int
+	}{
+		// ReuseBuild sees the failed build, so no key is sent.
+		{"head commit", true, 1},
+		// The replayed upload reveals the failed build; it is sent again
+		// without the key.
+		{"no head commit", false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &retryServer{head: tt.head}
+			d := &Deploy{
+				Client:        server.client(t),
+				Project:       &client.Project{ProjectID: "proj_1"},
+				SourceArchive: archive,
+				SourceDigest:  testDigest,
+			}
+			if err := New(nil, ReuseBuild{}, UploadSource{}).Run(context.Background(), d); err != nil {
+				t.Fatal(err)
+			}
+			if d.Build == nil || d.Build.BuildID != "b2" {
+				t.Fatalf("got build %+v, want new build b2", d.Build)
+			}
+			if d.Reused || d.Deduplicated {
+				t.Errorf("Reused = %v, Deduplicated = %v; want a fresh build", d.Reused, d.Deduplicated)
+			}
+			if len(server.keys) != tt.keys || server.keys[len(server.keys)-1] != "" {
+				t.Errorf("upload idempotency keys = %q, want %d uploads ending without a key", server.keys, tt.keys)
+			}
+		})
+	}
+}
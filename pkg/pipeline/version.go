@@ -0,0 +1,179 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/haibingtown/robotx_cli/pkg/client"
+)
+
+// Version label schemes of GenerateVersionLabel. The semantic schemes bump
+// one part of the highest vMAJOR.MINOR.PATCH label; the date scheme numbers
+// the deploys of each UTC day, as in 2024.06.12-1.
+const (
+	VersionSchemePatch = "patch"
+	VersionSchemeMinor = "minor"
+	VersionSchemeMajor = "major"
+	VersionSchemeDate  = "date"
+)
+
+// VersionSchemes lists the accepted version label schemes.
+var VersionSchemes = []string{VersionSchemePatch, VersionSchemeMinor, VersionSchemeMajor, VersionSchemeDate}
+
+// versionLabelHistory is how many recent builds GenerateVersionLabel and
+// CheckVersionLabel look at.
+const versionLabelHistory = 100
+
+const dateLabelLayout = "2006.01.02"
+
+var semverLabelPattern = regexp.MustCompile(`^(v?)(\d+)\.(\d+)\.(\d+)$`)
+
+// GenerateVersionLabel sets the version label of the build to the one
+// following the labels of the project's recent builds. Only the deploy lock
+// keeps concurrent deploys from picking the same label: without it (no
+// AcquireLock step, as with --no-lock or a server without deploy locks) a
+// warning is logged.
+type GenerateVersionLabel struct {
+	skipWhenReused
+	Scheme string
+}
+
+func (GenerateVersionLabel) Name() string { return StepVersionLabel }
+
+func (s GenerateVersionLabel) Run(ctx context.Context, d *Deploy) error {
+	builds, err := d.Client.ListBuildsForProject(d.Project.ProjectID, versionLabelHistory)
+	if err != nil {
+		return err
+	}
+	labels := make([]string, 0, len(builds))
+	for _, build := range builds {
+		if build != nil && build.VersionLabel != "" {
+			labels = append(labels, build.VersionLabel)
+		}
+	}
+	label, err := NextVersionLabel(s.Scheme, labels, time.Now())
+	if err != nil {
+		return err
+	}
+	if d.Version == nil {
+		d.Version = &client.BuildVersionInput{}
+	}
+	// Retries of the deploy must send the idempotency key of the first
+	// attempt, which did not know the label it would get either.
+	requested := *d.Version
+	d.requestedVersion = &requested
+	d.Version.VersionLabel = label
+	d.Logf(LevelSuccess, "Version label: %s", label)
+	if d.lock == nil {
+		d.Logf(LevelWarn, "Version label %s was generated without the deploy lock; a concurrent deploy may pick the same label", label)
+	}
+	return nil
+}
+
+// DuplicateVersionLabelError reports a version label already carried by a
+// build of the project.
+type DuplicateVersionLabelError struct {
+	Label   string `json:"version_label"`
+	BuildID string `json:"build_id"`
+}
+
+func (e *DuplicateVersionLabelError) Error() string {
+	return fmt.Sprintf("version label %s is already used by build %s", e.Label, e.BuildID)
+}
+
+// CheckVersionLabel refuses a version label already used by one of the
+// project's recent builds. The server accepts duplicates, which makes it
+// ambiguous which build a label rolls back to. A retry of a deploy whose
+// first attempt created the labeled build is let through, since the upload
+// returns that build again.
+type CheckVersionLabel struct {
+	skipWhenReused
+}
+
+func (CheckVersionLabel) Name() string { return StepCheckVersionLabel }
+
+func (CheckVersionLabel) Run(ctx context.Context, d *Deploy) error {
+	if d.Version == nil || d.Version.VersionLabel == "" {
+		return nil
+	}
+	label := d.Version.VersionLabel
+	builds, err := d.Client.ListBuildsForProject(d.Project.ProjectID, versionLabelHistory)
+	if err != nil {
+		return err
+	}
+	for _, build := range builds {
+		if build == nil || build.VersionLabel != label {
+			continue
+		}
+		if d.SourceDigest != "" && !d.Force {
+			head, _, err := d.Client.HeadCommit(d.Project.ProjectID)
+			if err == nil && head != nil && head.Digest == d.SourceDigest && head.CommitID == build.CommitID {
+				d.Logf(LevelInfo, "Version label %s belongs to build %s of the same source; retrying it", label, build.BuildID)
+				return nil
+			}
+		}
+		return &DuplicateVersionLabelError{Label: label, BuildID: build.BuildID}
+	}
+	return nil
+}
+
+// NextVersionLabel returns the label following labels under scheme. Labels
+// that do not follow the scheme are ignored; without any, the semantic
+// schemes start from v0.0.0.
+func NextVersionLabel(scheme string, labels []string, now time.Time) (string, error) {
+	switch scheme {
+	case VersionSchemePatch, VersionSchemeMinor, VersionSchemeMajor:
+		prefix := "v"
+		var latest [3]int
+		found := false
+		for _, label := range labels {
+			m := semverLabelPattern.FindStringSubmatch(strings.TrimSpace(label))
+			if m == nil {
+				continue
+			}
+			var parts [3]int
+			for i := range parts {
+				parts[i], _ = strconv.Atoi(m[i+2])
+			}
+			if !found || semverLess(latest, parts) {
+				latest, prefix, found = parts, m[1], true
+			}
+		}
+		switch scheme {
+		case VersionSchemeMajor:
+			latest = [3]int{latest[0] + 1, 0, 0}
+		case VersionSchemeMinor:
+			latest = [3]int{latest[0], latest[1] + 1, 0}
+		default:
+			latest[2]++
+		}
+		return fmt.Sprintf("%s%d.%d.%d", prefix, latest[0], latest[1], latest[2]), nil
+	case VersionSchemeDate:
+		day := now.UTC().Format(dateLabelLayout) + "-"
+		last := 0
+		for _, label := range labels {
+			rest, ok := strings.CutPrefix(strings.TrimSpace(label), day)
+			if !ok {
+				continue
+			}
+			if n, err := strconv.Atoi(rest); err == nil && n > last {
+				last = n
+			}
+		}
+		return fmt.Sprintf("%s%d", day, last+1), nil
+	}
+	return "", fmt.Errorf("unknown version scheme %q (use %s)", scheme, strings.Join(VersionSchemes, ", "))
+}
+
+func semverLess(a, b [3]int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
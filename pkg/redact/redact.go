@@ -0,0 +1,53 @@
+// Package redact scrubs credentials from text the CLI writes: log lines,
+// error messages, traces and HTTP dumps.
+package redact
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Placeholder replaces every redacted value.
+const Placeholder = "[REDACTED]"
+
+// minSecretLength is the shortest secret value replaced verbatim; shorter
+// values would match ordinary text.
+const minSecretLength = 8
+
+var (
+	// secretHeader matches credential headers, such as a dumped request or
+	// an error quoting one.
+	secretHeader = regexp.MustCompile(`(?im)^(Authorization|Proxy-Authorization|Cookie|Set-Cookie|X-Api-Key|X-Vault-Token):[^\r\n]*`)
+	// authScheme matches credentials of an Authorization header quoted
+	// outside a header line.
+	authScheme = regexp.MustCompile(`(?i)\b(Bearer|Basic)\s+[A-Za-z0-9._~+/=-]{8,}`)
+	// secretField matches credential fields of JSON bodies, such as the
+	// tokens returned by login and token exchange.
+	secretField = regexp.MustCompile(`"(access_token|refresh_token|api_key|service_token|client_secret|token|password|secret)"(\s*:\s*)"[^"]*"`)
+	// urlUserinfo matches the user:password part of a URL.
+	urlUserinfo = regexp.MustCompile(`\b([a-zA-Z][a-zA-Z0-9+.-]*://)[^/\s:@]+:[^/\s@]+@`)
+	// signedParam matches query parameters carrying credentials or
+	// signatures, e.g. of presigned S3, GCS and Azure upload URLs.
+	signedParam = regexp.MustCompile(`(?i)([?&](?:access_token|api_key|apikey|token|key|password|secret|auth|code|sig|signature|x-amz-signature|x-amz-credential|x-amz-security-token|x-goog-signature|x-goog-credential)=)[^&\s"'#]*`)
+)
+
+// String returns s with credential headers, bearer tokens, secret JSON
+// fields, passwords in URLs and signed-URL parameters replaced by
+// Placeholder, along with every occurrence of the given secret values.
+func String(s string, secrets ...string) string {
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			s = strings.ReplaceAll(s, secret, Placeholder)
+		}
+	}
+	s = secretHeader.ReplaceAllString(s, "$1: "+Placeholder)
+	s = authScheme.ReplaceAllString(s, "$1 "+Placeholder)
+	s = secretField.ReplaceAllString(s, `"$1"$2"`+Placeholder+`"`)
+	s = urlUserinfo.ReplaceAllString(s, "$1"+Placeholder+"@")
+	return signedParam.ReplaceAllString(s, "${1}"+Placeholder)
+}
+
+// Bytes is String for byte slices.
+func Bytes(b []byte, secrets ...string) []byte {
+	return []byte(String(string(b), secrets...))
+}
@@ -0,0 +1,273 @@
+// Package telemetry exports OpenTelemetry traces over OTLP/HTTP using the
+// JSON encoding, without depending on the OpenTelemetry SDK. Spans are kept
+// in memory and sent in one request by Flush, which suits a short-lived CLI
+// process.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SpanKind values from the OTLP specification.
+const (
+	KindInternal = 1
+	KindClient   = 3
+)
+
+// Tracer collects the spans of one trace.
+type Tracer struct {
+	url     string
+	headers map[string]string
+	service string
+	version string
+	client  *http.Client
+
+	mu      sync.Mutex
+	traceID string
+	spans   []*Span
+}
+
+// Span is one timed operation. Its methods are safe to call on a nil span, so
+// callers need not check whether tracing is enabled.
+type Span struct {
+	tracer   *Tracer
+	spanID   string
+	parentID string
+	name     string
+	kind     int
+	start    time.Time
+	end      time.Time
+	attrs    map[string]interface{}
+	errMsg   string
+}
+
+// New returns a tracer exporting to the OTLP/HTTP endpoint, e.g.
+// "http://localhost:4318"; spans are posted to <endpoint>/v1/traces. An
+// endpoint that already ends in /v1/traces is used as is.
+func New(endpoint, service, version string, headers map[string]string) *Tracer {
+	url := strings.TrimRight(strings.TrimSpace(endpoint), "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+	return &Tracer{
+		url:     url,
+		headers: headers,
+		service: service,
+		version: version,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		traceID: randomHex(16),
+	}
+}
+
+// ParseHeaders parses the OTEL_EXPORTER_OTLP_HEADERS format: comma-separated
+// key=value pairs.
+func ParseHeaders(raw string) map[string]string {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); ok && key != "" {
+			headers[key] = strings.TrimSpace(value)
+		}
+	}
+	return headers
+}
+
+// Start begins a span under parent, or a root span when parent is nil.
+func (t *Tracer) Start(name string, parent *Span) *Span {
+	return t.StartAt(name, parent, KindInternal, time.Now())
+}
+
+// StartAt begins a span of the given kind at start.
+func (t *Tracer) StartAt(name string, parent *Span, kind int, start time.Time) *Span {
+	if t == nil {
+		return nil
+	}
+	s := &Span{
+		tracer: t,
+		spanID: randomHex(8),
+		name:   name,
+		kind:   kind,
+		start:  start,
+		attrs:  map[string]interface{}{},
+	}
+	if parent != nil {
+		s.parentID = parent.spanID
+	}
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+	return s
+}
+
+// SetAttribute records a string, bool, integer or float attribute.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.tracer.mu.Lock()
+	s.attrs[key] = value
+	s.tracer.mu.Unlock()
+}
+
+// End finishes the span now, marking it failed when err is non-nil.
+func (s *Span) End(err error) {
+	s.EndAt(time.Now(), err)
+}
+
+// EndAt finishes the span at end. Ending a span twice keeps the first end.
+func (s *Span) EndAt(end time.Time, err error) {
+	if s == nil {
+		return
+	}
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	if !s.end.IsZero() {
+		return
+	}
+	s.end = end
+	if err != nil {
+		s.errMsg = err.Error()
+	}
+}
+
+// Flush sends all finished spans and forgets them. Spans still open are
+// ended first.
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	spans := t.spans
+	t.spans = nil
+	now := time.Now()
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		if s.end.IsZero() {
+			s.end = now
+		}
+		encoded = append(encoded, t.encode(s))
+	}
+	t.mu.Unlock()
+	if len(encoded) == 0 {
+		return nil
+	}
+
+	payload := map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{"attributes": attributes(map[string]interface{}{
+				"service.name":    t.service,
+				"service.version": t.version,
+			})},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]interface{}{"name": t.service, "version": t.version},
+				"spans": encoded,
+			}},
+		}},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("OTLP export to %s failed with status %d: %s", t.url, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func (t *Tracer) encode(s *Span) otlpSpan {
+	out := otlpSpan{
+		TraceID:           t.traceID,
+		SpanID:            s.spanID,
+		ParentSpanID:      s.parentID,
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		Attributes:        attributes(s.attrs),
+	}
+	if s.errMsg != "" {
+		out.Status = &otlpStatus{Code: 2, Message: s.errMsg}
+	}
+	return out
+}
+
+func attributes(attrs map[string]interface{}) []otlpAttribute {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	out := make([]otlpAttribute, 0, len(attrs))
+	for _, key := range keys {
+		value := attrs[key]
+		var v map[string]interface{}
+		switch value := value.(type) {
+		case bool:
+			v = map[string]interface{}{"boolValue": value}
+		case int:
+			v = map[string]interface{}{"intValue": strconv.Itoa(value)}
+		case int64:
+			v = map[string]interface{}{"intValue": strconv.FormatInt(value, 10)}
+		case float64:
+			v = map[string]interface{}{"doubleValue": value}
+		default:
+			v = map[string]interface{}{"stringValue": fmt.Sprint(value)}
+		}
+		out = append(out, otlpAttribute{Key: key, Value: v})
+	}
+	return out
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}